@@ -0,0 +1,19 @@
+#!/bin/bash
+
+if [[ $1 == "-h" || $1 == "--help" ]] ; then
+    echo 'repair-card-properties.sh [board id] [--dry-run]'
+    echo 'Repairs the card property values of a board, or of every board if no board id is given.'
+    exit 1
+fi
+
+BOARD_ID=""
+DRY_RUN="false"
+for arg in "$@" ; do
+    if [[ $arg == "--dry-run" ]] ; then
+        DRY_RUN="true"
+    else
+        BOARD_ID=$arg
+    fi
+done
+
+curl --unix-socket /var/tmp/focalboard_local.socket "http://localhost/api/v2/admin/properties/repair?boardID=$BOARD_ID&dryRun=$DRY_RUN" -X POST
@@ -2,11 +2,14 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/mattermost/focalboard/server/app"
+	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/services/audit"
 
 	"github.com/mattermost/mattermost-server/v6/shared/mlog"
@@ -53,3 +56,113 @@ func (a *API) handleAdminSetPassword(w http.ResponseWriter, r *http.Request) {
 	jsonStringResponse(w, http.StatusOK, "{}")
 	auditRec.Success()
 }
+
+func (a *API) handleAdminGetBackupHealth(w http.ResponseWriter, r *http.Request) {
+	health, err := a.app.GetBackupHealth()
+	if errors.Is(err, app.ErrBackupsDisabled) {
+		a.errorResponse(w, r.URL.Path, http.StatusNotImplemented, "backups are not enabled", err)
+		return
+	}
+	if model.IsErrNotFound(err) {
+		a.errorResponse(w, r.URL.Path, http.StatusNotFound, "no backup has run yet", err)
+		return
+	}
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(health)
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+func (a *API) handleAdminRepairCardProperties(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	boardID := query.Get("boardID")
+	dryRun := query.Get("dryRun") == "true"
+
+	auditRec := a.makeAuditRecord(r, "adminRepairCardProperties", audit.Fail)
+	defer a.audit.LogRecord(audit.LevelModify, auditRec)
+	auditRec.AddMeta("boardID", boardID)
+	auditRec.AddMeta("dryRun", dryRun)
+
+	result, err := a.app.RepairCardProperties(boardID, dryRun)
+	if model.IsErrNotFound(err) {
+		a.errorResponse(w, r.URL.Path, http.StatusNotFound, "board not found", err)
+		return
+	}
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	a.logger.Info("AdminRepairCardProperties",
+		mlog.String("boardID", boardID),
+		mlog.Bool("dryRun", dryRun),
+		mlog.Int("repairedCards", result.RepairedCards),
+	)
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+	auditRec.AddMeta("repairedCards", result.RepairedCards)
+	auditRec.Success()
+}
+
+func (a *API) handleAdminGetFileCleanupReport(w http.ResponseWriter, r *http.Request) {
+	report, err := a.app.GetFileCleanupReport()
+	if model.IsErrNotFound(err) {
+		a.errorResponse(w, r.URL.Path, http.StatusNotFound, "no file cleanup has run yet", err)
+		return
+	}
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+func (a *API) handleAdminRunFileCleanup(w http.ResponseWriter, r *http.Request) {
+	auditRec := a.makeAuditRecord(r, "adminRunFileCleanup", audit.Fail)
+	defer a.audit.LogRecord(audit.LevelModify, auditRec)
+
+	report, err := a.app.RunFileCleanup()
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	a.logger.Info("AdminRunFileCleanup",
+		mlog.Int("quarantined", report.Quarantined),
+		mlog.Int("deleted", report.Deleted),
+		mlog.Int64("reclaimedBytes", report.ReclaimedBytes),
+	)
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+	auditRec.AddMeta("quarantined", report.Quarantined)
+	auditRec.AddMeta("deleted", report.Deleted)
+	auditRec.AddMeta("reclaimedBytes", report.ReclaimedBytes)
+	auditRec.Success()
+}
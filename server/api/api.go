@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"path/filepath"
 	"runtime/debug"
@@ -68,6 +69,11 @@ func NewAPI(app *app.App, singleUserToken string, authService string, permission
 }
 
 func (a *API) RegisterRoutes(r *mux.Router) {
+	// the signed file URLs are embedded in pages and emails, they are fetched without
+	// the CSRF header.
+	r.Handle("/api/v2/files/teams/{teamID}/{boardID}/{filename}", a.panicHandler(http.HandlerFunc(a.handleServeFile))).
+		Methods("GET").Queries(app.FileURLSignatureParam, "{signature}")
+
 	apiv2 := r.PathPrefix("/api/v2").Subrouter()
 	apiv2.Use(a.panicHandler)
 	apiv2.Use(a.requireCSRFToken)
@@ -90,6 +96,14 @@ func (a *API) RegisterRoutes(r *mux.Router) {
 	apiv2.HandleFunc("/boards/{boardID}/blocks/{blockID}/undelete", a.sessionRequired(a.handleUndeleteBlock)).Methods("POST")
 	apiv2.HandleFunc("/boards/{boardID}/blocks/{blockID}/duplicate", a.sessionRequired(a.handleDuplicateBlock)).Methods("POST")
 	apiv2.HandleFunc("/boards/{boardID}/metadata", a.sessionRequired(a.handleGetBoardMetadata)).Methods("GET")
+	apiv2.HandleFunc("/boards/{boardID}/views/{viewID}/aggregations", a.sessionRequired(a.handleGetViewAggregations)).Methods("GET")
+	apiv2.HandleFunc("/boards/{boardID}/cards/dates", a.sessionRequired(a.handleGetCardsOverlappingDates)).Methods("GET")
+	apiv2.HandleFunc("/boards/{boardID}/cards/checklist", a.sessionRequired(a.handleGetChecklistCards)).Methods("GET")
+	apiv2.HandleFunc("/boards/{boardID}/cards/{cardID}/relations/{propertyID}", a.sessionRequired(a.handleGetRelatedCards)).Methods("GET")
+	apiv2.HandleFunc("/boards/{boardID}/properties/conformance", a.sessionRequired(a.handleGetPropertyConformance)).Methods("GET")
+	apiv2.HandleFunc("/boards/{boardID}/properties/migrations", a.sessionRequired(a.handleGetPropertyMigrations)).Methods("GET")
+	apiv2.HandleFunc("/boards/{boardID}/properties/migrations", a.sessionRequired(a.handleCreatePropertyMigration)).Methods("POST")
+	apiv2.HandleFunc("/boards/{boardID}/properties/migrations/{migrationID}/undo", a.sessionRequired(a.handleUndoPropertyMigration)).Methods("POST")
 
 	// Member APIs
 	apiv2.HandleFunc("/boards/{boardID}/members", a.sessionRequired(a.handleGetMembersForBoard)).Methods("GET")
@@ -140,6 +154,7 @@ func (a *API) RegisterRoutes(r *mux.Router) {
 
 	// Get Files API
 	apiv2.HandleFunc("/files/teams/{teamID}/{boardID}/{filename}", a.attachSession(a.handleServeFile, false)).Methods("GET")
+	apiv2.HandleFunc("/files/teams/{teamID}/{boardID}/{filename}/url", a.sessionRequired(a.handleGetFileURL)).Methods("GET")
 
 	// Subscription APIs
 	apiv2.HandleFunc("/subscriptions", a.sessionRequired(a.handleCreateSubscription)).Methods("POST")
@@ -151,6 +166,7 @@ func (a *API) RegisterRoutes(r *mux.Router) {
 
 	// Archive APIs
 	apiv2.HandleFunc("/boards/{boardID}/archive/export", a.sessionRequired(a.handleArchiveExportBoard)).Methods("GET")
+	apiv2.HandleFunc("/boards/{boardID}/export", a.sessionRequired(a.handleExportBoardDocument)).Methods("GET")
 	apiv2.HandleFunc("/teams/{teamID}/archive/import", a.sessionRequired(a.handleArchiveImport)).Methods("POST")
 
 	// System APIs
@@ -159,6 +175,10 @@ func (a *API) RegisterRoutes(r *mux.Router) {
 
 func (a *API) RegisterAdminRoutes(r *mux.Router) {
 	r.HandleFunc("/api/v2/admin/users/{username}/password", a.adminRequired(a.handleAdminSetPassword)).Methods("POST")
+	r.HandleFunc("/api/v2/admin/backups/health", a.adminRequired(a.handleAdminGetBackupHealth)).Methods("GET")
+	r.HandleFunc("/api/v2/admin/properties/repair", a.adminRequired(a.handleAdminRepairCardProperties)).Methods("POST")
+	r.HandleFunc("/api/v2/admin/files/cleanup", a.adminRequired(a.handleAdminGetFileCleanupReport)).Methods("GET")
+	r.HandleFunc("/api/v2/admin/files/cleanup", a.adminRequired(a.handleAdminRunFileCleanup)).Methods("POST")
 }
 
 func getUserID(r *http.Request) string {
@@ -170,6 +190,25 @@ func getUserID(r *http.Request) string {
 	return session.UserID
 }
 
+// hasPermissionToRelatedBoards checks that a user can view the boards the relation
+// properties among cardProperties link to, and manage their cards when the relations
+// have back-references. The boards of ownBoardIDs are not checked.
+func (a *API) hasPermissionToRelatedBoards(userID string, cardProperties []map[string]interface{}, ownBoardIDs map[string]bool) bool {
+	for _, relation := range model.CardPropertyRelations(cardProperties) {
+		if ownBoardIDs[relation.BoardID] {
+			continue
+		}
+		if !a.permissions.HasPermissionToBoard(userID, relation.BoardID, model.PermissionViewBoard) {
+			return false
+		}
+		if relation.BackReferencePropertyID != "" &&
+			!a.permissions.HasPermissionToBoard(userID, relation.BoardID, model.PermissionManageBoardCards) {
+			return false
+		}
+	}
+	return true
+}
+
 func (a *API) panicHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -798,6 +837,10 @@ func (a *API) handlePostBlocks(w http.ResponseWriter, r *http.Request) {
 	//       items:
 	//         $ref: '#/definitions/Block'
 	//       type: array
+	//   '400':
+	//     description: invalid card property values
+	//     schema:
+	//       "$ref": "#/definitions/PropertyErrorResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
@@ -875,6 +918,19 @@ func (a *API) handlePostBlocks(w http.ResponseWriter, r *http.Request) {
 
 	newBlocks, err := a.app.InsertBlocks(blocks, session.UserID, true)
 	if err != nil {
+		var errProps *model.ErrInvalidPropertyValues
+		if errors.As(err, &errProps) {
+			a.propertyErrorResponse(w, r.URL.Path, errProps)
+			return
+		}
+		if errors.Is(err, model.ErrInvalidChecklistItem) {
+			a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		if errors.Is(err, model.ErrInvalidAttachmentBlock) {
+			a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
+			return
+		}
 		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
 		return
 	}
@@ -952,6 +1008,10 @@ func (a *API) handleUpdateUserConfig(w http.ResponseWriter, r *http.Request) {
 	}
 
 	updatedConfig, err := a.app.UpdateUserConfig(userID, *patch)
+	if errors.Is(err, model.ErrInvalidTimezone) {
+		a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
+		return
+	}
 	if err != nil {
 		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
 		return
@@ -1363,6 +1423,14 @@ func (a *API) handlePatchBlock(w http.ResponseWriter, r *http.Request) {
 	//     description: success
 	//   '404':
 	//     description: block not found
+	//   '400':
+	//     description: invalid card property values
+	//     schema:
+	//       "$ref": "#/definitions/PropertyErrorResponse"
+	//   '409':
+	//     description: the patch conflicts with changes made since the version it is based on
+	//     schema:
+	//       "$ref": "#/definitions/PatchConflictResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
@@ -1408,6 +1476,20 @@ func (a *API) handlePatchBlock(w http.ResponseWriter, r *http.Request) {
 
 	err = a.app.PatchBlock(blockID, patch, userID)
 	if err != nil {
+		var errProps *model.ErrInvalidPropertyValues
+		if errors.As(err, &errProps) {
+			a.propertyErrorResponse(w, r.URL.Path, errProps)
+			return
+		}
+		var errConflict *model.ErrPatchConflict
+		if errors.As(err, &errConflict) {
+			a.patchConflictResponse(w, r.URL.Path, errConflict)
+			return
+		}
+		if errors.Is(err, model.ErrInvalidChecklistItem) {
+			a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
+			return
+		}
 		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
 		return
 	}
@@ -1443,6 +1525,14 @@ func (a *API) handlePatchBlocks(w http.ResponseWriter, r *http.Request) {
 	// responses:
 	//   '200':
 	//     description: success
+	//   '400':
+	//     description: invalid card property values
+	//     schema:
+	//       "$ref": "#/definitions/PropertyErrorResponse"
+	//   '409':
+	//     description: the patch conflicts with changes made since the version it is based on
+	//     schema:
+	//       "$ref": "#/definitions/PatchConflictResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
@@ -1489,6 +1579,20 @@ func (a *API) handlePatchBlocks(w http.ResponseWriter, r *http.Request) {
 
 	err = a.app.PatchBlocks(teamID, patches, userID)
 	if err != nil {
+		var errProps *model.ErrInvalidPropertyValues
+		if errors.As(err, &errProps) {
+			a.propertyErrorResponse(w, r.URL.Path, errProps)
+			return
+		}
+		var errConflict *model.ErrPatchConflict
+		if errors.As(err, &errConflict) {
+			a.patchConflictResponse(w, r.URL.Path, errConflict)
+			return
+		}
+		if errors.Is(err, model.ErrInvalidChecklistItem) {
+			a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
+			return
+		}
 		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
 		return
 	}
@@ -1855,11 +1959,32 @@ func (a *API) handleServeFile(w http.ResponseWriter, r *http.Request) {
 	//   description: name of the file
 	//   required: true
 	//   type: string
+	// - name: size
+	//   in: query
+	//   description: variant of an image to return, thumbnail (200px wide) or preview (800px wide)
+	//   required: false
+	//   type: string
+	// - name: expires
+	//   in: query
+	//   description: expiry time of a signed URL, in milliseconds
+	//   required: false
+	//   type: integer
+	// - name: signature
+	//   in: query
+	//   description: signature of a signed URL, which grants access to the file without a session
+	//   required: false
+	//   type: string
 	// security:
 	// - BearerAuth: []
 	// responses:
 	//   '200':
 	//     description: success
+	//   '302':
+	//     description: redirect to a presigned URL of the files storage
+	//   '400':
+	//     description: invalid size
+	//   '403':
+	//     description: invalid or expired signed URL
 	//   '404':
 	//     description: file not found
 	//   default:
@@ -1868,20 +1993,182 @@ func (a *API) handleServeFile(w http.ResponseWriter, r *http.Request) {
 	//       "$ref": "#/definitions/ErrorResponse"
 
 	vars := mux.Vars(r)
+	teamID := vars["teamID"]
 	boardID := vars["boardID"]
 	filename := vars["filename"]
-	userID := getUserID(r)
+	query := r.URL.Query()
+	variant := query.Get("size")
 
-	hasValidReadToken := a.hasValidReadTokenForBoard(r, boardID)
-	if userID == "" && !hasValidReadToken {
-		a.errorResponse(w, r.URL.Path, http.StatusUnauthorized, "", nil)
+	// the signed URLs are checked without a session or a database lookup.
+	var signedURLExpiresAt int64
+	if signature := query.Get(app.FileURLSignatureParam); signature != "" {
+		expiresAt, err := a.app.CheckSignedFileURL(teamID, boardID, filename, variant, query.Get(app.FileURLExpiresParam), signature)
+		if err != nil {
+			a.errorResponse(w, r.URL.Path, http.StatusForbidden, err.Error(), err)
+			return
+		}
+		signedURLExpiresAt = expiresAt
+	} else {
+		userID := getUserID(r)
+		hasValidReadToken := a.hasValidReadTokenForBoard(r, boardID)
+		if userID == "" && !hasValidReadToken {
+			a.errorResponse(w, r.URL.Path, http.StatusUnauthorized, "", nil)
+			return
+		}
+
+		if !hasValidReadToken && !a.permissions.HasPermissionToBoard(userID, boardID, model.PermissionViewBoard) {
+			a.errorResponse(w, r.URL.Path, http.StatusForbidden, "", PermissionError{"access denied to board"})
+			return
+		}
+
+		board, err := a.app.GetBoard(boardID)
+		if err != nil {
+			a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+			return
+		}
+		if board == nil {
+			a.errorResponse(w, r.URL.Path, http.StatusNotFound, "", nil)
+			return
+		}
+		teamID = board.TeamID
+	}
+
+	auditRec := a.makeAuditRecord(r, "getFile", audit.Fail)
+	defer a.audit.LogRecord(audit.LevelRead, auditRec)
+	auditRec.AddMeta("boardID", boardID)
+	auditRec.AddMeta("teamID", teamID)
+	auditRec.AddMeta("filename", filename)
+	if variant != "" {
+		auditRec.AddMeta("size", variant)
+	}
+
+	if signedURLExpiresAt != 0 && variant == "" {
+		presignedURL, err := a.app.GetPresignedFileURL(teamID, boardID, filename, signedURLExpiresAt)
+		if err != nil {
+			a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+			return
+		}
+		if presignedURL != "" {
+			http.Redirect(w, r, presignedURL, http.StatusFound)
+			auditRec.Success()
+			return
+		}
+	}
+
+	fileReader, variantType, err := a.openFile(teamID, boardID, filename, variant)
+	if errors.Is(err, model.ErrInvalidFileVariant) {
+		a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
 		return
 	}
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+	defer fileReader.Close()
+
+	// the metadata is unknown for the files uploaded before it was recorded.
+	fileInfo, err := a.app.GetFileInfo(filename)
+	if err != nil && !model.IsErrNotFound(err) {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+	if fileInfo != nil && fileInfo.BoardID != boardID {
+		fileInfo = nil
+	}
+
+	contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename)))
+	if fileInfo != nil && fileInfo.MimeType != "" {
+		contentType = fileInfo.MimeType
+	}
+	if variantType != "" {
+		contentType = variantType
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// raster images are displayed in the cards, the other files are downloaded
+	// with their original name.
+	disposition := "inline"
+	downloadName := filename
+	if !model.IsInlineMimeType(contentType) {
+		disposition = "attachment"
+		if fileInfo != nil && fileInfo.Name != "" {
+			downloadName = fileInfo.Name
+		}
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": downloadName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+
+	http.ServeContent(w, r, filename, time.Now(), fileReader)
+	auditRec.Success()
+}
 
-	if !hasValidReadToken && !a.permissions.HasPermissionToBoard(userID, boardID, model.PermissionViewBoard) {
+func (a *API) handleGetFileURL(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /files/teams/{teamID}/{boardID}/{filename}/url getFileURL
+	//
+	// Returns a signed URL to download an uploaded file without a session, until it expires
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: teamID
+	//   in: path
+	//   description: Team ID
+	//   required: true
+	//   type: string
+	// - name: boardID
+	//   in: path
+	//   description: Board ID
+	//   required: true
+	//   type: string
+	// - name: filename
+	//   in: path
+	//   description: name of the file
+	//   required: true
+	//   type: string
+	// - name: size
+	//   in: query
+	//   description: variant of an image to download, thumbnail (200px wide) or preview (800px wide)
+	//   required: false
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       "$ref": "#/definitions/FileURLResponse"
+	//   '400':
+	//     description: invalid size
+	//   '404':
+	//     description: board not found
+	//   '501':
+	//     description: file URL signing disabled, the server has no secret
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	vars := mux.Vars(r)
+	boardID := vars["boardID"]
+	filename := vars["filename"]
+	variant := r.URL.Query().Get("size")
+	userID := getUserID(r)
+
+	if !a.permissions.HasPermissionToBoard(userID, boardID, model.PermissionViewBoard) {
 		a.errorResponse(w, r.URL.Path, http.StatusForbidden, "", PermissionError{"access denied to board"})
 		return
 	}
+	if _, ok := model.FileVariantWidths[variant]; variant != "" && !ok {
+		err := fmt.Errorf("%w: %s", model.ErrInvalidFileVariant, variant)
+		a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
+		return
+	}
 
 	board, err := a.app.GetBoard(boardID)
 	if err != nil {
@@ -1893,41 +2180,80 @@ func (a *API) handleServeFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	auditRec := a.makeAuditRecord(r, "getFile", audit.Fail)
+	auditRec := a.makeAuditRecord(r, "getFileURL", audit.Fail)
 	defer a.audit.LogRecord(audit.LevelRead, auditRec)
 	auditRec.AddMeta("boardID", boardID)
 	auditRec.AddMeta("teamID", board.TeamID)
 	auditRec.AddMeta("filename", filename)
 
-	contentType := "image/jpg"
-
-	fileExtension := strings.ToLower(filepath.Ext(filename))
-	if fileExtension == "png" {
-		contentType = "image/png"
+	fileURL, expiresAt, err := a.app.GetSignedFileURL(board.TeamID, boardID, filename, variant)
+	if errors.Is(err, model.ErrFileURLSigningDisabled) {
+		a.errorResponse(w, r.URL.Path, http.StatusNotImplemented, err.Error(), err)
+		return
 	}
-
-	if fileExtension == "gif" {
-		contentType = "image/gif"
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
 	}
 
-	w.Header().Set("Content-Type", contentType)
-
-	fileReader, err := a.app.GetFileReader(board.TeamID, boardID, filename)
+	data, err := json.Marshal(FileURLResponse{URL: fileURL, ExpiresAt: expiresAt})
 	if err != nil {
 		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
 		return
 	}
-	defer fileReader.Close()
-	http.ServeContent(w, r, filename, time.Now(), fileReader)
+	jsonBytesResponse(w, http.StatusOK, data)
 	auditRec.Success()
 }
 
+// openFile returns a reader on an uploaded file, or on a variant of the image if a
+// variant is requested, and the MIME type of the variant.
+func (a *API) openFile(teamID, boardID, filename, variant string) (io.ReadSeekCloser, string, error) {
+	if variant == "" {
+		reader, err := a.app.GetFileReader(teamID, boardID, filename)
+		return reader, "", err
+	}
+	return a.app.GetFileVariantReader(teamID, boardID, filename, variant)
+}
+
 // FileUploadResponse is the response to a file upload
 // swagger:model
 type FileUploadResponse struct {
 	// The FileID to retrieve the uploaded file
 	// required: true
 	FileID string `json:"fileId"`
+
+	// The original name of the file
+	Name string `json:"name,omitempty"`
+
+	// The MIME type of the file
+	MimeType string `json:"mimeType,omitempty"`
+
+	// The size of the file in bytes
+	Size int64 `json:"size,omitempty"`
+
+	// The SHA-256 checksum of the file content, hex encoded
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// FileURLResponse is a signed URL to download a file
+// swagger:model
+type FileURLResponse struct {
+	// The URL of the file, which does not require a session
+	// required: true
+	URL string `json:"url"`
+
+	// The time the URL expires at, in milliseconds since the current epoch
+	// required: true
+	ExpiresAt int64 `json:"expiresAt"`
+}
+
+func FileURLResponseFromJSON(data io.Reader) (*FileURLResponse, error) {
+	var fileURLResponse FileURLResponse
+
+	if err := json.NewDecoder(data).Decode(&fileURLResponse); err != nil {
+		return nil, err
+	}
+	return &fileURLResponse, nil
 }
 
 func FileUploadResponseFromJSON(data io.Reader) (*FileUploadResponse, error) {
@@ -1971,8 +2297,14 @@ func (a *API) handleUploadFile(w http.ResponseWriter, r *http.Request) {
 	//     description: success
 	//     schema:
 	//       "$ref": "#/definitions/FileUploadResponse"
+	//   '400':
+	//     description: infected file
 	//   '404':
 	//     description: board not found
+	//   '413':
+	//     description: file too large or team file quota exceeded
+	//   '415':
+	//     description: file type not allowed or not matching the file content
 	//   default:
 	//     description: internal error
 	//     schema:
@@ -2018,17 +2350,36 @@ func (a *API) handleUploadFile(w http.ResponseWriter, r *http.Request) {
 	auditRec.AddMeta("teamID", board.TeamID)
 	auditRec.AddMeta("filename", handle.Filename)
 
-	fileID, err := a.app.SaveFile(file, board.TeamID, boardID, handle.Filename)
+	fileInfo, err := a.app.UploadFile(file, board.TeamID, boardID, handle.Filename, userID)
+	if errors.Is(err, model.ErrTeamFileQuotaExceeded) {
+		a.errorResponse(w, r.URL.Path, http.StatusRequestEntityTooLarge, err.Error(), err)
+		return
+	}
+	if errors.Is(err, model.ErrFileTypeNotAllowed) || errors.Is(err, model.ErrFileTypeMismatch) {
+		a.errorResponse(w, r.URL.Path, http.StatusUnsupportedMediaType, err.Error(), err)
+		return
+	}
+	if errors.Is(err, model.ErrFileInfected) {
+		a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
+		return
+	}
 	if err != nil {
 		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
 		return
 	}
+	fileID := fileInfo.ID
 
 	a.logger.Debug("uploadFile",
 		mlog.String("filename", handle.Filename),
 		mlog.String("fileID", fileID),
 	)
-	data, err := json.Marshal(FileUploadResponse{FileID: fileID})
+	data, err := json.Marshal(FileUploadResponse{
+		FileID:   fileID,
+		Name:     fileInfo.Name,
+		MimeType: fileInfo.MimeType,
+		Size:     fileInfo.Size,
+		Checksum: fileInfo.Checksum,
+	})
 	if err != nil {
 		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
 		return
@@ -2518,6 +2869,11 @@ func (a *API) handleCreateBoard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !a.hasPermissionToRelatedBoards(userID, newBoard.CardProperties, nil) {
+		a.errorResponse(w, r.URL.Path, http.StatusForbidden, "", PermissionError{"access denied to related boards"})
+		return
+	}
+
 	auditRec := a.makeAuditRecord(r, "createBoard", audit.Fail)
 	defer a.audit.LogRecord(audit.LevelModify, auditRec)
 	auditRec.AddMeta("teamID", newBoard.TeamID)
@@ -2718,6 +3074,10 @@ func (a *API) handlePatchBoard(w http.ResponseWriter, r *http.Request) {
 	//       $ref: '#/definitions/Board'
 	//   '404':
 	//     description: board not found
+	//   '409':
+	//     description: the patch conflicts with changes made since the version it is based on
+	//     schema:
+	//       "$ref": "#/definitions/PatchConflictResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
@@ -2765,6 +3125,11 @@ func (a *API) handlePatchBoard(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if !a.hasPermissionToRelatedBoards(userID, patch.UpdatedCardProperties, map[string]bool{boardID: true}) {
+		a.errorResponse(w, r.URL.Path, http.StatusForbidden, "", PermissionError{"access denied to related boards"})
+		return
+	}
+
 	auditRec := a.makeAuditRecord(r, "patchBoard", audit.Fail)
 	defer a.audit.LogRecord(audit.LevelModify, auditRec)
 	auditRec.AddMeta("boardID", boardID)
@@ -2773,6 +3138,11 @@ func (a *API) handlePatchBoard(w http.ResponseWriter, r *http.Request) {
 	// patch board
 	updatedBoard, err := a.app.PatchBoard(patch, boardID, userID)
 	if err != nil {
+		var errConflict *model.ErrPatchConflict
+		if errors.As(err, &errConflict) {
+			a.patchConflictResponse(w, r.URL.Path, errConflict)
+			return
+		}
 		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
 		return
 	}
@@ -3129,6 +3499,154 @@ func (a *API) handleGetBoardMetadata(w http.ResponseWriter, r *http.Request) {
 	auditRec.Success()
 }
 
+func (a *API) handleGetViewAggregations(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /boards/{boardID}/views/{viewID}/aggregations getViewAggregations
+	//
+	// Returns the sum, average, min, max and counts of the card property values of a view,
+	// in total and per group
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: boardID
+	//   in: path
+	//   description: Board ID
+	//   required: true
+	//   type: string
+	// - name: viewID
+	//   in: path
+	//   description: View ID
+	//   required: true
+	//   type: string
+	// - name: propertyId
+	//   in: query
+	//   description: The properties to aggregate, every property of the board if absent
+	//   required: false
+	//   type: array
+	//   items:
+	//     type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       "$ref": "#/definitions/ViewAggregations"
+	//   '400':
+	//     description: unknown property
+	//   '404':
+	//     description: board or view not found
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	boardID := mux.Vars(r)["boardID"]
+	viewID := mux.Vars(r)["viewID"]
+	userID := getUserID(r)
+	propertyIDs := r.URL.Query()["propertyId"]
+
+	if !a.permissions.HasPermissionToBoard(userID, boardID, model.PermissionViewBoard) {
+		a.errorResponse(w, r.URL.Path, http.StatusForbidden, "", PermissionError{"access denied to board"})
+		return
+	}
+
+	auditRec := a.makeAuditRecord(r, "getViewAggregations", audit.Fail)
+	defer a.audit.LogRecord(audit.LevelRead, auditRec)
+	auditRec.AddMeta("boardID", boardID)
+	auditRec.AddMeta("viewID", viewID)
+
+	aggregations, err := a.app.GetViewAggregations(boardID, viewID, propertyIDs)
+	if model.IsErrNotFound(err) {
+		a.errorResponse(w, r.URL.Path, http.StatusNotFound, "", err)
+		return
+	}
+	if errors.Is(err, model.ErrInvalidProperty) {
+		a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(aggregations)
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	// response
+	jsonBytesResponse(w, http.StatusOK, data)
+
+	auditRec.Success()
+}
+
+func (a *API) handleGetPropertyConformance(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /boards/{boardID}/properties/conformance getPropertyConformance
+	//
+	// Returns the cards of a board that miss required properties or have property values
+	// that do not match the property schema of the board
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: boardID
+	//   in: path
+	//   description: Board ID
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       "$ref": "#/definitions/PropertyConformanceReport"
+	//   '404':
+	//     description: board not found
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	boardID := mux.Vars(r)["boardID"]
+	userID := getUserID(r)
+
+	if !a.permissions.HasPermissionToBoard(userID, boardID, model.PermissionViewBoard) {
+		a.errorResponse(w, r.URL.Path, http.StatusForbidden, "", PermissionError{"access denied to board"})
+		return
+	}
+
+	auditRec := a.makeAuditRecord(r, "getPropertyConformance", audit.Fail)
+	defer a.audit.LogRecord(audit.LevelRead, auditRec)
+	auditRec.AddMeta("boardID", boardID)
+
+	report, err := a.app.GetPropertyConformance(boardID)
+	if model.IsErrNotFound(err) {
+		a.errorResponse(w, r.URL.Path, http.StatusNotFound, "", err)
+		return
+	}
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	// response
+	jsonBytesResponse(w, http.StatusOK, data)
+
+	auditRec.AddMeta("nonConformingCards", report.NonConformingCards)
+	auditRec.Success()
+}
+
 func (a *API) handleSearchBoards(w http.ResponseWriter, r *http.Request) {
 	// swagger:operation GET /teams/{teamID}/boards/search searchBoards
 	//
@@ -3791,6 +4309,13 @@ func (a *API) handleCreateBoardsAndBlocks(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	for _, board := range newBab.Boards {
+		if !a.hasPermissionToRelatedBoards(userID, board.CardProperties, boardIDs) {
+			a.errorResponse(w, r.URL.Path, http.StatusForbidden, "", PermissionError{"access denied to related boards"})
+			return
+		}
+	}
+
 	for _, block := range newBab.Blocks {
 		// Error checking
 		if len(block.Type) < 1 {
@@ -3881,6 +4406,10 @@ func (a *API) handlePatchBoardsAndBlocks(w http.ResponseWriter, r *http.Request)
 	//     description: success
 	//     schema:
 	//       $ref: '#/definitions/BoardsAndBlocks'
+	//   '409':
+	//     description: the patch conflicts with changes made since the version it is based on
+	//     schema:
+	//       "$ref": "#/definitions/PatchConflictResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
@@ -3928,6 +4457,11 @@ func (a *API) handlePatchBoardsAndBlocks(w http.ResponseWriter, r *http.Request)
 			}
 		}
 
+		if !a.hasPermissionToRelatedBoards(userID, patch.UpdatedCardProperties, map[string]bool{boardID: true}) {
+			a.errorResponse(w, r.URL.Path, http.StatusForbidden, "", PermissionError{"access denied to related boards"})
+			return
+		}
+
 		board, err2 := a.app.GetBoard(boardID)
 		if err2 != nil {
 			a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err2)
@@ -3976,6 +4510,11 @@ func (a *API) handlePatchBoardsAndBlocks(w http.ResponseWriter, r *http.Request)
 
 	bab, err := a.app.PatchBoardsAndBlocks(pbab, userID)
 	if err != nil {
+		var errConflict *model.ErrPatchConflict
+		if errors.As(err, &errConflict) {
+			a.patchConflictResponse(w, r.URL.Path, errConflict)
+			return
+		}
 		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
 		return
 	}
@@ -4156,6 +4695,51 @@ func (a *API) errorResponse(w http.ResponseWriter, api string, code int, message
 	_, _ = w.Write(data)
 }
 
+// propertyErrorResponse writes a bad request response listing the card property
+// values that do not match the board's property schema.
+func (a *API) propertyErrorResponse(w http.ResponseWriter, api string, err *model.ErrInvalidPropertyValues) {
+	a.logger.Debug("API DEBUG",
+		mlog.Int("code", http.StatusBadRequest),
+		mlog.Err(err),
+		mlog.String("api", api),
+	)
+
+	data, errMarshal := json.Marshal(model.PropertyErrorResponse{
+		ErrorResponse: model.ErrorResponse{
+			Error:     err.Error(),
+			ErrorCode: http.StatusBadRequest,
+		},
+		PropertyErrors: err.Errors,
+	})
+	if errMarshal != nil {
+		data = []byte("{}")
+	}
+	jsonBytesResponse(w, http.StatusBadRequest, data)
+}
+
+// patchConflictResponse writes a conflict response including the current version of
+// the block or board a patch conflicts with.
+func (a *API) patchConflictResponse(w http.ResponseWriter, api string, err *model.ErrPatchConflict) {
+	a.logger.Debug("API DEBUG",
+		mlog.Int("code", http.StatusConflict),
+		mlog.Err(err),
+		mlog.String("api", api),
+	)
+
+	data, errMarshal := json.Marshal(model.PatchConflictResponse{
+		ErrorResponse: model.ErrorResponse{
+			Error:     err.Error(),
+			ErrorCode: http.StatusConflict,
+		},
+		Block: err.Block,
+		Board: err.Board,
+	})
+	if errMarshal != nil {
+		data = []byte("{}")
+	}
+	jsonBytesResponse(w, http.StatusConflict, data)
+}
+
 func stringResponse(w http.ResponseWriter, message string) {
 	w.Header().Set("Content-Type", "text/plain")
 	_, _ = fmt.Fprint(w, message)
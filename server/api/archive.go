@@ -1,6 +1,9 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -13,7 +16,9 @@ import (
 )
 
 const (
-	archiveExtension = ".boardarchive"
+	archiveExtension  = ".boardarchive"
+	markdownExtension = ".md"
+	htmlExtension     = ".html"
 )
 
 func (a *API) handleArchiveExportBoard(w http.ResponseWriter, r *http.Request) {
@@ -30,6 +35,11 @@ func (a *API) handleArchiveExportBoard(w http.ResponseWriter, r *http.Request) {
 	//   description: Id of board to export
 	//   required: true
 	//   type: string
+	// - name: includeMembership
+	//   in: query
+	//   description: Include board members, their categories and subscriptions. Requires permission to manage board roles.
+	//   required: false
+	//   type: boolean
 	// security:
 	// - BearerAuth: []
 	// responses:
@@ -53,9 +63,16 @@ func (a *API) handleArchiveExportBoard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	includeMembership := r.URL.Query().Get("includeMembership") == "true"
+	if includeMembership && !a.permissions.HasPermissionToBoard(userID, boardID, model.PermissionManageBoardRoles) {
+		a.errorResponse(w, r.URL.Path, http.StatusForbidden, "", PermissionError{"access denied to board members"})
+		return
+	}
+
 	auditRec := a.makeAuditRecord(r, "archiveExportBoard", audit.Fail)
 	defer a.audit.LogRecord(audit.LevelRead, auditRec)
 	auditRec.AddMeta("BoardID", boardID)
+	auditRec.AddMeta("includeMembership", includeMembership)
 
 	board, err := a.app.GetBoard(boardID)
 	if err != nil {
@@ -68,8 +85,10 @@ func (a *API) handleArchiveExportBoard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	opts := model.ExportArchiveOptions{
-		TeamID:   board.TeamID,
-		BoardIDs: []string{board.ID},
+		TeamID:            board.TeamID,
+		BoardIDs:          []string{board.ID},
+		ExportedBy:        userID,
+		IncludeMembership: includeMembership,
 	}
 
 	filename := fmt.Sprintf("archive-%s%s", time.Now().Format("2006-01-02"), archiveExtension)
@@ -84,6 +103,130 @@ func (a *API) handleArchiveExportBoard(w http.ResponseWriter, r *http.Request) {
 	auditRec.Success()
 }
 
+func (a *API) handleExportBoardDocument(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /boards/{boardID}/export exportBoardDocument
+	//
+	// Exports a board as a human-readable Markdown or HTML document.
+	//
+	// ---
+	// produces:
+	// - text/markdown
+	// - text/html
+	// parameters:
+	// - name: boardID
+	//   in: path
+	//   description: Id of board to export
+	//   required: true
+	//   type: string
+	// - name: format
+	//   in: query
+	//   description: Document format, either "markdown" or "html"
+	//   required: false
+	//   type: string
+	// - name: groupBy
+	//   in: query
+	//   description: Id of a select property used to group cards
+	//   required: false
+	//   type: string
+	// - name: embedImages
+	//   in: query
+	//   description: Embed images in the document instead of linking them
+	//   required: false
+	//   type: boolean
+	// - name: timezone
+	//   in: query
+	//   description: IANA timezone dates are rendered in, the timezone of the user by default
+	//   required: false
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     content:
+	//       text/markdown:
+	//         type: string
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	vars := mux.Vars(r)
+	boardID := vars["boardID"]
+	userID := getUserID(r)
+	query := r.URL.Query()
+
+	if !a.permissions.HasPermissionToBoard(userID, boardID, model.PermissionViewBoard) {
+		a.errorResponse(w, r.URL.Path, http.StatusForbidden, "", PermissionError{"access denied to board"})
+		return
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = model.ExportFormatMarkdown
+	}
+
+	var contentType, extension string
+	switch format {
+	case model.ExportFormatMarkdown:
+		contentType = "text/markdown; charset=utf-8"
+		extension = markdownExtension
+	case model.ExportFormatHTML:
+		contentType = "text/html; charset=utf-8"
+		extension = htmlExtension
+	default:
+		a.errorResponse(w, r.URL.Path, http.StatusBadRequest, "invalid format", nil)
+		return
+	}
+
+	auditRec := a.makeAuditRecord(r, "exportBoardDocument", audit.Fail)
+	defer a.audit.LogRecord(audit.LevelRead, auditRec)
+	auditRec.AddMeta("BoardID", boardID)
+	auditRec.AddMeta("format", format)
+
+	board, err := a.app.GetBoard(boardID)
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+	if board == nil {
+		a.errorResponse(w, r.URL.Path, http.StatusNotFound, "", nil)
+		return
+	}
+
+	loc, err := a.userLocation(userID, query.Get("timezone"))
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	opts := model.ExportDocumentOptions{
+		TeamID:            board.TeamID,
+		Format:            format,
+		GroupByPropertyID: query.Get("groupBy"),
+		EmbedImages:       query.Get("embedImages") == "true",
+		Location:          loc,
+	}
+
+	// render to a buffer first so errors can still be reported with a proper status.
+	var buf bytes.Buffer
+	if err = a.app.ExportBoardDocument(&buf, board.ID, opts); err != nil {
+		if errors.Is(err, model.ErrInvalidGroupByProperty) {
+			a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	filename := fmt.Sprintf("board-%s%s", time.Now().Format("2006-01-02"), extension)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	_, _ = w.Write(buf.Bytes())
+
+	auditRec.Success()
+}
+
 func (a *API) handleArchiveExportTeam(w http.ResponseWriter, r *http.Request) {
 	// swagger:operation GET /teams/{teamID}/archive/export archiveExportTeam
 	//
@@ -137,8 +280,9 @@ func (a *API) handleArchiveExportTeam(w http.ResponseWriter, r *http.Request) {
 	}
 
 	opts := model.ExportArchiveOptions{
-		TeamID:   teamID,
-		BoardIDs: ids,
+		TeamID:     teamID,
+		BoardIDs:   ids,
+		ExportedBy: userID,
 	}
 
 	filename := fmt.Sprintf("archive-%s%s", time.Now().Format("2006-01-02"), archiveExtension)
@@ -174,11 +318,18 @@ func (a *API) handleArchiveImport(w http.ResponseWriter, r *http.Request) {
 	//   description: archive file to import
 	//   required: true
 	//   type: file
+	// - name: importMembership
+	//   in: query
+	//   description: Restore board members, categories and subscriptions found in the archive
+	//   required: false
+	//   type: boolean
 	// security:
 	// - BearerAuth: []
 	// responses:
 	//   '200':
 	//     description: success
+	//     schema:
+	//       "$ref": "#/definitions/ImportArchiveResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
@@ -209,11 +360,13 @@ func (a *API) handleArchiveImport(w http.ResponseWriter, r *http.Request) {
 	auditRec.AddMeta("size", handle.Size)
 
 	opt := model.ImportArchiveOptions{
-		TeamID:     teamID,
-		ModifiedBy: userID,
+		TeamID:           teamID,
+		ModifiedBy:       userID,
+		ImportMembership: r.URL.Query().Get("importMembership") == "true",
 	}
 
-	if err := a.app.ImportArchive(file, opt); err != nil {
+	boardIDs, err := a.app.ImportArchive(file, opt)
+	if err != nil {
 		a.logger.Debug("Error importing archive",
 			mlog.String("team_id", teamID),
 			mlog.Err(err),
@@ -222,6 +375,13 @@ func (a *API) handleArchiveImport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jsonStringResponse(w, http.StatusOK, "{}")
+	data, err := json.Marshal(model.ImportArchiveResponse{BoardIDs: boardIDs})
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+	auditRec.AddMeta("boardCount", len(boardIDs))
 	auditRec.Success()
 }
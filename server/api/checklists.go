@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/audit"
+)
+
+var errInvalidDueBefore = errors.New("dueBefore must be a time in milliseconds")
+
+func (a *API) handleGetChecklistCards(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /boards/{boardID}/cards/checklist getChecklistCards
+	//
+	// Returns the cards of a board with incomplete checklist items, and the progress of
+	// their checklist
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: boardID
+	//   in: path
+	//   description: Board ID
+	//   required: true
+	//   type: string
+	// - name: assignee
+	//   in: query
+	//   description: Only the items assigned to this user ID, or to the current user for "me"
+	//   required: false
+	//   type: string
+	// - name: dueBefore
+	//   in: query
+	//   description: Only the items due before this time in milliseconds
+	//   required: false
+	//   type: integer
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/ChecklistCard"
+	//   '400':
+	//     description: invalid due date
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	boardID := mux.Vars(r)["boardID"]
+	userID := getUserID(r)
+	query := r.URL.Query()
+
+	if !a.permissions.HasPermissionToBoard(userID, boardID, model.PermissionViewBoard) {
+		a.errorResponse(w, r.URL.Path, http.StatusForbidden, "", PermissionError{"access denied to board"})
+		return
+	}
+
+	checklistQuery := model.ChecklistQuery{Assignee: query.Get("assignee")}
+	if checklistQuery.Assignee == "me" {
+		checklistQuery.Assignee = userID
+	}
+	if dueBefore := query.Get("dueBefore"); dueBefore != "" {
+		ms, err := strconv.ParseInt(dueBefore, 10, 64)
+		if err != nil || ms <= 0 {
+			a.errorResponse(w, r.URL.Path, http.StatusBadRequest, errInvalidDueBefore.Error(), errInvalidDueBefore)
+			return
+		}
+		checklistQuery.DueBefore = ms
+	}
+
+	auditRec := a.makeAuditRecord(r, "getChecklistCards", audit.Fail)
+	defer a.audit.LogRecord(audit.LevelRead, auditRec)
+	auditRec.AddMeta("boardID", boardID)
+
+	cards, err := a.app.GetChecklistCards(boardID, checklistQuery)
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(cards)
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	// response
+	jsonBytesResponse(w, http.StatusOK, data)
+
+	auditRec.AddMeta("cardCount", len(cards))
+	auditRec.Success()
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/audit"
+)
+
+var errInvalidDateRange = errors.New("either a period or a from and to range is required")
+
+func (a *API) handleGetCardsOverlappingDates(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /boards/{boardID}/cards/dates getCardsOverlappingDates
+	//
+	// Returns the cards whose value of a date property overlaps a date range
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: boardID
+	//   in: path
+	//   description: Board ID
+	//   required: true
+	//   type: string
+	// - name: propertyId
+	//   in: query
+	//   description: ID of the date property
+	//   required: true
+	//   type: string
+	// - name: from
+	//   in: query
+	//   description: Start of the range in milliseconds, included
+	//   required: false
+	//   type: integer
+	// - name: to
+	//   in: query
+	//   description: End of the range in milliseconds, excluded
+	//   required: false
+	//   type: integer
+	// - name: period
+	//   in: query
+	//   description: The range as a period containing the current date, one of "today", "thisWeek" or "thisMonth"
+	//   required: false
+	//   type: string
+	// - name: timezone
+	//   in: query
+	//   description: IANA timezone of the period, the timezone of the user by default
+	//   required: false
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Block"
+	//   '400':
+	//     description: invalid date range or property
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	boardID := mux.Vars(r)["boardID"]
+	userID := getUserID(r)
+	query := r.URL.Query()
+	propertyID := query.Get("propertyId")
+
+	if !a.permissions.HasPermissionToBoard(userID, boardID, model.PermissionViewBoard) {
+		a.errorResponse(w, r.URL.Path, http.StatusForbidden, "", PermissionError{"access denied to board"})
+		return
+	}
+
+	from, to, err := a.dateRangeFromQuery(userID, query)
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	auditRec := a.makeAuditRecord(r, "getCardsOverlappingDates", audit.Fail)
+	defer a.audit.LogRecord(audit.LevelRead, auditRec)
+	auditRec.AddMeta("boardID", boardID)
+	auditRec.AddMeta("propertyID", propertyID)
+
+	cards, err := a.app.GetCardsOverlappingDates(boardID, propertyID, from, to)
+	if errors.Is(err, model.ErrInvalidProperty) {
+		a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(cards)
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	// response
+	jsonBytesResponse(w, http.StatusOK, data)
+
+	auditRec.AddMeta("cardCount", len(cards))
+	auditRec.Success()
+}
+
+// dateRangeFromQuery returns the date range of a request, given either as a period
+// in the timezone of the user or as a from and to range.
+func (a *API) dateRangeFromQuery(userID string, query url.Values) (int64, int64, error) {
+	if period := query.Get("period"); period != "" {
+		loc, err := a.userLocation(userID, query.Get("timezone"))
+		if err != nil {
+			return 0, 0, err
+		}
+		if loc == nil {
+			loc = time.UTC
+		}
+		return model.DatePeriodBounds(period, time.Now(), loc)
+	}
+
+	from, errFrom := strconv.ParseInt(query.Get("from"), 10, 64)
+	to, errTo := strconv.ParseInt(query.Get("to"), 10, 64)
+	if errFrom != nil || errTo != nil || to <= from {
+		return 0, 0, errInvalidDateRange
+	}
+	return from, to, nil
+}
+
+// userLocation returns the location of the given timezone, or of the timezone of the
+// user if it is empty. It returns nil if the user has no timezone.
+func (a *API) userLocation(userID, timezone string) (*time.Location, error) {
+	if timezone != "" {
+		return model.LoadLocation(timezone)
+	}
+	// users that cannot be loaded, e.g. in single user mode, have no timezone.
+	var user *model.User
+	if u, err := a.app.GetUser(userID); err == nil {
+		user = u
+	}
+	return model.UserLocation(user), nil
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/audit"
+)
+
+func (a *API) handleGetRelatedCards(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /boards/{boardID}/cards/{cardID}/relations/{propertyID} getRelatedCards
+	//
+	// Returns the cards linked to a card by a relation property, omitting the cards
+	// of the boards the user cannot see
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: boardID
+	//   in: path
+	//   description: Board ID
+	//   required: true
+	//   type: string
+	// - name: cardID
+	//   in: path
+	//   description: Card ID
+	//   required: true
+	//   type: string
+	// - name: propertyID
+	//   in: path
+	//   description: ID of the relation property
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Block"
+	//   '400':
+	//     description: not a relation property
+	//   '404':
+	//     description: card not found
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	vars := mux.Vars(r)
+	boardID := vars["boardID"]
+	cardID := vars["cardID"]
+	propertyID := vars["propertyID"]
+	userID := getUserID(r)
+
+	if !a.permissions.HasPermissionToBoard(userID, boardID, model.PermissionViewBoard) {
+		a.errorResponse(w, r.URL.Path, http.StatusForbidden, "", PermissionError{"access denied to board"})
+		return
+	}
+
+	auditRec := a.makeAuditRecord(r, "getRelatedCards", audit.Fail)
+	defer a.audit.LogRecord(audit.LevelRead, auditRec)
+	auditRec.AddMeta("boardID", boardID)
+	auditRec.AddMeta("cardID", cardID)
+	auditRec.AddMeta("propertyID", propertyID)
+
+	cards, err := a.app.GetRelatedCards(boardID, cardID, propertyID)
+	if errors.Is(err, model.ErrInvalidProperty) {
+		a.errorResponse(w, r.URL.Path, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if model.IsErrNotFound(err) {
+		a.errorResponse(w, r.URL.Path, http.StatusNotFound, "", err)
+		return
+	}
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	// the linked cards may be on boards the user cannot see.
+	canView := map[string]bool{boardID: true}
+	visible := make([]model.Block, 0, len(cards))
+	for _, card := range cards {
+		allowed, ok := canView[card.BoardID]
+		if !ok {
+			allowed = a.permissions.HasPermissionToBoard(userID, card.BoardID, model.PermissionViewBoard)
+			canView[card.BoardID] = allowed
+		}
+		if allowed {
+			visible = append(visible, card)
+		}
+	}
+
+	data, err := json.Marshal(visible)
+	if err != nil {
+		a.errorResponse(w, r.URL.Path, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	// response
+	jsonBytesResponse(w, http.StatusOK, data)
+
+	auditRec.AddMeta("cardCount", len(visible))
+	auditRec.Success()
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/mattermost/focalboard/server/auth"
 	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/filepresign"
+	"github.com/mattermost/focalboard/server/services/filescan"
 	"github.com/mattermost/focalboard/server/services/metrics"
 	"github.com/mattermost/focalboard/server/services/notify"
 	"github.com/mattermost/focalboard/server/services/permissions"
@@ -28,6 +30,9 @@ type Services struct {
 	Auth             *auth.Auth
 	Store            store.Store
 	FilesBackend     filestore.FileBackend
+	BackupBackend    filestore.FileBackend
+	FileScanner      filescan.Scanner
+	FilePresigner    filepresign.Presigner
 	Webhook          *webhook.Client
 	Metrics          *metrics.Metrics
 	Notifications    *notify.Service
@@ -42,10 +47,14 @@ type App struct {
 	auth                *auth.Auth
 	wsAdapter           ws.Adapter
 	filesBackend        filestore.FileBackend
+	backupBackend       filestore.FileBackend
+	fileScanner         filescan.Scanner
+	filePresigner       filepresign.Presigner
 	webhook             *webhook.Client
 	metrics             *metrics.Metrics
 	notifications       *notify.Service
 	logger              *mlog.Logger
+	permissions         permissions.PermissionsService
 	blockChangeNotifier *utils.CallbackQueue
 }
 
@@ -64,10 +73,14 @@ func New(config *config.Configuration, wsAdapter ws.Adapter, services Services)
 		auth:                services.Auth,
 		wsAdapter:           wsAdapter,
 		filesBackend:        services.FilesBackend,
+		backupBackend:       services.BackupBackend,
+		fileScanner:         services.FileScanner,
+		filePresigner:       services.FilePresigner,
 		webhook:             services.Webhook,
 		metrics:             services.Metrics,
 		notifications:       services.Notifications,
 		logger:              services.Logger,
+		permissions:         services.Permissions,
 		blockChangeNotifier: utils.NewCallbackQueue("blockChangeNotifier", blockChangeNotifierQueueSize, blockChangeNotifierPoolSize, services.Logger),
 	}
 	app.initialize(services.SkipTemplateInit)
@@ -0,0 +1,269 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+const (
+	backupPrefix         = "focalboard-backup-"
+	backupTimeFormat     = "20060102T150405Z"
+	backupHealthFilename = "backup-health.json"
+	backupFileExtension  = ".boardarchive"
+)
+
+var ErrBackupsDisabled = errors.New("backups are not enabled")
+
+type backupResult struct {
+	teams  int
+	boards int
+	size   int64
+}
+
+// RunBackup exports the boards of every team to the backup target, rotates
+// the old backups and updates the backup health record.
+func (a *App) RunBackup() (*model.BackupHealth, error) {
+	if a.backupBackend == nil {
+		return nil, ErrBackupsDisabled
+	}
+
+	health, err := a.GetBackupHealth()
+	if err != nil {
+		if !model.IsErrNotFound(err) {
+			a.logger.Warn("Cannot read the backup health record, starting a new one", mlog.Err(err))
+		}
+		health = &model.BackupHealth{}
+	}
+
+	start := time.Now().UTC()
+	name := backupPrefix + start.Format(backupTimeFormat)
+	health.LastRunAt = utils.GetMillisForTime(start)
+
+	result, err := a.writeBackup(name)
+	if err == nil {
+		health.LastSuccessAt = utils.GetMillis()
+		health.LastBackup = name
+		health.Duration = health.LastSuccessAt - health.LastRunAt
+		health.Teams = result.teams
+		health.Boards = result.boards
+		health.Size = result.size
+
+		health.Backups, err = a.rotateBackups()
+	}
+
+	health.LastError = ""
+	if err != nil {
+		health.LastError = err.Error()
+	}
+
+	if errHealth := a.writeBackupHealth(health); errHealth != nil {
+		a.logger.Error("Cannot write the backup health record", mlog.Err(errHealth))
+	}
+
+	if err != nil {
+		return health, err
+	}
+
+	a.logger.Info("Backup completed",
+		mlog.String("backup", name),
+		mlog.Int("teams", health.Teams),
+		mlog.Int("boards", health.Boards),
+		mlog.Int64("size", health.Size),
+	)
+	return health, nil
+}
+
+// GetBackupHealth returns the health record written by the last backup.
+func (a *App) GetBackupHealth() (*model.BackupHealth, error) {
+	if a.backupBackend == nil {
+		return nil, ErrBackupsDisabled
+	}
+
+	exists, err := a.backupBackend.FileExists(backupHealthFilename)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, model.NewErrNotFound("backup health")
+	}
+
+	data, err := a.backupBackend.ReadFile(backupHealthFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	var health model.BackupHealth
+	if err = json.Unmarshal(data, &health); err != nil {
+		return nil, fmt.Errorf("cannot decode backup health record: %w", err)
+	}
+	return &health, nil
+}
+
+func (a *App) writeBackupHealth(health *model.BackupHealth) error {
+	data, err := json.Marshal(health)
+	if err != nil {
+		return err
+	}
+	_, err = a.backupBackend.WriteFile(bytes.NewReader(data), backupHealthFilename)
+	return err
+}
+
+// writeBackup writes one archive per team into the backup directory. A failed
+// backup is removed so that it is never picked as a restore candidate.
+func (a *App) writeBackup(name string) (backupResult, error) {
+	var result backupResult
+
+	teamIDs, err := a.getAllTeamIDs()
+	if err != nil {
+		return result, fmt.Errorf("cannot list teams: %w", err)
+	}
+
+	var started bool
+	for _, teamID := range teamIDs {
+		boards, err2 := a.store.GetBoardsForTeam(teamID)
+		if err2 != nil {
+			err = fmt.Errorf("cannot list boards for team %s: %w", teamID, err2)
+			break
+		}
+		if len(boards) == 0 {
+			continue
+		}
+
+		boardIDs := make([]string, 0, len(boards))
+		for _, board := range boards {
+			boardIDs = append(boardIDs, board.ID)
+		}
+
+		opts := model.ExportArchiveOptions{
+			TeamID:            teamID,
+			BoardIDs:          boardIDs,
+			ExportedBy:        model.SystemUserID,
+			IncludeMembership: true,
+		}
+
+		started = true
+		size, err2 := a.writeBackupArchive(name+"/"+teamID+backupFileExtension, opts)
+		if err2 != nil {
+			err = fmt.Errorf("cannot back up team %s: %w", teamID, err2)
+			break
+		}
+
+		result.teams++
+		result.boards += len(boards)
+		result.size += size
+	}
+
+	if err != nil {
+		if started {
+			if errRemove := a.backupBackend.RemoveDirectory(name); errRemove != nil {
+				a.logger.Error("Cannot remove incomplete backup", mlog.String("backup", name), mlog.Err(errRemove))
+			}
+		}
+		return result, err
+	}
+	return result, nil
+}
+
+// writeBackupArchive streams an archive export into the backup target.
+func (a *App) writeBackupArchive(path string, opts model.ExportArchiveOptions) (int64, error) {
+	pr, pw := io.Pipe()
+
+	errExport := make(chan error, 1)
+	go func() {
+		err := a.ExportArchive(pw, opts)
+		_ = pw.CloseWithError(err)
+		errExport <- err
+	}()
+
+	size, err := a.backupBackend.WriteFile(pr, path)
+	_ = pr.Close()
+
+	if errE := <-errExport; errE != nil && err == nil {
+		err = errE
+	}
+	return size, err
+}
+
+// rotateBackups removes the backups that are not retained by the daily and
+// weekly rotation, and returns the names of the remaining ones, newest first.
+func (a *App) rotateBackups() ([]string, error) {
+	paths, err := a.backupBackend.ListDirectory("")
+	if err != nil {
+		return nil, fmt.Errorf("cannot list backups: %w", err)
+	}
+
+	names := make([]string, 0, len(paths))
+	times := make(map[string]time.Time, len(paths))
+	for _, path := range paths {
+		name := strings.Trim(path, "/")
+		if !strings.HasPrefix(name, backupPrefix) {
+			continue
+		}
+		t, errParse := time.Parse(backupTimeFormat, strings.TrimPrefix(name, backupPrefix))
+		if errParse != nil {
+			continue
+		}
+		names = append(names, name)
+		times[name] = t
+	}
+	sort.Slice(names, func(i, j int) bool { return times[names[i]].After(times[names[j]]) })
+
+	keep := selectBackupsToKeep(names, times, a.config.BackupKeepDaily, a.config.BackupKeepWeekly)
+
+	kept := make([]string, 0, len(keep))
+	for _, name := range names {
+		if keep[name] {
+			kept = append(kept, name)
+			continue
+		}
+		if err = a.backupBackend.RemoveDirectory(name); err != nil {
+			return nil, fmt.Errorf("cannot remove backup %s: %w", name, err)
+		}
+		a.logger.Debug("Removed rotated backup", mlog.String("backup", name))
+	}
+	return kept, nil
+}
+
+// selectBackupsToKeep keeps the newest backup of each of the last keepDaily days
+// and of each of the last keepWeekly ISO weeks. Names must be sorted newest first.
+// When both limits are zero every backup is kept.
+func selectBackupsToKeep(names []string, times map[string]time.Time, keepDaily, keepWeekly int) map[string]bool {
+	keep := make(map[string]bool, len(names))
+	if keepDaily <= 0 && keepWeekly <= 0 {
+		for _, name := range names {
+			keep[name] = true
+		}
+		return keep
+	}
+
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	for _, name := range names {
+		t := times[name]
+
+		day := t.Format("2006-01-02")
+		if !days[day] && len(days) < keepDaily {
+			days[day] = true
+			keep[name] = true
+		}
+
+		year, week := t.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%02d", year, week)
+		if !weeks[weekKey] && len(weeks) < keepWeekly {
+			weeks[weekKey] = true
+			keep[name] = true
+		}
+	}
+	return keep
+}
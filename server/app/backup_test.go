@@ -0,0 +1,189 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/filestore"
+)
+
+// newTestBackupBackend returns a local backup backend, or an S3 one when
+// FOCALBOARD_TEST_S3_ENDPOINT points to an S3 compatible server such as MinIO.
+func newTestBackupBackend(t *testing.T) filestore.FileBackend {
+	settings := filestore.FileBackendSettings{
+		DriverName: "local",
+		Directory:  t.TempDir(),
+	}
+
+	if endpoint := os.Getenv("FOCALBOARD_TEST_S3_ENDPOINT"); endpoint != "" {
+		settings = filestore.FileBackendSettings{
+			DriverName:              "amazons3",
+			AmazonS3AccessKeyId:     os.Getenv("FOCALBOARD_TEST_S3_ACCESS_KEY"),
+			AmazonS3SecretAccessKey: os.Getenv("FOCALBOARD_TEST_S3_SECRET_KEY"),
+			AmazonS3Bucket:          os.Getenv("FOCALBOARD_TEST_S3_BUCKET"),
+			AmazonS3PathPrefix:      "backups-" + utils.NewID(utils.IDTypeNone),
+			AmazonS3Endpoint:        endpoint,
+			AmazonS3Region:          "us-east-1",
+		}
+	}
+
+	backend, err := filestore.NewFileBackend(settings)
+	require.NoError(t, err)
+	if settings.DriverName == "amazons3" {
+		require.NoError(t, backend.TestConnection())
+		t.Cleanup(func() { _ = backend.RemoveDirectory("") })
+	}
+	return backend
+}
+
+func TestRunBackup(t *testing.T) {
+	board := &model.Board{
+		ID:     "board-id",
+		TeamID: "team-id",
+		Title:  "Backed up board",
+	}
+	blocks := []model.Block{
+		{ID: "card-id", BoardID: board.ID, ParentID: board.ID, Type: model.TypeCard, Title: "card"},
+	}
+
+	expectBackup := func(th *TestHelper) {
+		th.Store.EXPECT().GetAllTeams().Return([]*model.Team{{ID: board.TeamID}}, nil)
+		th.Store.EXPECT().GetBoardsForTeam(model.GlobalTeamID).Return([]*model.Board{}, nil)
+		th.Store.EXPECT().GetBoardsForTeam(board.TeamID).Return([]*model.Board{board}, nil)
+		th.Store.EXPECT().GetBoard(board.ID).Return(board, nil)
+		th.Store.EXPECT().GetBlocksWithBoardID(board.ID).Return(blocks, nil)
+		th.Store.EXPECT().GetMembersForBoard(board.ID).Return([]*model.BoardMember{}, nil)
+		th.Store.EXPECT().GetSubscribersForBlock(gomock.Any()).Return([]*model.Subscriber{}, nil).AnyTimes()
+	}
+
+	t.Run("backups disabled", func(t *testing.T) {
+		th, tearDown := SetupTestHelper(t)
+		defer tearDown()
+
+		_, err := th.App.RunBackup()
+		require.ErrorIs(t, err, ErrBackupsDisabled)
+
+		_, err = th.App.GetBackupHealth()
+		require.ErrorIs(t, err, ErrBackupsDisabled)
+	})
+
+	t.Run("writes an archive per team and a health record", func(t *testing.T) {
+		th, tearDown := SetupTestHelper(t)
+		defer tearDown()
+		th.App.backupBackend = newTestBackupBackend(t)
+
+		_, err := th.App.GetBackupHealth()
+		require.True(t, model.IsErrNotFound(err))
+
+		expectBackup(th)
+		health, err := th.App.RunBackup()
+		require.NoError(t, err)
+		require.Empty(t, health.LastError)
+		require.Equal(t, 1, health.Teams)
+		require.Equal(t, 1, health.Boards)
+		require.NotZero(t, health.Size)
+		require.Equal(t, []string{health.LastBackup}, health.Backups)
+
+		archive, err := th.App.backupBackend.ReadFile(health.LastBackup + "/" + board.TeamID + backupFileExtension)
+		require.NoError(t, err)
+		require.Equal(t, health.Size, int64(len(archive)))
+
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		require.NoError(t, err)
+		_, err = zr.Open(archiveManifestFilename)
+		require.NoError(t, err)
+
+		stored, err := th.App.GetBackupHealth()
+		require.NoError(t, err)
+		require.Equal(t, health, stored)
+	})
+
+	t.Run("rotates old backups", func(t *testing.T) {
+		th, tearDown := SetupTestHelper(t)
+		defer tearDown()
+		th.App.backupBackend = newTestBackupBackend(t)
+		th.App.config.BackupKeepDaily = 2
+
+		now := time.Now().UTC()
+		old := []time.Time{
+			now.Add(-3 * 24 * time.Hour),
+			now.Add(-4 * 24 * time.Hour),
+			now.Add(-5 * 24 * time.Hour),
+		}
+		for _, ts := range old {
+			_, err := th.App.backupBackend.WriteFile(bytes.NewReader([]byte("{}")), backupPrefix+ts.Format(backupTimeFormat)+"/0.boardarchive")
+			require.NoError(t, err)
+		}
+
+		expectBackup(th)
+		health, err := th.App.RunBackup()
+		require.NoError(t, err)
+
+		require.Equal(t, []string{health.LastBackup, backupPrefix + old[0].Format(backupTimeFormat)}, health.Backups)
+
+		exists, err := th.App.backupBackend.FileExists(backupPrefix + old[2].Format(backupTimeFormat) + "/0.boardarchive")
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	t.Run("records failures", func(t *testing.T) {
+		th, tearDown := SetupTestHelper(t)
+		defer tearDown()
+		th.App.backupBackend = newTestBackupBackend(t)
+
+		expectBackup(th)
+		first, err := th.App.RunBackup()
+		require.NoError(t, err)
+
+		errStore := errors.New("store unavailable")
+		th.Store.EXPECT().GetAllTeams().Return(nil, errStore)
+		_, err = th.App.RunBackup()
+		require.ErrorIs(t, err, errStore)
+
+		health, err := th.App.GetBackupHealth()
+		require.NoError(t, err)
+		require.Contains(t, health.LastError, errStore.Error())
+		require.Equal(t, first.LastSuccessAt, health.LastSuccessAt)
+		require.Equal(t, first.LastBackup, health.LastBackup)
+		require.GreaterOrEqual(t, health.LastRunAt, first.LastRunAt)
+	})
+}
+
+func TestSelectBackupsToKeep(t *testing.T) {
+	// Wednesday.
+	base := time.Date(2022, 6, 15, 12, 0, 0, 0, time.UTC)
+	names := []string{"wed-late", "wed-early", "tue", "mon", "prev-week-fri", "two-weeks-ago"}
+	times := map[string]time.Time{
+		"wed-late":      base.Add(2 * time.Hour),
+		"wed-early":     base,
+		"tue":           base.Add(-24 * time.Hour),
+		"mon":           base.Add(-48 * time.Hour),
+		"prev-week-fri": base.Add(-5 * 24 * time.Hour),
+		"two-weeks-ago": base.Add(-14 * 24 * time.Hour),
+	}
+
+	t.Run("daily and weekly", func(t *testing.T) {
+		keep := selectBackupsToKeep(names, times, 2, 2)
+		require.Equal(t, map[string]bool{"wed-late": true, "tue": true, "prev-week-fri": true}, keep)
+	})
+
+	t.Run("weekly only", func(t *testing.T) {
+		keep := selectBackupsToKeep(names, times, 0, 3)
+		require.Equal(t, map[string]bool{"wed-late": true, "prev-week-fri": true, "two-weeks-ago": true}, keep)
+	})
+
+	t.Run("no rotation", func(t *testing.T) {
+		keep := selectBackupsToKeep(names, times, 0, 0)
+		require.Len(t, keep, len(names))
+	})
+}
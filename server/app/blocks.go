@@ -3,11 +3,9 @@ package app
 import (
 	"errors"
 	"fmt"
-	"path/filepath"
 
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/services/notify"
-	"github.com/mattermost/focalboard/server/utils"
 
 	"github.com/mattermost/mattermost-server/v6/shared/mlog"
 )
@@ -68,16 +66,33 @@ func (a *App) PatchBlock(blockID string, blockPatch *model.BlockPatch, modifiedB
 		return err
 	}
 
+	if err = a.validatePatchCardProperties(board, *oldBlock, blockPatch, modifiedByID); err != nil {
+		return err
+	}
+	if oldBlock.Type == model.TypeCheckbox {
+		if err = model.ValidateChecklistItem(patchedCopy(*oldBlock, blockPatch)); err != nil {
+			return err
+		}
+	}
+
 	err = a.store.PatchBlock(blockID, blockPatch, modifiedByID)
 	if err != nil {
 		return err
 	}
 
 	a.metrics.IncrementBlocksPatched(1)
+	a.recomputeRebasedCard(board, blockPatch, blockID, modifiedByID)
 	block, err := a.store.GetBlock(blockID)
 	if err != nil {
 		return nil
 	}
+	if patchAffectsParentRollups(blockPatch) {
+		a.refreshParentRollups(board, modifiedByID, oldBlock.ParentID, block.ParentID)
+		a.syncRelations(board, modifiedByID, relationChange{before: oldBlock, after: block})
+	}
+	if block.Type == model.TypeCheckbox {
+		a.refreshChecklistProgress(board, oldBlock.ParentID, block.ParentID)
+	}
 	a.blockChangeNotifier.Enqueue(func() error {
 		// broadcast on websocket
 		a.wsAdapter.BroadcastBlockChange(board.TeamID, *block)
@@ -102,11 +117,83 @@ func (a *App) PatchBlocks(teamID string, blockPatches *model.BlockPatchBatch, mo
 		oldBlocks = append(oldBlocks, *oldBlock)
 	}
 
+	boards := make(map[string]*model.Board)
+	getBoard := func(boardID string) (*model.Board, error) {
+		if board, ok := boards[boardID]; ok {
+			return board, nil
+		}
+		board, err := a.store.GetBoard(boardID)
+		if err != nil {
+			return nil, err
+		}
+		boards[boardID] = board
+		return board, nil
+	}
+
+	for i := range blockPatches.BlockPatches {
+		if i >= len(oldBlocks) {
+			break
+		}
+		oldBlock := oldBlocks[i]
+		if oldBlock.Type == model.TypeCheckbox {
+			if err := model.ValidateChecklistItem(patchedCopy(oldBlock, &blockPatches.BlockPatches[i])); err != nil {
+				return err
+			}
+		}
+		if _, ok := blockPatches.BlockPatches[i].UpdatedFields["properties"]; !ok {
+			continue
+		}
+
+		board, err := getBoard(oldBlock.BoardID)
+		if err != nil {
+			return err
+		}
+
+		if err = a.validatePatchCardProperties(board, oldBlock, &blockPatches.BlockPatches[i], modifiedByID); err != nil {
+			return err
+		}
+	}
+
 	err := a.store.PatchBlocks(blockPatches, modifiedByID)
 	if err != nil {
 		return err
 	}
 
+	for i := range blockPatches.BlockPatches {
+		if i >= len(oldBlocks) {
+			continue
+		}
+		oldBlock := oldBlocks[i]
+		if len(blockPatches.BlockPatches[i].ComputedPropertyIDs) > 0 {
+			board, errBoard := getBoard(oldBlock.BoardID)
+			if errBoard != nil {
+				return errBoard
+			}
+			a.recomputeRebasedCard(board, &blockPatches.BlockPatches[i], oldBlock.ID, modifiedByID)
+		}
+		isChecklistItem := oldBlock.Type == model.TypeCheckbox
+		if !isChecklistItem && !patchAffectsParentRollups(&blockPatches.BlockPatches[i]) {
+			continue
+		}
+		board, errBoard := getBoard(oldBlock.BoardID)
+		if errBoard != nil {
+			return errBoard
+		}
+		parentIDs := []string{oldBlock.ParentID}
+		if parentID := blockPatches.BlockPatches[i].ParentID; parentID != nil {
+			parentIDs = append(parentIDs, *parentID)
+		}
+		if isChecklistItem {
+			a.refreshChecklistProgress(board, parentIDs...)
+			continue
+		}
+		a.refreshParentRollups(board, modifiedByID, parentIDs...)
+		a.syncRelations(board, modifiedByID, relationChange{
+			before: &oldBlocks[i],
+			after:  patchedCopy(oldBlock, &blockPatches.BlockPatches[i]),
+		})
+	}
+
 	a.blockChangeNotifier.Enqueue(func() error {
 		a.metrics.IncrementBlocksPatched(len(oldBlocks))
 		for i, blockID := range blockPatches.BlockIDs {
@@ -129,8 +216,24 @@ func (a *App) InsertBlock(block model.Block, modifiedByID string) error {
 		return bErr
 	}
 
+	a.applyCardPropertyDefaults(board, []*model.Block{&block})
+	if err := a.validateCardProperties(board, []*model.Block{&block}, modifiedByID); err != nil {
+		return err
+	}
+	if err := validateChecklistItems([]*model.Block{&block}); err != nil {
+		return err
+	}
+	if err := a.prepareAttachments([]*model.Block{&block}); err != nil {
+		return err
+	}
+
 	err := a.store.InsertBlock(&block, modifiedByID)
 	if err == nil {
+		a.refreshParentRollups(board, modifiedByID, block.ParentID)
+		a.syncRelations(board, modifiedByID, relationChange{after: &block})
+		if block.Type == model.TypeCheckbox {
+			a.refreshChecklistProgress(board, block.ParentID)
+		}
 		a.blockChangeNotifier.Enqueue(func() error {
 			a.wsAdapter.BroadcastBlockChange(board.TeamID, block)
 			a.metrics.IncrementBlocksInserted(1)
@@ -160,6 +263,21 @@ func (a *App) InsertBlocks(blocks []model.Block, modifiedByID string, allowNotif
 		return nil, err
 	}
 
+	cards := make([]*model.Block, 0, len(blocks))
+	for i := range blocks {
+		cards = append(cards, &blocks[i])
+	}
+	a.applyCardPropertyDefaults(board, cards)
+	if err = a.validateCardProperties(board, cards, modifiedByID); err != nil {
+		return nil, err
+	}
+	if err = validateChecklistItems(cards); err != nil {
+		return nil, err
+	}
+	if err = a.prepareAttachments(cards); err != nil {
+		return nil, err
+	}
+
 	needsNotify := make([]model.Block, 0, len(blocks))
 	for i := range blocks {
 		err := a.store.InsertBlock(&blocks[i], modifiedByID)
@@ -172,6 +290,22 @@ func (a *App) InsertBlocks(blocks []model.Block, modifiedByID string, allowNotif
 		a.metrics.IncrementBlocksInserted(1)
 	}
 
+	parentIDs := make([]string, 0, len(blocks))
+	checklistCardIDs := make([]string, 0)
+	changes := make([]relationChange, 0, len(blocks))
+	for i, block := range blocks {
+		if block.Type == model.TypeCard && block.ParentID != "" {
+			parentIDs = append(parentIDs, block.ParentID)
+		}
+		if block.Type == model.TypeCheckbox {
+			checklistCardIDs = append(checklistCardIDs, block.ParentID)
+		}
+		changes = append(changes, relationChange{after: &blocks[i]})
+	}
+	a.refreshParentRollups(board, modifiedByID, parentIDs...)
+	a.syncRelations(board, modifiedByID, changes...)
+	a.refreshChecklistProgress(board, checklistCardIDs...)
+
 	a.blockChangeNotifier.Enqueue(func() error {
 		for _, b := range needsNotify {
 			block := b
@@ -187,48 +321,49 @@ func (a *App) InsertBlocks(blocks []model.Block, modifiedByID string, allowNotif
 }
 
 func (a *App) CopyCardFiles(sourceBoardID string, blocks []model.Block) error {
-	// Images attached in cards have a path comprising the card's board ID.
+	// Images and attachments in cards reference files of the card's board.
 	// When we create a template from this board, we need to copy the files
-	// with the new board ID in path.
+	// to the new board, sharing their content.
 	// Not doing so causing images in templates (and boards created from this
 	// template) to fail to load.
 
 	// look up ID of source board, which may be different than the blocks.
-	board, err := a.GetBlockByID(sourceBoardID)
+	board, err := a.GetBoard(sourceBoardID)
 	if err != nil || board == nil {
 		return fmt.Errorf("cannot fetch board %s for CopyCardFiles: %w", sourceBoardID, err)
 	}
 
+	destBoards := map[string]*model.Board{}
 	for i := range blocks {
 		block := blocks[i]
 
-		fileName, ok := block.Fields["fileId"]
-		if block.Type == model.TypeImage && ok {
-			// create unique filename in case we are copying cards within the same board.
-			ext := filepath.Ext(fileName.(string))
-			destFilename := utils.NewID(utils.IDTypeNone) + ext
+		fileName, ok := model.BlockFileID(&block)
+		if !ok {
+			continue
+		}
 
-			sourceFilePath := filepath.Join(sourceBoardID, fileName.(string))
-			destinationFilePath := filepath.Join(block.BoardID, destFilename)
+		destBoard, ok := destBoards[block.BoardID]
+		if !ok {
+			if destBoard, err = a.GetBoard(block.BoardID); err != nil || destBoard == nil {
+				return fmt.Errorf("cannot fetch board %s for CopyCardFiles: %w", block.BoardID, err)
+			}
+			destBoards[block.BoardID] = destBoard
+		}
 
-			a.logger.Debug(
-				"Copying card file",
-				mlog.String("sourceFilePath", sourceFilePath),
-				mlog.String("destinationFilePath", destinationFilePath),
+		// a new file ID in case we are copying cards within the same board.
+		destFilename, errCopy := a.copyFile(board, fileName, destBoard)
+		if errCopy != nil {
+			a.logger.Error(
+				"CopyCardFiles failed to copy file",
+				mlog.String("sourceBoardID", sourceBoardID),
+				mlog.String("fileID", fileName),
+				mlog.String("destBoardID", destBoard.ID),
+				mlog.Err(errCopy),
 			)
 
-			if err := a.filesBackend.CopyFile(sourceFilePath, destinationFilePath); err != nil {
-				a.logger.Error(
-					"CopyCardFiles failed to copy file",
-					mlog.String("sourceFilePath", sourceFilePath),
-					mlog.String("destinationFilePath", destinationFilePath),
-					mlog.Err(err),
-				)
-
-				return err
-			}
-			block.Fields["fileId"] = destFilename
+			return errCopy
 		}
+		block.Fields[model.AttachmentFileID] = destFilename
 	}
 
 	return nil
@@ -258,20 +393,16 @@ func (a *App) DeleteBlock(blockID string, modifiedBy string) error {
 	if err != nil {
 		return err
 	}
-
-	if block.Type == model.TypeImage {
-		fileName, fileIDExists := block.Fields["fileId"]
-		if fileName, fileIDIsString := fileName.(string); fileIDExists && fileIDIsString {
-			filePath := filepath.Join(block.BoardID, fileName)
-			err = a.filesBackend.RemoveFile(filePath)
-
-			if err != nil {
-				a.logger.Error("Error deleting image file",
-					mlog.String("FilePath", filePath),
-					mlog.Err(err))
-			}
+	if block.Type == model.TypeCard {
+		a.refreshParentRollups(board, modifiedBy, block.ParentID)
+		a.syncRelations(board, modifiedBy, relationChange{before: block})
+		if err = a.removeRelationReferences(board, blockID, modifiedBy); err != nil {
+			a.logger.Error("Cannot remove the references to a deleted card", mlog.String("block_id", blockID), mlog.Err(err))
 		}
 	}
+	if block.Type == model.TypeCheckbox {
+		a.refreshChecklistProgress(board, block.ParentID)
+	}
 
 	a.blockChangeNotifier.Enqueue(func() error {
 		a.wsAdapter.BroadcastBlockDelete(board.TeamID, blockID, block.BoardID)
@@ -323,6 +454,13 @@ func (a *App) UndeleteBlock(blockID string, modifiedBy string) (*model.Block, er
 	if err != nil {
 		return nil, err
 	}
+	if block.Type == model.TypeCard {
+		a.refreshParentRollups(board, modifiedBy, block.ParentID)
+		a.syncRelations(board, modifiedBy, relationChange{after: block})
+	}
+	if block.Type == model.TypeCheckbox {
+		a.refreshChecklistProgress(board, block.ParentID)
+	}
 
 	a.blockChangeNotifier.Enqueue(func() error {
 		a.wsAdapter.BroadcastBlockChange(board.TeamID, *block)
@@ -6,6 +6,8 @@ import (
 
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
 )
 
 var (
@@ -144,6 +146,8 @@ func (a *App) DuplicateBoard(boardID, userID, toTeam string, asTemplate bool) (*
 	if err != nil {
 		return nil, nil, err
 	}
+	a.copyDuplicatedBoardFiles(boardID, bab, userID)
+
 	go func() {
 		teamID := ""
 		for _, board := range bab.Boards {
@@ -160,6 +164,60 @@ func (a *App) DuplicateBoard(boardID, userID, toTeam string, asTemplate bool) (*
 	return bab, members, err
 }
 
+// copyDuplicatedBoardFiles gives the duplicated blocks their own copy of the files of
+// the source board, which shares the content of the files.
+func (a *App) copyDuplicatedBoardFiles(sourceBoardID string, bab *model.BoardsAndBlocks, userID string) {
+	var sourceBoard *model.Board
+	patches := &model.BlockPatchBatch{}
+	for i := range bab.Blocks {
+		block := &bab.Blocks[i]
+		fileID, ok := model.BlockFileID(block)
+		if !ok {
+			continue
+		}
+
+		if sourceBoard == nil {
+			board, err := a.store.GetBoard(sourceBoardID)
+			if err != nil {
+				a.logger.Error("Cannot fetch the duplicated board", mlog.String("board_id", sourceBoardID), mlog.Err(err))
+				return
+			}
+			sourceBoard = board
+		}
+		var destBoard *model.Board
+		for _, board := range bab.Boards {
+			if board.ID == block.BoardID {
+				destBoard = board
+			}
+		}
+		if destBoard == nil {
+			continue
+		}
+
+		newFileID, err := a.copyFile(sourceBoard, fileID, destBoard)
+		if err != nil {
+			a.logger.Error("Cannot copy the file of a duplicated block",
+				mlog.String("block_id", block.ID),
+				mlog.String("file_id", fileID),
+				mlog.Err(err),
+			)
+			continue
+		}
+		block.Fields[model.AttachmentFileID] = newFileID
+		patches.BlockIDs = append(patches.BlockIDs, block.ID)
+		patches.BlockPatches = append(patches.BlockPatches, model.BlockPatch{
+			UpdatedFields: map[string]interface{}{model.AttachmentFileID: newFileID},
+		})
+	}
+
+	if len(patches.BlockIDs) == 0 {
+		return
+	}
+	if err := a.store.PatchBlocks(patches, userID); err != nil {
+		a.logger.Error("Cannot update the files of the duplicated blocks", mlog.String("board_id", sourceBoardID), mlog.Err(err))
+	}
+}
+
 func (a *App) GetBoardsForUserAndTeam(userID, teamID string) ([]*model.Board, error) {
 	return a.store.GetBoardsForUserAndTeam(userID, teamID)
 }
@@ -204,6 +262,15 @@ func (a *App) PatchBoard(patch *model.BoardPatch, boardID, userID string) (*mode
 		return nil, err
 	}
 
+	if len(patch.UpdatedCardProperties) > 0 || len(patch.DeletedCardProperties) > 0 {
+		if err = a.RecomputeBoardProperties(updatedBoard, userID); err != nil {
+			a.logger.Error("Cannot recompute card properties after a schema change",
+				mlog.String("board_id", boardID),
+				mlog.Err(err),
+			)
+		}
+	}
+
 	go func() {
 		a.wsAdapter.BroadcastBoardChange(updatedBoard.TeamID, updatedBoard)
 	}()
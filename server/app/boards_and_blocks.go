@@ -12,6 +12,17 @@ func (a *App) CreateBoardsAndBlocks(bab *model.BoardsAndBlocks, userID string, a
 	var members []*model.BoardMember
 	var err error
 
+	// new cards, created or imported, get the default values of their board.
+	for _, board := range bab.Boards {
+		cards := make([]*model.Block, 0, len(bab.Blocks))
+		for i := range bab.Blocks {
+			if bab.Blocks[i].BoardID == board.ID {
+				cards = append(cards, &bab.Blocks[i])
+			}
+		}
+		a.applyCardPropertyDefaults(board, cards)
+	}
+
 	if addMember {
 		newBab, members, err = a.store.CreateBoardsAndBlocksWithAdmin(bab, userID)
 	} else {
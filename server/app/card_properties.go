@@ -0,0 +1,233 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/mattermost/focalboard/server/model"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+// validateCardProperties normalizes the property values of the cards among blocks
+// against the property schema of the board, and returns a *model.ErrInvalidPropertyValues
+// listing the values that cannot be normalized. Cards missing required properties are
+// rejected or only logged, depending on the RequiredPropertiesMode setting. The computed
+// properties of valid cards are updated.
+func (a *App) validateCardProperties(board *model.Board, blocks []*model.Block, userID string) error {
+	schema, err := model.ParsePropertySchema(board)
+	if err != nil {
+		// a broken schema must not prevent cards from being saved.
+		a.logger.Warn("Cannot validate card properties, invalid property schema",
+			mlog.String("board_id", board.ID),
+			mlog.Err(err),
+		)
+		return nil
+	}
+
+	reject := a.config.RequiredPropertiesMode == model.RequiredPropertiesReject
+	var errs []model.PropertyValueError
+	for _, block := range blocks {
+		if block.Type != model.TypeCard {
+			continue
+		}
+		_, blockErrs := model.NormalizePropertyValues(block, schema)
+		errs = append(errs, blockErrs...)
+
+		if isTemplate, _ := block.Fields["isTemplate"].(bool); isTemplate {
+			continue
+		}
+		missing := model.MissingRequiredProperties(block, schema)
+		if reject {
+			errs = append(errs, missing...)
+			continue
+		}
+		for _, m := range missing {
+			a.logger.Warn("Card is missing a required property",
+				mlog.String("board_id", board.ID),
+				mlog.String("block_id", block.ID),
+				mlog.String("property_id", m.PropertyID),
+			)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &model.ErrInvalidPropertyValues{Errors: errs}
+	}
+	if err = a.checkRelationValues(schema, blocks); err != nil {
+		return err
+	}
+	return a.computeCardProperties(board, schema, blocks, userID)
+}
+
+// applyCardPropertyDefaults sets the properties of new cards that have no value to the
+// default value of the property.
+func (a *App) applyCardPropertyDefaults(board *model.Board, blocks []*model.Block) {
+	schema, err := model.ParsePropertySchema(board)
+	if err != nil {
+		return
+	}
+	for _, block := range blocks {
+		if block.Type == model.TypeCard {
+			model.ApplyPropertyDefaults(block, schema)
+		}
+	}
+}
+
+// GetPropertyConformance checks the property values of every card of a board against
+// the property schema of the board, reporting the missing required properties and the
+// values that cannot be normalized.
+func (a *App) GetPropertyConformance(boardID string) (*model.PropertyConformanceReport, error) {
+	board, err := a.store.GetBoard(boardID)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := model.ParsePropertySchema(board)
+	if err != nil {
+		return nil, err
+	}
+	cards, err := a.store.GetBlocksWithType(boardID, model.TypeCard)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.PropertyConformanceReport{
+		Errors: []model.PropertyValueError{},
+	}
+	for i := range cards {
+		card := &cards[i]
+		if isTemplate, _ := card.Fields["isTemplate"].(bool); isTemplate {
+			continue
+		}
+		report.Cards++
+
+		_, errs := model.NormalizePropertyValues(card, schema)
+		errs = append(errs, model.MissingRequiredProperties(card, schema)...)
+		if len(errs) > 0 {
+			report.NonConformingCards++
+			report.Errors = append(report.Errors, errs...)
+		}
+	}
+	return report, nil
+}
+
+// validatePatchCardProperties validates the properties a patch sets on a card,
+// replacing them in the patch with their normalized values.
+func (a *App) validatePatchCardProperties(board *model.Board, block model.Block, patch *model.BlockPatch, userID string) error {
+	if _, ok := patch.UpdatedFields["properties"]; !ok {
+		return nil
+	}
+
+	// patch a copy, the original block is used for the change notifications.
+	patched := patchedCopy(block, patch)
+	if err := a.validateCardProperties(board, []*model.Block{patched}, userID); err != nil {
+		return err
+	}
+
+	patch.UpdatedFields["properties"] = patched.Fields["properties"]
+	if schema, errSchema := model.ParsePropertySchema(board); errSchema == nil {
+		patch.ComputedPropertyIDs = computedPropertyIDs(schema)
+	}
+	return nil
+}
+
+// patchedCopy returns a copy of a block with a patch applied, leaving the block
+// and its fields untouched.
+func patchedCopy(block model.Block, patch *model.BlockPatch) *model.Block {
+	fields := make(map[string]interface{}, len(block.Fields))
+	for k, v := range block.Fields {
+		fields[k] = v
+	}
+	block.Fields = fields
+	patch.Patch(&block)
+	return &block
+}
+
+// RepairCardProperties normalizes the property values of every card of a board, or of
+// every board if boardID is empty, and removes the values that cannot be normalized.
+// When dryRun is true the repairs are reported but not saved.
+func (a *App) RepairCardProperties(boardID string, dryRun bool) (*model.PropertyRepairResult, error) {
+	var boards []*model.Board
+	if boardID != "" {
+		board, err := a.store.GetBoard(boardID)
+		if err != nil {
+			return nil, err
+		}
+		boards = append(boards, board)
+	} else {
+		teamIDs, err := a.getAllTeamIDs()
+		if err != nil {
+			return nil, fmt.Errorf("cannot list teams: %w", err)
+		}
+		for _, teamID := range teamIDs {
+			teamBoards, errBoards := a.store.GetBoardsForTeam(teamID)
+			if errBoards != nil {
+				return nil, fmt.Errorf("cannot list boards for team %s: %w", teamID, errBoards)
+			}
+			boards = append(boards, teamBoards...)
+		}
+	}
+
+	result := &model.PropertyRepairResult{
+		Removed: []model.PropertyValueError{},
+	}
+	for _, board := range boards {
+		if err := a.repairBoardCardProperties(board, dryRun, result); err != nil {
+			return nil, fmt.Errorf("cannot repair board %s: %w", board.ID, err)
+		}
+	}
+	return result, nil
+}
+
+func (a *App) repairBoardCardProperties(board *model.Board, dryRun bool, result *model.PropertyRepairResult) error {
+	result.Boards++
+
+	schema, err := model.ParsePropertySchema(board)
+	if err != nil {
+		a.logger.Warn("Skipping board with invalid property schema",
+			mlog.String("board_id", board.ID),
+			mlog.Err(err),
+		)
+		return nil
+	}
+
+	cards, err := a.store.GetBlocksWithType(board.ID, model.TypeCard)
+	if err != nil {
+		return err
+	}
+
+	for i := range cards {
+		card := &cards[i]
+		result.Cards++
+
+		changed, removed := model.RepairPropertyValues(card, schema)
+		if !changed {
+			continue
+		}
+		result.RepairedCards++
+		result.Removed = append(result.Removed, removed...)
+
+		if dryRun {
+			continue
+		}
+
+		patch := &model.BlockPatch{
+			UpdatedFields: map[string]interface{}{"properties": card.Fields["properties"]},
+		}
+		if _, ok := card.Fields["properties"]; !ok {
+			patch = &model.BlockPatch{DeletedFields: []string{"properties"}}
+		}
+		if err = a.store.PatchBlock(card.ID, patch, model.SystemUserID); err != nil {
+			return err
+		}
+
+		repaired, errGet := a.store.GetBlock(card.ID)
+		if errGet != nil {
+			return errGet
+		}
+		a.blockChangeNotifier.Enqueue(func() error {
+			a.wsAdapter.BroadcastBlockChange(board.TeamID, *repaired)
+			return nil
+		})
+	}
+	return nil
+}
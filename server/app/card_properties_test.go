@@ -0,0 +1,176 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+)
+
+func setupCardPropertiesBoard() *model.Board {
+	return &model.Board{
+		ID:     testBoardID,
+		TeamID: "team-id",
+		CardProperties: []map[string]interface{}{
+			{
+				"id":   "status",
+				"name": "Status",
+				"type": "select",
+				"options": []interface{}{
+					map[string]interface{}{"id": "todo", "value": "To Do"},
+				},
+			},
+			{
+				"id":   "due",
+				"name": "Due",
+				"type": "date",
+			},
+		},
+	}
+}
+
+func TestValidateCardProperties(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	board := setupCardPropertiesBoard()
+
+	t.Run("insert rejects invalid values", func(t *testing.T) {
+		block := model.Block{
+			ID:      "card-id",
+			BoardID: board.ID,
+			Type:    model.TypeCard,
+			Fields: map[string]interface{}{
+				"properties": map[string]interface{}{"due": "not a date"},
+			},
+		}
+		th.Store.EXPECT().GetBoard(board.ID).Return(board, nil)
+
+		err := th.App.InsertBlock(block, "user-id-1")
+		var errProps *model.ErrInvalidPropertyValues
+		require.ErrorAs(t, err, &errProps)
+		require.Len(t, errProps.Errors, 1)
+		require.Equal(t, "due", errProps.Errors[0].PropertyID)
+		require.Equal(t, model.PropertyErrorMalformedDate, errProps.Errors[0].Reason)
+	})
+
+	t.Run("insert normalizes values", func(t *testing.T) {
+		blocks := []model.Block{{
+			ID:      "card-id",
+			BoardID: board.ID,
+			Type:    model.TypeCard,
+			Fields: map[string]interface{}{
+				"properties": map[string]interface{}{"status": "deleted-option", "due": float64(1642161600000)},
+			},
+		}}
+		th.Store.EXPECT().GetBoard(board.ID).Return(board, nil)
+		th.Store.EXPECT().InsertBlock(gomock.Any(), "user-id-1").Return(nil)
+		th.Store.EXPECT().GetMembersForBoard(board.ID).Return([]*model.BoardMember{}, nil).AnyTimes()
+
+		inserted, err := th.App.InsertBlocks(blocks, "user-id-1", false)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"due": `{"from":1642161600000}`}, inserted[0].Fields["properties"])
+	})
+
+	t.Run("patch normalizes the patched properties", func(t *testing.T) {
+		oldBlock := &model.Block{
+			ID:      "card-id",
+			BoardID: board.ID,
+			Type:    model.TypeCard,
+			Fields:  map[string]interface{}{"properties": map[string]interface{}{"status": "todo"}},
+		}
+		patch := &model.BlockPatch{
+			UpdatedFields: map[string]interface{}{
+				"properties": map[string]interface{}{"status": "deleted-option", "due": `{"from":1}`},
+			},
+		}
+		expected := map[string]interface{}{"due": `{"from":1}`}
+
+		th.Store.EXPECT().GetBlock("card-id").Return(oldBlock, nil).Times(2)
+		th.Store.EXPECT().GetBoard(board.ID).Return(board, nil)
+		th.Store.EXPECT().PatchBlock("card-id", gomock.Any(), "user-id-1").DoAndReturn(
+			func(blockID string, p *model.BlockPatch, userID string) error {
+				require.Equal(t, expected, p.UpdatedFields["properties"])
+				return nil
+			})
+
+		err := th.App.PatchBlock("card-id", patch, "user-id-1")
+		require.NoError(t, err)
+		// the old block used for notifications is not modified.
+		require.Equal(t, map[string]interface{}{"status": "todo"}, oldBlock.Fields["properties"])
+	})
+
+	t.Run("patch ignores blocks without property changes", func(t *testing.T) {
+		patches := &model.BlockPatchBatch{
+			BlockIDs: []string{"card-id"},
+			BlockPatches: []model.BlockPatch{
+				{Title: strPtr("new title")},
+			},
+		}
+		th.Store.EXPECT().GetBlock("card-id").Return(&model.Block{ID: "card-id", BoardID: board.ID}, nil)
+		th.Store.EXPECT().PatchBlocks(patches, "user-id-1").Return(nil)
+		th.Store.EXPECT().GetBlock("card-id").Return(&model.Block{ID: "card-id", BoardID: board.ID}, nil).AnyTimes()
+
+		err := th.App.PatchBlocks(board.TeamID, patches, "user-id-1")
+		require.NoError(t, err)
+	})
+}
+
+func TestRepairCardProperties(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	board := setupCardPropertiesBoard()
+	cards := func() []model.Block {
+		return []model.Block{
+			{
+				ID:      "valid-card",
+				BoardID: board.ID,
+				Type:    model.TypeCard,
+				Fields:  map[string]interface{}{"properties": map[string]interface{}{"status": "todo"}},
+			},
+			{
+				ID:      "broken-card",
+				BoardID: board.ID,
+				Type:    model.TypeCard,
+				Fields:  map[string]interface{}{"properties": map[string]interface{}{"status": "todo", "due": "{"}},
+			},
+		}
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		th.Store.EXPECT().GetBoard(board.ID).Return(board, nil)
+		th.Store.EXPECT().GetBlocksWithType(board.ID, model.TypeCard).Return(cards(), nil)
+
+		result, err := th.App.RepairCardProperties(board.ID, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Boards)
+		require.Equal(t, 2, result.Cards)
+		require.Equal(t, 1, result.RepairedCards)
+		require.Len(t, result.Removed, 1)
+		require.Equal(t, "broken-card", result.Removed[0].BlockID)
+	})
+
+	t.Run("repair every board", func(t *testing.T) {
+		th.Store.EXPECT().GetAllTeams().Return([]*model.Team{{ID: board.TeamID}}, nil)
+		th.Store.EXPECT().GetBoardsForTeam(model.GlobalTeamID).Return([]*model.Board{}, nil)
+		th.Store.EXPECT().GetBoardsForTeam(board.TeamID).Return([]*model.Board{board}, nil)
+		th.Store.EXPECT().GetBlocksWithType(board.ID, model.TypeCard).Return(cards(), nil)
+		th.Store.EXPECT().PatchBlock("broken-card", &model.BlockPatch{
+			UpdatedFields: map[string]interface{}{"properties": map[string]interface{}{"status": "todo"}},
+		}, model.SystemUserID).Return(nil)
+		th.Store.EXPECT().GetBlock("broken-card").Return(&cards()[1], nil)
+		th.Store.EXPECT().GetMembersForBoard(board.ID).Return([]*model.BoardMember{}, nil).AnyTimes()
+
+		result, err := th.App.RepairCardProperties("", false)
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Boards)
+		require.Equal(t, 1, result.RepairedCards)
+	})
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"github.com/mattermost/focalboard/server/model"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+// validateChecklistItems checks the fields of the checklist items among blocks.
+func validateChecklistItems(blocks []*model.Block) error {
+	for _, block := range blocks {
+		if block.Type != model.TypeCheckbox {
+			continue
+		}
+		if err := model.ValidateChecklistItem(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshChecklistProgress updates the checklist progress of the given cards after their
+// checklist items changed. Errors are logged, as the items are already saved.
+func (a *App) refreshChecklistProgress(board *model.Board, cardIDs ...string) {
+	if board == nil {
+		return
+	}
+
+	visited := make(map[string]bool, len(cardIDs))
+	for _, cardID := range cardIDs {
+		if cardID == "" || visited[cardID] {
+			continue
+		}
+		visited[cardID] = true
+
+		if err := a.updateChecklistProgress(board, cardID); err != nil {
+			a.logger.Error("Cannot update the checklist progress of a card",
+				mlog.String("block_id", cardID),
+				mlog.Err(err),
+			)
+		}
+	}
+}
+
+func (a *App) updateChecklistProgress(board *model.Board, cardID string) error {
+	card, err := a.store.GetBlock(cardID)
+	if err != nil {
+		return err
+	}
+	if card == nil || card.Type != model.TypeCard || card.BoardID != board.ID {
+		return nil
+	}
+
+	items, err := a.store.GetBlocksWithParentAndType(board.ID, cardID, model.TypeCheckbox)
+	if err != nil {
+		return err
+	}
+	progress := model.ComputeChecklistProgress(items)
+	_, stored := card.Fields[model.ChecklistProgressField]
+	if progress == model.GetChecklistProgress(card) && (stored || progress.Total == 0) {
+		return nil
+	}
+
+	// the progress is derived from the items, saving it does not change the update
+	// time and the author of the card.
+	var value interface{}
+	if progress.Total > 0 {
+		value = progress.FieldValue()
+	}
+	if err = a.store.SetBlockField(cardID, model.ChecklistProgressField, value); err != nil {
+		return err
+	}
+
+	updated, err := a.store.GetBlock(cardID)
+	if err != nil {
+		return err
+	}
+	teamID := board.TeamID
+	a.blockChangeNotifier.Enqueue(func() error {
+		a.wsAdapter.BroadcastBlockChange(teamID, *updated)
+		a.webhook.NotifyUpdate(*updated)
+		return nil
+	})
+	return nil
+}
+
+// GetChecklistCards returns the cards of a board with incomplete checklist items matching
+// the query, in creation order, with the progress of their checklist.
+func (a *App) GetChecklistCards(boardID string, query model.ChecklistQuery) ([]model.ChecklistCard, error) {
+	items, err := a.store.GetBlocksWithType(boardID, model.TypeCheckbox)
+	if err != nil {
+		return nil, err
+	}
+
+	itemsByCard := make(map[string][]model.Block)
+	matching := make(map[string][]model.Block)
+	for i := range items {
+		item := &items[i]
+		itemsByCard[item.ParentID] = append(itemsByCard[item.ParentID], *item)
+		if query.Matches(item) {
+			matching[item.ParentID] = append(matching[item.ParentID], *item)
+		}
+	}
+
+	cards, err := a.store.GetBlocksWithType(boardID, model.TypeCard)
+	if err != nil {
+		return nil, err
+	}
+	sortBlocksByCreateAt(cards)
+
+	result := make([]model.ChecklistCard, 0, len(matching))
+	for _, card := range cards {
+		cardItems, ok := matching[card.ID]
+		if !ok {
+			continue
+		}
+		sortBlocksByCreateAt(cardItems)
+		result = append(result, model.ChecklistCard{
+			Card:     card,
+			Progress: model.ComputeChecklistProgress(itemsByCard[card.ID]),
+			Items:    cardItems,
+		})
+	}
+	return result, nil
+}
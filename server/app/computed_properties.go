@@ -0,0 +1,633 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/formula"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+// propertyComputer computes the formula and rollup properties of the cards of a board.
+type propertyComputer struct {
+	a      *App
+	board  *model.Board
+	schema model.PropSchema
+	names  map[string]string // property ids keyed by name
+
+	// cards and children are set when a whole board is recomputed, so that the
+	// rollups use the freshly computed values of the child cards.
+	cards    map[string]*model.Block
+	children map[string][]*model.Block
+
+	// schemas caches the property schemas of the boards linked by relations.
+	schemas map[string]model.PropSchema
+
+	// formulas caches the parsed formulas, keyed by property id.
+	formulas map[string]parsedFormula
+
+	// userID is the user the properties are computed for, the rollups over the
+	// boards they cannot view are not updated. viewable caches their permissions.
+	userID   string
+	viewable map[string]bool
+}
+
+type parsedFormula struct {
+	formula *formula.Formula
+	err     error
+}
+
+func (a *App) newPropertyComputer(board *model.Board, schema model.PropSchema, userID string) *propertyComputer {
+	names := make(map[string]string, len(schema))
+	for id, def := range schema {
+		names[def.Name] = id
+	}
+	return &propertyComputer{
+		a:        a,
+		board:    board,
+		schema:   schema,
+		names:    names,
+		formulas: make(map[string]parsedFormula),
+		userID:   userID,
+		viewable: make(map[string]bool),
+	}
+}
+
+// canViewRelatedBoard tells if the user the properties are computed for can view a
+// board linked by a relation. The computations of the server are not restricted.
+func (pc *propertyComputer) canViewRelatedBoard(relationID string) bool {
+	relation := pc.schema[relationID].Relation
+	if relationID == "" || relation == nil || relation.BoardID == pc.board.ID || pc.userID == model.SystemUserID {
+		return true
+	}
+	if allowed, ok := pc.viewable[relation.BoardID]; ok {
+		return allowed
+	}
+	allowed := pc.a.permissions.HasPermissionToBoard(pc.userID, relation.BoardID, model.PermissionViewBoard)
+	pc.viewable[relation.BoardID] = allowed
+	return allowed
+}
+
+// parseFormula parses the formula of a property once for all the cards.
+func (pc *propertyComputer) parseFormula(id string) (*formula.Formula, error) {
+	parsed, ok := pc.formulas[id]
+	if !ok {
+		parsed.formula, parsed.err = formula.Parse(pc.schema[id].Formula)
+		pc.formulas[id] = parsed
+	}
+	return parsed.formula, parsed.err
+}
+
+func hasComputedProperties(schema model.PropSchema) bool {
+	for _, def := range schema {
+		if def.IsComputed() {
+			return true
+		}
+	}
+	return false
+}
+
+// computedPropertyIDs returns the ids of the formula and rollup properties.
+func computedPropertyIDs(schema model.PropSchema) []string {
+	var ids []string
+	for id, def := range schema {
+		if def.IsComputed() {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// hasChildRollups returns true if the value of a card depends on its child cards.
+func hasChildRollups(schema model.PropSchema) bool {
+	for _, def := range schema {
+		if def.Type == model.PropTypeRollup && def.Rollup.RelationPropertyID == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// compute sets the values of the computed properties of a card, rollups first as
+// formulas may reference them. It returns true if any value changed.
+func (pc *propertyComputer) compute(card *model.Block) (bool, error) {
+	props, _ := card.Fields["properties"].(map[string]interface{})
+	computed := make(map[string]interface{}, len(props))
+	for k, v := range props {
+		computed[k] = v
+	}
+
+	for id, def := range pc.schema {
+		if def.Type != model.PropTypeRollup {
+			continue
+		}
+		if !pc.canViewRelatedBoard(def.Rollup.RelationPropertyID) {
+			// the value is kept until it is computed for a user that can view the board.
+			continue
+		}
+		value, err := pc.rollup(card, computed, def)
+		if err != nil {
+			return false, err
+		}
+		setComputedValue(computed, id, value)
+	}
+
+	resolver := &formulaResolver{
+		pc:         pc,
+		props:      computed,
+		results:    make(map[string]interface{}),
+		evaluating: make(map[string]bool),
+	}
+	for id, def := range pc.schema {
+		if def.Type != model.PropTypeFormula {
+			continue
+		}
+		value, err := resolver.evalFormula(id)
+		if err != nil {
+			pc.a.logger.Debug("Cannot evaluate formula property",
+				mlog.String("board_id", pc.board.ID),
+				mlog.String("card_id", card.ID),
+				mlog.String("property_id", id),
+				mlog.Err(err),
+			)
+			value = nil
+		}
+		setComputedValue(computed, id, value)
+	}
+
+	if propertiesEqual(props, computed) {
+		return false, nil
+	}
+	if card.Fields == nil {
+		card.Fields = make(map[string]interface{})
+	}
+	card.Fields["properties"] = computed
+	return true, nil
+}
+
+func setComputedValue(props map[string]interface{}, id string, value interface{}) {
+	s := formula.ToString(value)
+	if s == "" {
+		delete(props, id)
+		return
+	}
+	props[id] = s
+}
+
+func propertiesEqual(a, b map[string]interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		w, ok := b[k]
+		if !ok || fmt.Sprintf("%v", v) != fmt.Sprintf("%v", w) {
+			return false
+		}
+	}
+	return true
+}
+
+// rollup aggregates the values of a property over the child cards of a card,
+// or over the cards referenced by a relation property.
+func (pc *propertyComputer) rollup(card *model.Block, props map[string]interface{}, def model.PropDef) (interface{}, error) {
+	related, err := pc.relatedCards(card, props, def.Rollup.RelationPropertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if def.Rollup.Function == model.RollupCount {
+		return float64(len(related)), nil
+	}
+
+	valueSchema, err := pc.relatedSchema(def.Rollup.RelationPropertyID)
+	if err != nil {
+		return nil, err
+	}
+	valueDef, ok := valueSchema[def.Rollup.PropertyID]
+	if !ok {
+		return nil, nil
+	}
+
+	var values []interface{}
+	for _, rc := range related {
+		rcProps, _ := rc.Fields["properties"].(map[string]interface{})
+		if v := typedPropertyValue(valueDef, rcProps[valueDef.ID]); v != nil && v != "" {
+			values = append(values, v)
+		}
+	}
+
+	switch def.Rollup.Function {
+	case model.RollupCountValues:
+		return float64(len(values)), nil
+	case model.RollupCountUnique:
+		unique := make(map[string]bool, len(values))
+		for _, v := range values {
+			unique[formula.ToString(v)] = true
+		}
+		return float64(len(unique)), nil
+	}
+
+	var numbers []float64
+	for _, v := range values {
+		if n, isNumber := v.(float64); isNumber {
+			numbers = append(numbers, n)
+		}
+	}
+	if len(numbers) == 0 {
+		return nil, nil
+	}
+
+	var result float64
+	switch def.Rollup.Function {
+	case model.RollupSum, model.RollupAverage:
+		for _, n := range numbers {
+			result += n
+		}
+		if def.Rollup.Function == model.RollupAverage {
+			result /= float64(len(numbers))
+		}
+	case model.RollupMin:
+		result = math.Inf(1)
+		for _, n := range numbers {
+			result = math.Min(result, n)
+		}
+	case model.RollupMax:
+		result = math.Inf(-1)
+		for _, n := range numbers {
+			result = math.Max(result, n)
+		}
+	default:
+		return nil, nil
+	}
+	return result, nil
+}
+
+// relatedSchema returns the property schema of the cards a rollup aggregates, which
+// are on another board for relations to other boards.
+func (pc *propertyComputer) relatedSchema(relationID string) (model.PropSchema, error) {
+	relation := pc.schema[relationID].Relation
+	if relationID == "" || relation == nil || relation.BoardID == pc.board.ID {
+		return pc.schema, nil
+	}
+	if schema, ok := pc.schemas[relation.BoardID]; ok {
+		return schema, nil
+	}
+
+	schema := model.PropSchema{}
+	board, err := pc.a.store.GetBoard(relation.BoardID)
+	if err != nil && !model.IsErrNotFound(err) {
+		return nil, err
+	}
+	if board != nil {
+		if parsed, errSchema := model.ParsePropertySchema(board); errSchema == nil {
+			schema = parsed
+		}
+	}
+	if pc.schemas == nil {
+		pc.schemas = make(map[string]model.PropSchema)
+	}
+	pc.schemas[relation.BoardID] = schema
+	return schema, nil
+}
+
+func (pc *propertyComputer) relatedCards(card *model.Block, props map[string]interface{}, relationID string) ([]*model.Block, error) {
+	if relationID == "" {
+		if pc.children != nil {
+			return pc.children[card.ID], nil
+		}
+		if card.ID == "" {
+			return nil, nil
+		}
+		children, err := pc.a.store.GetBlocksWithParentAndType(pc.board.ID, card.ID, model.TypeCard)
+		if err != nil {
+			return nil, err
+		}
+		related := make([]*model.Block, len(children))
+		for i := range children {
+			related[i] = &children[i]
+		}
+		return related, nil
+	}
+
+	ids := model.RelationIDs(props[relationID])
+	related := make([]*model.Block, 0, len(ids))
+	for _, id := range ids {
+		if rc, ok := pc.cards[id]; ok {
+			related = append(related, rc)
+			continue
+		}
+		rc, err := pc.a.store.GetBlock(id)
+		if err != nil {
+			return nil, err
+		}
+		if rc == nil {
+			continue
+		}
+		related = append(related, rc)
+	}
+	return related, nil
+}
+
+// typedPropertyValue converts a stored property value to a formula value.
+func typedPropertyValue(def model.PropDef, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	s, isString := v.(string)
+
+	switch def.Type {
+	case model.PropTypeNumber, model.PropTypeCurrency, model.PropTypePercentage, model.PropTypeRollup:
+		n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if !isString || err != nil {
+			return nil
+		}
+		return n
+	case "date":
+		if !isString {
+			return nil
+		}
+		date, err := model.ParseDateValue(s)
+		if err != nil {
+			return nil
+		}
+		return float64(date.From)
+	case model.PropTypeFormula:
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+	case "checkbox":
+		return s == "true"
+	case "select":
+		if opt, ok := def.Options[s]; ok {
+			return opt.Value
+		}
+		return nil
+	case "multiSelect":
+		ids, _ := v.([]interface{})
+		values := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if opt, ok := def.Options[fmt.Sprintf("%v", id)]; ok {
+				values = append(values, opt.Value)
+			}
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		return strings.Join(values, ", ")
+	}
+
+	if isString {
+		if s == "" {
+			return nil
+		}
+		return s
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// formulaResolver resolves the property references of the formulas of a card,
+// evaluating referenced formulas on demand.
+type formulaResolver struct {
+	pc         *propertyComputer
+	props      map[string]interface{}
+	results    map[string]interface{}
+	evaluating map[string]bool
+}
+
+func (r *formulaResolver) PropertyValue(name string) (interface{}, error) {
+	id, ok := r.pc.names[name]
+	if !ok {
+		id = name
+	}
+	def, ok := r.pc.schema[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", formula.ErrUnknownPropertyValue, name)
+	}
+	if def.Type == model.PropTypeFormula {
+		return r.evalFormula(id)
+	}
+	return typedPropertyValue(def, r.props[id]), nil
+}
+
+func (r *formulaResolver) evalFormula(id string) (interface{}, error) {
+	if result, ok := r.results[id]; ok {
+		return result, nil
+	}
+	if r.evaluating[id] {
+		return nil, fmt.Errorf("%w: %s", formula.ErrCircularReference, r.pc.schema[id].Name)
+	}
+	r.evaluating[id] = true
+	defer delete(r.evaluating, id)
+
+	f, err := r.pc.parseFormula(id)
+	if err != nil {
+		return nil, err
+	}
+	result, err := f.Eval(r)
+	if err != nil {
+		return nil, err
+	}
+	r.results[id] = result
+	return result, nil
+}
+
+// computeCardProperties sets the values of the computed properties of the cards
+// among blocks for a user.
+func (a *App) computeCardProperties(board *model.Board, schema model.PropSchema, blocks []*model.Block, userID string) error {
+	if !hasComputedProperties(schema) {
+		return nil
+	}
+	pc := a.newPropertyComputer(board, schema, userID)
+	for _, block := range blocks {
+		if block.Type != model.TypeCard {
+			continue
+		}
+		if _, err := pc.compute(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshParentRollups recomputes the properties of the given parent cards, and of their
+// own parents while their values change, after their child cards changed.
+func (a *App) refreshParentRollups(board *model.Board, userID string, parentIDs ...string) {
+	if board == nil {
+		return
+	}
+	schema, err := model.ParsePropertySchema(board)
+	if err != nil || !hasChildRollups(schema) {
+		return
+	}
+	pc := a.newPropertyComputer(board, schema, userID)
+
+	visited := make(map[string]bool)
+	for _, parentID := range parentIDs {
+		for depth := 0; parentID != "" && !visited[parentID] && depth < maxSearchDepth; depth++ {
+			visited[parentID] = true
+
+			parent, errGet := a.store.GetBlock(parentID)
+			if errGet != nil {
+				a.logger.Error("Cannot load parent card to update its rollups", mlog.String("block_id", parentID), mlog.Err(errGet))
+				break
+			}
+			if parent == nil || parent.Type != model.TypeCard || parent.BoardID != board.ID {
+				break
+			}
+
+			changed, errUpdate := a.saveComputedProperties(pc, parent)
+			if errUpdate != nil {
+				a.logger.Error("Cannot update rollups of card", mlog.String("block_id", parentID), mlog.Err(errUpdate))
+				break
+			}
+			if !changed {
+				break
+			}
+			parentID = parent.ParentID
+		}
+	}
+}
+
+// patchAffectsParentRollups returns true if a block patch may change the rollups of
+// the parent cards of the block.
+func patchAffectsParentRollups(patch *model.BlockPatch) bool {
+	if patch.ParentID != nil {
+		return true
+	}
+	if _, ok := patch.UpdatedFields["properties"]; ok {
+		return true
+	}
+	return utils.ContainsString(patch.DeletedFields, "properties")
+}
+
+// RecomputeBoardProperties recomputes the computed properties of every card of a board
+// for a user, child cards first so that the rollups of their parents use the new values.
+func (a *App) RecomputeBoardProperties(board *model.Board, userID string) error {
+	schema, err := model.ParsePropertySchema(board)
+	if err != nil {
+		return err
+	}
+	if !hasComputedProperties(schema) {
+		return nil
+	}
+
+	cards, err := a.store.GetBlocksWithType(board.ID, model.TypeCard)
+	if err != nil {
+		return err
+	}
+
+	pc := a.newPropertyComputer(board, schema, userID)
+	pc.cards = make(map[string]*model.Block, len(cards))
+	pc.children = make(map[string][]*model.Block)
+	for i := range cards {
+		card := &cards[i]
+		pc.cards[card.ID] = card
+		if card.ParentID != "" {
+			pc.children[card.ParentID] = append(pc.children[card.ParentID], card)
+		}
+	}
+
+	done := make(map[string]bool, len(cards))
+	var visit func(card *model.Block, depth int) error
+	visit = func(card *model.Block, depth int) error {
+		if done[card.ID] || depth > maxSearchDepth {
+			return nil
+		}
+		done[card.ID] = true
+		for _, child := range pc.children[card.ID] {
+			if errVisit := visit(child, depth+1); errVisit != nil {
+				return errVisit
+			}
+		}
+		_, errSave := a.saveComputedProperties(pc, card)
+		return errSave
+	}
+
+	for i := range cards {
+		if err = visit(&cards[i], 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveComputedProperties computes the properties of a stored card and saves the values
+// that changed, without changing the update time and the author of the card.
+func (a *App) saveComputedProperties(pc *propertyComputer, card *model.Block) (bool, error) {
+	before, _ := card.Fields["properties"].(map[string]interface{})
+	changed, err := pc.compute(card)
+	if err != nil || !changed {
+		return false, err
+	}
+	after, _ := card.Fields["properties"].(map[string]interface{})
+	if err = a.saveCardProperties(pc.board, card.ID, changedPropertyValues(before, after), ""); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// recomputeRebasedCard computes again the properties of a card saved with a patch based
+// on an older version of it, as the patch was merged with the newer values of the
+// inputs of the computed properties. Errors are logged, as the card is already saved.
+func (a *App) recomputeRebasedCard(board *model.Board, patch *model.BlockPatch, cardID, userID string) {
+	if patch.UpdateAt == nil || len(patch.ComputedPropertyIDs) == 0 {
+		return
+	}
+	schema, err := model.ParsePropertySchema(board)
+	if err != nil {
+		return
+	}
+	card, err := a.store.GetBlock(cardID)
+	if err == nil && card != nil {
+		_, err = a.saveComputedProperties(a.newPropertyComputer(board, schema, userID), card)
+	}
+	if err != nil {
+		a.logger.Error("Cannot recompute the properties of a rebased card",
+			mlog.String("block_id", cardID),
+			mlog.Err(err),
+		)
+	}
+}
+
+// saveCardProperties saves some property values of a stored card updated by the server,
+// a nil value deleting the property. The change is recorded as an edit of the user, or
+// keeps the update time and the author of the card if the user ID is empty.
+func (a *App) saveCardProperties(board *model.Board, cardID string, values map[string]interface{}, userID string) error {
+	if err := a.store.SetCardPropertyValues(cardID, values, userID); err != nil {
+		return err
+	}
+
+	updated, err := a.store.GetBlock(cardID)
+	if err != nil {
+		return err
+	}
+	teamID := board.TeamID
+	a.blockChangeNotifier.Enqueue(func() error {
+		a.wsAdapter.BroadcastBlockChange(teamID, *updated)
+		a.webhook.NotifyUpdate(*updated)
+		return nil
+	})
+	return nil
+}
+
+// changedPropertyValues returns the property values that differ between two versions
+// of the properties of a card, with a nil value for the removed properties.
+func changedPropertyValues(before, after map[string]interface{}) map[string]interface{} {
+	values := make(map[string]interface{})
+	for k, v := range after {
+		if w, ok := before[k]; !ok || fmt.Sprintf("%v", v) != fmt.Sprintf("%v", w) {
+			values[k] = v
+		}
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			values[k] = nil
+		}
+	}
+	return values
+}
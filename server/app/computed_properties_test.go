@@ -0,0 +1,118 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+)
+
+func setupComputedPropertiesBoard() *model.Board {
+	return &model.Board{
+		ID:     testBoardID,
+		TeamID: "team-id",
+		CardProperties: []map[string]interface{}{
+			{"id": "estimate", "name": "Estimate", "type": "number"},
+			{"id": "double", "name": "Double", "type": "formula", "formula": `prop("Estimate") * 2`},
+			{"id": "total", "name": "Total", "type": "rollup", "rollup": map[string]interface{}{
+				"propertyId": "estimate",
+				"function":   "sum",
+			}},
+			{"id": "summary", "name": "Summary", "type": "formula", "formula": `concat(prop("Total"), "/", prop("Double"))`},
+			{"id": "loop", "name": "Loop", "type": "formula", "formula": `prop("Loop") + 1`},
+		},
+	}
+}
+
+func newComputedCard(id, parentID string, props map[string]interface{}) model.Block {
+	return model.Block{
+		ID:       id,
+		ParentID: parentID,
+		BoardID:  testBoardID,
+		Type:     model.TypeCard,
+		Fields:   map[string]interface{}{"properties": props},
+	}
+}
+
+func TestComputeCardProperties(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	board := setupComputedPropertiesBoard()
+
+	t.Run("insert computes formulas and updates the parent rollups", func(t *testing.T) {
+		child := newComputedCard("child", "parent", map[string]interface{}{"estimate": "2.5"})
+		parent := newComputedCard("parent", "", map[string]interface{}{"estimate": "1", "double": "2"})
+		expectedParent := map[string]interface{}{"total": "2.5", "summary": "2.5/2"}
+
+		th.Store.EXPECT().GetBoard(board.ID).Return(board, nil)
+		th.Store.EXPECT().GetBlocksWithParentAndType(board.ID, "child", model.TypeCard).Return([]model.Block{}, nil)
+		th.Store.EXPECT().InsertBlock(gomock.Any(), "user-id-1").DoAndReturn(
+			func(block *model.Block, userID string) error {
+				require.Equal(t, map[string]interface{}{"estimate": "2.5", "double": "5", "summary": "/5"}, block.Fields["properties"])
+				return nil
+			})
+		th.Store.EXPECT().GetBlock("parent").Return(&parent, nil)
+		th.Store.EXPECT().GetBlocksWithParentAndType(board.ID, "parent", model.TypeCard).Return([]model.Block{child}, nil)
+		th.Store.EXPECT().SetCardPropertyValues("parent", expectedParent, "").Return(nil)
+		th.Store.EXPECT().GetBlock("parent").Return(&parent, nil)
+		th.Store.EXPECT().GetMembersForBoard(board.ID).Return([]*model.BoardMember{}, nil).AnyTimes()
+
+		err := th.App.InsertBlock(child, "user-id-1")
+		require.NoError(t, err)
+	})
+
+	t.Run("unchanged parents are not saved", func(t *testing.T) {
+		parent := newComputedCard("parent", "", map[string]interface{}{"total": "3", "double": "0", "summary": "3/0"})
+		children := []model.Block{
+			newComputedCard("child-1", "parent", map[string]interface{}{"estimate": "1"}),
+			newComputedCard("child-2", "parent", map[string]interface{}{"estimate": "2"}),
+		}
+
+		th.Store.EXPECT().GetBlock("parent").Return(&parent, nil)
+		th.Store.EXPECT().GetBlocksWithParentAndType(board.ID, "parent", model.TypeCard).Return(children, nil)
+
+		th.App.refreshParentRollups(board, model.SystemUserID, "parent")
+	})
+
+	t.Run("boards without computed properties are ignored", func(t *testing.T) {
+		card := newComputedCard("card", "", map[string]interface{}{"estimate": "1"})
+		err := th.App.computeCardProperties(board, model.PropSchema{}, []*model.Block{&card}, model.SystemUserID)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"estimate": "1"}, card.Fields["properties"])
+	})
+}
+
+func TestRecomputeBoardProperties(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	board := setupComputedPropertiesBoard()
+	cards := []model.Block{
+		newComputedCard("root", "", map[string]interface{}{}),
+		newComputedCard("middle", "root", map[string]interface{}{"estimate": "1"}),
+		newComputedCard("leaf", "middle", map[string]interface{}{"estimate": "2", "total": "100"}),
+	}
+	expected := map[string]map[string]interface{}{
+		"leaf":   {"double": "4", "total": nil, "summary": "/4"},
+		"middle": {"double": "2", "total": "2", "summary": "2/2"},
+		"root":   {"total": "1", "double": "0", "summary": "1/0"},
+	}
+
+	th.Store.EXPECT().GetBlocksWithType(board.ID, model.TypeCard).Return(cards, nil)
+	var saved []string
+	th.Store.EXPECT().SetCardPropertyValues(gomock.Any(), gomock.Any(), "").DoAndReturn(
+		func(cardID string, values map[string]interface{}, userID string) error {
+			require.Equal(t, expected[cardID], values)
+			saved = append(saved, cardID)
+			return nil
+		}).Times(3)
+	th.Store.EXPECT().GetBlock(gomock.Any()).Return(&cards[0], nil).Times(3)
+	th.Store.EXPECT().GetMembersForBoard(board.ID).Return([]*model.BoardMember{}, nil).AnyTimes()
+
+	err := th.App.RecomputeBoardProperties(board, model.SystemUserID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"leaf", "middle", "root"}, saved)
+}
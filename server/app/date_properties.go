@@ -0,0 +1,50 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/mattermost/focalboard/server/model"
+)
+
+// GetCardsOverlappingDates returns the cards of a board whose value of a date
+// property overlaps the interval [from, to[ in milliseconds. Card templates are
+// skipped.
+func (a *App) GetCardsOverlappingDates(boardID, propertyID string, from, to int64) ([]model.Block, error) {
+	board, err := a.store.GetBoard(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := model.ParsePropertySchema(board)
+	if err != nil {
+		return nil, err
+	}
+	if def, ok := schema[propertyID]; !ok || def.Type != "date" {
+		return nil, fmt.Errorf("%w: %s is not a date property", model.ErrInvalidProperty, propertyID)
+	}
+
+	cards, err := a.store.GetBlocksWithType(boardID, model.TypeCard)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := make([]model.Block, 0)
+	for _, card := range cards {
+		if isTemplate, _ := boolValue(card.Fields, "isTemplate"); isTemplate {
+			continue
+		}
+		props, _ := card.Fields["properties"].(map[string]interface{})
+		s, ok := props[propertyID].(string)
+		if !ok {
+			continue
+		}
+		date, errDate := model.ParseDateValue(s)
+		if errDate != nil {
+			continue
+		}
+		if date.Overlaps(from, to) {
+			matching = append(matching, card)
+		}
+	}
+	return matching, nil
+}
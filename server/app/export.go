@@ -2,20 +2,104 @@ package app
 
 import (
 	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
 
 	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
 	"github.com/wiggin77/merror"
 
 	"github.com/mattermost/mattermost-server/v6/shared/mlog"
 )
 
+const (
+	archiveVersionFilename  = "version.json"
+	archiveManifestFilename = "manifest.json"
+	archiveBoardFilename    = "board.jsonl"
+)
+
 var (
 	newline = []byte{'\n'}
 )
 
+// archiveWriter wraps a zip writer and records the size and SHA-256 hash of
+// every file written so they can be listed in the archive manifest.
+type archiveWriter struct {
+	zw       *zip.Writer
+	manifest *model.ArchiveManifest
+
+	w    io.Writer
+	path string
+	hash hash.Hash
+	size int64
+}
+
+func newArchiveWriter(w io.Writer, manifest *model.ArchiveManifest) *archiveWriter {
+	return &archiveWriter{
+		zw:       zip.NewWriter(w),
+		manifest: manifest,
+	}
+}
+
+// Create adds a file to the archive. The returned writer is valid until the
+// next call to Create or Close.
+func (aw *archiveWriter) Create(path string) (io.Writer, error) {
+	aw.endFile()
+
+	w, err := aw.zw.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	aw.w = w
+	aw.path = path
+	aw.hash = sha256.New()
+	aw.size = 0
+	return aw, nil
+}
+
+func (aw *archiveWriter) Write(p []byte) (int, error) {
+	n, err := aw.w.Write(p)
+	aw.hash.Write(p[:n])
+	aw.size += int64(n)
+	return n, err
+}
+
+// endFile records the file currently being written in the manifest.
+func (aw *archiveWriter) endFile() {
+	if aw.hash == nil {
+		return
+	}
+	aw.manifest.Files = append(aw.manifest.Files, model.ArchiveManifestFile{
+		Path:   aw.path,
+		Size:   aw.size,
+		SHA256: hex.EncodeToString(aw.hash.Sum(nil)),
+	})
+	aw.w = nil
+	aw.hash = nil
+}
+
+// Close writes the manifest and closes the zip.
+func (aw *archiveWriter) Close() error {
+	aw.endFile()
+
+	b, err := json.Marshal(aw.manifest)
+	if err != nil {
+		return fmt.Errorf("cannot marshal archive manifest: %w", err)
+	}
+	w, err := aw.zw.Create(archiveManifestFilename)
+	if err != nil {
+		return fmt.Errorf("cannot write archive manifest: %w", err)
+	}
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("cannot write archive manifest: %w", err)
+	}
+	return aw.zw.Close()
+}
+
 func (a *App) ExportArchive(w io.Writer, opt model.ExportArchiveOptions) (errs error) {
 	boards, err := a.getBoardsForArchive(opt.BoardIDs)
 	if err != nil {
@@ -27,19 +111,32 @@ func (a *App) ExportArchive(w io.Writer, opt model.ExportArchiveOptions) (errs e
 		errs = merr.ErrorOrNil()
 	}()
 
+	manifest := &model.ArchiveManifest{
+		Version: archiveVersion,
+		Date:    model.GetMillis(),
+		Exporter: model.ArchiveExporter{
+			UserID:      opt.ExportedBy,
+			Version:     model.CurrentVersion,
+			BuildNumber: model.BuildNumber,
+			Edition:     model.Edition,
+		},
+		Boards: make([]model.ArchiveManifestBoard, 0, len(boards)),
+		Files:  make([]model.ArchiveManifestFile, 0),
+	}
+
 	// wrap the writer in a zip.
-	zw := zip.NewWriter(w)
+	aw := newArchiveWriter(w, manifest)
 	defer func() {
-		merr.Append(zw.Close())
+		merr.Append(aw.Close())
 	}()
 
-	if err := a.writeArchiveVersion(zw); err != nil {
+	if err := a.writeArchiveVersion(aw); err != nil {
 		merr.Append(err)
 		return
 	}
 
 	for _, board := range boards {
-		if err := a.writeArchiveBoard(zw, board, opt); err != nil {
+		if err := a.writeArchiveBoard(aw, board, opt); err != nil {
 			merr.Append(fmt.Errorf("cannot export board %s: %w", board.ID, err))
 			return
 		}
@@ -48,14 +145,14 @@ func (a *App) ExportArchive(w io.Writer, opt model.ExportArchiveOptions) (errs e
 }
 
 // writeArchiveVersion writes a version file to the zip.
-func (a *App) writeArchiveVersion(zw *zip.Writer) error {
+func (a *App) writeArchiveVersion(aw *archiveWriter) error {
 	archiveHeader := model.ArchiveHeader{
 		Version: archiveVersion,
 		Date:    model.GetMillis(),
 	}
 	b, _ := json.Marshal(&archiveHeader)
 
-	w, err := zw.Create("version.json")
+	w, err := aw.Create(archiveVersionFilename)
 	if err != nil {
 		return fmt.Errorf("cannot write archive header: %w", err)
 	}
@@ -67,9 +164,9 @@ func (a *App) writeArchiveVersion(zw *zip.Writer) error {
 }
 
 // writeArchiveBoard writes a single board to the archive in a zip directory.
-func (a *App) writeArchiveBoard(zw *zip.Writer, board model.Board, opt model.ExportArchiveOptions) error {
+func (a *App) writeArchiveBoard(aw *archiveWriter, board model.Board, opt model.ExportArchiveOptions) error {
 	// create a directory per board
-	w, err := zw.Create(board.ID + "/board.jsonl")
+	w, err := aw.Create(board.ID + "/" + archiveBoardFilename)
 	if err != nil {
 		return err
 	}
@@ -87,12 +184,18 @@ func (a *App) writeArchiveBoard(zw *zip.Writer, board model.Board, opt model.Exp
 		return err
 	}
 
+	aw.manifest.Boards = append(aw.manifest.Boards, model.ArchiveManifestBoard{
+		ID:     board.ID,
+		Title:  board.Title,
+		Blocks: len(blocks),
+	})
+
 	for _, block := range blocks {
 		if err = a.writeArchiveBlockLine(w, block); err != nil {
 			return err
 		}
-		if block.Type == model.TypeImage {
-			filename, err := extractImageFilename(block)
+		if block.Type == model.TypeImage || block.Type == model.TypeAttachment {
+			filename, err := extractFilename(block)
 			if err != nil {
 				return err
 			}
@@ -100,49 +203,141 @@ func (a *App) writeArchiveBoard(zw *zip.Writer, board model.Board, opt model.Exp
 		}
 	}
 
+	if opt.IncludeMembership {
+		if err = a.writeArchiveMembership(w, aw, board, blocks); err != nil {
+			return err
+		}
+	}
+
 	// write the files
 	for _, filename := range files {
-		if err := a.writeArchiveFile(zw, filename, board.ID, opt); err != nil {
+		if err := a.writeArchiveFile(aw, filename, board.ID, opt); err != nil {
 			return fmt.Errorf("cannot write file %s to archive: %w", filename, err)
 		}
 	}
 	return nil
 }
 
-// writeArchiveBlockLine writes a single block to the archive.
-func (a *App) writeArchiveBlockLine(w io.Writer, block model.Block) error {
-	b, err := json.Marshal(&block)
+// writeArchiveMembership writes the board members, the sidebar categories
+// they placed the board in, and their subscriptions to the board and its cards.
+func (a *App) writeArchiveMembership(w io.Writer, aw *archiveWriter, board model.Board, blocks []model.Block) error {
+	members, err := a.GetMembersForBoard(board.ID)
 	if err != nil {
 		return err
 	}
-	line := model.ArchiveLine{
-		Type: "block",
-		Data: b,
-	}
 
-	b, err = json.Marshal(&line)
-	if err != nil {
-		return err
+	// subscriptions can exist on the board itself and on any card.
+	subscribable := map[string]model.BlockType{board.ID: model.TypeBoard}
+	for _, block := range blocks {
+		if block.Type == model.TypeCard {
+			subscribable[block.ID] = block.Type
+		}
 	}
 
-	_, err = w.Write(b)
-	if err != nil {
-		return err
+	var memberCount, categoryCount int
+	for _, member := range members {
+		user, ok, errUser := a.getArchiveUser(member.UserID)
+		if errUser != nil {
+			return errUser
+		}
+		if !ok {
+			continue
+		}
+
+		archiveMember := model.ArchiveMember{
+			ArchiveUser:     user,
+			Roles:           member.Roles,
+			SchemeAdmin:     member.SchemeAdmin,
+			SchemeEditor:    member.SchemeEditor,
+			SchemeCommenter: member.SchemeCommenter,
+			SchemeViewer:    member.SchemeViewer,
+		}
+		if err = a.writeArchiveLine(w, "member", archiveMember); err != nil {
+			return err
+		}
+		memberCount++
+
+		categories, errCategories := a.GetUserCategoryBoards(member.UserID, board.TeamID)
+		if errCategories != nil {
+			return errCategories
+		}
+		for _, category := range categories {
+			if !utils.ContainsString(category.BoardIDs, board.ID) {
+				continue
+			}
+			archiveCategory := model.ArchiveCategory{
+				ArchiveUser: user,
+				Name:        category.Name,
+			}
+			if err = a.writeArchiveLine(w, "category", archiveCategory); err != nil {
+				return err
+			}
+			categoryCount++
+		}
+
+		// only subscriptions of board members are exported.
+		subs, errSubs := a.store.GetSubscriptions(member.UserID)
+		if errSubs != nil {
+			return errSubs
+		}
+		for _, sub := range subs {
+			blockType, ok := subscribable[sub.BlockID]
+			if !ok || sub.SubscriberType != model.SubTypeUser {
+				continue
+			}
+			archiveSub := model.ArchiveSubscription{
+				ArchiveUser: user,
+				BlockType:   blockType,
+				BlockID:     sub.BlockID,
+			}
+			if err = a.writeArchiveLine(w, "subscription", archiveSub); err != nil {
+				return err
+			}
+		}
 	}
 
-	// jsonl files need a newline
-	_, err = w.Write(newline)
-	return err
+	manifestBoard := &aw.manifest.Boards[len(aw.manifest.Boards)-1]
+	manifestBoard.Members = memberCount
+	manifestBoard.Categories = categoryCount
+	return nil
+}
+
+// getArchiveUser returns the identifying fields of a user for an archive. The
+// bool result is false if the user no longer exists.
+func (a *App) getArchiveUser(userID string) (model.ArchiveUser, bool, error) {
+	user, err := a.store.GetUserByID(userID)
+	if err != nil && !model.IsErrNotFound(err) {
+		return model.ArchiveUser{}, false, err
+	}
+	if user == nil {
+		a.logger.Debug("skipping missing user for archive", mlog.String("user_id", userID))
+		return model.ArchiveUser{}, false, nil
+	}
+	return model.ArchiveUser{
+		UserID:   user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+	}, true, nil
 }
 
 // writeArchiveBlockLine writes a single block to the archive.
+func (a *App) writeArchiveBlockLine(w io.Writer, block model.Block) error {
+	return a.writeArchiveLine(w, "block", block)
+}
+
+// writeArchiveBoardLine writes a single board to the archive.
 func (a *App) writeArchiveBoardLine(w io.Writer, board model.Board) error {
-	b, err := json.Marshal(&board)
+	return a.writeArchiveLine(w, "board", board)
+}
+
+// writeArchiveLine writes a single line of the given type to the archive.
+func (a *App) writeArchiveLine(w io.Writer, lineType string, data interface{}) error {
+	b, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
 	line := model.ArchiveLine{
-		Type: "board",
+		Type: lineType,
 		Data: b,
 	}
 
@@ -162,8 +357,8 @@ func (a *App) writeArchiveBoardLine(w io.Writer, board model.Board) error {
 }
 
 // writeArchiveFile writes a single file to the archive.
-func (a *App) writeArchiveFile(zw *zip.Writer, filename string, boardID string, opt model.ExportArchiveOptions) error {
-	dest, err := zw.Create(boardID + "/" + filename)
+func (a *App) writeArchiveFile(aw *archiveWriter, filename string, boardID string, opt model.ExportArchiveOptions) error {
+	dest, err := aw.Create(boardID + "/" + filename)
 	if err != nil {
 		return err
 	}
@@ -199,14 +394,13 @@ func (a *App) getBoardsForArchive(boardIDs []string) ([]model.Board, error) {
 	return boards, nil
 }
 
-func extractImageFilename(imageBlock model.Block) (string, error) {
-	f, ok := imageBlock.Fields["fileId"]
-	if !ok {
-		return "", model.ErrInvalidImageBlock
-	}
-
-	filename, ok := f.(string)
+// extractFilename returns the name of the file of an image or attachment block.
+func extractFilename(block model.Block) (string, error) {
+	filename, ok := model.BlockFileID(&block)
 	if !ok {
+		if block.Type == model.TypeAttachment {
+			return "", model.ErrInvalidAttachmentBlock
+		}
 		return "", model.ErrInvalidImageBlock
 	}
 	return filename, nil
@@ -0,0 +1,495 @@
+package app
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/markdown"
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+// exportDoc is a format-neutral rendering of a board used by the Markdown
+// and HTML exporters.
+type exportDoc struct {
+	Title       string
+	Icon        string
+	Description string
+	Groups      []exportDocGroup
+}
+
+type exportDocGroup struct {
+	Title string
+	Cards []exportDocCard
+}
+
+type exportDocCard struct {
+	Title      string
+	Icon       string
+	Properties []model.BlockProp
+	Contents   []exportDocContent
+	Comments   []exportDocContent
+}
+
+type exportDocContent struct {
+	Type     model.BlockType
+	Text     string
+	Author   string
+	ImageURL string
+}
+
+// ExportBoardDocument renders a board, its cards and their content as a
+// human-readable Markdown or HTML document.
+func (a *App) ExportBoardDocument(w io.Writer, boardID string, opt model.ExportDocumentOptions) error {
+	if opt.Format != model.ExportFormatMarkdown && opt.Format != model.ExportFormatHTML {
+		return fmt.Errorf("%w: %s", model.ErrUnsupportedExportFormat, opt.Format)
+	}
+
+	board, err := a.GetBoard(boardID)
+	if err != nil {
+		return fmt.Errorf("could not fetch board %s: %w", boardID, err)
+	}
+
+	blocks, err := a.GetBlocksWithBoardID(boardID)
+	if err != nil {
+		return fmt.Errorf("could not fetch blocks for board %s: %w", boardID, err)
+	}
+
+	doc, err := a.buildExportDoc(board, blocks, opt)
+	if err != nil {
+		return err
+	}
+
+	if opt.Format == model.ExportFormatHTML {
+		return writeExportDocHTML(w, doc)
+	}
+	return writeExportDocMarkdown(w, doc)
+}
+
+// buildExportDoc groups the cards of a board by the requested select property
+// and resolves their properties and content.
+func (a *App) buildExportDoc(board *model.Board, blocks []model.Block, opt model.ExportDocumentOptions) (*exportDoc, error) {
+	schema, err := model.ParsePropertySchema(board)
+	if err != nil {
+		return nil, err
+	}
+
+	var groupBy *model.PropDef
+	if opt.GroupByPropertyID != "" {
+		def, ok := schema[opt.GroupByPropertyID]
+		if !ok || def.Type != "select" {
+			return nil, fmt.Errorf("%w: %s", model.ErrInvalidGroupByProperty, opt.GroupByPropertyID)
+		}
+		groupBy = &def
+	}
+
+	propDefs := make([]model.PropDef, 0, len(schema))
+	for _, def := range schema {
+		propDefs = append(propDefs, def)
+	}
+	sort.Slice(propDefs, func(i, j int) bool { return propDefs[i].Index < propDefs[j].Index })
+
+	cards := make([]model.Block, 0)
+	children := make(map[string][]model.Block)
+	for _, block := range blocks {
+		if block.DeleteAt != 0 {
+			continue
+		}
+		if block.Type == model.TypeCard {
+			cards = append(cards, block)
+			continue
+		}
+		children[block.ParentID] = append(children[block.ParentID], block)
+	}
+	sortBlocksByCreateAt(cards)
+
+	groupIndex := make(map[string]int)
+	groups := make([]exportDocGroup, 0)
+	if groupBy != nil {
+		options := make([]model.PropDefOption, 0, len(groupBy.Options))
+		for _, option := range groupBy.Options {
+			options = append(options, option)
+		}
+		sort.Slice(options, func(i, j int) bool { return options[i].Index < options[j].Index })
+
+		for _, option := range options {
+			groupIndex[option.ID] = len(groups)
+			groups = append(groups, exportDocGroup{Title: option.Value})
+		}
+	}
+	groupIndex[""] = len(groups)
+	noValueTitle := ""
+	if groupBy != nil {
+		noValueTitle = "No " + groupBy.Name
+	}
+	groups = append(groups, exportDocGroup{Title: noValueTitle})
+
+	for _, card := range cards {
+		docCard := exportDocCard{
+			Title:      card.Title,
+			Properties: a.exportCardProperties(card, propDefs, opt.Location),
+		}
+		if icon, ok := stringValue(card.Fields, "icon"); ok {
+			docCard.Icon = icon
+		}
+		docCard.Contents, docCard.Comments = a.exportCardContents(board, card, children[card.ID], opt)
+
+		optionID := ""
+		if groupBy != nil {
+			if props, ok := mapValue(card.Fields, "properties"); ok {
+				optionID, _ = stringValue(props, groupBy.ID)
+			}
+		}
+		idx, ok := groupIndex[optionID]
+		if !ok {
+			idx = groupIndex[""]
+		}
+		groups[idx].Cards = append(groups[idx].Cards, docCard)
+	}
+
+	// drop empty groups so only options that have cards are rendered.
+	nonEmpty := make([]exportDocGroup, 0, len(groups))
+	for _, group := range groups {
+		if len(group.Cards) != 0 {
+			nonEmpty = append(nonEmpty, group)
+		}
+	}
+
+	return &exportDoc{
+		Title:       board.Title,
+		Icon:        board.Icon,
+		Description: board.Description,
+		Groups:      nonEmpty,
+	}, nil
+}
+
+// exportCardProperties resolves the property values of a card in schema order, rendering
+// dates in the given location. Values that cannot be resolved (e.g. a deleted select
+// option) are exported raw.
+func (a *App) exportCardProperties(card model.Block, propDefs []model.PropDef, loc *time.Location) []model.BlockProp {
+	props := make([]model.BlockProp, 0, len(propDefs))
+	resolver := model.ResolverWithLocation(a.store, loc)
+
+	values, _ := mapValue(card.Fields, "properties")
+	for _, def := range propDefs {
+		v, ok := values[def.ID]
+		if sv, isSystem := model.SystemPropertyValue(def, &card); isSystem {
+			v, ok = sv, true
+		}
+		if !ok || v == "" {
+			continue
+		}
+		val, err := def.GetValue(v, resolver)
+		if err != nil {
+			a.logger.Debug("cannot resolve property value for export",
+				mlog.String("card_id", card.ID),
+				mlog.String("property_id", def.ID),
+				mlog.Err(err),
+			)
+			val = fmt.Sprintf("%v", v)
+		}
+		props = append(props, model.BlockProp{
+			ID:    def.ID,
+			Index: def.Index,
+			Name:  def.Name,
+			Value: val,
+		})
+	}
+	return props
+}
+
+// exportCardContents returns the content blocks of a card in `contentOrder`
+// order, followed by any content not referenced there, and the card's comments
+// in creation order.
+func (a *App) exportCardContents(board *model.Board, card model.Block, children []model.Block, opt model.ExportDocumentOptions) ([]exportDocContent, []exportDocContent) {
+	byID := make(map[string]model.Block, len(children))
+	comments := make([]model.Block, 0)
+	for _, child := range children {
+		if child.Type == model.TypeComment {
+			comments = append(comments, child)
+			continue
+		}
+		byID[child.ID] = child
+	}
+
+	ordered := make([]model.Block, 0, len(byID))
+	for _, id := range contentOrderIDs(card) {
+		if block, ok := byID[id]; ok {
+			ordered = append(ordered, block)
+			delete(byID, id)
+		}
+	}
+	remaining := make([]model.Block, 0, len(byID))
+	for _, block := range byID {
+		remaining = append(remaining, block)
+	}
+	sortBlocksByCreateAt(remaining)
+	ordered = append(ordered, remaining...)
+
+	contents := make([]exportDocContent, 0, len(ordered))
+	for _, block := range ordered {
+		content := exportDocContent{
+			Type: block.Type,
+			Text: block.Title,
+		}
+		if block.Type == model.TypeImage {
+			content.ImageURL = a.exportImageURL(board, block, opt)
+			if content.ImageURL == "" {
+				continue
+			}
+		}
+		if block.Type == model.TypeAttachment {
+			content.Text = a.exportAttachmentLink(board, block)
+			if content.Text == "" {
+				continue
+			}
+		}
+		if block.Type == model.TypeCheckbox {
+			// rendered as a task list item.
+			item := block
+			mark := " "
+			if model.IsChecklistItemChecked(&item) {
+				mark = "x"
+			}
+			content.Text = fmt.Sprintf("- [%s] %s", mark, block.Title)
+		}
+		contents = append(contents, content)
+	}
+
+	sortBlocksByCreateAt(comments)
+	docComments := make([]exportDocContent, 0, len(comments))
+	for _, comment := range comments {
+		author := comment.CreatedBy
+		if user, err := a.store.GetUserByID(comment.CreatedBy); err == nil && user != nil {
+			author = user.Username
+		}
+		docComments = append(docComments, exportDocContent{
+			Type:   model.TypeComment,
+			Text:   comment.Title,
+			Author: author,
+		})
+	}
+	return contents, docComments
+}
+
+// exportAttachmentLink returns a Markdown link to the file of an attachment block on
+// this server, or an empty string for invalid attachment blocks.
+func (a *App) exportAttachmentLink(board *model.Board, block model.Block) string {
+	filename, err := extractFilename(block)
+	if err != nil {
+		return ""
+	}
+	name := strings.NewReplacer("[", "\\[", "]", "\\]").Replace(model.GetAttachmentName(&block))
+	link := utils.MakeFileLink(a.config.ServerRoot, board.TeamID, board.ID, filename)
+	return fmt.Sprintf("[%s](%s)", name, link)
+}
+
+// exportImageURL returns a data URI for the image when images are embedded,
+// otherwise a link to the file on this server. An empty string is returned
+// for invalid image blocks.
+func (a *App) exportImageURL(board *model.Board, block model.Block, opt model.ExportDocumentOptions) string {
+	filename, err := extractFilename(block)
+	if err != nil {
+		return ""
+	}
+
+	link := utils.MakeFileLink(a.config.ServerRoot, board.TeamID, board.ID, filename)
+	if !opt.EmbedImages {
+		return link
+	}
+
+	src, err := a.GetFileReader(board.TeamID, board.ID, filename)
+	if err != nil {
+		a.logger.Error("image file missing for export",
+			mlog.String("filename", filename),
+			mlog.String("team_id", board.TeamID),
+			mlog.String("board_id", board.ID),
+		)
+		return link
+	}
+	defer src.Close()
+
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		a.logger.Error("cannot read image file for export",
+			mlog.String("filename", filename),
+			mlog.Err(err),
+		)
+		return link
+	}
+
+	mimeType := mime.TypeByExtension(filepath.Ext(filename))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// contentOrderIDs flattens a card's `contentOrder` field, which may contain
+// nested arrays for content rendered side by side.
+func contentOrderIDs(card model.Block) []string {
+	ids := make([]string, 0)
+	order, ok := card.Fields["contentOrder"].([]interface{})
+	if !ok {
+		return ids
+	}
+	for _, item := range order {
+		switch v := item.(type) {
+		case string:
+			ids = append(ids, v)
+		case []interface{}:
+			for _, nested := range v {
+				if id, ok := nested.(string); ok {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+func sortBlocksByCreateAt(blocks []model.Block) {
+	sort.SliceStable(blocks, func(i, j int) bool {
+		if blocks[i].CreateAt == blocks[j].CreateAt {
+			return blocks[i].ID < blocks[j].ID
+		}
+		return blocks[i].CreateAt < blocks[j].CreateAt
+	})
+}
+
+func writeExportDocMarkdown(w io.Writer, doc *exportDoc) error {
+	var sb strings.Builder
+
+	sb.WriteString("# ")
+	if doc.Icon != "" {
+		sb.WriteString(doc.Icon + " ")
+	}
+	sb.WriteString(doc.Title + "\n\n")
+	if doc.Description != "" {
+		sb.WriteString(doc.Description + "\n\n")
+	}
+
+	for _, group := range doc.Groups {
+		cardLevel := "## "
+		if group.Title != "" {
+			sb.WriteString("## " + group.Title + "\n\n")
+			cardLevel = "### "
+		}
+
+		for _, card := range group.Cards {
+			sb.WriteString(cardLevel)
+			if card.Icon != "" {
+				sb.WriteString(card.Icon + " ")
+			}
+			sb.WriteString(card.Title + "\n\n")
+
+			for _, prop := range card.Properties {
+				sb.WriteString(fmt.Sprintf("- **%s:** %s\n", prop.Name, prop.Value))
+			}
+			if len(card.Properties) != 0 {
+				sb.WriteString("\n")
+			}
+
+			for _, content := range card.Contents {
+				if content.Type == model.TypeImage {
+					sb.WriteString(fmt.Sprintf("![%s](%s)\n\n", content.Text, content.ImageURL))
+					continue
+				}
+				if content.Text != "" {
+					sb.WriteString(content.Text + "\n\n")
+				}
+			}
+
+			if len(card.Comments) != 0 {
+				sb.WriteString("**Comments**\n\n")
+				for _, comment := range card.Comments {
+					sb.WriteString("> **" + comment.Author + ":** ")
+					sb.WriteString(strings.ReplaceAll(comment.Text, "\n", "\n> ") + "\n\n")
+				}
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// markdownURLAttr matches the link and image URLs rendered by markdown.RenderHTML, which
+// escapes the quotes of the attribute values.
+var markdownURLAttr = regexp.MustCompile(`(href|src)="([^"]*)"`)
+
+// markdownURLSchemes are the schemes allowed in the links and images of exported markdown.
+var markdownURLSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// renderMarkdownHTML renders markdown to HTML, replacing the URLs with a scheme other than
+// http, https and mailto, like javascript: links.
+func renderMarkdownHTML(s string) string {
+	return markdownURLAttr.ReplaceAllStringFunc(markdown.RenderHTML(s), func(attr string) string {
+		match := markdownURLAttr.FindStringSubmatch(attr)
+		u, err := url.Parse(html.UnescapeString(match[2]))
+		if err != nil || (u.Scheme != "" && !markdownURLSchemes[strings.ToLower(u.Scheme)]) {
+			return match[1] + `="#"`
+		}
+		return attr
+	})
+}
+
+var exportDocHTMLTemplate = template.Must(template.New("board").Funcs(template.FuncMap{
+	"markdown": func(s string) template.HTML {
+		// RenderHTML escapes raw HTML and renderMarkdownHTML drops the unsafe URLs, so the
+		// result is safe to embed.
+		return template.HTML(renderMarkdownHTML(s)) //nolint:gosec
+	},
+	// image sources are either links built by the server or data URIs.
+	"imageURL": func(s string) template.URL { return template.URL(s) }, //nolint:gosec
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; max-width: 960px; margin: 0 auto; padding: 24px; color: #3f4350; }
+.card { border: 1px solid #ddd; border-radius: 4px; padding: 8px 16px; margin-bottom: 16px; }
+.properties td { padding: 2px 12px 2px 0; vertical-align: top; }
+.properties td:first-child { color: #888; }
+.comment { border-left: 3px solid #ddd; padding-left: 8px; margin: 8px 0; }
+img { max-width: 100%; }
+</style>
+</head>
+<body>
+<h1>{{if .Icon}}{{.Icon}} {{end}}{{.Title}}</h1>
+{{if .Description}}<div class="description">{{markdown .Description}}</div>{{end}}
+{{range .Groups}}<section class="group">
+{{if .Title}}<h2>{{.Title}}</h2>{{end}}
+{{range .Cards}}<article class="card">
+<h3>{{if .Icon}}{{.Icon}} {{end}}{{.Title}}</h3>
+{{if .Properties}}<table class="properties">
+{{range .Properties}}<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>{{end}}
+{{range .Contents}}{{if .ImageURL}}<p><img src="{{imageURL .ImageURL}}" alt="{{.Text}}"></p>
+{{else if .Text}}<div class="content">{{markdown .Text}}</div>
+{{end}}{{end}}
+{{if .Comments}}<h4>Comments</h4>
+{{range .Comments}}<div class="comment"><strong>{{.Author}}</strong>{{markdown .Text}}</div>
+{{end}}{{end}}</article>
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
+
+func writeExportDocHTML(w io.Writer, doc *exportDoc) error {
+	return exportDocHTMLTemplate.Execute(w, doc)
+}
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func setupExportDocumentBoard() (*model.Board, []model.Block) {
+	board := &model.Board{
+		ID:     "board-id",
+		TeamID: "team-id",
+		Title:  "Release plan",
+		CardProperties: []map[string]interface{}{
+			{
+				"id":   "status",
+				"name": "Status",
+				"type": "select",
+				"options": []interface{}{
+					map[string]interface{}{"id": "todo", "value": "To Do"},
+					map[string]interface{}{"id": "done", "value": "Done"},
+				},
+			},
+			{
+				"id":   "estimate",
+				"name": "Estimate",
+				"type": "number",
+			},
+		},
+	}
+
+	blocks := []model.Block{
+		{
+			ID:       "card-1",
+			BoardID:  board.ID,
+			ParentID: board.ID,
+			Type:     model.TypeCard,
+			Title:    "Write docs",
+			CreateAt: 1,
+			Fields: map[string]interface{}{
+				"properties":   map[string]interface{}{"status": "done", "estimate": "3"},
+				"contentOrder": []interface{}{"text-2", []interface{}{"text-1", "image-1"}},
+			},
+		},
+		{
+			ID:       "card-2",
+			BoardID:  board.ID,
+			ParentID: board.ID,
+			Type:     model.TypeCard,
+			Title:    "Ship it",
+			CreateAt: 2,
+			Fields: map[string]interface{}{
+				"properties": map[string]interface{}{"status": "todo"},
+			},
+		},
+		{ID: "text-1", BoardID: board.ID, ParentID: "card-1", Type: model.TypeText, Title: "first paragraph", CreateAt: 3},
+		{ID: "text-2", BoardID: board.ID, ParentID: "card-1", Type: model.TypeText, Title: "second paragraph", CreateAt: 4},
+		{
+			ID:       "image-1",
+			BoardID:  board.ID,
+			ParentID: "card-1",
+			Type:     model.TypeImage,
+			CreateAt: 5,
+			Fields:   map[string]interface{}{"fileId": "7abc.png"},
+		},
+		{ID: "comment-1", BoardID: board.ID, ParentID: "card-1", Type: model.TypeComment, Title: "looks good", CreatedBy: "user-1", CreateAt: 6},
+	}
+	return board, blocks
+}
+
+func TestExportBoardDocument(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	board, blocks := setupExportDocumentBoard()
+
+	t.Run("markdown grouped by select property", func(t *testing.T) {
+		th.Store.EXPECT().GetBoard(board.ID).Return(board, nil)
+		th.Store.EXPECT().GetBlocksWithBoardID(board.ID).Return(blocks, nil)
+		th.Store.EXPECT().GetUserByID("user-1").Return(&model.User{ID: "user-1", Username: "alice"}, nil)
+
+		var buf bytes.Buffer
+		opts := model.ExportDocumentOptions{
+			TeamID:            board.TeamID,
+			Format:            model.ExportFormatMarkdown,
+			GroupByPropertyID: "status",
+		}
+		err := th.App.ExportBoardDocument(&buf, board.ID, opts)
+		require.NoError(t, err)
+
+		md := buf.String()
+		require.Contains(t, md, "# Release plan")
+		require.Contains(t, md, "- **Status:** DONE")
+		require.Contains(t, md, "- **Estimate:** 3")
+		require.Contains(t, md, "![](/api/v2/files/teams/team-id/board-id/7abc.png)")
+		require.Contains(t, md, "> **alice:** looks good")
+
+		// groups follow option order, content follows contentOrder.
+		require.Less(t, bytes.Index(buf.Bytes(), []byte("## To Do")), bytes.Index(buf.Bytes(), []byte("## Done")))
+		require.Less(t, bytes.Index(buf.Bytes(), []byte("### Ship it")), bytes.Index(buf.Bytes(), []byte("### Write docs")))
+		require.Less(t, bytes.Index(buf.Bytes(), []byte("second paragraph")), bytes.Index(buf.Bytes(), []byte("first paragraph")))
+	})
+
+	t.Run("system properties", func(t *testing.T) {
+		systemBoard := *board
+		systemBoard.CardProperties = []map[string]interface{}{
+			{"id": "creator", "name": "Created by", "type": model.PropTypeCreatedBy},
+			{"id": "created", "name": "Created", "type": model.PropTypeCreatedTime},
+		}
+		card := model.Block{
+			ID:        "card-3",
+			BoardID:   board.ID,
+			ParentID:  board.ID,
+			Type:      model.TypeCard,
+			Title:     "Plan it",
+			CreatedBy: "user-2",
+			CreateAt:  1642161600000,
+		}
+		th.Store.EXPECT().GetBoard(board.ID).Return(&systemBoard, nil)
+		th.Store.EXPECT().GetBlocksWithBoardID(board.ID).Return([]model.Block{card}, nil)
+		th.Store.EXPECT().GetUserByID("user-2").Return(&model.User{ID: "user-2", Username: "bob"}, nil)
+
+		var buf bytes.Buffer
+		err := th.App.ExportBoardDocument(&buf, board.ID, model.ExportDocumentOptions{Format: model.ExportFormatMarkdown})
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), "- **Created by:** bob")
+		require.Contains(t, buf.String(), "- **Created:** January 14, 2022 12:00 PM UTC")
+	})
+
+	t.Run("html escapes content", func(t *testing.T) {
+		htmlBlocks := append([]model.Block{}, blocks...)
+		htmlBlocks[1].Title = "<script>alert(1)</script>"
+
+		th.Store.EXPECT().GetBoard(board.ID).Return(board, nil)
+		th.Store.EXPECT().GetBlocksWithBoardID(board.ID).Return(htmlBlocks, nil)
+		th.Store.EXPECT().GetUserByID("user-1").Return(&model.User{ID: "user-1", Username: "alice"}, nil)
+
+		var buf bytes.Buffer
+		opts := model.ExportDocumentOptions{
+			TeamID: board.TeamID,
+			Format: model.ExportFormatHTML,
+		}
+		err := th.App.ExportBoardDocument(&buf, board.ID, opts)
+		require.NoError(t, err)
+
+		html := buf.String()
+		require.Contains(t, html, "<title>Release plan</title>")
+		require.Contains(t, html, "<p>first paragraph</p>")
+		require.Contains(t, html, `<img src="/api/v2/files/teams/team-id/board-id/7abc.png"`)
+		require.NotContains(t, html, "<script>")
+	})
+
+	t.Run("html drops unsafe links", func(t *testing.T) {
+		linkBlocks := append([]model.Block{}, blocks...)
+		linkBlocks[3].Title = "[docs](https://example.com) [run](javascript:alert(1)) ![img](JavaScript:alert(2)) [mail](mailto:a@example.com)"
+
+		th.Store.EXPECT().GetBoard(board.ID).Return(board, nil)
+		th.Store.EXPECT().GetBlocksWithBoardID(board.ID).Return(linkBlocks, nil)
+		th.Store.EXPECT().GetUserByID("user-1").Return(&model.User{ID: "user-1", Username: "alice"}, nil)
+
+		var buf bytes.Buffer
+		err := th.App.ExportBoardDocument(&buf, board.ID, model.ExportDocumentOptions{Format: model.ExportFormatHTML})
+		require.NoError(t, err)
+
+		html := buf.String()
+		require.Contains(t, html, `<a href="https://example.com">docs</a>`)
+		require.Contains(t, html, `<a href="#">run</a>`)
+		require.Contains(t, html, `<img src="#" alt="img" />`)
+		require.Contains(t, html, `<a href="mailto:a@example.com">mail</a>`)
+		require.NotContains(t, strings.ToLower(html), "javascript:")
+	})
+
+	t.Run("invalid group by property", func(t *testing.T) {
+		th.Store.EXPECT().GetBoard(board.ID).Return(board, nil)
+		th.Store.EXPECT().GetBlocksWithBoardID(board.ID).Return(blocks, nil)
+
+		var buf bytes.Buffer
+		opts := model.ExportDocumentOptions{
+			TeamID:            board.TeamID,
+			Format:            model.ExportFormatMarkdown,
+			GroupByPropertyID: "estimate",
+		}
+		err := th.App.ExportBoardDocument(&buf, board.ID, opts)
+		require.ErrorIs(t, err, model.ErrInvalidGroupByProperty)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := th.App.ExportBoardDocument(&buf, board.ID, model.ExportDocumentOptions{Format: "pdf"})
+		require.ErrorIs(t, err, model.ErrUnsupportedExportFormat)
+	})
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_ExportArchive(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	board := &model.Board{
+		ID:     "board-id",
+		TeamID: "test-team",
+		Title:  "Manifest board",
+	}
+	blocks := []model.Block{
+		{ID: "card-id", BoardID: board.ID, ParentID: board.ID, Type: model.TypeCard, Title: "card"},
+		{ID: "text-id", BoardID: board.ID, ParentID: "card-id", Type: model.TypeText, Title: "text"},
+	}
+
+	exportArchive := func(t *testing.T) []byte {
+		th.Store.EXPECT().GetBoard(board.ID).Return(board, nil)
+		th.Store.EXPECT().GetBlocksWithBoardID(board.ID).Return(blocks, nil)
+
+		var buf bytes.Buffer
+		opts := model.ExportArchiveOptions{
+			TeamID:     board.TeamID,
+			BoardIDs:   []string{board.ID},
+			ExportedBy: "user-id",
+		}
+		require.NoError(t, th.App.ExportArchive(&buf, opts))
+		return buf.Bytes()
+	}
+
+	t.Run("manifest lists boards and file hashes", func(t *testing.T) {
+		archive := exportArchive(t)
+
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		require.NoError(t, err)
+
+		contents := make(map[string][]byte)
+		for _, zf := range zr.File {
+			r, err := zf.Open()
+			require.NoError(t, err)
+			b, err := io.ReadAll(r)
+			require.NoError(t, err)
+			contents[zf.Name] = b
+		}
+
+		var manifest model.ArchiveManifest
+		require.NoError(t, json.Unmarshal(contents[archiveManifestFilename], &manifest))
+		require.Equal(t, archiveVersion, manifest.Version)
+		require.Equal(t, "user-id", manifest.Exporter.UserID)
+		require.Equal(t, model.CurrentVersion, manifest.Exporter.Version)
+		require.Len(t, manifest.Boards, 1)
+		require.Equal(t, board.ID, manifest.Boards[0].ID)
+		require.Equal(t, 2, manifest.Boards[0].Blocks)
+
+		require.Len(t, manifest.Files, 2)
+		for _, mf := range manifest.Files {
+			b, ok := contents[mf.Path]
+			require.True(t, ok, "missing file %s", mf.Path)
+			sum := sha256.Sum256(b)
+			require.Equal(t, hex.EncodeToString(sum[:]), mf.SHA256)
+			require.Equal(t, int64(len(b)), mf.Size)
+		}
+	})
+
+	t.Run("import rejects tampered archive", func(t *testing.T) {
+		archive := exportArchive(t)
+
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, zf := range zr.File {
+			r, err := zf.Open()
+			require.NoError(t, err)
+			b, err := io.ReadAll(r)
+			require.NoError(t, err)
+			if zf.Name == board.ID+"/"+archiveBoardFilename {
+				b = bytes.Replace(b, []byte(`"title":"card"`), []byte(`"title":"evil"`), 1)
+			}
+			w, err := zw.Create(zf.Name)
+			require.NoError(t, err)
+			_, err = w.Write(b)
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+
+		opts := model.ImportArchiveOptions{
+			TeamID:     "test-team",
+			ModifiedBy: "user",
+		}
+		_, err = th.App.ImportArchive(&buf, opts)
+		var errMismatch model.ErrArchiveChecksumMismatch
+		require.ErrorAs(t, err, &errMismatch)
+	})
+
+	t.Run("import rejects archive without manifest", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, err := zw.Create(archiveVersionFilename)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(`{"version":3,"date":0}`))
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+
+		_, err = th.App.ImportArchive(&buf, model.ImportArchiveOptions{TeamID: "test-team"})
+		require.ErrorIs(t, err, model.ErrMissingArchiveManifest)
+	})
+}
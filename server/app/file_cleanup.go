@@ -0,0 +1,410 @@
+package app
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+const (
+	// fileQuarantineDir holds the orphaned files until their grace period is over, in
+	// a directory named after the time of the sweep that found them.
+	fileQuarantineDir = "quarantine"
+
+	fileCleanupReportKey = "FileCleanupReport"
+
+	// orphanedFileMinAge keeps the files uploaded for blocks that are not saved yet.
+	orphanedFileMinAge = 24 * time.Hour
+)
+
+// RunFileCleanup moves the files no block references to the quarantine, restores the
+// quarantined files that are referenced again and deletes the files whose grace period
+// is over. The files referenced by the block history within the retention window are
+// kept, so that deleted blocks and boards can still be restored with their files.
+func (a *App) RunFileCleanup() (*model.FileCleanupReport, error) {
+	start := time.Now()
+	report := &model.FileCleanupReport{RunAt: utils.GetMillisForTime(start)}
+
+	err := a.cleanUpFiles(report, start)
+	report.Duration = utils.GetMillis() - report.RunAt
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	if errReport := a.saveFileCleanupReport(report); errReport != nil {
+		a.logger.Error("Cannot save the file cleanup report", mlog.Err(errReport))
+	}
+
+	if err != nil {
+		return report, err
+	}
+
+	a.logger.Info("File cleanup completed",
+		mlog.Int("scanned", report.Scanned),
+		mlog.Int("quarantined", report.Quarantined),
+		mlog.Int("restored", report.Restored),
+		mlog.Int("deleted", report.Deleted),
+		mlog.Int64("reclaimed_bytes", report.ReclaimedBytes),
+	)
+	return report, nil
+}
+
+// GetFileCleanupReport returns the report of the last file cleanup.
+func (a *App) GetFileCleanupReport() (*model.FileCleanupReport, error) {
+	value, err := a.store.GetSystemSetting(fileCleanupReportKey)
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, model.NewErrNotFound("file cleanup report")
+	}
+
+	var report model.FileCleanupReport
+	if err = json.Unmarshal([]byte(value), &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (a *App) saveFileCleanupReport(report *model.FileCleanupReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return a.store.SetSystemSetting(fileCleanupReportKey, string(data))
+}
+
+func (a *App) cleanUpFiles(report *model.FileCleanupReport, now time.Time) error {
+	historySince := utils.GetMillisForTime(now) - utils.SecondsToMillis(a.config.FileCleanupRetentionSeconds)
+	blocks, err := a.store.GetBlocksReferencingFiles(historySince)
+	if err != nil {
+		return err
+	}
+	referenced := make(map[string]bool, len(blocks))
+	for i := range blocks {
+		if fileID, ok := model.BlockFileID(&blocks[i]); ok {
+			referenced[fileID] = true
+		}
+	}
+
+	if err = a.sweepFileQuarantine(report, referenced, now); err != nil {
+		return err
+	}
+
+	teamIDs, err := a.getAllTeamIDs()
+	if err != nil {
+		return err
+	}
+
+	variantSources := make(map[string]bool, len(referenced))
+	for fileID := range referenced {
+		variantSources[fileVariantSource(fileID)] = true
+	}
+
+	quarantine := filepath.Join(fileQuarantineDir, strconv.FormatInt(report.RunAt, 10))
+	for _, teamID := range teamIDs {
+		boardDirs, err := a.filesBackend.ListDirectory(teamID)
+		if err != nil {
+			return err
+		}
+		for _, boardDir := range boardDirs {
+			if err = a.quarantineOrphanedFiles(report, referenced, teamID, boardDir, quarantine, now); err != nil {
+				return err
+			}
+			if err = a.removeOrphanedFileVariants(variantSources, boardDir, now); err != nil {
+				return err
+			}
+		}
+	}
+	return a.quarantineOrphanedContents(report, referenced, quarantine, now)
+}
+
+// quarantineOrphanedFiles moves the files of a board directory that are not referenced
+// to the quarantine. Their image variants are removed, as they can be generated again.
+func (a *App) quarantineOrphanedFiles(report *model.FileCleanupReport, referenced map[string]bool, teamID, boardDir, quarantine string, now time.Time) error {
+	filePaths, err := a.filesBackend.ListDirectory(boardDir)
+	if err != nil {
+		return err
+	}
+
+	boardID := filepath.Base(boardDir)
+	for _, filePath := range filePaths {
+		fileID := filepath.Base(filePath)
+		if fileID == "variants" {
+			continue
+		}
+		report.Scanned++
+		if referenced[fileID] {
+			continue
+		}
+
+		modTime, err := a.filesBackend.FileModTime(filePath)
+		if err != nil {
+			a.logger.Warn("Cannot check the age of a file", mlog.String("FilePath", filePath), mlog.Err(err))
+			continue
+		}
+		if now.Sub(modTime) < orphanedFileMinAge {
+			continue
+		}
+
+		size, err := a.filesBackend.FileSize(filePath)
+		if err != nil {
+			return err
+		}
+		if err = a.filesBackend.MoveFile(filePath, filepath.Join(quarantine, filePath)); err != nil {
+			return err
+		}
+		a.removeFileVariants(teamID, boardID, fileID)
+
+		a.logger.Debug("Quarantined orphaned file", mlog.String("FilePath", filePath))
+		report.Quarantined++
+		report.QuarantinedBytes += size
+	}
+	return nil
+}
+
+// removeOrphanedFileVariants removes the image variants of a board directory whose file
+// is not referenced, including the variants of the files sharing a content, which are
+// not stored in the board directory.
+func (a *App) removeOrphanedFileVariants(variantSources map[string]bool, boardDir string, now time.Time) error {
+	variantDirs, err := a.filesBackend.ListDirectory(filepath.Join(boardDir, "variants"))
+	if err != nil {
+		return err
+	}
+	for _, variantDir := range variantDirs {
+		variantPaths, err := a.filesBackend.ListDirectory(variantDir)
+		if err != nil {
+			return err
+		}
+		for _, variantPath := range variantPaths {
+			if variantSources[fileVariantSource(filepath.Base(variantPath))] {
+				continue
+			}
+			modTime, err := a.filesBackend.FileModTime(variantPath)
+			if err != nil {
+				a.logger.Warn("Cannot check the age of a file", mlog.String("FilePath", variantPath), mlog.Err(err))
+				continue
+			}
+			if now.Sub(modTime) < orphanedFileMinAge {
+				continue
+			}
+			if err = a.filesBackend.RemoveFile(variantPath); err != nil {
+				return err
+			}
+			a.logger.Debug("Removed orphaned file variant", mlog.String("FilePath", variantPath))
+		}
+	}
+	return nil
+}
+
+// fileVariantSource returns the name of a file without its extension, which the variants
+// of an image share with it.
+func fileVariantSource(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename))
+}
+
+// removeContentVariants removes the image variants of the files sharing a content.
+func (a *App) removeContentVariants(checksum string) {
+	fileInfos, err := a.store.GetFileInfosByChecksum(checksum)
+	if err != nil {
+		a.logger.Error("Cannot fetch file infos", mlog.String("checksum", checksum), mlog.Err(err))
+		return
+	}
+	for _, fileInfo := range fileInfos {
+		a.removeFileVariants(fileInfo.TeamID, fileInfo.BoardID, fileInfo.ID)
+	}
+}
+
+// quarantineOrphanedContents moves the file contents that no referenced file shares to
+// the quarantine.
+func (a *App) quarantineOrphanedContents(report *model.FileCleanupReport, referenced map[string]bool, quarantine string, now time.Time) error {
+	shardDirs, err := a.filesBackend.ListDirectory(fileContentDir)
+	if err != nil {
+		return err
+	}
+	for _, shardDir := range shardDirs {
+		contentPaths, err := a.filesBackend.ListDirectory(shardDir)
+		if err != nil {
+			return err
+		}
+		for _, contentPath := range contentPaths {
+			report.Scanned++
+			if err = a.quarantineOrphanedContent(report, referenced, contentPath, quarantine, now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *App) quarantineOrphanedContent(report *model.FileCleanupReport, referenced map[string]bool, contentPath, quarantine string, now time.Time) error {
+	checksum := filepath.Base(contentPath)
+	isReferenced, err := a.isContentReferenced(checksum, referenced, now)
+	if err != nil || isReferenced {
+		return err
+	}
+
+	size, err := a.filesBackend.FileSize(contentPath)
+	if err != nil {
+		return err
+	}
+	quarantinedPath := filepath.Join(quarantine, contentPath)
+	if err = a.filesBackend.MoveFile(contentPath, quarantinedPath); err != nil {
+		return err
+	}
+
+	// a file sharing the content may have been saved while it was moved, possibly
+	// by another server that found the content before it was moved.
+	isReferenced, err = a.isContentReferenced(checksum, referenced, now)
+	if err != nil {
+		return err
+	}
+	if isReferenced {
+		return a.filesBackend.MoveFile(quarantinedPath, contentPath)
+	}
+	a.removeContentVariants(checksum)
+
+	a.logger.Debug("Quarantined orphaned file content", mlog.String("FilePath", contentPath))
+	report.Quarantined++
+	report.QuarantinedBytes += size
+	return nil
+}
+
+// isContentReferenced tells if one of the files sharing a content is referenced, or was
+// saved too recently to be referenced yet.
+func (a *App) isContentReferenced(checksum string, referenced map[string]bool, now time.Time) (bool, error) {
+	fileInfos, err := a.store.GetFileInfosByChecksum(checksum)
+	if err != nil {
+		return false, err
+	}
+	for _, fileInfo := range fileInfos {
+		if referenced[fileInfo.ID] || now.Sub(utils.GetTimeForMillis(fileInfo.CreateAt)) < orphanedFileMinAge {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// deleteOrphanedFileInfos deletes the file infos of a content saved before it was
+// quarantined, and the image variants of the files.
+func (a *App) deleteOrphanedFileInfos(checksum string, quarantinedAt int64) {
+	fileInfos, err := a.store.GetFileInfosByChecksum(checksum)
+	if err != nil {
+		a.logger.Error("Cannot fetch file infos", mlog.String("checksum", checksum), mlog.Err(err))
+		return
+	}
+	for _, fileInfo := range fileInfos {
+		if fileInfo.DeleteAt != 0 || fileInfo.CreateAt >= quarantinedAt {
+			continue
+		}
+		a.removeFileVariants(fileInfo.TeamID, fileInfo.BoardID, fileInfo.ID)
+		if err = a.store.DeleteFileInfo(fileInfo.ID); err != nil {
+			a.logger.Error("Cannot delete file info", mlog.String("file_id", fileInfo.ID), mlog.Err(err))
+		}
+	}
+}
+
+// sweepFileQuarantine restores the quarantined files that are referenced again and
+// deletes the other files once their grace period is over.
+func (a *App) sweepFileQuarantine(report *model.FileCleanupReport, referenced map[string]bool, now time.Time) error {
+	runDirs, err := a.filesBackend.ListDirectory(fileQuarantineDir)
+	if err != nil {
+		return err
+	}
+
+	grace := time.Duration(a.config.FileCleanupGraceSeconds) * time.Second
+	for _, runDir := range runDirs {
+		if filepath.Base(runDir) == infectedFileQuarantineDir {
+			continue
+		}
+		runAt, err := strconv.ParseInt(filepath.Base(runDir), 10, 64)
+		if err != nil {
+			a.logger.Warn("Unexpected entry in the file quarantine", mlog.String("path", runDir))
+			continue
+		}
+		expired := now.Sub(utils.GetTimeForMillis(runAt)) >= grace
+
+		filePaths, err := a.listQuarantinedFiles(runDir)
+		if err != nil {
+			return err
+		}
+		for _, filePath := range filePaths {
+			originalPath, err := filepath.Rel(runDir, filePath)
+			if err != nil {
+				return err
+			}
+
+			fileID := filepath.Base(filePath)
+			isContent := isFileContentPath(originalPath)
+			isReferenced := referenced[fileID]
+			if isContent {
+				if isReferenced, err = a.isContentReferenced(fileID, referenced, now); err != nil {
+					return err
+				}
+			}
+			if isReferenced {
+				if err = a.filesBackend.MoveFile(filePath, originalPath); err != nil {
+					return err
+				}
+				report.Restored++
+				continue
+			}
+			if !expired {
+				continue
+			}
+
+			size, err := a.filesBackend.FileSize(filePath)
+			if err != nil {
+				return err
+			}
+			if err = a.filesBackend.RemoveFile(filePath); err != nil {
+				return err
+			}
+			if isContent {
+				a.deleteOrphanedFileInfos(fileID, runAt)
+			} else if err = a.store.DeleteFileInfo(fileID); err != nil {
+				a.logger.Error("Cannot delete file info", mlog.String("file_id", fileID), mlog.Err(err))
+			}
+			report.Deleted++
+			report.ReclaimedBytes += size
+		}
+
+		if expired {
+			if err = a.filesBackend.RemoveDirectory(runDir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// listQuarantinedFiles returns the paths of the files quarantined by a sweep, which
+// keep the team and board directories of their original path.
+func (a *App) listQuarantinedFiles(runDir string) ([]string, error) {
+	var filePaths []string
+	teamDirs, err := a.filesBackend.ListDirectory(runDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, teamDir := range teamDirs {
+		boardDirs, err := a.filesBackend.ListDirectory(teamDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, boardDir := range boardDirs {
+			files, err := a.filesBackend.ListDirectory(boardDir)
+			if err != nil {
+				return nil, err
+			}
+			filePaths = append(filePaths, files...)
+		}
+	}
+	return filePaths, nil
+}
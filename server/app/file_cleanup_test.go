@@ -0,0 +1,213 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/filestore"
+)
+
+func TestRunFileCleanup(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	dir := t.TempDir()
+	backend, err := filestore.NewFileBackend(filestore.FileBackendSettings{DriverName: "local", Directory: dir})
+	require.NoError(t, err)
+	th.App.filesBackend = backend
+	th.App.config.FileCleanupRetentionSeconds = 3600
+	th.App.config.FileCleanupGraceSeconds = 3600
+
+	boardDir := filepath.Join("team-id", testBoardID)
+	old := time.Now().Add(-48 * time.Hour)
+	writeFile := func(name string, data string, modTime time.Time) {
+		filePath := filepath.Join(boardDir, name)
+		_, err := backend.WriteFile(bytes.NewReader([]byte(data)), filePath)
+		require.NoError(t, err)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, filePath), modTime, modTime))
+	}
+	exists := func(filePath string) bool {
+		ok, err := backend.FileExists(filePath)
+		require.NoError(t, err)
+		return ok
+	}
+
+	writeFile("7used.png", "used", old)
+	writeFile("7orphan.png", "orphan", old)
+	writeFile("7fresh.txt", "fresh", time.Now())
+	writeFile(filepath.Join("variants", "thumbnail", "7orphan.png"), "thumbnail", old)
+	writeFile(filepath.Join("variants", "preview", "7used.png"), "preview", old)
+	// the variant of a file sharing a content, which is not in the board directory.
+	writeFile(filepath.Join("variants", "preview", "7shared.png"), "preview", old)
+
+	referenced := []model.Block{
+		{ID: "image", Type: model.TypeImage, Fields: map[string]interface{}{"fileId": "7used.png"}},
+	}
+	th.Store.EXPECT().GetAllTeams().Return([]*model.Team{{ID: "team-id"}}, nil).AnyTimes()
+	th.Store.EXPECT().SetSystemSetting(fileCleanupReportKey, gomock.Any()).Return(nil).AnyTimes()
+
+	t.Run("quarantines the orphaned files", func(t *testing.T) {
+		th.Store.EXPECT().GetBlocksReferencingFiles(gomock.Any()).Return(referenced, nil)
+
+		report, err := th.App.RunFileCleanup()
+		require.NoError(t, err)
+		require.Equal(t, 3, report.Scanned)
+		require.Equal(t, 1, report.Quarantined)
+		require.Equal(t, int64(6), report.QuarantinedBytes)
+
+		quarantined := filepath.Join(fileQuarantineDir, strconv.FormatInt(report.RunAt, 10), boardDir, "7orphan.png")
+		require.True(t, exists(quarantined))
+		require.False(t, exists(filepath.Join(boardDir, "7orphan.png")))
+		require.False(t, exists(filepath.Join(boardDir, "variants", "thumbnail", "7orphan.png")))
+		require.False(t, exists(filepath.Join(boardDir, "variants", "preview", "7shared.png")))
+		require.True(t, exists(filepath.Join(boardDir, "variants", "preview", "7used.png")))
+		require.True(t, exists(filepath.Join(boardDir, "7used.png")))
+		require.True(t, exists(filepath.Join(boardDir, "7fresh.txt")))
+	})
+
+	t.Run("restores the files referenced again", func(t *testing.T) {
+		restored := append([]model.Block{
+			{ID: "restored", Type: model.TypeAttachment, Fields: map[string]interface{}{"fileId": "7orphan.png"}},
+		}, referenced...)
+		th.Store.EXPECT().GetBlocksReferencingFiles(gomock.Any()).Return(restored, nil)
+
+		report, err := th.App.RunFileCleanup()
+		require.NoError(t, err)
+		require.Equal(t, 1, report.Restored)
+		require.Zero(t, report.Quarantined)
+		require.True(t, exists(filepath.Join(boardDir, "7orphan.png")))
+	})
+
+	t.Run("deletes the files after the grace period", func(t *testing.T) {
+		th.Store.EXPECT().GetBlocksReferencingFiles(gomock.Any()).Return(referenced, nil).Times(2)
+		th.Store.EXPECT().DeleteFileInfo("7orphan.png").Return(nil)
+
+		report, err := th.App.RunFileCleanup()
+		require.NoError(t, err)
+		require.Equal(t, 1, report.Quarantined)
+		require.Zero(t, report.Deleted)
+
+		th.App.config.FileCleanupGraceSeconds = 0
+		report, err = th.App.RunFileCleanup()
+		require.NoError(t, err)
+		require.Equal(t, 1, report.Deleted)
+		require.Equal(t, int64(6), report.ReclaimedBytes)
+
+		runDirs, err := backend.ListDirectory(fileQuarantineDir)
+		require.NoError(t, err)
+		require.Empty(t, runDirs)
+	})
+}
+
+func TestRunFileCleanupContents(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	backend, err := filestore.NewFileBackend(filestore.FileBackendSettings{DriverName: "local", Directory: t.TempDir()})
+	require.NoError(t, err)
+	th.App.filesBackend = backend
+	th.App.config.FileCleanupRetentionSeconds = 3600
+	th.App.config.FileCleanupGraceSeconds = 3600
+
+	const (
+		usedChecksum   = "aa6b8e5c0fa1d4e6bb7ae1e8c3cb9d1a4a2cce0e1f8a54e4c0e6a5a1d2b1c001"
+		orphanChecksum = "bb6b8e5c0fa1d4e6bb7ae1e8c3cb9d1a4a2cce0e1f8a54e4c0e6a5a1d2b1c002"
+	)
+	for _, checksum := range []string{usedChecksum, orphanChecksum} {
+		_, err = backend.WriteFile(bytes.NewReader([]byte("content")), fileContentPath(checksum))
+		require.NoError(t, err)
+	}
+	usedVariant := filepath.Join("team-id", testBoardID, "variants", model.FileVariantThumbnail, "7used.png")
+	orphanVariant := filepath.Join("team-id", testBoardID, "variants", model.FileVariantThumbnail, "7orphan.png")
+	for _, variantPath := range []string{usedVariant, orphanVariant} {
+		_, err = backend.WriteFile(bytes.NewReader([]byte("variant")), variantPath)
+		require.NoError(t, err)
+	}
+
+	old := utils.GetMillisForTime(time.Now().Add(-48 * time.Hour))
+	referenced := []model.Block{
+		{ID: "image", Type: model.TypeImage, Fields: map[string]interface{}{"fileId": "7used.png"}},
+	}
+	th.Store.EXPECT().GetAllTeams().Return([]*model.Team{}, nil).AnyTimes()
+	th.Store.EXPECT().SetSystemSetting(fileCleanupReportKey, gomock.Any()).Return(nil).AnyTimes()
+	th.Store.EXPECT().GetBlocksReferencingFiles(gomock.Any()).Return(referenced, nil).AnyTimes()
+	th.Store.EXPECT().GetFileInfosByChecksum(usedChecksum).Return([]*model.FileInfo{
+		{ID: "7used.png", Checksum: usedChecksum, CreateAt: old},
+	}, nil).AnyTimes()
+	th.Store.EXPECT().GetFileInfosByChecksum(orphanChecksum).Return([]*model.FileInfo{
+		{ID: "7orphan.png", TeamID: "team-id", BoardID: testBoardID, Checksum: orphanChecksum, CreateAt: old},
+		{ID: "7deleted.png", Checksum: orphanChecksum, CreateAt: old, DeleteAt: old},
+	}, nil).AnyTimes()
+
+	report, err := th.App.RunFileCleanup()
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Scanned)
+	require.Equal(t, 1, report.Quarantined)
+
+	exists, err := backend.FileExists(fileContentPath(usedChecksum))
+	require.NoError(t, err)
+	require.True(t, exists)
+	exists, err = backend.FileExists(fileContentPath(orphanChecksum))
+	require.NoError(t, err)
+	require.False(t, exists)
+	exists, err = backend.FileExists(usedVariant)
+	require.NoError(t, err)
+	require.True(t, exists)
+	exists, err = backend.FileExists(orphanVariant)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	th.App.config.FileCleanupGraceSeconds = 0
+	th.Store.EXPECT().DeleteFileInfo("7orphan.png").Return(nil)
+
+	report, err = th.App.RunFileCleanup()
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Deleted)
+	require.Equal(t, int64(7), report.ReclaimedBytes)
+}
+
+func TestRunFileCleanupSharedContent(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	backend, err := filestore.NewFileBackend(filestore.FileBackendSettings{DriverName: "local", Directory: t.TempDir()})
+	require.NoError(t, err)
+	th.App.filesBackend = backend
+	th.App.config.FileCleanupGraceSeconds = 3600
+
+	_, err = backend.WriteFile(bytes.NewReader([]byte("content")), fileContentPath(testChecksum))
+	require.NoError(t, err)
+
+	old := utils.GetMillisForTime(time.Now().Add(-48 * time.Hour))
+	orphan := &model.FileInfo{ID: "7orphan.png", Checksum: testChecksum, CreateAt: old}
+	th.Store.EXPECT().GetAllTeams().Return([]*model.Team{}, nil)
+	th.Store.EXPECT().SetSystemSetting(fileCleanupReportKey, gomock.Any()).Return(nil)
+	th.Store.EXPECT().GetBlocksReferencingFiles(gomock.Any()).Return([]model.Block{}, nil)
+
+	// another server saves a file sharing the content while it is moved.
+	gomock.InOrder(
+		th.Store.EXPECT().GetFileInfosByChecksum(testChecksum).Return([]*model.FileInfo{orphan}, nil),
+		th.Store.EXPECT().GetFileInfosByChecksum(testChecksum).Return([]*model.FileInfo{
+			orphan,
+			{ID: "7new.png", Checksum: testChecksum, CreateAt: utils.GetMillis()},
+		}, nil),
+	)
+
+	report, err := th.App.RunFileCleanup()
+	require.NoError(t, err)
+	require.Equal(t, 0, report.Quarantined)
+
+	exists, err := backend.FileExists(fileContentPath(testChecksum))
+	require.NoError(t, err)
+	require.True(t, exists)
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+// fileContentDir holds the contents of the files, named after their SHA-256 checksum
+// and sharded by its first two characters. The files with the same content share it,
+// each file being a reference recorded by its file info. The file cleanup removes the
+// content once none of its references is used.
+//
+// A reference is always saved before the content is checked, and the cleanup checks
+// the references again after it moves a content away, so that the servers sharing the
+// files storage need no lock.
+//
+// The files uploaded before are stored under the directory of their board.
+const fileContentDir = "content"
+
+// fileContentPath returns the path of the content with a checksum.
+func fileContentPath(checksum string) string {
+	return filepath.Join(fileContentDir, checksum[:2], checksum)
+}
+
+// isFileContentPath tells if a path, relative to the root of the files storage, is the
+// path of a content.
+func isFileContentPath(filePath string) bool {
+	return strings.HasPrefix(filePath, fileContentDir+string(filepath.Separator))
+}
+
+// newFileID returns a new ID for a file, which keeps the extension of its name.
+func newFileID(filename string) string {
+	// NOTE: File extension includes the dot
+	fileExtension := strings.ToLower(filepath.Ext(filename))
+	if fileExtension == ".jpeg" {
+		fileExtension = ".jpg"
+	}
+	return utils.NewID(utils.IDTypeNone) + fileExtension
+}
+
+// spoolFile copies the content of a file to a temporary file, to learn its checksum
+// before it is stored. The size and the checksum of fileInfo are set. The temporary
+// file is returned, to be removed with removeTempFile.
+func (a *App) spoolFile(reader io.Reader, fileInfo *model.FileInfo) (*os.File, error) {
+	file, err := ioutil.TempFile("", "focalboard-upload-")
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), reader)
+	if err != nil {
+		a.removeTempFile(file)
+		return nil, err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		a.removeTempFile(file)
+		return nil, err
+	}
+
+	fileInfo.Size = size
+	fileInfo.Checksum = hex.EncodeToString(hash.Sum(nil))
+	return file, nil
+}
+
+// storeFile records a file and stores its content, unless a file with the same
+// content is already stored.
+func (a *App) storeFile(fileInfo *model.FileInfo, content io.Reader) error {
+	if err := a.store.SaveFileInfo(fileInfo); err != nil {
+		return err
+	}
+
+	contentPath := fileContentPath(fileInfo.Checksum)
+	exists, err := a.filesBackend.FileExists(contentPath)
+	if err == nil && !exists {
+		_, err = a.filesBackend.WriteFile(content, contentPath)
+	}
+	if err != nil {
+		if errDelete := a.store.DeleteFileInfo(fileInfo.ID); errDelete != nil {
+			a.logger.Error("Cannot delete file info", mlog.String("file_id", fileInfo.ID), mlog.Err(errDelete))
+		}
+		return fmt.Errorf("unable to store the file in the files storage: %w", err)
+	}
+	return nil
+}
+
+// getFileContent returns the file info of a file of a board and the path of its
+// content. The path is empty for the files stored under the directory of their board.
+func (a *App) getFileContent(boardID, fileID string) (*model.FileInfo, string, error) {
+	fileInfo, err := a.store.GetFileInfo(fileID)
+	if model.IsErrNotFound(err) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	// the file ID is not enough, the file must belong to the board the user can access.
+	if fileInfo.BoardID != boardID || fileInfo.Checksum == "" {
+		return nil, "", nil
+	}
+
+	contentPath := fileContentPath(fileInfo.Checksum)
+	exists, err := a.filesBackend.FileExists(contentPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if !exists {
+		return fileInfo, "", nil
+	}
+	return fileInfo, contentPath, nil
+}
+
+// copyFile records a copy of a file of a board on another board, and returns the ID
+// of the copy. The copies share the content of the file, only the files stored under
+// the directory of their board are copied.
+func (a *App) copyFile(sourceBoard *model.Board, fileID string, destBoard *model.Board) (string, error) {
+	destFileID := newFileID(fileID)
+
+	fileInfo, contentPath, err := a.getFileContent(sourceBoard.ID, fileID)
+	if err != nil {
+		return "", err
+	}
+	if contentPath == "" {
+		sourceFilePath := filepath.Join(sourceBoard.TeamID, sourceBoard.ID, fileID)
+		destinationFilePath := filepath.Join(destBoard.TeamID, destBoard.ID, destFileID)
+
+		a.logger.Debug(
+			"Copying card file",
+			mlog.String("sourceFilePath", sourceFilePath),
+			mlog.String("destinationFilePath", destinationFilePath),
+		)
+
+		if err = a.filesBackend.CopyFile(sourceFilePath, destinationFilePath); err != nil {
+			return "", err
+		}
+		a.copyFileInfo(fileID, destFileID, destBoard)
+		return destFileID, nil
+	}
+
+	fileInfo.ID = destFileID
+	fileInfo.TeamID = destBoard.TeamID
+	fileInfo.BoardID = destBoard.ID
+	fileInfo.CreateAt = 0
+	fileInfo.DeleteAt = 0
+
+	if err = a.store.SaveFileInfo(fileInfo); err != nil {
+		return "", err
+	}
+
+	// the content may have been removed by the file cleanup since it was found.
+	exists, err := a.filesBackend.FileExists(contentPath)
+	if err == nil && !exists {
+		err = fmt.Errorf("the content of file %s was removed: %w", fileID, model.NewErrNotFound(fileID))
+	}
+	if err != nil {
+		if errDelete := a.store.DeleteFileInfo(destFileID); errDelete != nil {
+			a.logger.Error("Cannot delete file info", mlog.String("file_id", destFileID), mlog.Err(errDelete))
+		}
+		return "", err
+	}
+	return destFileID, nil
+}
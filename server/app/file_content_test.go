@@ -0,0 +1,56 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+)
+
+func TestCopyFile(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	source := &model.Board{ID: testBoardID, TeamID: "team-id"}
+	dest := &model.Board{ID: "other-board-id", TeamID: "team-id"}
+	contentPath := fileContentPath(testChecksum)
+	newFileInfo := func() *model.FileInfo {
+		return &model.FileInfo{ID: "7first.txt", TeamID: "team-id", BoardID: testBoardID, Checksum: testChecksum}
+	}
+
+	t.Run("the copy shares the content", func(t *testing.T) {
+		th.Store.EXPECT().GetFileInfo("7first.txt").Return(newFileInfo(), nil)
+		th.FilesBackend.On("FileExists", contentPath).Return(true, nil).Twice()
+		var saved *model.FileInfo
+		th.Store.EXPECT().SaveFileInfo(gomock.Any()).DoAndReturn(func(fileInfo *model.FileInfo) error {
+			saved = fileInfo
+			return nil
+		})
+
+		copyID, err := th.App.copyFile(source, "7first.txt", dest)
+		require.NoError(t, err)
+		require.Equal(t, copyID, saved.ID)
+		require.Equal(t, dest.ID, saved.BoardID)
+		require.Equal(t, testChecksum, saved.Checksum)
+	})
+
+	t.Run("the copy is dropped if the content was removed meanwhile", func(t *testing.T) {
+		th.Store.EXPECT().GetFileInfo("7first.txt").Return(newFileInfo(), nil)
+		th.FilesBackend.On("FileExists", contentPath).Return(true, nil).Once()
+		th.FilesBackend.On("FileExists", contentPath).Return(false, nil).Once()
+		var saved *model.FileInfo
+		th.Store.EXPECT().SaveFileInfo(gomock.Any()).DoAndReturn(func(fileInfo *model.FileInfo) error {
+			saved = fileInfo
+			return nil
+		})
+		th.Store.EXPECT().DeleteFileInfo(gomock.Any()).DoAndReturn(func(fileID string) error {
+			require.Equal(t, saved.ID, fileID)
+			return nil
+		})
+
+		_, err := th.App.copyFile(source, "7first.txt", dest)
+		require.True(t, model.IsErrNotFound(err))
+	})
+}
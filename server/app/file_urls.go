@@ -0,0 +1,119 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// Query parameters of the signed file URLs.
+const (
+	FileURLExpiresParam   = "expires"
+	FileURLSignatureParam = "signature"
+)
+
+// defaultFileURLExpireSeconds is the lifetime of the signed file URLs when the
+// FileURLExpireSeconds setting is not set.
+const defaultFileURLExpireSeconds = 60 * 60
+
+// GetSignedFileURL returns a URL to download a file, or a variant of an image, without
+// a session until it expires, and the time it expires at in milliseconds. The URL is
+// signed with the Secret setting, so that it can be checked without a database lookup.
+func (a *App) GetSignedFileURL(teamID, boardID, filename, variant string) (string, int64, error) {
+	if a.config.Secret == "" {
+		return "", 0, model.ErrFileURLSigningDisabled
+	}
+
+	expireSeconds := a.config.FileURLExpireSeconds
+	if expireSeconds <= 0 {
+		expireSeconds = defaultFileURLExpireSeconds
+	}
+	expiresAt := utils.GetMillis() + expireSeconds*1000
+	query := url.Values{}
+	query.Set(FileURLExpiresParam, strconv.FormatInt(expiresAt, 10))
+	query.Set(FileURLSignatureParam, a.signFileURL(teamID, boardID, filename, variant, expiresAt))
+	if variant != "" {
+		query.Set("size", variant)
+	}
+
+	fileURL := fmt.Sprintf("%s/api/v2/files/teams/%s/%s/%s?%s",
+		strings.TrimSuffix(a.config.ServerRoot, "/"),
+		url.PathEscape(teamID), url.PathEscape(boardID), url.PathEscape(filename), query.Encode())
+	return fileURL, expiresAt, nil
+}
+
+// CheckSignedFileURL checks the expiry time and the signature of a signed file URL,
+// and returns the time it expires at in milliseconds.
+func (a *App) CheckSignedFileURL(teamID, boardID, filename, variant, expires, signature string) (int64, error) {
+	if a.config.Secret == "" {
+		return 0, model.ErrFileURLSigningDisabled
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid expiry time %q", model.ErrInvalidFileURL, expires)
+	}
+	expected := a.signFileURL(teamID, boardID, filename, variant, expiresAt)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return 0, model.ErrInvalidFileURL
+	}
+	if expiresAt <= utils.GetMillis() {
+		return 0, model.ErrFileURLExpired
+	}
+	return expiresAt, nil
+}
+
+// signFileURL returns the HMAC-SHA256 signature of the file a URL gives access to and of
+// its expiry time.
+func (a *App) signFileURL(teamID, boardID, filename, variant string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, []byte(a.config.Secret))
+	mac.Write([]byte(strings.Join([]string{teamID, boardID, filename, variant, strconv.FormatInt(expiresAt, 10)}, "\n")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// GetPresignedFileURL returns a URL of the files storage to download a file from until a
+// time in milliseconds, or an empty string if the files storage does not issue such
+// URLs. The variants of the images are served by the server.
+func (a *App) GetPresignedFileURL(teamID, boardID, filename string, expiresAt int64) (string, error) {
+	if a.filePresigner == nil {
+		return "", nil
+	}
+
+	fileInfo, contentPath, err := a.getFileContent(boardID, filename)
+	if err != nil {
+		return "", err
+	}
+	if contentPath == "" {
+		contentPath = filepath.Join(teamID, boardID, filename)
+	}
+
+	contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename)))
+	downloadName := filename
+	if fileInfo != nil {
+		if fileInfo.MimeType != "" {
+			contentType = fileInfo.MimeType
+		}
+		if fileInfo.Name != "" {
+			downloadName = fileInfo.Name
+		}
+	}
+	disposition := "attachment"
+	if model.IsInlineMimeType(contentType) {
+		disposition = "inline"
+		downloadName = filename
+	}
+
+	expiry := time.Until(time.Unix(0, expiresAt*int64(time.Millisecond)))
+	return a.filePresigner.PresignedURL(contentPath, expiry, contentType,
+		mime.FormatMediaType(disposition, map[string]string{"filename": downloadName}))
+}
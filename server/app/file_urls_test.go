@@ -0,0 +1,158 @@
+package app
+
+import (
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+type testPresigner struct {
+	path               string
+	expiry             time.Duration
+	contentType        string
+	contentDisposition string
+}
+
+func (p *testPresigner) PresignedURL(path string, expiry time.Duration, contentType, contentDisposition string) (string, error) {
+	p.path = path
+	p.expiry = expiry
+	p.contentType = contentType
+	p.contentDisposition = contentDisposition
+	return "https://s3.example.com/bucket/" + path, nil
+}
+
+func TestSignedFileURL(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	th.App.config.ServerRoot = "https://boards.example.com/"
+
+	t.Run("signing requires a secret", func(t *testing.T) {
+		_, _, err := th.App.GetSignedFileURL("team-id", testBoardID, "file.png", "")
+		require.ErrorIs(t, err, model.ErrFileURLSigningDisabled)
+
+		_, err = th.App.CheckSignedFileURL("team-id", testBoardID, "file.png", "", "1", "signature")
+		require.ErrorIs(t, err, model.ErrFileURLSigningDisabled)
+	})
+
+	th.App.config.Secret = "test-secret"
+	th.App.config.FileURLExpireSeconds = 60
+
+	t.Run("a signed URL is valid until it expires", func(t *testing.T) {
+		fileURL, expiresAt, err := th.App.GetSignedFileURL("team-id", testBoardID, "file.png", model.FileVariantThumbnail)
+		require.NoError(t, err)
+		require.InDelta(t, utils.GetMillis()+60*1000, expiresAt, 1000)
+
+		u, err := url.Parse(fileURL)
+		require.NoError(t, err)
+		require.Equal(t, "boards.example.com", u.Host)
+		require.Equal(t, "/api/v2/files/teams/team-id/"+testBoardID+"/file.png", u.Path)
+		query := u.Query()
+		require.Equal(t, strconv.FormatInt(expiresAt, 10), query.Get(FileURLExpiresParam))
+		require.Equal(t, model.FileVariantThumbnail, query.Get("size"))
+
+		checkedExpiresAt, err := th.App.CheckSignedFileURL("team-id", testBoardID, "file.png", model.FileVariantThumbnail,
+			query.Get(FileURLExpiresParam), query.Get(FileURLSignatureParam))
+		require.NoError(t, err)
+		require.Equal(t, expiresAt, checkedExpiresAt)
+	})
+
+	t.Run("a signed URL only gives access to its file", func(t *testing.T) {
+		fileURL, _, err := th.App.GetSignedFileURL("team-id", testBoardID, "file.png", "")
+		require.NoError(t, err)
+		u, err := url.Parse(fileURL)
+		require.NoError(t, err)
+		expires := u.Query().Get(FileURLExpiresParam)
+		signature := u.Query().Get(FileURLSignatureParam)
+
+		_, err = th.App.CheckSignedFileURL("team-id", testBoardID, "other.png", "", expires, signature)
+		require.ErrorIs(t, err, model.ErrInvalidFileURL)
+		_, err = th.App.CheckSignedFileURL("team-id", "other-board", "file.png", "", expires, signature)
+		require.ErrorIs(t, err, model.ErrInvalidFileURL)
+		_, err = th.App.CheckSignedFileURL("team-id", testBoardID, "file.png", model.FileVariantPreview, expires, signature)
+		require.ErrorIs(t, err, model.ErrInvalidFileURL)
+		_, err = th.App.CheckSignedFileURL("team-id", testBoardID, "file.png", "", expires+"0", signature)
+		require.ErrorIs(t, err, model.ErrInvalidFileURL)
+		_, err = th.App.CheckSignedFileURL("team-id", testBoardID, "file.png", "", "never", signature)
+		require.ErrorIs(t, err, model.ErrInvalidFileURL)
+	})
+
+	t.Run("an expired URL is rejected", func(t *testing.T) {
+		expiresAt := utils.GetMillis() - 1000
+		signature := th.App.signFileURL("team-id", testBoardID, "file.png", "", expiresAt)
+
+		_, err := th.App.CheckSignedFileURL("team-id", testBoardID, "file.png", "", strconv.FormatInt(expiresAt, 10), signature)
+		require.ErrorIs(t, err, model.ErrFileURLExpired)
+	})
+
+	t.Run("the URLs signed with another secret are rejected", func(t *testing.T) {
+		expiresAt := utils.GetMillis() + 60*1000
+		signature := th.App.signFileURL("team-id", testBoardID, "file.png", "", expiresAt)
+		th.App.config.Secret = "other-secret"
+		defer func() { th.App.config.Secret = "test-secret" }()
+
+		_, err := th.App.CheckSignedFileURL("team-id", testBoardID, "file.png", "", strconv.FormatInt(expiresAt, 10), signature)
+		require.ErrorIs(t, err, model.ErrInvalidFileURL)
+	})
+}
+
+func TestGetPresignedFileURL(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	expiresAt := utils.GetMillis() + 60*1000
+
+	t.Run("the files are served by the server without a presigner", func(t *testing.T) {
+		presignedURL, err := th.App.GetPresignedFileURL("team-id", testBoardID, "file.png", expiresAt)
+		require.NoError(t, err)
+		require.Empty(t, presignedURL)
+	})
+
+	presigner := &testPresigner{}
+	th.App.filePresigner = presigner
+
+	t.Run("the content of a file is presigned", func(t *testing.T) {
+		fileInfo := &model.FileInfo{
+			ID:       "file.pdf",
+			BoardID:  testBoardID,
+			Name:     "report.pdf",
+			MimeType: "application/pdf",
+			Checksum: testChecksum,
+		}
+		th.Store.EXPECT().GetFileInfo("file.pdf").Return(fileInfo, nil)
+		th.FilesBackend.On("FileExists", fileContentPath(testChecksum)).Return(true, nil).Once()
+
+		presignedURL, err := th.App.GetPresignedFileURL("team-id", testBoardID, "file.pdf", expiresAt)
+		require.NoError(t, err)
+		require.Equal(t, "https://s3.example.com/bucket/"+fileContentPath(testChecksum), presignedURL)
+		require.Equal(t, "application/pdf", presigner.contentType)
+		require.Equal(t, `attachment; filename=report.pdf`, presigner.contentDisposition)
+		require.InDelta(t, time.Minute, presigner.expiry, float64(time.Second))
+	})
+
+	t.Run("the files stored under their board are presigned", func(t *testing.T) {
+		th.Store.EXPECT().GetFileInfo("file.png").Return(nil, model.NewErrNotFound("file.png"))
+
+		_, err := th.App.GetPresignedFileURL("team-id", testBoardID, "file.png", expiresAt)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join("team-id", testBoardID, "file.png"), presigner.path)
+		require.Equal(t, "image/png", presigner.contentType)
+		require.Equal(t, `inline; filename=file.png`, presigner.contentDisposition)
+	})
+
+	t.Run("svg images are presigned as attachments", func(t *testing.T) {
+		th.Store.EXPECT().GetFileInfo("file.svg").Return(nil, model.NewErrNotFound("file.svg"))
+
+		_, err := th.App.GetPresignedFileURL("team-id", testBoardID, "file.svg", expiresAt)
+		require.NoError(t, err)
+		require.Equal(t, "image/svg+xml", presigner.contentType)
+		require.Equal(t, `attachment; filename=file.svg`, presigner.contentDisposition)
+	})
+}
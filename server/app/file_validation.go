@@ -0,0 +1,175 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+const (
+	// infectedFileQuarantineDir holds the uploaded files the file scanner found a threat
+	// in, for the administrators to review. They are not swept by the file cleanup.
+	infectedFileQuarantineDir = "infected"
+
+	mimeTypeOctetStream = "application/octet-stream"
+	mimeTypeTextPlain   = "text/plain"
+)
+
+// sniffedTypeAliases are the other names the MIME types database gives to some of the
+// types the sniffer reports.
+var sniffedTypeAliases = map[string][]string{
+	"application/x-gzip":           {"application/gzip"},
+	"application/x-rar-compressed": {"application/vnd.rar", "application/x-rar"},
+	"audio/aiff":                   {"audio/x-aiff"},
+	"audio/midi":                   {"audio/x-midi"},
+	"audio/wave":                   {"audio/wav", "audio/x-wav", "audio/vnd.wave"},
+	"font/ttf":                     {"application/x-font-ttf", "font/sfnt"},
+	"image/bmp":                    {"image/x-ms-bmp"},
+	"image/x-icon":                 {"image/vnd.microsoft.icon"},
+	"video/avi":                    {"video/x-msvideo"},
+}
+
+// checkFileType returns the MIME type of an uploaded file from its name and the first
+// bytes of its content. The file is rejected if its content does not match its
+// extension, or if its type is not allowed for the team.
+func (a *App) checkFileType(teamID, filename string, head []byte) (string, error) {
+	sniffedType := baseMimeType(http.DetectContentType(head))
+	mimeType := sniffedType
+	if extensionType := baseMimeType(mime.TypeByExtension(strings.ToLower(filepath.Ext(filename)))); extensionType != "" {
+		if !isContentCompatible(extensionType, sniffedType) {
+			return "", fmt.Errorf("%w: %s has the content of a %s file", model.ErrFileTypeMismatch, filename, sniffedType)
+		}
+		mimeType = extensionType
+	}
+
+	allowedTypes, err := a.getAllowedFileTypes(teamID)
+	if err != nil {
+		return "", err
+	}
+	if len(allowedTypes) > 0 && !matchesFileType(allowedTypes, mimeType) {
+		return "", fmt.Errorf("%w: %s", model.ErrFileTypeNotAllowed, mimeType)
+	}
+	return mimeType, nil
+}
+
+// getAllowedFileTypes returns the file types allowed for a team, from the team settings
+// or the AllowedFileTypes setting. No types means that all the types are allowed.
+func (a *App) getAllowedFileTypes(teamID string) ([]string, error) {
+	team, err := a.GetTeam(teamID)
+	if err != nil {
+		return nil, err
+	}
+	if team != nil {
+		switch types := team.Settings[model.TeamSettingAllowedFileTypes].(type) {
+		case []string:
+			return types, nil
+		case []interface{}:
+			allowedTypes := make([]string, 0, len(types))
+			for _, t := range types {
+				if s, ok := t.(string); ok {
+					allowedTypes = append(allowedTypes, s)
+				}
+			}
+			return allowedTypes, nil
+		}
+	}
+	return a.config.AllowedFileTypes, nil
+}
+
+// isContentCompatible tells if the type sniffed from the content of a file can be the
+// type of its extension. The sniffer only knows a few formats and reports the others as
+// plain text or binary data, which can be anything.
+func isContentCompatible(extensionType, sniffedType string) bool {
+	if sniffedType == extensionType || sniffedType == mimeTypeOctetStream || sniffedType == mimeTypeTextPlain {
+		return true
+	}
+
+	extensionSubtype := extensionType[strings.Index(extensionType, "/")+1:]
+	switch sniffedType {
+	case "application/zip":
+		// office documents and other archive based formats.
+		return strings.HasPrefix(extensionType, "application/vnd.") ||
+			strings.HasSuffix(extensionSubtype, "zip") ||
+			extensionType == "application/java-archive"
+	case "text/xml":
+		return strings.HasSuffix(extensionSubtype, "xml")
+	}
+	for _, alias := range sniffedTypeAliases[sniffedType] {
+		if alias == extensionType {
+			return true
+		}
+	}
+
+	// like application/ogg for audio/ogg, or video/mp4 for audio/mp4.
+	return sniffedType[strings.Index(sniffedType, "/")+1:] == extensionSubtype
+}
+
+// matchesFileType tells if a MIME type matches one of the patterns, like "image/png",
+// "image/*" or "*".
+func matchesFileType(patterns []string, mimeType string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case pattern == "*" || pattern == "*/*" || pattern == mimeType:
+			return true
+		case strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(pattern, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+// baseMimeType removes the parameters from a MIME type.
+func baseMimeType(mimeType string) string {
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// scanFile passes the temporary copy of an uploaded file to the file scanner, before
+// the file is stored. The infected files are moved to the quarantine and rejected.
+func (a *App) scanFile(file *os.File, teamID, boardID, filename string) error {
+	result, err := a.fileScanner.Scan(file)
+	if err != nil {
+		return fmt.Errorf("unable to scan the file: %w", err)
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if result.Clean {
+		return nil
+	}
+
+	fileExtension := strings.ToLower(filepath.Ext(filename))
+	quarantinePath := filepath.Join(fileQuarantineDir, infectedFileQuarantineDir, teamID, boardID, utils.NewID(utils.IDTypeNone)+fileExtension)
+	if _, err = a.filesBackend.WriteFile(file, quarantinePath); err != nil {
+		a.logger.Error("Cannot quarantine infected file", mlog.String("FilePath", quarantinePath), mlog.Err(err))
+	}
+
+	a.logger.Warn("Rejected infected file",
+		mlog.String("filename", filename),
+		mlog.String("threat", result.Threat),
+		mlog.String("quarantine_path", quarantinePath),
+	)
+	return fmt.Errorf("%w: %s", model.ErrFileInfected, result.Threat)
+}
+
+// removeTempFile closes and removes a temporary file, logging the errors.
+func (a *App) removeTempFile(file *os.File) {
+	if err := file.Close(); err != nil {
+		a.logger.Error("Cannot close temporary file", mlog.String("path", file.Name()), mlog.Err(err))
+	}
+	if err := os.Remove(file.Name()); err != nil {
+		a.logger.Error("Cannot remove temporary file", mlog.String("path", file.Name()), mlog.Err(err))
+	}
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/filescan"
+
+	"github.com/mattermost/mattermost-server/v6/shared/filestore"
+)
+
+type testScanner struct {
+	threat string
+}
+
+func (s *testScanner) Scan(content io.Reader) (*filescan.Result, error) {
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Contains(data, []byte(s.threat)) {
+		return &filescan.Result{Threat: "Test-Signature"}, nil
+	}
+	return &filescan.Result{Clean: true}, nil
+}
+
+func TestIsContentCompatible(t *testing.T) {
+	testCases := []struct {
+		extensionType string
+		sniffedType   string
+		compatible    bool
+	}{
+		{"image/png", "image/png", true},
+		{"application/pdf", "text/plain", true},
+		{"application/pdf", "application/octet-stream", true},
+		{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/zip", true},
+		{"image/svg+xml", "text/xml", true},
+		{"audio/ogg", "application/ogg", true},
+		{"audio/x-wav", "audio/wave", true},
+		{"image/png", "text/html", false},
+		{"application/pdf", "image/png", false},
+		{"image/jpeg", "image/png", false},
+		{"text/plain", "application/zip", false},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.compatible, isContentCompatible(tc.extensionType, tc.sniffedType), "%s with %s content", tc.extensionType, tc.sniffedType)
+	}
+}
+
+func TestMatchesFileType(t *testing.T) {
+	require.True(t, matchesFileType([]string{"image/png"}, "image/png"))
+	require.True(t, matchesFileType([]string{"application/pdf", "image/*"}, "image/jpeg"))
+	require.True(t, matchesFileType([]string{"*"}, "text/html"))
+	require.False(t, matchesFileType([]string{"image/*"}, "application/pdf"))
+	require.False(t, matchesFileType([]string{"image/png"}, "image/jpeg"))
+}
+
+func TestCheckFileType(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	pngContent := newTestImage(1, 1)
+
+	t.Run("the type of the extension is kept", func(t *testing.T) {
+		th.Store.EXPECT().GetTeam("team-id").Return(nil, model.NewErrNotFound("team-id"))
+
+		mimeType, err := th.App.checkFileType("team-id", "photo.PNG", pngContent)
+		require.NoError(t, err)
+		require.Equal(t, "image/png", mimeType)
+	})
+
+	t.Run("the content type is used for unknown extensions", func(t *testing.T) {
+		th.Store.EXPECT().GetTeam("team-id").Return(nil, model.NewErrNotFound("team-id"))
+
+		mimeType, err := th.App.checkFileType("team-id", "photo", pngContent)
+		require.NoError(t, err)
+		require.Equal(t, "image/png", mimeType)
+	})
+
+	t.Run("a mismatch is rejected", func(t *testing.T) {
+		_, err := th.App.checkFileType("team-id", "photo.png", []byte("<html><body></body></html>"))
+		require.ErrorIs(t, err, model.ErrFileTypeMismatch)
+	})
+
+	t.Run("the team settings override the server settings", func(t *testing.T) {
+		th.App.config.AllowedFileTypes = []string{"application/pdf"}
+		defer func() { th.App.config.AllowedFileTypes = nil }()
+
+		th.Store.EXPECT().GetTeam("team-id").Return(nil, model.NewErrNotFound("team-id"))
+		_, err := th.App.checkFileType("team-id", "photo.png", pngContent)
+		require.ErrorIs(t, err, model.ErrFileTypeNotAllowed)
+
+		team := &model.Team{
+			ID:       "team-id",
+			Settings: map[string]interface{}{model.TeamSettingAllowedFileTypes: []interface{}{"image/*"}},
+		}
+		th.Store.EXPECT().GetTeam("team-id").Return(team, nil).Times(2)
+		mimeType, err := th.App.checkFileType("team-id", "photo.png", pngContent)
+		require.NoError(t, err)
+		require.Equal(t, "image/png", mimeType)
+
+		_, err = th.App.checkFileType("team-id", "report.pdf", []byte("%PDF-1.4"))
+		require.ErrorIs(t, err, model.ErrFileTypeNotAllowed)
+	})
+}
+
+func TestUploadFileScan(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	backend, err := filestore.NewFileBackend(filestore.FileBackendSettings{DriverName: "local", Directory: t.TempDir()})
+	require.NoError(t, err)
+	th.App.filesBackend = backend
+	th.App.fileScanner = &testScanner{threat: "virus"}
+	th.Store.EXPECT().GetTeam("team-id").Return(nil, model.NewErrNotFound("team-id")).AnyTimes()
+
+	t.Run("clean files are stored", func(t *testing.T) {
+		th.Store.EXPECT().SaveFileInfo(gomock.Any()).Return(nil)
+
+		fileInfo, err := th.App.UploadFile(bytes.NewBufferString("clean"), "team-id", testBoardID, "notes.txt", "user-id")
+		require.NoError(t, err)
+		require.Equal(t, int64(5), fileInfo.Size)
+
+		exists, err := backend.FileExists(fileContentPath(fileInfo.Checksum))
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
+	t.Run("infected files are quarantined", func(t *testing.T) {
+		fileInfo, err := th.App.UploadFile(bytes.NewBufferString("a virus"), "team-id", testBoardID, "notes.txt", "user-id")
+		require.ErrorIs(t, err, model.ErrFileInfected)
+		require.Nil(t, fileInfo)
+
+		quarantined, err := backend.ListDirectory(filepath.Join(fileQuarantineDir, infectedFileQuarantineDir, "team-id", testBoardID))
+		require.NoError(t, err)
+		require.Len(t, quarantined, 1)
+		shardDirs, err := backend.ListDirectory(fileContentDir)
+		require.NoError(t, err)
+		require.Len(t, shardDirs, 1)
+	})
+}
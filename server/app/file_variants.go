@@ -0,0 +1,195 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"strings"
+
+	// decoders of the uploaded images.
+	_ "image/gif"
+
+	"github.com/mattermost/focalboard/server/model"
+
+	"github.com/mattermost/mattermost-server/v6/shared/filestore"
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+// maxVariantSourcePixels is the size of the largest image variants are generated for,
+// to bound the memory used to decode it.
+const maxVariantSourcePixels = 50 * 1000 * 1000
+
+const variantJPEGQuality = 85
+
+// GetFileVariantReader returns a reader on a variant of an uploaded image, and the MIME
+// type of the variant. The variant is generated and stored the first time it is
+// requested. The original file is returned, with an empty MIME type, for the files that
+// are not images or are not larger than the variant.
+func (a *App) GetFileVariantReader(teamID, boardID, filename, variant string) (filestore.ReadCloseSeeker, string, error) {
+	width, ok := model.FileVariantWidths[variant]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s", model.ErrInvalidFileVariant, variant)
+	}
+
+	variantPath, mimeType, ok := fileVariantPath(teamID, boardID, filename, variant)
+	if !ok {
+		reader, err := a.GetFileReader(teamID, boardID, filename)
+		return reader, "", err
+	}
+
+	exists, err := a.filesBackend.FileExists(variantPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if !exists {
+		generated, genErr := a.generateFileVariant(teamID, boardID, filename, variantPath, width)
+		if genErr != nil {
+			return nil, "", genErr
+		}
+		if !generated {
+			reader, readErr := a.GetFileReader(teamID, boardID, filename)
+			return reader, "", readErr
+		}
+	}
+
+	reader, err := a.filesBackend.Reader(variantPath)
+	if err != nil {
+		return nil, "", err
+	}
+	return reader, mimeType, nil
+}
+
+// generateFileVariant resizes an image to the width of a variant and stores the result.
+// It returns false when the image is not larger than the variant or cannot be decoded.
+func (a *App) generateFileVariant(teamID, boardID, filename, variantPath string, width int) (bool, error) {
+	src, err := a.GetFileReader(teamID, boardID, filename)
+	if err != nil {
+		return false, err
+	}
+	defer src.Close()
+
+	config, format, err := image.DecodeConfig(src)
+	if err != nil {
+		a.logger.Debug("Cannot decode image for variant", mlog.String("filename", filename), mlog.Err(err))
+		return false, nil
+	}
+	if config.Width <= width {
+		return false, nil
+	}
+	if config.Width*config.Height > maxVariantSourcePixels {
+		a.logger.Warn("Image too large for variant",
+			mlog.String("filename", filename),
+			mlog.Int("width", config.Width),
+			mlog.Int("height", config.Height),
+		)
+		return false, nil
+	}
+
+	if _, err = src.Seek(0, 0); err != nil {
+		return false, err
+	}
+	img, _, err := image.Decode(src)
+	if err != nil {
+		a.logger.Debug("Cannot decode image for variant", mlog.String("filename", filename), mlog.Err(err))
+		return false, nil
+	}
+
+	resized := resizeImage(img, width)
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: variantJPEGQuality})
+	} else {
+		err = png.Encode(&buf, resized)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err = a.filesBackend.WriteFile(&buf, variantPath); err != nil {
+		return false, fmt.Errorf("unable to store the file variant in the files storage: %w", err)
+	}
+	return true, nil
+}
+
+// removeFileVariants removes the stored variants of a file, logging the errors.
+func (a *App) removeFileVariants(teamID, boardID, filename string) {
+	for variant := range model.FileVariantWidths {
+		variantPath, _, ok := fileVariantPath(teamID, boardID, filename, variant)
+		if !ok {
+			return
+		}
+		exists, err := a.filesBackend.FileExists(variantPath)
+		if err == nil && exists {
+			err = a.filesBackend.RemoveFile(variantPath)
+		}
+		if err != nil {
+			a.logger.Error("Cannot remove file variant", mlog.String("FilePath", variantPath), mlog.Err(err))
+		}
+	}
+}
+
+// fileVariantPath returns the path and the MIME type of a variant of a file, and false
+// if no variant is generated for the type of the file. The variants of JPEG images
+// are JPEG images, the variants of PNG and GIF images PNG images.
+func fileVariantPath(teamID, boardID, filename, variant string) (string, string, bool) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	variantExt, mimeType := ".png", "image/png"
+	switch ext {
+	case ".jpg", ".jpeg":
+		variantExt, mimeType = ".jpg", "image/jpeg"
+	case ".png", ".gif":
+	default:
+		return "", "", false
+	}
+	variantName := strings.TrimSuffix(filename, filepath.Ext(filename)) + variantExt
+	return filepath.Join(teamID, boardID, "variants", variant, variantName), mimeType, true
+}
+
+// resizeImage scales an image down to a width, keeping its aspect ratio. Each pixel
+// of the result is the average of the pixels of the source it covers.
+func resizeImage(src image.Image, width int) *image.RGBA {
+	bounds := src.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		sy0 := bounds.Min.Y + y*bounds.Dy()/height
+		sy1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		if sy1 <= sy0 {
+			sy1 = sy0 + 1
+		}
+		for x := 0; x < width; x++ {
+			sx0 := bounds.Min.X + x*bounds.Dx()/width
+			sx1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+			if sx1 <= sx0 {
+				sx1 = sx0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((r / n) >> 8),
+				G: uint8((g / n) >> 8),
+				B: uint8((b / n) >> 8),
+				A: uint8((a / n) >> 8),
+			})
+		}
+	}
+	return dst
+}
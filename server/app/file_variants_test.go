@@ -0,0 +1,102 @@
+package app
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+
+	"github.com/mattermost/mattermost-server/v6/shared/filestore"
+)
+
+func newTestImage(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func TestResizeImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		img.SetRGBA(x, 0, color.RGBA{R: 200, A: 255})
+		img.SetRGBA(x, 1, color.RGBA{R: 100, A: 255})
+	}
+
+	resized := resizeImage(img, 2)
+	require.Equal(t, image.Rect(0, 0, 2, 1), resized.Bounds())
+	require.Equal(t, color.RGBA{R: 150, A: 255}, resized.RGBAAt(0, 0))
+}
+
+func TestGetFileVariantReader(t *testing.T) {
+	th, _ := SetupTestHelper(t)
+	backend, err := filestore.NewFileBackend(filestore.FileBackendSettings{
+		DriverName: "local",
+		Directory:  t.TempDir(),
+	})
+	require.NoError(t, err)
+	th.App.filesBackend = backend
+	// the files are stored under the directory of their board.
+	th.Store.EXPECT().GetFileInfo(gomock.Any()).Return(nil, model.NewErrNotFound("file")).AnyTimes()
+
+	_, err = backend.WriteFile(bytes.NewReader(newTestImage(400, 300)), filepath.Join("team-id", testBoardID, "7photo.png"))
+	require.NoError(t, err)
+	_, err = backend.WriteFile(bytes.NewReader([]byte("notes")), filepath.Join("team-id", testBoardID, "7notes.txt"))
+	require.NoError(t, err)
+
+	t.Run("generates the variant", func(t *testing.T) {
+		reader, mimeType, err := th.App.GetFileVariantReader("team-id", testBoardID, "7photo.png", model.FileVariantThumbnail)
+		require.NoError(t, err)
+		defer reader.Close()
+		require.Equal(t, "image/png", mimeType)
+
+		img, err := png.Decode(reader)
+		require.NoError(t, err)
+		require.Equal(t, image.Rect(0, 0, 200, 150), img.Bounds())
+
+		exists, err := backend.FileExists(filepath.Join("team-id", testBoardID, "variants", "thumbnail", "7photo.png"))
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
+	t.Run("returns the original for smaller images and other files", func(t *testing.T) {
+		reader, mimeType, err := th.App.GetFileVariantReader("team-id", testBoardID, "7photo.png", model.FileVariantPreview)
+		require.NoError(t, err)
+		require.Empty(t, mimeType)
+		data, err := ioutil.ReadAll(reader)
+		require.NoError(t, reader.Close())
+		require.NoError(t, err)
+		require.Equal(t, newTestImage(400, 300), data)
+
+		reader, mimeType, err = th.App.GetFileVariantReader("team-id", testBoardID, "7notes.txt", model.FileVariantThumbnail)
+		require.NoError(t, err)
+		require.Empty(t, mimeType)
+		require.NoError(t, reader.Close())
+	})
+
+	t.Run("rejects unknown variants", func(t *testing.T) {
+		_, _, err := th.App.GetFileVariantReader("team-id", testBoardID, "7photo.png", "huge")
+		require.ErrorIs(t, err, model.ErrInvalidFileVariant)
+	})
+
+	t.Run("removes the variants", func(t *testing.T) {
+		th.App.removeFileVariants("team-id", testBoardID, "7photo.png")
+
+		exists, err := backend.FileExists(filepath.Join("team-id", testBoardID, "variants", "thumbnail", "7photo.png"))
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+}
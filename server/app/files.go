@@ -1,37 +1,118 @@
 package app
 
 import (
+	"bufio"
 	"fmt"
 	"io"
+	"mime"
 	"path/filepath"
 	"strings"
 
-	"github.com/mattermost/focalboard/server/utils"
+	"github.com/mattermost/focalboard/server/model"
 
 	"github.com/mattermost/mattermost-server/v6/shared/mlog"
 
 	"github.com/mattermost/mattermost-server/v6/shared/filestore"
 )
 
+// SaveFile stores a file of a board and returns its new ID. The files with the same
+// content share it in the files storage.
 func (a *App) SaveFile(reader io.Reader, teamID, rootID, filename string) (string, error) {
-	// NOTE: File extension includes the dot
-	fileExtension := strings.ToLower(filepath.Ext(filename))
-	if fileExtension == ".jpeg" {
-		fileExtension = ".jpg"
+	fileInfo := &model.FileInfo{
+		ID:       newFileID(filename),
+		TeamID:   teamID,
+		BoardID:  rootID,
+		Name:     filepath.Base(filename),
+		MimeType: mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))),
+	}
+	file, err := a.spoolFile(reader, fileInfo)
+	if err != nil {
+		return "", err
+	}
+	defer a.removeTempFile(file)
+
+	if err = a.storeFile(fileInfo, file); err != nil {
+		return "", err
+	}
+	return fileInfo.ID, nil
+}
+
+// UploadFile saves a file uploaded to a board and records its metadata: the original
+// name, the MIME type, the size and the checksum of the content. The file is rejected
+// if it would take the files of the team over the TeamFileQuota setting, if its type is
+// not allowed or does not match its content, or if the file scanner finds a threat in
+// it. The location data of the photos is removed.
+func (a *App) UploadFile(reader io.Reader, teamID, boardID, filename, userID string) (*model.FileInfo, error) {
+	quota := a.config.TeamFileQuota
+	var remaining int64
+	if quota > 0 {
+		usage, err := a.store.GetTeamFileUsage(teamID)
+		if err != nil {
+			return nil, err
+		}
+		remaining = quota - usage
+		if remaining <= 0 {
+			return nil, fmt.Errorf("%w: team %s uses %d of %d bytes", model.ErrTeamFileQuotaExceeded, teamID, usage, quota)
+		}
+		// read one byte over the quota to detect the files that exceed it.
+		reader = io.LimitReader(reader, remaining+1)
 	}
 
-	createdFilename := fmt.Sprintf(`%s%s`, utils.NewID(utils.IDTypeNone), fileExtension)
-	filePath := filepath.Join(teamID, rootID, createdFilename)
+	buffered := bufio.NewReader(reader)
+	head, _ := buffered.Peek(512)
+	mimeType, err := a.checkFileType(teamID, filename, head)
+	if err != nil {
+		return nil, err
+	}
+
+	var content io.Reader = buffered
+	if mimeType == "image/jpeg" {
+		content = stripJPEGLocation(content)
+	}
+
+	fileInfo := &model.FileInfo{
+		ID:        newFileID(filename),
+		TeamID:    teamID,
+		BoardID:   boardID,
+		Name:      filepath.Base(filename),
+		MimeType:  mimeType,
+		CreatedBy: userID,
+	}
+	file, err := a.spoolFile(content, fileInfo)
+	if err != nil {
+		return nil, err
+	}
+	defer a.removeTempFile(file)
+
+	if quota > 0 && fileInfo.Size > remaining {
+		return nil, fmt.Errorf("%w: the file does not fit in the %d bytes left to team %s", model.ErrTeamFileQuotaExceeded, remaining, teamID)
+	}
+	if a.fileScanner != nil {
+		if err = a.scanFile(file, teamID, boardID, filename); err != nil {
+			return nil, err
+		}
+	}
 
-	_, appErr := a.filesBackend.WriteFile(reader, filePath)
-	if appErr != nil {
-		return "", fmt.Errorf("unable to store the file in the files storage: %w", appErr)
+	if err = a.storeFile(fileInfo, file); err != nil {
+		return nil, err
 	}
+	return fileInfo, nil
+}
 
-	return createdFilename, nil
+// GetFileInfo returns the metadata of an uploaded file.
+func (a *App) GetFileInfo(fileID string) (*model.FileInfo, error) {
+	return a.store.GetFileInfo(fileID)
 }
 
 func (a *App) GetFileReader(teamID, rootID, filename string) (filestore.ReadCloseSeeker, error) {
+	_, contentPath, err := a.getFileContent(rootID, filename)
+	if err != nil {
+		return nil, err
+	}
+	if contentPath != "" {
+		return a.filesBackend.Reader(contentPath)
+	}
+
 	filePath := filepath.Join(teamID, rootID, filename)
 	exists, err := a.filesBackend.FileExists(filePath)
 	if err != nil {
@@ -67,3 +148,51 @@ func (a *App) GetFileReader(teamID, rootID, filename string) (filestore.ReadClos
 
 	return reader, nil
 }
+
+// copyFileInfo records the metadata of a copy of a file on another board, when the
+// metadata of the original file is known.
+func (a *App) copyFileInfo(sourceFileID, destFileID string, destBoard *model.Board) {
+	fileInfo, err := a.store.GetFileInfo(sourceFileID)
+	if err != nil {
+		if !model.IsErrNotFound(err) {
+			a.logger.Error("Cannot fetch file info", mlog.String("file_id", sourceFileID), mlog.Err(err))
+		}
+		return
+	}
+
+	fileInfo.ID = destFileID
+	fileInfo.TeamID = destBoard.TeamID
+	fileInfo.BoardID = destBoard.ID
+	fileInfo.CreateAt = 0
+	fileInfo.DeleteAt = 0
+	if err = a.store.SaveFileInfo(fileInfo); err != nil {
+		a.logger.Error("Cannot save file info", mlog.String("file_id", destFileID), mlog.Err(err))
+	}
+}
+
+// prepareAttachments checks the attachment blocks among blocks and copies the metadata
+// of their file into their fields. The fields sent by the client are kept for the files
+// whose metadata is unknown, like the files of imported boards.
+func (a *App) prepareAttachments(blocks []*model.Block) error {
+	for _, block := range blocks {
+		if block.Type != model.TypeAttachment {
+			continue
+		}
+		if err := model.ValidateAttachmentBlock(block); err != nil {
+			return err
+		}
+
+		fileID, _ := model.BlockFileID(block)
+		fileInfo, err := a.store.GetFileInfo(fileID)
+		if model.IsErrNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for k, v := range fileInfo.AttachmentFields() {
+			block.Fields[k] = v
+		}
+	}
+	return nil
+}
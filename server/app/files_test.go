@@ -1,14 +1,15 @@
 package app
 
 import (
-	"io"
-	"os"
+	"bytes"
 	"path/filepath"
-	"strings"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/mattermost/focalboard/server/model"
+
 	"github.com/mattermost/mattermost-server/v6/plugin/plugintest/mock"
 	"github.com/mattermost/mattermost-server/v6/shared/filestore"
 	"github.com/mattermost/mattermost-server/v6/shared/filestore/mocks"
@@ -17,6 +18,7 @@ import (
 const (
 	testFileName = "temp-file-name"
 	testBoardID  = "test-board-id"
+	testChecksum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
 )
 
 type TestError struct{}
@@ -28,6 +30,8 @@ func TestGetFileReader(t *testing.T) {
 
 	th, _ := SetupTestHelper(t)
 	mockedReadCloseSeek := &mocks.ReadCloseSeeker{}
+	th.Store.EXPECT().GetFileInfo(testFileName).Return(nil, model.NewErrNotFound(testFileName)).AnyTimes()
+
 	t.Run("should get file reader from filestore successfully", func(t *testing.T) {
 		mockedFileBackend := &mocks.FileBackend{}
 		th.App.filesBackend = mockedFileBackend
@@ -177,77 +181,90 @@ func TestGetFileReader(t *testing.T) {
 		actual, _ := th.App.GetFileReader(workspaceid, testBoardID, testFileName)
 		assert.Equal(t, mockedReadCloseSeek, actual)
 	})
+
+	t.Run("should read the content shared by the files of the board", func(t *testing.T) {
+		mockedFileBackend := &mocks.FileBackend{}
+		th.App.filesBackend = mockedFileBackend
+		contentPath := fileContentPath(testChecksum)
+
+		th.Store.EXPECT().GetFileInfo("7shared.png").Return(&model.FileInfo{ID: "7shared.png", BoardID: testBoardID, Checksum: testChecksum}, nil).Times(2)
+		mockedFileBackend.On("FileExists", contentPath).Return(true, nil)
+		mockedFileBackend.On("Reader", contentPath).Return(mockedReadCloseSeek, nil)
+
+		actual, err := th.App.GetFileReader("1", testBoardID, "7shared.png")
+		assert.NoError(t, err)
+		assert.Equal(t, mockedReadCloseSeek, actual)
+
+		// the content is only shared with the board of the file.
+		otherBoardPath := filepath.Join("1", "other-board-id", "7shared.png")
+		mockedFileBackend.On("FileExists", otherBoardPath).Return(false, nil)
+		mockedFileBackend.On("Reader", otherBoardPath).Return(nil, &TestError{})
+		_, err = th.App.GetFileReader("1", "other-board-id", "7shared.png")
+		assert.Error(t, err)
+	})
 }
 
 func TestSaveFile(t *testing.T) {
 	th, _ := SetupTestHelper(t)
-	mockedReadCloseSeek := &mocks.ReadCloseSeeker{}
-	t.Run("should save file to file store using file backend", func(t *testing.T) {
-		fileName := "temp-file-name.txt"
+	contentPath := fileContentPath(testChecksum)
+
+	t.Run("should save the content of the file by its checksum", func(t *testing.T) {
 		mockedFileBackend := &mocks.FileBackend{}
 		th.App.filesBackend = mockedFileBackend
 
-		writeFileFunc := func(reader io.Reader, path string) int64 {
-			paths := strings.Split(path, string(os.PathSeparator))
-			assert.Equal(t, "1", paths[0])
-			assert.Equal(t, testBoardID, paths[1])
-			fileName = paths[2]
-			return int64(10)
-		}
-
-		writeFileErrorFunc := func(reader io.Reader, filePath string) error {
+		var saved *model.FileInfo
+		th.Store.EXPECT().SaveFileInfo(gomock.Any()).DoAndReturn(func(fileInfo *model.FileInfo) error {
+			saved = fileInfo
 			return nil
-		}
+		})
+		mockedFileBackend.On("FileExists", contentPath).Return(false, nil)
+		mockedFileBackend.On("WriteFile", mock.Anything, contentPath).Return(int64(4), nil)
 
-		mockedFileBackend.On("WriteFile", mockedReadCloseSeek, mock.Anything).Return(writeFileFunc, writeFileErrorFunc)
-		actual, err := th.App.SaveFile(mockedReadCloseSeek, "1", testBoardID, fileName)
-		assert.Equal(t, fileName, actual)
+		actual, err := th.App.SaveFile(bytes.NewBufferString("test"), "1", testBoardID, "temp-file-name.txt")
 		assert.Nil(t, err)
+		assert.Equal(t, saved.ID, actual)
+		assert.Equal(t, ".txt", filepath.Ext(actual))
+		assert.Equal(t, testBoardID, saved.BoardID)
+		assert.Equal(t, int64(4), saved.Size)
+		assert.Equal(t, testChecksum, saved.Checksum)
+		mockedFileBackend.AssertCalled(t, "WriteFile", mock.Anything, contentPath)
 	})
 
-	t.Run("should save .jpeg file as jpg file to file store using file backend", func(t *testing.T) {
-		fileName := "temp-file-name.jpeg"
+	t.Run("should save .jpeg file as jpg file", func(t *testing.T) {
 		mockedFileBackend := &mocks.FileBackend{}
 		th.App.filesBackend = mockedFileBackend
 
-		writeFileFunc := func(reader io.Reader, path string) int64 {
-			paths := strings.Split(path, string(os.PathSeparator))
-			assert.Equal(t, "1", paths[0])
-			assert.Equal(t, "test-board-id", paths[1])
-			assert.Equal(t, "jpg", strings.Split(paths[2], ".")[1])
-			return int64(10)
-		}
+		th.Store.EXPECT().SaveFileInfo(gomock.Any()).Return(nil)
+		mockedFileBackend.On("FileExists", contentPath).Return(true, nil)
 
-		writeFileErrorFunc := func(reader io.Reader, filePath string) error {
-			return nil
-		}
+		actual, err := th.App.SaveFile(bytes.NewBufferString("test"), "1", testBoardID, "temp-file-name.jpeg")
+		assert.Nil(t, err)
+		assert.Equal(t, ".jpg", filepath.Ext(actual))
+	})
+
+	t.Run("should not store a content again", func(t *testing.T) {
+		mockedFileBackend := &mocks.FileBackend{}
+		th.App.filesBackend = mockedFileBackend
 
-		mockedFileBackend.On("WriteFile", mockedReadCloseSeek, mock.Anything).Return(writeFileFunc, writeFileErrorFunc)
-		actual, err := th.App.SaveFile(mockedReadCloseSeek, "1", "test-board-id", fileName)
+		th.Store.EXPECT().SaveFileInfo(gomock.Any()).Return(nil)
+		mockedFileBackend.On("FileExists", contentPath).Return(true, nil)
+
+		_, err := th.App.SaveFile(bytes.NewBufferString("test"), "1", testBoardID, "temp-file-name.txt")
 		assert.Nil(t, err)
-		assert.NotNil(t, actual)
+		mockedFileBackend.AssertNotCalled(t, "WriteFile", mock.Anything, mock.Anything)
 	})
 
 	t.Run("should return error when fileBackend.WriteFile returns error", func(t *testing.T) {
-		fileName := "temp-file-name.jpeg"
 		mockedFileBackend := &mocks.FileBackend{}
 		th.App.filesBackend = mockedFileBackend
 		mockedError := &TestError{}
 
-		writeFileFunc := func(reader io.Reader, path string) int64 {
-			paths := strings.Split(path, string(os.PathSeparator))
-			assert.Equal(t, "1", paths[0])
-			assert.Equal(t, "test-board-id", paths[1])
-			assert.Equal(t, "jpg", strings.Split(paths[2], ".")[1])
-			return int64(10)
-		}
-
-		writeFileErrorFunc := func(reader io.Reader, filePath string) error {
-			return mockedError
-		}
+		th.Store.EXPECT().SaveFileInfo(gomock.Any()).Return(nil)
+		th.Store.EXPECT().DeleteFileInfo(gomock.Any()).Return(nil)
+		mockedFileBackend.On("FileExists", contentPath).Return(false, nil)
+		mockedFileBackend.On("WriteFile", mock.Anything, contentPath).Return(int64(0), mockedError)
 
-		mockedFileBackend.On("WriteFile", mockedReadCloseSeek, mock.Anything).Return(writeFileFunc, writeFileErrorFunc)
-		actual, err := th.App.SaveFile(mockedReadCloseSeek, "1", "test-board-id", fileName)
+		actual, err := th.App.SaveFile(bytes.NewBufferString("test"), "1", testBoardID, "temp-file-name.jpeg")
 		assert.Equal(t, "", actual)
 		assert.Equal(t, "unable to store the file in the files storage: Mocked File backend error", err.Error())
 	})
@@ -10,6 +10,7 @@ import (
 	"github.com/mattermost/focalboard/server/auth"
 	"github.com/mattermost/focalboard/server/services/config"
 	"github.com/mattermost/focalboard/server/services/metrics"
+	"github.com/mattermost/focalboard/server/services/permissions/localpermissions"
 	"github.com/mattermost/focalboard/server/services/store/mockstore"
 	"github.com/mattermost/focalboard/server/services/webhook"
 	"github.com/mattermost/focalboard/server/ws"
@@ -31,10 +32,11 @@ func SetupTestHelper(t *testing.T) (*TestHelper, func()) {
 	cfg := config.Configuration{}
 	store := mockstore.NewMockStore(ctrl)
 	filesBackend := &mocks.FileBackend{}
-	auth := auth.New(&cfg, store, nil)
 	logger := mlog.CreateConsoleTestLogger(false, mlog.LvlDebug)
+	permissions := localpermissions.New(store, logger)
+	auth := auth.New(&cfg, store, permissions)
 	sessionToken := "TESTTOKEN"
-	wsserver := ws.NewServer(auth, sessionToken, false, logger, store)
+	wsserver := ws.NewServer(auth, permissions, sessionToken, false, logger, store)
 	webhook := webhook.NewClient(&cfg, logger)
 	metricsService := metrics.NewMetrics(metrics.InstanceInfo{})
 
@@ -45,6 +47,7 @@ func SetupTestHelper(t *testing.T) (*TestHelper, func()) {
 		Webhook:          webhook,
 		Metrics:          metricsService,
 		Logger:           logger,
+		Permissions:      permissions,
 		SkipTemplateInit: true,
 	}
 	app2 := New(&cfg, wsserver, appServices)
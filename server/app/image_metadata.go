@@ -0,0 +1,150 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	jpegMarkerSOI  = 0xD8
+	jpegMarkerSOS  = 0xDA
+	jpegMarkerAPP1 = 0xE1
+
+	// maxJPEGHeaderSize bounds the segments read before the image data, the files with
+	// larger headers are stored unchanged.
+	maxJPEGHeaderSize = 1024 * 1024
+
+	exifTagGPSInfo = 0x8825
+	exifHeader     = "Exif\x00\x00"
+)
+
+// exifTypeSizes are the sizes in bytes of the values of the TIFF field types.
+var exifTypeSizes = map[uint16]int64{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8,
+}
+
+// stripJPEGLocation returns the content of a JPEG image without the GPS data of its
+// EXIF metadata, which gives away where the photo was taken. The GPS fields are erased
+// in place, so that the offsets of the other metadata stay valid. The content is
+// returned unchanged if it is not a JPEG image or its metadata cannot be parsed.
+func stripJPEGLocation(r io.Reader) io.Reader {
+	var header bytes.Buffer
+	tee := io.TeeReader(r, &header)
+	passThrough := func() io.Reader {
+		return io.MultiReader(bytes.NewReader(header.Bytes()), r)
+	}
+
+	marker := make([]byte, 4)
+	if _, err := io.ReadFull(tee, marker[:2]); err != nil || marker[0] != 0xFF || marker[1] != jpegMarkerSOI {
+		return passThrough()
+	}
+
+	var exifSegments [][2]int
+	for {
+		if header.Len() > maxJPEGHeaderSize {
+			return passThrough()
+		}
+		if _, err := io.ReadFull(tee, marker); err != nil || marker[0] != 0xFF {
+			return passThrough()
+		}
+		if marker[1] == jpegMarkerSOS {
+			break
+		}
+
+		length := int64(binary.BigEndian.Uint16(marker[2:]))
+		if length < 2 {
+			return passThrough()
+		}
+		start := header.Len()
+		if _, err := io.CopyN(ioutil.Discard, tee, length-2); err != nil {
+			return passThrough()
+		}
+		if marker[1] == jpegMarkerAPP1 {
+			exifSegments = append(exifSegments, [2]int{start, header.Len()})
+		}
+	}
+
+	data := header.Bytes()
+	for _, segment := range exifSegments {
+		payload := data[segment[0]:segment[1]]
+		if bytes.HasPrefix(payload, []byte(exifHeader)) {
+			removeExifGPS(payload[len(exifHeader):])
+		}
+	}
+	return io.MultiReader(bytes.NewReader(data), r)
+}
+
+// removeExifGPS erases the GPS fields of the TIFF structure of EXIF metadata, and leaves
+// an empty GPS directory.
+func removeExifGPS(tiff []byte) {
+	if len(tiff) < 8 {
+		return
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return
+	}
+
+	ifd0 := int64(order.Uint32(tiff[4:8]))
+	entries, ok := exifDirectoryEntries(tiff, order, ifd0)
+	if !ok {
+		return
+	}
+	for _, entry := range entries {
+		if order.Uint16(entry) == exifTagGPSInfo {
+			clearExifDirectory(tiff, order, int64(order.Uint32(entry[8:12])))
+		}
+	}
+}
+
+// clearExifDirectory zeroes the entries of a directory, with their values stored out of
+// the entries, and sets its number of entries to zero.
+func clearExifDirectory(tiff []byte, order binary.ByteOrder, offset int64) {
+	entries, ok := exifDirectoryEntries(tiff, order, offset)
+	if !ok {
+		return
+	}
+	for _, entry := range entries {
+		typeSize, known := exifTypeSizes[order.Uint16(entry[2:4])]
+		size := typeSize * int64(order.Uint32(entry[4:8]))
+		if known && size > 4 {
+			valueOffset := int64(order.Uint32(entry[8:12]))
+			if valueOffset+size <= int64(len(tiff)) {
+				zero(tiff[valueOffset : valueOffset+size])
+			}
+		}
+		zero(entry)
+	}
+	order.PutUint16(tiff[offset:], 0)
+}
+
+// exifDirectoryEntries returns the 12 bytes entries of the directory at an offset of a
+// TIFF structure.
+func exifDirectoryEntries(tiff []byte, order binary.ByteOrder, offset int64) ([][]byte, bool) {
+	if offset < 8 || offset+2 > int64(len(tiff)) {
+		return nil, false
+	}
+	count := int64(order.Uint16(tiff[offset:]))
+	if offset+2+count*12 > int64(len(tiff)) {
+		return nil, false
+	}
+	entries := make([][]byte, count)
+	for i := range entries {
+		start := offset + 2 + int64(i)*12
+		entries[i] = tiff[start : start+12]
+	}
+	return entries, true
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestExif returns the TIFF structure of EXIF metadata with the make of the camera and
+// the latitude where the photo was taken.
+func newTestExif() []byte {
+	order := binary.LittleEndian
+	tiff := make([]byte, 98)
+	copy(tiff, "II")
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+
+	// IFD0, with the make stored at 38 and the GPS directory at 44.
+	order.PutUint16(tiff[8:], 2)
+	putExifEntry(tiff[10:], 0x010F, 2, 6, 38)
+	putExifEntry(tiff[22:], exifTagGPSInfo, 4, 1, 44)
+	copy(tiff[38:], "Phone\x00")
+
+	// GPS directory, with the latitude stored at 74.
+	order.PutUint16(tiff[44:], 2)
+	putExifEntry(tiff[46:], 0x0001, 2, 2, uint32('N'))
+	putExifEntry(tiff[58:], 0x0002, 5, 3, 74)
+	for i := 0; i < 6; i++ {
+		order.PutUint32(tiff[74+i*4:], 0x11223344)
+	}
+	return tiff
+}
+
+func putExifEntry(entry []byte, tag, fieldType uint16, count, value uint32) {
+	binary.LittleEndian.PutUint16(entry, tag)
+	binary.LittleEndian.PutUint16(entry[2:], fieldType)
+	binary.LittleEndian.PutUint32(entry[4:], count)
+	binary.LittleEndian.PutUint32(entry[8:], value)
+}
+
+func newTestJPEG(t *testing.T, exif []byte) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil))
+	encoded := buf.Bytes()
+
+	segment := []byte{0xFF, jpegMarkerAPP1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+len(exifHeader)+len(exif)))
+	segment = append(segment, exifHeader...)
+	segment = append(segment, exif...)
+
+	// the APP1 segment follows the SOI marker.
+	data := append([]byte{}, encoded[:2]...)
+	data = append(data, segment...)
+	return append(data, encoded[2:]...)
+}
+
+func TestStripJPEGLocation(t *testing.T) {
+	t.Run("removes the GPS data", func(t *testing.T) {
+		original := newTestJPEG(t, newTestExif())
+
+		stripped, err := ioutil.ReadAll(stripJPEGLocation(bytes.NewReader(original)))
+		require.NoError(t, err)
+		require.Len(t, stripped, len(original))
+		require.False(t, bytes.Contains(stripped, []byte{0x44, 0x33, 0x22, 0x11}))
+		require.True(t, bytes.Contains(stripped, []byte("Phone\x00")))
+
+		// the TIFF structure follows the SOI marker, the APP1 marker and length and the EXIF header.
+		tiff := stripped[6+len(exifHeader):]
+		require.Zero(t, binary.LittleEndian.Uint16(tiff[44:]))
+
+		_, err = jpeg.Decode(bytes.NewReader(stripped))
+		require.NoError(t, err)
+	})
+
+	t.Run("keeps the other content unchanged", func(t *testing.T) {
+		for _, content := range [][]byte{
+			[]byte("not an image"),
+			newTestJPEG(t, []byte("MM\x00")),
+			newTestImage(4, 4),
+		} {
+			stripped, err := ioutil.ReadAll(stripJPEGLocation(bytes.NewReader(content)))
+			require.NoError(t, err)
+			require.Equal(t, content, stripped)
+		}
+	})
+}
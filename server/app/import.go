@@ -1,18 +1,22 @@
 package app
 
 import (
+	"archive/zip"
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
+	"os"
 	"path"
 	"path/filepath"
 	"strings"
 
-	"github.com/krolaw/zipstream"
-
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/utils"
 
@@ -20,12 +24,19 @@ import (
 )
 
 const (
-	archiveVersion  = 2
+	archiveVersion  = 3
 	legacyFileBegin = "{\"version\":1"
+
+	// minArchiveVersion is the oldest zip archive version that can be imported.
+	minArchiveVersion = 2
+
+	// archiveManifestVersion is the first archive version containing a manifest.
+	archiveManifestVersion = 3
 )
 
 var (
-	errBlockIsNotABoard = errors.New("block is not a board")
+	errBlockIsNotABoard      = errors.New("block is not a board")
+	errMissingArchiveVersion = errors.New("archive is missing version.json")
 )
 
 // ImportArchive imports an archive containing zero or more boards, plus all
@@ -33,65 +44,109 @@ var (
 //
 // Archives are ZIP files containing a `version.json` file and zero or more
 // directories, each containing a `board.jsonl` and zero or more image files.
-func (a *App) ImportArchive(r io.Reader, opt model.ImportArchiveOptions) error {
+// Starting with version 3, archives also contain a `manifest.json` file listing
+// the hash of every other file; these are verified before anything is imported.
+//
+// The result maps the ids of the boards in the archive to the ids of the new boards.
+func (a *App) ImportArchive(r io.Reader, opt model.ImportArchiveOptions) (map[string]string, error) {
 	// peek at the first bytes to see if this is a legacy archive format
 	br := bufio.NewReader(r)
 	peek, err := br.Peek(len(legacyFileBegin))
 	if err == nil && string(peek) == legacyFileBegin {
 		a.logger.Debug("importing legacy archive")
-		_, errImport := a.ImportBoardJSONL(br, opt)
-		return errImport
+		oldID, newID, errImport := a.importBoardJSONL(br, opt, nil)
+		if errImport != nil {
+			return nil, errImport
+		}
+		return map[string]string{oldID: newID}, nil
 	}
 
 	a.logger.Debug("importing archive")
-	zr := zipstream.NewReader(br)
 
-	boardMap := make(map[string]string) // maps old board ids to new
+	// the manifest is the last file in the zip, so the archive is spooled to
+	// disk to allow verifying it before importing.
+	tmp, err := ioutil.TempFile("", "focalboard-import-*.boardarchive")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create temporary file for archive: %w", err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
 
-	for {
-		hdr, err := zr.Next()
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				a.logger.Debug("import archive - done", mlog.Int("boards_imported", len(boardMap)))
-				return nil
-			}
-			return err
+	size, err := io.Copy(tmp, br)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read archive: %w", err)
+	}
+
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.verifyArchive(zr); err != nil {
+		return nil, err
+	}
+
+	dirMap := make(map[string]string)             // maps archive directories to new board ids
+	boardMap := make(map[string]string)           // maps old board ids to new
+	fileMap := make(map[string]map[string]string) // maps the file names of each directory to new file ids
+
+	// the files get new ids, so that importing an archive again shares their content.
+	for _, zf := range zr.File {
+		dir, filename := filepath.Split(zf.Name)
+		switch filename {
+		case archiveVersionFilename, archiveManifestFilename, archiveBoardFilename, "":
+			continue
+		}
+		dir = path.Clean(dir)
+		if fileMap[dir] == nil {
+			fileMap[dir] = make(map[string]string)
 		}
+		fileMap[dir][filename] = newFileID(filename)
+	}
 
-		dir, filename := filepath.Split(hdr.Name)
+	// import boards first so the files can be recorded under the new board ids.
+	for _, zf := range zr.File {
+		dir, filename := filepath.Split(zf.Name)
+		if filename != archiveBoardFilename {
+			continue
+		}
+		dir = path.Clean(dir)
+
+		oldID, newID, errImport := a.importArchiveBoard(zf, opt, fileMap[dir])
+		if errImport != nil {
+			return nil, fmt.Errorf("cannot import board %s: %w", dir, errImport)
+		}
+		dirMap[dir] = newID
+		boardMap[oldID] = newID
+
+		a.logger.Trace("import archive file",
+			mlog.String("dir", dir),
+			mlog.String("filename", filename),
+		)
+	}
+
+	for _, zf := range zr.File {
+		dir, filename := filepath.Split(zf.Name)
 		dir = path.Clean(dir)
 
 		switch filename {
-		case "version.json":
-			ver, errVer := parseVersionFile(zr)
-			if errVer != nil {
-				return errVer
-			}
-			if ver != archiveVersion {
-				return model.NewErrUnsupportedArchiveVersion(ver, archiveVersion)
-			}
-		case "board.jsonl":
-			boardID, err := a.ImportBoardJSONL(zr, opt)
-			if err != nil {
-				return fmt.Errorf("cannot import board %s: %w", dir, err)
-			}
-			boardMap[dir] = boardID
-		default:
-			// import file/image;  dir is the old board id
-			boardID, ok := boardMap[dir]
-			if !ok {
-				a.logger.Warn("skipping orphan image in archive",
-					mlog.String("dir", dir),
-					mlog.String("filename", filename),
-				)
-				continue
-			}
-			// save file with original filename so it matches name in image block.
-			filePath := filepath.Join(opt.TeamID, boardID, filename)
-			_, err := a.filesBackend.WriteFile(zr, filePath)
-			if err != nil {
-				return fmt.Errorf("cannot import file %s for board %s: %w", filename, dir, err)
-			}
+		case archiveVersionFilename, archiveManifestFilename, archiveBoardFilename, "":
+			continue
+		}
+
+		// import file/image;  dir is the old board id
+		boardID, ok := dirMap[dir]
+		if !ok {
+			a.logger.Warn("skipping orphan image in archive",
+				mlog.String("dir", dir),
+				mlog.String("filename", filename),
+			)
+			continue
+		}
+		if err = a.importArchiveFile(zf, opt, boardID, fileMap[dir][filename]); err != nil {
+			return nil, fmt.Errorf("cannot import file %s for board %s: %w", filename, dir, err)
 		}
 
 		a.logger.Trace("import archive file",
@@ -99,11 +154,159 @@ func (a *App) ImportArchive(r io.Reader, opt model.ImportArchiveOptions) error {
 			mlog.String("filename", filename),
 		)
 	}
+
+	a.logger.Debug("import archive - done", mlog.Int("boards_imported", len(boardMap)))
+	return boardMap, nil
+}
+
+// verifyArchive checks the archive version and, for archives that include a
+// manifest, that every file matches the size and hash listed in it.
+func (a *App) verifyArchive(zr *zip.Reader) error {
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, zf := range zr.File {
+		files[zf.Name] = zf
+	}
+
+	versionFile, ok := files[archiveVersionFilename]
+	if !ok {
+		return errMissingArchiveVersion
+	}
+	ver, err := parseArchiveVersionFile(versionFile)
+	if err != nil {
+		return err
+	}
+	if ver < minArchiveVersion || ver > archiveVersion {
+		return model.NewErrUnsupportedArchiveVersion(ver, archiveVersion)
+	}
+	if ver < archiveManifestVersion {
+		return nil
+	}
+
+	manifestFile, ok := files[archiveManifestFilename]
+	if !ok {
+		return model.ErrMissingArchiveManifest
+	}
+	manifest, err := parseArchiveManifestFile(manifestFile)
+	if err != nil {
+		return err
+	}
+
+	listed := make(map[string]bool, len(manifest.Files))
+	for _, mf := range manifest.Files {
+		zf, ok := files[mf.Path]
+		if !ok {
+			return model.NewErrArchiveChecksumMismatch(mf.Path)
+		}
+		sum, size, errHash := hashArchiveFile(zf)
+		if errHash != nil {
+			return errHash
+		}
+		if size != mf.Size || sum != mf.SHA256 {
+			return model.NewErrArchiveChecksumMismatch(mf.Path)
+		}
+		listed[mf.Path] = true
+	}
+
+	// every file must be covered by the manifest.
+	for name := range files {
+		if name != archiveManifestFilename && !listed[name] && !strings.HasSuffix(name, "/") {
+			return model.NewErrArchiveChecksumMismatch(name)
+		}
+	}
+
+	a.logger.Debug("verified archive manifest",
+		mlog.Int("boards", len(manifest.Boards)),
+		mlog.Int("files", len(manifest.Files)),
+		mlog.String("exporter_version", manifest.Exporter.Version),
+	)
+	return nil
+}
+
+func parseArchiveVersionFile(zf *zip.File) (int, error) {
+	r, err := zf.Open()
+	if err != nil {
+		return 0, fmt.Errorf("cannot open %s: %w", zf.Name, err)
+	}
+	defer r.Close()
+	return parseVersionFile(r)
+}
+
+func parseArchiveManifestFile(zf *zip.File) (*model.ArchiveManifest, error) {
+	r, err := zf.Open()
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s: %w", zf.Name, err)
+	}
+	defer r.Close()
+
+	var manifest model.ArchiveManifest
+	if err = json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", zf.Name, err)
+	}
+	return &manifest, nil
+}
+
+// hashArchiveFile returns the hex encoded SHA-256 hash and the size of a file
+// within an archive.
+func hashArchiveFile(zf *zip.File) (string, int64, error) {
+	r, err := zf.Open()
+	if err != nil {
+		return "", 0, fmt.Errorf("cannot open %s: %w", zf.Name, err)
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, r)
+	if err != nil {
+		return "", 0, fmt.Errorf("cannot read %s: %w", zf.Name, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+func (a *App) importArchiveBoard(zf *zip.File, opt model.ImportArchiveOptions, fileMap map[string]string) (string, string, error) {
+	r, err := zf.Open()
+	if err != nil {
+		return "", "", err
+	}
+	defer r.Close()
+	return a.importBoardJSONL(r, opt, fileMap)
+}
+
+// importArchiveFile stores a file of an imported board, under its new id.
+func (a *App) importArchiveFile(zf *zip.File, opt model.ImportArchiveOptions, boardID, fileID string) error {
+	r, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	filename := filepath.Base(zf.Name)
+	fileInfo := &model.FileInfo{
+		ID:        fileID,
+		TeamID:    opt.TeamID,
+		BoardID:   boardID,
+		Name:      filename,
+		MimeType:  mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))),
+		CreatedBy: opt.ModifiedBy,
+	}
+	file, err := a.spoolFile(r, fileInfo)
+	if err != nil {
+		return err
+	}
+	defer a.removeTempFile(file)
+	return a.storeFile(fileInfo, file)
 }
 
 // ImportBoardJSONL imports a JSONL file containing blocks for one board. The resulting
 // board id is returned.
 func (a *App) ImportBoardJSONL(r io.Reader, opt model.ImportArchiveOptions) (string, error) {
+	_, boardID, err := a.importBoardJSONL(r, opt, nil)
+	return boardID, err
+}
+
+// importBoardJSONL imports a JSONL file containing blocks for one board and
+// returns the board id found in the file along with the id of the new board.
+// The files referenced by the blocks are renamed following fileMap.
+func (a *App) importBoardJSONL(r io.Reader, opt model.ImportArchiveOptions, fileMap map[string]string) (string, string, error) {
 	// TODO: Stream this once `model.GenerateBlockIDs` can take a stream of blocks.
 	//       We don't want to load the whole file in memory, even though it's a single board.
 	boardsAndBlocks := &model.BoardsAndBlocks{
@@ -118,6 +321,7 @@ func (a *App) ImportBoardJSONL(r io.Reader, opt model.ImportArchiveOptions) (str
 	}
 	now := utils.GetMillis()
 	var boardID string
+	membership := &archiveMembership{}
 
 	lineNum := 1
 	firstLine := true
@@ -135,7 +339,7 @@ func (a *App) ImportBoardJSONL(r io.Reader, opt model.ImportArchiveOptions) (str
 			if !skip {
 				var archiveLine model.ArchiveLine
 				if err := json.Unmarshal(line, &archiveLine); err != nil {
-					return "", fmt.Errorf("error parsing archive line %d: %w", lineNum, err)
+					return "", "", fmt.Errorf("error parsing archive line %d: %w", lineNum, err)
 				}
 
 				// first line must be a board
@@ -147,7 +351,7 @@ func (a *App) ImportBoardJSONL(r io.Reader, opt model.ImportArchiveOptions) (str
 				case "board":
 					var board model.Board
 					if err2 := json.Unmarshal(archiveLine.Data, &board); err2 != nil {
-						return "", fmt.Errorf("invalid board in archive line %d: %w", lineNum, err2)
+						return "", "", fmt.Errorf("invalid board in archive line %d: %w", lineNum, err2)
 					}
 					board.ModifiedBy = userID
 					board.UpdateAt = now
@@ -158,27 +362,48 @@ func (a *App) ImportBoardJSONL(r io.Reader, opt model.ImportArchiveOptions) (str
 					// legacy archives encoded boards as blocks; we need to convert them to real boards.
 					var block model.Block
 					if err2 := json.Unmarshal(archiveLine.Data, &block); err2 != nil {
-						return "", fmt.Errorf("invalid board block in archive line %d: %w", lineNum, err2)
+						return "", "", fmt.Errorf("invalid board block in archive line %d: %w", lineNum, err2)
 					}
 					block.ModifiedBy = userID
 					block.UpdateAt = now
 					board, err := a.blockToBoard(&block, opt)
 					if err != nil {
-						return "", fmt.Errorf("cannot convert archive line %d to block: %w", lineNum, err)
+						return "", "", fmt.Errorf("cannot convert archive line %d to block: %w", lineNum, err)
 					}
 					boardsAndBlocks.Boards = append(boardsAndBlocks.Boards, board)
 					boardID = board.ID
 				case "block":
 					var block model.Block
 					if err2 := json.Unmarshal(archiveLine.Data, &block); err2 != nil {
-						return "", fmt.Errorf("invalid block in archive line %d: %w", lineNum, err2)
+						return "", "", fmt.Errorf("invalid block in archive line %d: %w", lineNum, err2)
 					}
 					block.ModifiedBy = userID
 					block.UpdateAt = now
 					block.BoardID = boardID
+					if fileID, ok := model.BlockFileID(&block); ok && fileMap[fileID] != "" {
+						block.Fields[model.AttachmentFileID] = fileMap[fileID]
+					}
 					boardsAndBlocks.Blocks = append(boardsAndBlocks.Blocks, block)
+				case "member":
+					var member model.ArchiveMember
+					if err2 := json.Unmarshal(archiveLine.Data, &member); err2 != nil {
+						return "", "", fmt.Errorf("invalid member in archive line %d: %w", lineNum, err2)
+					}
+					membership.members = append(membership.members, member)
+				case "category":
+					var category model.ArchiveCategory
+					if err2 := json.Unmarshal(archiveLine.Data, &category); err2 != nil {
+						return "", "", fmt.Errorf("invalid category in archive line %d: %w", lineNum, err2)
+					}
+					membership.categories = append(membership.categories, category)
+				case "subscription":
+					var sub model.ArchiveSubscription
+					if err2 := json.Unmarshal(archiveLine.Data, &sub); err2 != nil {
+						return "", "", fmt.Errorf("invalid subscription in archive line %d: %w", lineNum, err2)
+					}
+					membership.subscriptions = append(membership.subscriptions, sub)
 				default:
-					return "", model.NewErrUnsupportedArchiveLineType(lineNum, archiveLine.Type)
+					return "", "", model.NewErrUnsupportedArchiveLineType(lineNum, archiveLine.Type)
 				}
 				firstLine = false
 			}
@@ -188,22 +413,32 @@ func (a *App) ImportBoardJSONL(r io.Reader, opt model.ImportArchiveOptions) (str
 			if errors.Is(errRead, io.EOF) {
 				break
 			}
-			return "", fmt.Errorf("error reading archive line %d: %w", lineNum, errRead)
+			return "", "", fmt.Errorf("error reading archive line %d: %w", lineNum, errRead)
 		}
 		lineNum++
 	}
 
 	a.fixBoardsandBlocks(boardsAndBlocks, opt)
 
+	// remember the original ids so membership lines can be remapped.
+	oldIDs := make([]string, 0, len(boardsAndBlocks.Boards)+len(boardsAndBlocks.Blocks))
+	for _, board := range boardsAndBlocks.Boards {
+		oldIDs = append(oldIDs, board.ID)
+	}
+	for _, block := range boardsAndBlocks.Blocks {
+		oldIDs = append(oldIDs, block.ID)
+	}
+
 	var err error
 	boardsAndBlocks, err = model.GenerateBoardsAndBlocksIDs(boardsAndBlocks, a.logger)
 	if err != nil {
-		return "", fmt.Errorf("error generating archive block IDs: %w", err)
+		return "", "", fmt.Errorf("error generating archive block IDs: %w", err)
 	}
+	idMap := mapArchiveIDs(oldIDs, boardsAndBlocks)
 
 	boardsAndBlocks, err = a.CreateBoardsAndBlocks(boardsAndBlocks, opt.ModifiedBy, false)
 	if err != nil {
-		return "", fmt.Errorf("error inserting archive blocks: %w", err)
+		return "", "", fmt.Errorf("error inserting archive blocks: %w", err)
 	}
 
 	// add user to all the new boards.
@@ -214,15 +449,198 @@ func (a *App) ImportBoardJSONL(r io.Reader, opt model.ImportArchiveOptions) (str
 			SchemeAdmin: true,
 		}
 		if _, err := a.AddMemberToBoard(boardMember); err != nil {
-			return "", fmt.Errorf("cannot add member to board: %w", err)
+			return "", "", fmt.Errorf("cannot add member to board: %w", err)
+		}
+
+		if opt.ImportMembership {
+			if err := a.importArchiveMembership(board, membership, idMap, opt); err != nil {
+				return "", "", fmt.Errorf("cannot import membership for board: %w", err)
+			}
 		}
 	}
 
 	// find new board id
 	for _, board := range boardsAndBlocks.Boards {
-		return board.ID, nil
+		return boardID, board.ID, nil
+	}
+	return "", "", fmt.Errorf("missing board in archive: %w", model.ErrInvalidBoardBlock)
+}
+
+// archiveMembership holds the optional membership lines of a board archive.
+type archiveMembership struct {
+	members       []model.ArchiveMember
+	categories    []model.ArchiveCategory
+	subscriptions []model.ArchiveSubscription
+}
+
+// mapArchiveIDs maps the ids found in an archive to the ids generated on import.
+// Ids are generated per board while preserving block order, so a mapping is
+// only returned for single board imports.
+func mapArchiveIDs(oldIDs []string, bab *model.BoardsAndBlocks) map[string]string {
+	idMap := make(map[string]string, len(oldIDs))
+	if len(bab.Boards) != 1 || len(oldIDs) != 1+len(bab.Blocks) {
+		return idMap
+	}
+
+	idMap[oldIDs[0]] = bab.Boards[0].ID
+	for i, block := range bab.Blocks {
+		idMap[oldIDs[i+1]] = block.ID
+	}
+	return idMap
+}
+
+// importArchiveMembership restores the members of an imported board, the
+// sidebar categories they placed it in and their subscriptions. Archived users
+// are matched to users on this server by username or email and are skipped
+// if no match exists.
+//
+// Only team admins import the other members, and only those of the team, with
+// at most the editor role. Other users only get their own categories and
+// subscriptions back.
+func (a *App) importArchiveMembership(board *model.Board, membership *archiveMembership, idMap map[string]string, opt model.ImportArchiveOptions) error {
+	userIDs := make(map[string]string)
+	resolve := func(archiveUser model.ArchiveUser) (string, error) {
+		if userID, ok := userIDs[archiveUser.UserID]; ok {
+			return userID, nil
+		}
+		user, err := a.findArchiveUser(archiveUser)
+		if err != nil {
+			return "", err
+		}
+		userID := ""
+		if user != nil {
+			userID = user.ID
+		}
+		userIDs[archiveUser.UserID] = userID
+		return userID, nil
+	}
+
+	// categories and subscriptions are only restored for board members.
+	members := map[string]bool{opt.ModifiedBy: true}
+	if !a.permissions.HasPermissionToTeam(opt.ModifiedBy, opt.TeamID, model.PermissionManageTeam) {
+		a.logger.Debug("importing the membership of the importing user only",
+			mlog.String("boardID", board.ID),
+			mlog.String("userID", opt.ModifiedBy),
+		)
+		membership = &archiveMembership{categories: membership.categories, subscriptions: membership.subscriptions}
+	}
+
+	for _, archiveMember := range membership.members {
+		userID, err := resolve(archiveMember.ArchiveUser)
+		if err != nil {
+			return err
+		}
+		if userID == "" || members[userID] {
+			continue
+		}
+		if !a.permissions.HasPermissionToTeam(userID, opt.TeamID, model.PermissionViewTeam) {
+			a.logger.Debug("skipping archive member outside of the team",
+				mlog.String("boardID", board.ID),
+				mlog.String("userID", userID),
+			)
+			continue
+		}
+		member := &model.BoardMember{
+			BoardID:         board.ID,
+			UserID:          userID,
+			SchemeEditor:    archiveMember.SchemeAdmin || archiveMember.SchemeEditor,
+			SchemeCommenter: archiveMember.SchemeCommenter,
+			SchemeViewer:    archiveMember.SchemeViewer,
+		}
+		if _, err = a.AddMemberToBoard(member); err != nil {
+			return fmt.Errorf("cannot add member %s: %w", userID, err)
+		}
+		members[userID] = true
+	}
+
+	for _, archiveCategory := range membership.categories {
+		userID, err := resolve(archiveCategory.ArchiveUser)
+		if err != nil {
+			return err
+		}
+		if !members[userID] {
+			continue
+		}
+		categoryID, err := a.getOrCreateCategoryByName(userID, opt.TeamID, archiveCategory.Name)
+		if err != nil {
+			return err
+		}
+		if err = a.AddUpdateUserCategoryBoard(opt.TeamID, userID, categoryID, board.ID); err != nil {
+			return fmt.Errorf("cannot add board to category %s: %w", categoryID, err)
+		}
+	}
+
+	for _, archiveSub := range membership.subscriptions {
+		userID, err := resolve(archiveSub.ArchiveUser)
+		if err != nil {
+			return err
+		}
+		blockID, ok := idMap[archiveSub.BlockID]
+		if !members[userID] || !ok {
+			continue
+		}
+		sub := &model.Subscription{
+			BlockType:      archiveSub.BlockType,
+			BlockID:        blockID,
+			SubscriberType: model.SubTypeUser,
+			SubscriberID:   userID,
+		}
+		if _, err = a.CreateSubscription(sub); err != nil {
+			return fmt.Errorf("cannot create subscription for block %s: %w", blockID, err)
+		}
+	}
+	return nil
+}
+
+// findArchiveUser looks up an archived user on this server by username, then
+// by email. Nil is returned if there is no match.
+func (a *App) findArchiveUser(archiveUser model.ArchiveUser) (*model.User, error) {
+	if archiveUser.Username != "" {
+		user, err := a.store.GetUserByUsername(archiveUser.Username)
+		if err != nil && !model.IsErrNotFound(err) {
+			return nil, err
+		}
+		if user != nil {
+			return user, nil
+		}
+	}
+	if archiveUser.Email != "" {
+		user, err := a.store.GetUserByEmail(archiveUser.Email)
+		if err != nil && !model.IsErrNotFound(err) {
+			return nil, err
+		}
+		if user != nil {
+			return user, nil
+		}
+	}
+	a.logger.Debug("no matching user for archive entry",
+		mlog.String("username", archiveUser.Username),
+	)
+	return nil, nil
+}
+
+// getOrCreateCategoryByName returns the id of the user's category with the
+// given name, creating the category if needed.
+func (a *App) getOrCreateCategoryByName(userID, teamID, name string) (string, error) {
+	categories, err := a.GetUserCategoryBoards(userID, teamID)
+	if err != nil {
+		return "", err
+	}
+	for _, category := range categories {
+		if category.Name == name && category.DeleteAt == 0 {
+			return category.ID, nil
+		}
+	}
+
+	category, err := a.CreateCategory(&model.Category{
+		Name:   name,
+		UserID: userID,
+		TeamID: teamID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot create category %s: %w", name, err)
 	}
-	return "", fmt.Errorf("missing board in archive: %w", model.ErrInvalidBoardBlock)
+	return category.ID, nil
 }
 
 // fixBoardsandBlocks allows the caller of `ImportArchive` to modify or filters boards and blocks being
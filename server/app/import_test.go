@@ -7,6 +7,8 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/stretchr/testify/require"
+
+	mmModel "github.com/mattermost/mattermost-server/v6/model"
 )
 
 func TestApp_ImportArchive(t *testing.T) {
@@ -48,11 +50,65 @@ func TestApp_ImportArchive(t *testing.T) {
 		th.Store.EXPECT().GetBoard(board.ID).Return(board, nil)
 		th.Store.EXPECT().GetMemberForBoard(board.ID, "user").Return(boardMember, nil)
 
-		err := th.App.ImportArchive(r, opts)
+		_, err := th.App.ImportArchive(r, opts)
 		require.NoError(t, err, "import archive should not fail")
 	})
 }
 
+// teamPermissions grants the team permissions of the listed users.
+type teamPermissions map[string][]*mmModel.Permission
+
+func (p teamPermissions) HasPermissionToTeam(userID, teamID string, permission *mmModel.Permission) bool {
+	for _, granted := range p[userID] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (p teamPermissions) HasPermissionToBoard(userID, boardID string, permission *mmModel.Permission) bool {
+	return false
+}
+
+func TestApp_ImportArchiveMembership(t *testing.T) {
+	th, tearDown := SetupTestHelper(t)
+	defer tearDown()
+
+	board := &model.Board{ID: "board-id", TeamID: "team-id"}
+	membership := &archiveMembership{
+		members: []model.ArchiveMember{
+			{ArchiveUser: model.ArchiveUser{UserID: "old-2", Username: "user2"}, SchemeAdmin: true},
+			{ArchiveUser: model.ArchiveUser{UserID: "old-3", Username: "user3"}, SchemeViewer: true},
+		},
+	}
+	opts := model.ImportArchiveOptions{TeamID: "team-id", ModifiedBy: "user-1", ImportMembership: true}
+
+	t.Run("team admins import the team members as editors at most", func(t *testing.T) {
+		th.App.permissions = teamPermissions{
+			"user-1": {model.PermissionManageTeam, model.PermissionViewTeam},
+			"user-2": {model.PermissionViewTeam},
+		}
+		th.Store.EXPECT().GetUserByUsername("user2").Return(&model.User{ID: "user-2"}, nil)
+		th.Store.EXPECT().GetUserByUsername("user3").Return(&model.User{ID: "user-3"}, nil)
+		th.Store.EXPECT().GetBoard(board.ID).Return(board, nil)
+		th.Store.EXPECT().GetMemberForBoard(board.ID, "user-2").Return(nil, model.NewErrNotFound("member"))
+		th.Store.EXPECT().SaveMember(&model.BoardMember{BoardID: board.ID, UserID: "user-2", SchemeEditor: true}).
+			Return(&model.BoardMember{BoardID: board.ID, UserID: "user-2", SchemeEditor: true}, nil)
+
+		require.NoError(t, th.App.importArchiveMembership(board, membership, nil, opts))
+	})
+
+	t.Run("other users only import their own membership", func(t *testing.T) {
+		th.App.permissions = teamPermissions{
+			"user-1": {model.PermissionViewTeam},
+			"user-2": {model.PermissionViewTeam},
+		}
+
+		require.NoError(t, th.App.importArchiveMembership(board, membership, nil, opts))
+	})
+}
+
 //nolint:lll
 const asana = `{"version":1,"date":1614714686842}
 {"type":"block","data":{"id":"d14b9df9-1f31-4732-8a64-92bc7162cd28","fields":{"icon":"","description":"","cardProperties":[{"id":"3bdcbaeb-bc78-4884-8531-a0323b74676a","name":"Section","type":"select","options":[{"id":"d8d94ef1-5e74-40bb-8be5-fc0eb3f47732","value":"Planning","color":"propColorGray"},{"id":"454559bb-b788-4ff6-873e-04def8491d2c","value":"Milestones","color":"propColorBrown"},{"id":"deaab476-c690-48df-828f-725b064dc476","value":"Next steps","color":"propColorOrange"},{"id":"2138305a-3157-461c-8bbe-f19ebb55846d","value":"Comms Plan","color":"propColorYellow"}]}]},"createAt":1614714686836,"updateAt":1614714686836,"deleteAt":0,"schema":1,"parentId":"","rootId":"d14b9df9-1f31-4732-8a64-92bc7162cd28","modifiedBy":"","type":"board","title":"Cross-Functional Project Plan"}}
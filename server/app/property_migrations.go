@@ -0,0 +1,67 @@
+package app
+
+import (
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+// MigrateCardProperty changes a card property of a board and rewrites the values of the
+// cards of the board in the same transaction. The migration is recorded so that it can
+// be undone.
+func (a *App) MigrateCardProperty(migration *model.PropertyMigration, userID string) (*model.PropertyMigration, error) {
+	migration.ID = utils.NewID(utils.IDTypeNone)
+	migration.CreatedBy = userID
+	migration.CreateAt = utils.GetMillis()
+	migration.UndoneBy = ""
+	migration.UndoneAt = 0
+
+	board, err := a.store.SavePropertyMigration(migration, userID)
+	if err != nil {
+		return nil, err
+	}
+	a.propertyMigrationSaved(board, migration, userID)
+	return migration, nil
+}
+
+// UndoPropertyMigration restores the property changed by a migration and the card values
+// it rewrote. Only the latest migration of a board that is not undone can be undone, and
+// the cards whose value changed since the migration keep their value.
+func (a *App) UndoPropertyMigration(boardID, migrationID, userID string) (*model.PropertyMigration, error) {
+	migration, board, err := a.store.UndoPropertyMigration(boardID, migrationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	a.propertyMigrationSaved(board, migration, userID)
+	return migration, nil
+}
+
+// GetPropertyMigrations returns the property migrations of a board, latest first.
+func (a *App) GetPropertyMigrations(boardID string) ([]*model.PropertyMigration, error) {
+	return a.store.GetPropertyMigrations(boardID)
+}
+
+// propertyMigrationSaved recomputes the computed properties of a migrated board, as they
+// may depend on the migrated property, and broadcasts the changes.
+func (a *App) propertyMigrationSaved(board *model.Board, migration *model.PropertyMigration, userID string) {
+	if err := a.RecomputeBoardProperties(board, userID); err != nil {
+		a.logger.Error("Cannot recompute card properties after a property migration",
+			mlog.String("board_id", board.ID),
+			mlog.Err(err),
+		)
+	}
+
+	a.blockChangeNotifier.Enqueue(func() error {
+		a.wsAdapter.BroadcastBoardChange(board.TeamID, board)
+		for _, change := range migration.Changes {
+			block, err := a.store.GetBlock(change.BlockID)
+			if err != nil || block == nil {
+				continue
+			}
+			a.wsAdapter.BroadcastBlockChange(board.TeamID, *block)
+			a.webhook.NotifyUpdate(*block)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,356 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/mattermost/focalboard/server/model"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+// relationChange is a card whose relations changed, before is nil for new cards
+// and after is nil for deleted cards.
+type relationChange struct {
+	before *model.Block
+	after  *model.Block
+}
+
+func hasRelations(schema model.PropSchema) bool {
+	for _, def := range schema {
+		if def.Type == model.PropTypeRelation {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRollupsOver returns true if the schema has rollups over a relation property.
+func hasRollupsOver(schema model.PropSchema, relationID string) bool {
+	for _, def := range schema {
+		if def.Type == model.PropTypeRollup && def.Rollup.RelationPropertyID == relationID {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRelationValues drops from the relation properties of the cards among blocks
+// the IDs of the cards that do not exist or are not on the board the relation links
+// to. Cards saved together can link to each other.
+func (a *App) checkRelationValues(schema model.PropSchema, blocks []*model.Block) error {
+	if !hasRelations(schema) {
+		return nil
+	}
+
+	cards := make(map[string]*model.Block, len(blocks))
+	for _, block := range blocks {
+		cards[block.ID] = block
+	}
+	getCard := func(cardID string) (*model.Block, error) {
+		if card, ok := cards[cardID]; ok {
+			return card, nil
+		}
+		card, err := a.store.GetBlock(cardID)
+		if err != nil {
+			return nil, err
+		}
+		cards[cardID] = card
+		return card, nil
+	}
+
+	for _, block := range blocks {
+		if block.Type != model.TypeCard {
+			continue
+		}
+		props, ok := block.Fields["properties"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		var checked map[string]interface{}
+		for id, def := range schema {
+			if def.Type != model.PropTypeRelation {
+				continue
+			}
+			ids := model.RelationIDs(props[id])
+			valid := make([]string, 0, len(ids))
+			for _, cardID := range ids {
+				card, err := getCard(cardID)
+				if err != nil {
+					return err
+				}
+				if card != nil && card.Type == model.TypeCard && card.BoardID == def.Relation.BoardID {
+					valid = append(valid, cardID)
+				}
+			}
+			if len(valid) == len(ids) {
+				continue
+			}
+
+			// copy on write, the properties map may be shared with a patch.
+			if checked == nil {
+				checked = make(map[string]interface{}, len(props))
+				for k, v := range props {
+					checked[k] = v
+				}
+			}
+			if len(valid) == 0 {
+				delete(checked, id)
+			} else {
+				checked[id] = model.RelationValue(valid)
+			}
+		}
+		if checked != nil {
+			block.Fields["properties"] = checked
+		}
+	}
+	return nil
+}
+
+// syncRelations updates the cards linked to changed cards through mirrored relations:
+// the back-references to the changed cards are added or removed, and the rollups
+// over the back-references are recomputed. The linked boards whose cards the user
+// cannot manage are skipped. Errors are logged, as the changed cards are already saved.
+func (a *App) syncRelations(board *model.Board, userID string, changes ...relationChange) {
+	if board == nil {
+		return
+	}
+	schema, err := model.ParsePropertySchema(board)
+	if err != nil || !hasRelations(schema) {
+		return
+	}
+
+	for _, change := range changes {
+		card := change.after
+		if card == nil {
+			card = change.before
+		}
+		if card == nil || card.Type != model.TypeCard {
+			continue
+		}
+
+		for id, def := range schema {
+			if def.Type != model.PropTypeRelation || def.Relation.BackReferencePropertyID == "" {
+				continue
+			}
+			before := model.CardRelationIDs(change.before, id)
+			after := model.CardRelationIDs(change.after, id)
+			if errSync := a.syncBackReferences(card.ID, userID, def.Relation, before, after); errSync != nil {
+				a.logger.Error("Cannot update the back-references of a card relation",
+					mlog.String("block_id", card.ID),
+					mlog.String("property_id", id),
+					mlog.Err(errSync),
+				)
+			}
+		}
+	}
+}
+
+func (a *App) syncBackReferences(cardID, userID string, relation *model.RelationDef, before, after []string) error {
+	if userID != model.SystemUserID && !a.permissions.HasPermissionToBoard(userID, relation.BoardID, model.PermissionManageBoardCards) {
+		a.logger.Debug("Skipping the back-references on a board the user cannot edit",
+			mlog.String("board_id", relation.BoardID),
+			mlog.String("user_id", userID),
+		)
+		return nil
+	}
+
+	target, err := a.store.GetBoard(relation.BoardID)
+	if model.IsErrNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	schema, err := model.ParsePropertySchema(target)
+	if err != nil {
+		return err
+	}
+	backRef, ok := schema[relation.BackReferencePropertyID]
+	if !ok || backRef.Type != model.PropTypeRelation {
+		return nil
+	}
+	refreshRollups := hasRollupsOver(schema, backRef.ID)
+
+	linked := make(map[string]bool, len(after))
+	linkedIDs := make([]string, 0, len(before)+len(after))
+	for _, id := range after {
+		linked[id] = true
+		linkedIDs = append(linkedIDs, id)
+	}
+	for _, id := range before {
+		if !linked[id] {
+			linkedIDs = append(linkedIDs, id)
+		}
+	}
+
+	pc := a.newPropertyComputer(target, schema, userID)
+	for _, id := range linkedIDs {
+		if id == cardID {
+			continue
+		}
+		other, errGet := a.store.GetBlock(id)
+		if errGet != nil {
+			return errGet
+		}
+		if other == nil || other.Type != model.TypeCard || other.BoardID != target.ID {
+			continue
+		}
+
+		ids := model.CardRelationIDs(other, backRef.ID)
+		updated := make([]string, 0, len(ids)+1)
+		found := false
+		for _, refID := range ids {
+			if refID == cardID {
+				found = true
+				if !linked[id] {
+					continue
+				}
+			}
+			updated = append(updated, refID)
+		}
+		if linked[id] && !found {
+			updated = append(updated, cardID)
+		}
+
+		changed := len(updated) != len(ids)
+		if !changed && !refreshRollups {
+			continue
+		}
+		props, _ := other.Fields["properties"].(map[string]interface{})
+		if changed {
+			setRelationValue(other, backRef.ID, updated)
+		}
+		computed, errCompute := pc.compute(other)
+		if errCompute != nil {
+			return errCompute
+		}
+		if !changed && !computed {
+			continue
+		}
+		values, _ := other.Fields["properties"].(map[string]interface{})
+		err = a.saveCardProperties(target, other.ID, changedPropertyValues(props, values), userID)
+		if err != nil && !model.IsErrNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeRelationReferences removes a card deleted by a user from the relation properties
+// of the cards of its team that link to it.
+func (a *App) removeRelationReferences(board *model.Board, cardID, userID string) error {
+	boards, err := a.store.GetBoardsReferencingBoard(board.TeamID, board.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range boards {
+		schema, errSchema := model.ParsePropertySchema(b)
+		if errSchema != nil {
+			continue
+		}
+		var relationIDs []string
+		for id, def := range schema {
+			if def.Type == model.PropTypeRelation && def.Relation.BoardID == board.ID {
+				relationIDs = append(relationIDs, id)
+			}
+		}
+		if len(relationIDs) == 0 {
+			continue
+		}
+
+		cards, errCards := a.store.GetBlocksWithType(b.ID, model.TypeCard)
+		if errCards != nil {
+			return errCards
+		}
+		pc := a.newPropertyComputer(b, schema, userID)
+		for i := range cards {
+			card := &cards[i]
+			props, _ := card.Fields["properties"].(map[string]interface{})
+			changed := false
+			for _, id := range relationIDs {
+				ids := model.CardRelationIDs(card, id)
+				updated := make([]string, 0, len(ids))
+				for _, refID := range ids {
+					if refID != cardID {
+						updated = append(updated, refID)
+					}
+				}
+				if len(updated) != len(ids) {
+					setRelationValue(card, id, updated)
+					changed = true
+				}
+			}
+			if !changed {
+				continue
+			}
+			if _, err = pc.compute(card); err != nil {
+				return err
+			}
+			values, _ := card.Fields["properties"].(map[string]interface{})
+			err = a.saveCardProperties(b, card.ID, changedPropertyValues(props, values), userID)
+			if err != nil && !model.IsErrNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setRelationValue sets the value of a relation property of a card, copying the
+// properties as they may be shared.
+func setRelationValue(card *model.Block, propertyID string, ids []string) {
+	props, _ := card.Fields["properties"].(map[string]interface{})
+	updated := make(map[string]interface{}, len(props)+1)
+	for k, v := range props {
+		updated[k] = v
+	}
+	if len(ids) == 0 {
+		delete(updated, propertyID)
+	} else {
+		updated[propertyID] = model.RelationValue(ids)
+	}
+	if card.Fields == nil {
+		card.Fields = make(map[string]interface{})
+	}
+	card.Fields["properties"] = updated
+}
+
+// GetRelatedCards returns the cards linked to a card by a relation property. Linked
+// cards that no longer exist are skipped.
+func (a *App) GetRelatedCards(boardID, cardID, propertyID string) ([]model.Block, error) {
+	card, err := a.store.GetBlock(cardID)
+	if err != nil {
+		return nil, err
+	}
+	if card == nil || card.Type != model.TypeCard || card.BoardID != boardID {
+		return nil, model.NewErrNotFound(cardID)
+	}
+
+	board, err := a.store.GetBoard(boardID)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := model.ParsePropertySchema(board)
+	if err != nil {
+		return nil, err
+	}
+	def, ok := schema[propertyID]
+	if !ok || def.Type != model.PropTypeRelation {
+		return nil, fmt.Errorf("%w: %s is not a relation property", model.ErrInvalidProperty, propertyID)
+	}
+
+	related := make([]model.Block, 0)
+	for _, id := range model.CardRelationIDs(card, propertyID) {
+		rc, errGet := a.store.GetBlock(id)
+		if errGet != nil {
+			return nil, errGet
+		}
+		if rc == nil || rc.Type != model.TypeCard || rc.BoardID != def.Relation.BoardID {
+			continue
+		}
+		related = append(related, *rc)
+	}
+	return related, nil
+}
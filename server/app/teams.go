@@ -63,3 +63,20 @@ func (a *App) UpsertTeamSignupToken(team model.Team) error {
 func (a *App) GetTeamCount() (int64, error) {
 	return a.store.GetTeamCount()
 }
+
+// getAllTeamIDs returns the ids of every team, including the global team
+// that holds the templates and the boards of the standalone server.
+func (a *App) getAllTeamIDs() ([]string, error) {
+	teams, err := a.store.GetAllTeams()
+	if err != nil && !model.IsErrNotFound(err) {
+		return nil, err
+	}
+
+	teamIDs := []string{model.GlobalTeamID}
+	for _, team := range teams {
+		if !utils.ContainsString(teamIDs, team.ID) {
+			teamIDs = append(teamIDs, team.ID)
+		}
+	}
+	return teamIDs, nil
+}
@@ -50,6 +50,9 @@ func TestApp_initializeTemplates(t *testing.T) {
 		th.Store.EXPECT().GetBoard(board.ID).AnyTimes().Return(board, nil)
 		th.Store.EXPECT().GetMemberForBoard(gomock.Any(), gomock.Any()).AnyTimes().Return(boardMember, nil)
 
+		th.Store.EXPECT().SaveFileInfo(gomock.Any()).AnyTimes().Return(nil)
+
+		th.FilesBackend.On("FileExists", mock.Anything).Return(false, nil)
 		th.FilesBackend.On("WriteFile", mock.Anything, mock.Anything).Return(int64(1), nil)
 
 		done, err := th.App.initializeTemplates()
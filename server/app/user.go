@@ -11,6 +11,12 @@ func (a *App) SearchTeamUsers(teamID string, searchQuery string) ([]*model.User,
 }
 
 func (a *App) UpdateUserConfig(userID string, patch model.UserPropPatch) (map[string]interface{}, error) {
+	if timezone, ok := patch.UpdatedFields[model.UserPropTimezone]; ok {
+		if _, err := model.LoadLocation(timezone); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := a.store.PatchUserProps(userID, patch); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/focalboard/server/model"
+)
+
+// GetViewAggregations aggregates the values of the card properties over the cards shown
+// by a view, in total and per group if the view is grouped. Only the properties in
+// propertyIDs are aggregated, or every property of the board if it is empty.
+func (a *App) GetViewAggregations(boardID, viewID string, propertyIDs []string) (*model.ViewAggregations, error) {
+	board, err := a.store.GetBoard(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	view, err := a.store.GetBlock(viewID)
+	if err != nil {
+		return nil, err
+	}
+	if view == nil || view.Type != model.TypeView || view.BoardID != boardID {
+		return nil, model.NewErrNotFound(viewID)
+	}
+
+	schema, err := model.ParsePropertySchema(board)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := model.ParseViewFilter(view)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(propertyIDs) == 0 {
+		for id := range schema {
+			propertyIDs = append(propertyIDs, id)
+		}
+	}
+	defs := make([]model.PropDef, 0, len(propertyIDs))
+	for _, id := range propertyIDs {
+		def, ok := schema[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", model.ErrInvalidProperty, id)
+		}
+		defs = append(defs, def)
+	}
+
+	cards, err := a.store.GetBlocksWithType(boardID, model.TypeCard)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ViewAggregations{
+		ViewID: viewID,
+		Total:  newAggregationGroup(""),
+		Groups: []*model.AggregationGroup{},
+	}
+
+	groupByID, _ := stringValue(view.Fields, "groupById")
+	groupBy, grouped := schema[groupByID]
+	groups := make(map[string]*aggregator)
+	if grouped {
+		result.GroupByPropertyID = groupBy.ID
+		options := make([]model.PropDefOption, 0, len(groupBy.Options))
+		for _, opt := range groupBy.Options {
+			options = append(options, opt)
+		}
+		sort.Slice(options, func(i, j int) bool { return options[i].Index < options[j].Index })
+		for _, opt := range options {
+			result.Groups = append(result.Groups, newAggregationGroup(opt.ID))
+			groups[opt.ID] = newAggregator(result.Groups[len(result.Groups)-1], defs)
+		}
+		result.Groups = append(result.Groups, newAggregationGroup(""))
+		groups[""] = newAggregator(result.Groups[len(result.Groups)-1], defs)
+	}
+
+	total := newAggregator(result.Total, defs)
+	for i := range cards {
+		card := &cards[i]
+		if isTemplate, _ := boolValue(card.Fields, "isTemplate"); isTemplate {
+			continue
+		}
+		props := model.CardPropertyValues(card, schema)
+		if !filter.MatchesValues(props) {
+			continue
+		}
+
+		total.add(props)
+		if grouped {
+			value, _ := stringValue(props, groupBy.ID)
+			group, ok := groups[value]
+			if !ok {
+				group = groups[""]
+			}
+			group.add(props)
+		}
+	}
+
+	total.finish()
+	for _, group := range groups {
+		group.finish()
+	}
+	return result, nil
+}
+
+func newAggregationGroup(value string) *model.AggregationGroup {
+	return &model.AggregationGroup{
+		Value:   value,
+		Columns: make(map[string]*model.PropertyAggregation),
+	}
+}
+
+// aggregator aggregates the property values of a group of cards. Numbers are summed
+// as exact decimals so that the totals of currency values have no rounding errors.
+type aggregator struct {
+	group   *model.AggregationGroup
+	defs    []model.PropDef
+	numbers map[string]*numberAggregate
+}
+
+type numberAggregate struct {
+	count    int64
+	sum      *big.Rat
+	min, max *big.Rat
+}
+
+func newAggregator(group *model.AggregationGroup, defs []model.PropDef) *aggregator {
+	agg := &aggregator{
+		group:   group,
+		defs:    defs,
+		numbers: make(map[string]*numberAggregate),
+	}
+	for _, def := range defs {
+		group.Columns[def.ID] = &model.PropertyAggregation{}
+		if def.IsNumeric() || def.IsComputed() || def.IsTimestamp() {
+			agg.numbers[def.ID] = &numberAggregate{sum: new(big.Rat)}
+		}
+	}
+	return agg
+}
+
+func (agg *aggregator) add(props map[string]interface{}) {
+	agg.group.Count++
+	for _, def := range agg.defs {
+		column := agg.group.Columns[def.ID]
+		column.Count++
+
+		value := props[def.ID]
+		if model.IsEmptyPropertyValue(value) {
+			column.CountEmpty++
+			continue
+		}
+		column.CountNotEmpty++
+
+		num, ok := agg.numbers[def.ID]
+		if !ok {
+			continue
+		}
+		s, _ := value.(string)
+		r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+		if !ok {
+			continue
+		}
+		num.count++
+		num.sum.Add(num.sum, r)
+		if num.min == nil || r.Cmp(num.min) < 0 {
+			num.min = r
+		}
+		if num.max == nil || r.Cmp(num.max) > 0 {
+			num.max = r
+		}
+	}
+}
+
+func (agg *aggregator) finish() {
+	for id, num := range agg.numbers {
+		if num.count == 0 {
+			continue
+		}
+		column := agg.group.Columns[id]
+		column.Sum = ratToFloat(num.sum)
+		column.Average = ratToFloat(new(big.Rat).Quo(num.sum, new(big.Rat).SetInt64(num.count)))
+		column.Min = ratToFloat(num.min)
+		column.Max = ratToFloat(num.max)
+	}
+}
+
+func ratToFloat(r *big.Rat) *float64 {
+	f, _ := r.Float64()
+	return &f
+}
@@ -3,11 +3,14 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/mattermost/focalboard/server/api"
@@ -61,6 +64,22 @@ func closeBody(r *http.Response) {
 	}
 }
 
+// PatchConflictFromError returns the conflict response of a patch based on an outdated
+// version, which includes the current version of the block or board, or nil if the error
+// is not a conflict.
+func PatchConflictFromError(err error) *model.PatchConflictResponse {
+	var rre RequestReaderError
+	if !errors.As(err, &rre) {
+		return nil
+	}
+
+	var conflict model.PatchConflictResponse
+	if json.Unmarshal(rre.buf, &conflict) != nil || conflict.ErrorCode != http.StatusConflict {
+		return nil
+	}
+	return &conflict
+}
+
 func toJSON(v interface{}) string {
 	b, _ := json.Marshal(v)
 	return string(b)
@@ -176,6 +195,10 @@ func (c *Client) GetBoardMetadataRoute(boardID string) string {
 	return fmt.Sprintf("%s/%s/metadata", c.GetBoardsRoute(), boardID)
 }
 
+func (c *Client) GetViewAggregationsRoute(boardID, viewID string) string {
+	return fmt.Sprintf("%s/views/%s/aggregations", c.GetBoardRoute(boardID), viewID)
+}
+
 func (c *Client) GetJoinBoardRoute(boardID string) string {
 	return fmt.Sprintf("%s/%s/join", c.GetBoardsRoute(), boardID)
 }
@@ -520,6 +543,146 @@ func (c *Client) GetBoardMetadata(boardID, readToken string) (*model.BoardMetada
 	return model.BoardMetadataFromJSON(r.Body), BuildResponse(r)
 }
 
+// GetViewAggregations returns the aggregated card property values of a view,
+// restricted to the given properties if any.
+func (c *Client) GetViewAggregations(boardID, viewID string, propertyIDs ...string) (*model.ViewAggregations, *Response) {
+	route := c.GetViewAggregationsRoute(boardID, viewID)
+	for i, propertyID := range propertyIDs {
+		sep := "&"
+		if i == 0 {
+			sep = "?"
+		}
+		route += sep + "propertyId=" + url.QueryEscape(propertyID)
+	}
+
+	r, err := c.DoAPIGet(route, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	return model.ViewAggregationsFromJSON(r.Body), BuildResponse(r)
+}
+
+// GetCardsOverlappingDates returns the cards whose value of a date property overlaps
+// the interval [from, to[ in milliseconds.
+func (c *Client) GetCardsOverlappingDates(boardID, propertyID string, from, to int64) ([]model.Block, *Response) {
+	query := url.Values{}
+	query.Set("propertyId", propertyID)
+	query.Set("from", strconv.FormatInt(from, 10))
+	query.Set("to", strconv.FormatInt(to, 10))
+	return c.getCardsOverlappingDates(boardID, query)
+}
+
+// GetCardsOverlappingPeriod returns the cards whose value of a date property overlaps
+// a period containing the current date, in the given timezone or the timezone of the user.
+func (c *Client) GetCardsOverlappingPeriod(boardID, propertyID, period, timezone string) ([]model.Block, *Response) {
+	query := url.Values{}
+	query.Set("propertyId", propertyID)
+	query.Set("period", period)
+	if timezone != "" {
+		query.Set("timezone", timezone)
+	}
+	return c.getCardsOverlappingDates(boardID, query)
+}
+
+func (c *Client) getCardsOverlappingDates(boardID string, query url.Values) ([]model.Block, *Response) {
+	r, err := c.DoAPIGet(c.GetBoardRoute(boardID)+"/cards/dates?"+query.Encode(), "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	return model.BlocksFromJSON(r.Body), BuildResponse(r)
+}
+
+// GetChecklistCards returns the cards of a board with incomplete checklist items assigned
+// to a user, "me" for the current user, and due before a time in milliseconds. Empty
+// values match every item.
+func (c *Client) GetChecklistCards(boardID, assignee string, dueBefore int64) ([]model.ChecklistCard, *Response) {
+	query := url.Values{}
+	if assignee != "" {
+		query.Set("assignee", assignee)
+	}
+	if dueBefore != 0 {
+		query.Set("dueBefore", strconv.FormatInt(dueBefore, 10))
+	}
+
+	r, err := c.DoAPIGet(c.GetBoardRoute(boardID)+"/cards/checklist?"+query.Encode(), "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	return model.ChecklistCardsFromJSON(r.Body), BuildResponse(r)
+}
+
+// GetRelatedCards returns the cards the user can see among the cards linked to a
+// card by a relation property.
+func (c *Client) GetRelatedCards(boardID, cardID, propertyID string) ([]model.Block, *Response) {
+	r, err := c.DoAPIGet(c.GetBoardRoute(boardID)+"/cards/"+cardID+"/relations/"+propertyID, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	return model.BlocksFromJSON(r.Body), BuildResponse(r)
+}
+
+// GetPropertyConformance returns the cards of a board that miss required properties or
+// have invalid property values.
+func (c *Client) GetPropertyConformance(boardID string) (*model.PropertyConformanceReport, *Response) {
+	r, err := c.DoAPIGet(c.GetBoardRoute(boardID)+"/properties/conformance", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	return model.PropertyConformanceReportFromJSON(r.Body), BuildResponse(r)
+}
+
+// MigrateCardProperty changes a card property of a board and rewrites the values of
+// the cards of the board.
+func (c *Client) MigrateCardProperty(boardID string, migration *model.PropertyMigration) (*model.PropertyMigration, *Response) {
+	r, err := c.DoAPIPost(c.GetBoardRoute(boardID)+"/properties/migrations", toJSON(migration))
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	migration, err = model.PropertyMigrationFromJSON(r.Body)
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	return migration, BuildResponse(r)
+}
+
+// GetPropertyMigrations returns the property migrations of a board, latest first.
+func (c *Client) GetPropertyMigrations(boardID string) ([]*model.PropertyMigration, *Response) {
+	r, err := c.DoAPIGet(c.GetBoardRoute(boardID)+"/properties/migrations", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	return model.PropertyMigrationsFromJSON(r.Body), BuildResponse(r)
+}
+
+// UndoPropertyMigration undoes the latest property migration of a board.
+func (c *Client) UndoPropertyMigration(boardID, migrationID string) (*model.PropertyMigration, *Response) {
+	r, err := c.DoAPIPost(c.GetBoardRoute(boardID)+"/properties/migrations/"+migrationID+"/undo", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	migration, err := model.PropertyMigrationFromJSON(r.Body)
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	return migration, BuildResponse(r)
+}
+
 func (c *Client) GetBoardsForTeam(teamID string) ([]*model.Board, *Response) {
 	r, err := c.DoAPIGet(c.GetTeamRoute(teamID)+"/boards", "")
 	if err != nil {
@@ -605,9 +768,13 @@ func (c *Client) GetTeamUploadFileRoute(teamID, boardID string) string {
 }
 
 func (c *Client) TeamUploadFile(teamID, boardID string, data io.Reader) (*api.FileUploadResponse, *Response) {
+	return c.TeamUploadNamedFile(teamID, boardID, "file", data)
+}
+
+func (c *Client) TeamUploadNamedFile(teamID, boardID, filename string, data io.Reader) (*api.FileUploadResponse, *Response) {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
-	part, err := writer.CreateFormFile(api.UploadFormFileKey, "file")
+	part, err := writer.CreateFormFile(api.UploadFormFileKey, filename)
 	if err != nil {
 		return nil, &Response{Error: err}
 	}
@@ -634,6 +801,48 @@ func (c *Client) TeamUploadFile(teamID, boardID string, data io.Reader) (*api.Fi
 	return fileUploadResponse, BuildResponse(r)
 }
 
+func (c *Client) GetFileRoute(teamID, boardID, fileID string) string {
+	return fmt.Sprintf("/files/teams/%s/%s/%s", teamID, boardID, fileID)
+}
+
+func (c *Client) GetFile(teamID, boardID, fileID string) ([]byte, *Response) {
+	return c.getFile(c.GetFileRoute(teamID, boardID, fileID))
+}
+
+// GetFileVariant returns a variant of an uploaded image, like its thumbnail.
+func (c *Client) GetFileVariant(teamID, boardID, fileID, size string) ([]byte, *Response) {
+	return c.getFile(c.GetFileRoute(teamID, boardID, fileID) + "?size=" + size)
+}
+
+// GetFileURL returns a signed URL to download an uploaded file without a session.
+func (c *Client) GetFileURL(teamID, boardID, fileID string) (*api.FileURLResponse, *Response) {
+	r, err := c.DoAPIGet(c.GetFileRoute(teamID, boardID, fileID)+"/url", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	fileURLResponse, err := api.FileURLResponseFromJSON(r.Body)
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	return fileURLResponse, BuildResponse(r)
+}
+
+func (c *Client) getFile(route string) ([]byte, *Response) {
+	r, err := c.DoAPIGet(route, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	buf, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	return buf, BuildResponse(r)
+}
+
 func (c *Client) GetSubscriptionsRoute() string {
 	return "/subscriptions"
 }
@@ -693,7 +902,36 @@ func (c *Client) GetTemplatesForTeam(teamID string) ([]*model.Board, *Response)
 }
 
 func (c *Client) ExportBoardArchive(boardID string) ([]byte, *Response) {
-	r, err := c.DoAPIGet(c.GetBoardRoute(boardID)+"/archive/export", "")
+	return c.exportBoardArchive(c.GetBoardRoute(boardID) + "/archive/export")
+}
+
+// ExportBoardArchiveWithMembership exports a board archive that includes the
+// board members, their categories and subscriptions.
+func (c *Client) ExportBoardArchiveWithMembership(boardID string) ([]byte, *Response) {
+	return c.exportBoardArchive(c.GetBoardRoute(boardID) + "/archive/export?includeMembership=true")
+}
+
+func (c *Client) exportBoardArchive(route string) ([]byte, *Response) {
+	r, err := c.DoAPIGet(route, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	buf, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	return buf, BuildResponse(r)
+}
+
+func (c *Client) ExportBoardDocument(boardID string, format string, groupBy string) ([]byte, *Response) {
+	route := fmt.Sprintf("%s/export?format=%s", c.GetBoardRoute(boardID), format)
+	if groupBy != "" {
+		route += "&groupBy=" + groupBy
+	}
+
+	r, err := c.DoAPIGet(route, "")
 	if err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
@@ -707,14 +945,22 @@ func (c *Client) ExportBoardArchive(boardID string) ([]byte, *Response) {
 }
 
 func (c *Client) ImportArchive(teamID string, data io.Reader) *Response {
+	_, resp := c.ImportArchiveWithOptions(teamID, data, false)
+	return resp
+}
+
+// ImportArchiveWithOptions imports an archive, optionally restoring the board
+// members, categories and subscriptions it contains, and returns the mapping
+// from the archived board ids to the imported ones.
+func (c *Client) ImportArchiveWithOptions(teamID string, data io.Reader, importMembership bool) (*model.ImportArchiveResponse, *Response) {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 	part, err := writer.CreateFormFile(api.UploadFormFileKey, "file")
 	if err != nil {
-		return &Response{Error: err}
+		return nil, &Response{Error: err}
 	}
 	if _, err = io.Copy(part, data); err != nil {
-		return &Response{Error: err}
+		return nil, &Response{Error: err}
 	}
 	writer.Close()
 
@@ -722,11 +968,16 @@ func (c *Client) ImportArchive(teamID string, data io.Reader) *Response {
 		r.Header.Add("Content-Type", writer.FormDataContentType())
 	}
 
-	r, err := c.doAPIRequestReader(http.MethodPost, c.APIURL+c.GetTeamRoute(teamID)+"/archive/import", body, "", opt)
+	route := c.GetTeamRoute(teamID) + "/archive/import"
+	if importMembership {
+		route += "?importMembership=true"
+	}
+
+	r, err := c.doAPIRequestReader(http.MethodPost, c.APIURL+route, body, "", opt)
 	if err != nil {
-		return BuildErrorResponse(r, err)
+		return nil, BuildErrorResponse(r, err)
 	}
 	defer closeBody(r)
 
-	return BuildResponse(r)
+	return model.ImportArchiveResponseFromJSON(r.Body), BuildResponse(r)
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mattermost/focalboard/server/client"
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/utils"
 
@@ -159,6 +160,33 @@ func TestPostBlock(t *testing.T) {
 		require.NotNil(t, block4)
 		require.Equal(t, "Updated title", block4.Title)
 	})
+
+	t.Run("Create a card with invalid property values", func(t *testing.T) {
+		patch := &model.BoardPatch{
+			UpdatedCardProperties: []map[string]interface{}{
+				{"id": "due", "name": "Due", "type": "date"},
+			},
+		}
+		_, resp := th.Client.PatchBoard(board.ID, patch)
+		th.CheckOK(resp)
+
+		block := model.Block{
+			ID:       utils.NewID(utils.IDTypeBlock),
+			BoardID:  board.ID,
+			CreateAt: 1,
+			UpdateAt: 1,
+			Type:     model.TypeCard,
+			Title:    "Invalid card",
+			Fields: map[string]interface{}{
+				"properties": map[string]interface{}{"due": "not a date"},
+			},
+		}
+
+		newBlocks, resp := th.Client.InsertBlocks(board.ID, []model.Block{block})
+		th.CheckBadRequest(resp)
+		require.Nil(t, newBlocks)
+		require.Contains(t, resp.Error.Error(), "invalid value for property due")
+	})
 }
 
 func TestPatchBlock(t *testing.T) {
@@ -257,6 +285,89 @@ func TestPatchBlock(t *testing.T) {
 		require.Equal(t, "test value 2", updatedBlock.Fields["test2"])
 		require.Equal(t, nil, updatedBlock.Fields["test3"])
 	})
+
+	t.Run("Patch a block based on an outdated version", func(t *testing.T) {
+		blocks, resp := th.Client.GetBlocksForBoard(board.ID)
+		require.NoError(t, resp.Error)
+		require.Len(t, blocks, 1)
+		baseUpdateAt := blocks[0].UpdateAt
+
+		time.Sleep(10 * time.Millisecond)
+		concurrentTitle := "Concurrent title"
+		_, resp = th.Client.PatchBlock(board.ID, blockID, &model.BlockPatch{Title: &concurrentTitle, UpdateAt: &baseUpdateAt})
+		th.CheckOK(resp)
+
+		time.Sleep(10 * time.Millisecond)
+		staleTitle := "Stale title"
+		_, resp = th.Client.PatchBlock(board.ID, blockID, &model.BlockPatch{Title: &staleTitle, UpdateAt: &baseUpdateAt})
+		th.CheckConflict(resp)
+		conflict := client.PatchConflictFromError(resp.Error)
+		require.NotNil(t, conflict)
+		require.NotNil(t, conflict.Block)
+		require.Equal(t, concurrentTitle, conflict.Block.Title)
+
+		blockPatch := &model.BlockPatch{
+			UpdatedFields: map[string]interface{}{"test2": "merged value"},
+			UpdateAt:      &baseUpdateAt,
+		}
+		_, resp = th.Client.PatchBlock(board.ID, blockID, blockPatch)
+		th.CheckOK(resp)
+
+		blocks, resp = th.Client.GetBlocksForBoard(board.ID)
+		require.NoError(t, resp.Error)
+		require.Len(t, blocks, 1)
+		require.Equal(t, concurrentTitle, blocks[0].Title)
+		require.Equal(t, "merged value", blocks[0].Fields["test2"])
+	})
+
+	t.Run("Patch the inputs of a formula based on an outdated version", func(t *testing.T) {
+		formulaBoard := th.CreateBoard("team-id", model.BoardTypeOpen)
+		_, resp := th.Client.PatchBoard(formulaBoard.ID, &model.BoardPatch{
+			UpdatedCardProperties: []map[string]interface{}{
+				{"id": "a", "name": "A", "type": "number"},
+				{"id": "b", "name": "B", "type": "number"},
+				{"id": "product", "name": "Product", "type": "formula", "formula": `prop("A") * prop("B")`},
+			},
+		})
+		th.CheckOK(resp)
+
+		card := model.Block{
+			ID:       utils.NewID(utils.IDTypeCard),
+			BoardID:  formulaBoard.ID,
+			Type:     model.TypeCard,
+			CreateAt: 1,
+			UpdateAt: 1,
+			Fields:   map[string]interface{}{"properties": map[string]interface{}{"a": "2", "b": "3"}},
+		}
+		inserted, resp := th.Client.InsertBlocks(formulaBoard.ID, []model.Block{card})
+		th.CheckOK(resp)
+		base := inserted[0]
+		require.Equal(t, "6", base.Fields["properties"].(map[string]interface{})["product"])
+
+		setProperty := func(id, value string) *model.BlockPatch {
+			props := map[string]interface{}{}
+			for k, v := range base.Fields["properties"].(map[string]interface{}) {
+				props[k] = v
+			}
+			props[id] = value
+			return &model.BlockPatch{
+				UpdatedFields: map[string]interface{}{"properties": props},
+				UpdateAt:      &base.UpdateAt,
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		_, resp = th.Client.PatchBlock(formulaBoard.ID, base.ID, setProperty("a", "4"))
+		th.CheckOK(resp)
+		time.Sleep(10 * time.Millisecond)
+		_, resp = th.Client.PatchBlock(formulaBoard.ID, base.ID, setProperty("b", "5"))
+		th.CheckOK(resp)
+
+		blocks, resp := th.Client.GetBlocksForBoard(formulaBoard.ID)
+		th.CheckOK(resp)
+		require.Len(t, blocks, 1)
+		require.Equal(t, map[string]interface{}{"a": "4", "b": "5", "product": "20"}, blocks[0].Fields["properties"])
+	})
 }
 
 func TestDeleteBlock(t *testing.T) {
@@ -392,3 +503,354 @@ func TestUndeleteBlock(t *testing.T) {
 		require.Len(t, blocks, initialCount)
 	})
 }
+
+func TestCardRelations(t *testing.T) {
+	th := SetupTestHelper(t).InitBasic()
+	defer th.TearDown()
+
+	projects := th.CreateBoard(testTeamID, model.BoardTypePrivate)
+	tasks := th.CreateBoard(testTeamID, model.BoardTypePrivate)
+
+	_, resp := th.Client.PatchBoard(projects.ID, &model.BoardPatch{
+		UpdatedCardProperties: []map[string]interface{}{
+			{"id": "tasks", "name": "Tasks", "type": "relation", "relation": map[string]interface{}{"boardId": tasks.ID, "backReferencePropertyId": "project"}},
+			{"id": "taskCount", "name": "Task count", "type": "rollup", "rollup": map[string]interface{}{"relationPropertyId": "tasks", "function": "count"}},
+			{"id": "total", "name": "Total", "type": "rollup", "rollup": map[string]interface{}{"relationPropertyId": "tasks", "propertyId": "estimate", "function": "sum"}},
+		},
+	})
+	th.CheckOK(resp)
+	_, resp = th.Client.PatchBoard(tasks.ID, &model.BoardPatch{
+		UpdatedCardProperties: []map[string]interface{}{
+			{"id": "project", "name": "Project", "type": "relation", "relation": map[string]interface{}{"boardId": projects.ID, "backReferencePropertyId": "tasks"}},
+			{"id": "estimate", "name": "Estimate", "type": "number"},
+		},
+	})
+	th.CheckOK(resp)
+
+	newCard := func(boardID, title string, props map[string]interface{}) model.Block {
+		return model.Block{
+			ID:       utils.NewID(utils.IDTypeCard),
+			BoardID:  boardID,
+			Type:     model.TypeCard,
+			Title:    title,
+			CreateAt: 1,
+			UpdateAt: 1,
+			Fields:   map[string]interface{}{"properties": props},
+		}
+	}
+	getCard := func(boardID, title string) model.Block {
+		blocks, resp := th.Client.GetBlocksForBoard(boardID)
+		th.CheckOK(resp)
+		for _, block := range blocks {
+			if block.Title == title {
+				return block
+			}
+		}
+		require.Failf(t, "card not found", title)
+		return model.Block{}
+	}
+	getProps := func(boardID, title string) map[string]interface{} {
+		props, _ := getCard(boardID, title).Fields["properties"].(map[string]interface{})
+		return props
+	}
+
+	inserted, resp := th.Client.InsertBlocks(projects.ID, []model.Block{newCard(projects.ID, "project", map[string]interface{}{})})
+	th.CheckOK(resp)
+	project := inserted[0]
+
+	_, resp = th.Client.InsertBlocks(tasks.ID, []model.Block{
+		newCard(tasks.ID, "task1", map[string]interface{}{"project": project.ID, "estimate": "3"}),
+		newCard(tasks.ID, "task2", map[string]interface{}{"project": []interface{}{project.ID}, "estimate": "2"}),
+		newCard(tasks.ID, "task3", map[string]interface{}{"project": []interface{}{"unknown-card-id"}}),
+	})
+	th.CheckOK(resp)
+	task1 := getCard(tasks.ID, "task1")
+	task2 := getCard(tasks.ID, "task2")
+
+	t.Run("back-references are mirrored", func(t *testing.T) {
+		props := getProps(projects.ID, "project")
+		require.ElementsMatch(t, []interface{}{task1.ID, task2.ID}, props["tasks"])
+		require.Equal(t, "2", props["taskCount"])
+		require.Equal(t, "5", props["total"])
+
+		require.Equal(t, []interface{}{project.ID}, task1.Fields["properties"].(map[string]interface{})["project"])
+		require.Equal(t, th.GetUser1().ID, getCard(projects.ID, "project").ModifiedBy)
+	})
+
+	t.Run("unknown cards are dropped", func(t *testing.T) {
+		require.NotContains(t, getProps(tasks.ID, "task3"), "project")
+	})
+
+	t.Run("rollups are refreshed when linked cards change", func(t *testing.T) {
+		_, resp := th.Client.PatchBlock(tasks.ID, task1.ID, &model.BlockPatch{
+			UpdatedFields: map[string]interface{}{"properties": map[string]interface{}{"project": []interface{}{project.ID}, "estimate": "4"}},
+		})
+		th.CheckOK(resp)
+		require.Equal(t, "6", getProps(projects.ID, "project")["total"])
+	})
+
+	t.Run("related cards are resolved for the users who can see them", func(t *testing.T) {
+		cards, resp := th.Client.GetRelatedCards(projects.ID, project.ID, "tasks")
+		th.CheckOK(resp)
+		require.Len(t, cards, 2)
+
+		_, err := th.Server.App().AddMemberToBoard(&model.BoardMember{
+			UserID:       th.GetUser2().ID,
+			BoardID:      projects.ID,
+			SchemeViewer: true,
+		})
+		require.NoError(t, err)
+		cards, resp = th.Client2.GetRelatedCards(projects.ID, project.ID, "tasks")
+		th.CheckOK(resp)
+		require.Empty(t, cards)
+
+		_, resp = th.Client.GetRelatedCards(projects.ID, project.ID, "taskCount")
+		th.CheckBadRequest(resp)
+		_, resp = th.Client.GetRelatedCards(projects.ID, "unknown-card-id", "tasks")
+		th.CheckNotFound(resp)
+	})
+
+	t.Run("relations require access to the related board", func(t *testing.T) {
+		own, resp := th.Client2.CreateBoard(&model.Board{TeamID: testTeamID, Type: model.BoardTypePrivate})
+		th.CheckOK(resp)
+
+		// user2 cannot view the tasks board.
+		_, resp = th.Client2.PatchBoard(own.ID, &model.BoardPatch{
+			UpdatedCardProperties: []map[string]interface{}{
+				{"id": "tasks", "name": "Tasks", "type": "relation", "relation": map[string]interface{}{"boardId": tasks.ID}},
+			},
+		})
+		th.CheckForbidden(resp)
+
+		// user2 can view the projects board but not edit its cards.
+		_, resp = th.Client2.PatchBoard(own.ID, &model.BoardPatch{
+			UpdatedCardProperties: []map[string]interface{}{
+				{"id": "project", "name": "Project", "type": "relation", "relation": map[string]interface{}{"boardId": projects.ID, "backReferencePropertyId": "tasks"}},
+			},
+		})
+		th.CheckForbidden(resp)
+		_, resp = th.Client2.PatchBoard(own.ID, &model.BoardPatch{
+			UpdatedCardProperties: []map[string]interface{}{
+				{"id": "project", "name": "Project", "type": "relation", "relation": map[string]interface{}{"boardId": projects.ID}},
+			},
+		})
+		th.CheckOK(resp)
+
+		_, resp = th.Client2.CreateBoard(&model.Board{
+			TeamID: testTeamID,
+			Type:   model.BoardTypePrivate,
+			CardProperties: []map[string]interface{}{
+				{"id": "tasks", "name": "Tasks", "type": "relation", "relation": map[string]interface{}{"boardId": tasks.ID}},
+			},
+		})
+		th.CheckForbidden(resp)
+	})
+
+	t.Run("deleted cards are removed from relations", func(t *testing.T) {
+		_, resp := th.Client.DeleteBlock(tasks.ID, task2.ID)
+		th.CheckOK(resp)
+
+		props := getProps(projects.ID, "project")
+		require.Equal(t, []interface{}{task1.ID}, props["tasks"])
+		require.Equal(t, "1", props["taskCount"])
+		require.Equal(t, "4", props["total"])
+		require.Equal(t, th.GetUser1().ID, getCard(projects.ID, "project").ModifiedBy)
+	})
+
+	t.Run("duplicated boards link the copied cards", func(t *testing.T) {
+		_, resp := th.Client.PatchBoard(tasks.ID, &model.BoardPatch{
+			UpdatedCardProperties: []map[string]interface{}{
+				{"id": "blockedBy", "name": "Blocked by", "type": "relation", "relation": map[string]interface{}{"boardId": tasks.ID}},
+			},
+		})
+		th.CheckOK(resp)
+		_, resp = th.Client.PatchBlock(tasks.ID, task1.ID, &model.BlockPatch{
+			UpdatedFields: map[string]interface{}{"properties": map[string]interface{}{"project": []interface{}{project.ID}, "blockedBy": []interface{}{getCard(tasks.ID, "task3").ID}}},
+		})
+		th.CheckOK(resp)
+
+		bab, resp := th.Client.DuplicateBoard(tasks.ID, false, testTeamID)
+		th.CheckOK(resp)
+		require.Len(t, bab.Boards, 1)
+
+		copies := map[string]model.Block{}
+		for _, block := range bab.Blocks {
+			copies[block.Title] = block
+		}
+		props := copies["task1"].Fields["properties"].(map[string]interface{})
+		require.Equal(t, []interface{}{copies["task3"].ID}, props["blockedBy"])
+		require.Equal(t, []interface{}{project.ID}, props["project"])
+
+		schema, err := model.ParsePropertySchema(bab.Boards[0])
+		require.NoError(t, err)
+		require.Equal(t, &model.RelationDef{BoardID: bab.Boards[0].ID}, schema["blockedBy"].Relation)
+		require.Equal(t, &model.RelationDef{BoardID: projects.ID}, schema["project"].Relation)
+
+		// the original project is not linked to the copies.
+		require.Equal(t, []interface{}{task1.ID}, getProps(projects.ID, "project")["tasks"])
+	})
+}
+
+func TestRequiredProperties(t *testing.T) {
+	th := SetupTestHelper(t).InitBasic()
+	defer th.TearDown()
+
+	board, resp := th.Client.CreateBoard(&model.Board{
+		TeamID: testTeamID,
+		Type:   model.BoardTypeOpen,
+		CardProperties: []map[string]interface{}{
+			{
+				"id":       "status",
+				"name":     "Status",
+				"type":     "select",
+				"required": true,
+				"default":  "todo",
+				"options": []interface{}{
+					map[string]interface{}{"id": "todo", "value": "To Do", "color": "propColorRed"},
+				},
+			},
+			{"id": "owner", "name": "Owner", "type": "person", "required": true},
+		},
+	})
+	th.CheckOK(resp)
+
+	newCard := func(props map[string]interface{}) model.Block {
+		return model.Block{
+			ID:       utils.NewID(utils.IDTypeCard),
+			BoardID:  board.ID,
+			Type:     model.TypeCard,
+			CreateAt: 1,
+			UpdateAt: 1,
+			Fields:   map[string]interface{}{"properties": props},
+		}
+	}
+
+	t.Run("defaults are applied and missing properties only warn", func(t *testing.T) {
+		blocks, resp := th.Client.InsertBlocks(board.ID, []model.Block{newCard(map[string]interface{}{})})
+		th.CheckOK(resp)
+		require.Len(t, blocks, 1)
+		require.Equal(t, map[string]interface{}{"status": "todo"}, blocks[0].Fields["properties"])
+
+		report, resp := th.Client.GetPropertyConformance(board.ID)
+		th.CheckOK(resp)
+		require.Equal(t, 1, report.Cards)
+		require.Equal(t, 1, report.NonConformingCards)
+		require.Len(t, report.Errors, 1)
+		require.Equal(t, "owner", report.Errors[0].PropertyID)
+		require.Equal(t, model.PropertyErrorRequired, report.Errors[0].Reason)
+	})
+
+	t.Run("missing properties are rejected", func(t *testing.T) {
+		th.Server.Config().RequiredPropertiesMode = model.RequiredPropertiesReject
+		defer func() { th.Server.Config().RequiredPropertiesMode = model.RequiredPropertiesWarn }()
+
+		_, resp := th.Client.InsertBlocks(board.ID, []model.Block{newCard(map[string]interface{}{})})
+		th.CheckBadRequest(resp)
+
+		blocks, resp := th.Client.InsertBlocks(board.ID, []model.Block{newCard(map[string]interface{}{"owner": th.GetUser1().ID})})
+		th.CheckOK(resp)
+		require.Len(t, blocks, 1)
+
+		_, resp = th.Client.PatchBlock(board.ID, blocks[0].ID, &model.BlockPatch{
+			UpdatedFields: map[string]interface{}{"properties": map[string]interface{}{"status": "todo"}},
+		})
+		th.CheckBadRequest(resp)
+	})
+}
+
+func TestChecklistItems(t *testing.T) {
+	th := SetupTestHelper(t).InitBasic()
+	defer th.TearDown()
+
+	board := th.CreateBoard(testTeamID, model.BoardTypeOpen)
+
+	cardID := utils.NewID(utils.IDTypeCard)
+	newItem := func(title string, fields map[string]interface{}) model.Block {
+		return model.Block{
+			ID:       utils.NewID(utils.IDTypeBlock),
+			BoardID:  board.ID,
+			ParentID: cardID,
+			Type:     model.TypeCheckbox,
+			Title:    title,
+			CreateAt: 1,
+			UpdateAt: 1,
+			Fields:   fields,
+		}
+	}
+	inserted, resp := th.Client.InsertBlocks(board.ID, []model.Block{
+		{ID: cardID, BoardID: board.ID, Type: model.TypeCard, Title: "card", CreateAt: 1, UpdateAt: 1},
+		newItem("done", map[string]interface{}{"value": true}),
+		newItem("mine", map[string]interface{}{"assignee": th.GetUser1().ID, "dueDate": float64(1000)}),
+		newItem("later", map[string]interface{}{}),
+	})
+	th.CheckOK(resp)
+	require.Len(t, inserted, 4)
+
+	getBlock := func(blockID string) model.Block {
+		blocks, resp := th.Client.GetBlocksForBoard(board.ID)
+		th.CheckOK(resp)
+		for _, block := range blocks {
+			if block.ID == blockID {
+				return block
+			}
+		}
+		require.Failf(t, "block not found", blockID)
+		return model.Block{}
+	}
+	card := inserted[0]
+	mine := inserted[2]
+
+	t.Run("progress", func(t *testing.T) {
+		updated := getBlock(card.ID)
+		require.Equal(t, model.ChecklistProgress{Total: 3, Checked: 1}, model.GetChecklistProgress(&updated))
+
+		_, resp := th.Client.PatchBlock(board.ID, mine.ID, &model.BlockPatch{
+			UpdatedFields: map[string]interface{}{"value": true},
+		})
+		th.CheckOK(resp)
+		updated = getBlock(card.ID)
+		require.Equal(t, model.ChecklistProgress{Total: 3, Checked: 2}, model.GetChecklistProgress(&updated))
+
+		_, resp = th.Client.PatchBlock(board.ID, mine.ID, &model.BlockPatch{
+			UpdatedFields: map[string]interface{}{"value": false},
+		})
+		th.CheckOK(resp)
+
+		_, resp = th.Client.DeleteBlock(board.ID, inserted[1].ID)
+		th.CheckOK(resp)
+		updated = getBlock(card.ID)
+		require.Equal(t, model.ChecklistProgress{Total: 2, Checked: 0}, model.GetChecklistProgress(&updated))
+
+		// the progress does not count as an update of the card.
+		require.Equal(t, card.UpdateAt, updated.UpdateAt)
+		require.Equal(t, th.GetUser1().ID, updated.ModifiedBy)
+	})
+
+	t.Run("filter cards by incomplete items", func(t *testing.T) {
+		cards, resp := th.Client.GetChecklistCards(board.ID, "", 0)
+		th.CheckOK(resp)
+		require.Len(t, cards, 1)
+		require.Equal(t, card.ID, cards[0].Card.ID)
+		require.Len(t, cards[0].Items, 2)
+
+		cards, resp = th.Client.GetChecklistCards(board.ID, "me", 2000)
+		th.CheckOK(resp)
+		require.Len(t, cards, 1)
+		require.Len(t, cards[0].Items, 1)
+		require.Equal(t, mine.ID, cards[0].Items[0].ID)
+
+		cards, resp = th.Client.GetChecklistCards(board.ID, th.GetUser2().ID, 0)
+		th.CheckOK(resp)
+		require.Empty(t, cards)
+	})
+
+	t.Run("invalid items", func(t *testing.T) {
+		_, resp := th.Client.InsertBlocks(board.ID, []model.Block{newItem("invalid", map[string]interface{}{"value": "yes"})})
+		th.CheckBadRequest(resp)
+
+		_, resp = th.Client.PatchBlock(board.ID, mine.ID, &model.BlockPatch{
+			UpdatedFields: map[string]interface{}{"dueDate": "tomorrow"},
+		})
+		th.CheckBadRequest(resp)
+	})
+}
@@ -2,6 +2,7 @@ package integrationtests
 
 import (
 	"encoding/json"
+	"fmt"
 	"sort"
 	"testing"
 	"time"
@@ -994,6 +995,45 @@ func TestPatchBoard(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, initialTitle, dbBoard.Title)
 	})
+
+	t.Run("patch on a board based on an outdated version", func(t *testing.T) {
+		th := SetupTestHelper(t).InitBasic()
+		defer th.TearDown()
+
+		user1 := th.GetUser1()
+
+		newBoard := &model.Board{
+			Title:       "title",
+			Description: "description",
+			Type:        model.BoardTypeOpen,
+			TeamID:      teamID,
+		}
+		board, err := th.Server.App().CreateBoard(newBoard, user1.ID, true)
+		require.NoError(t, err)
+		baseUpdateAt := board.UpdateAt
+
+		time.Sleep(10 * time.Millisecond)
+		concurrentTitle := "concurrent title"
+		rBoard, resp := th.Client.PatchBoard(board.ID, &model.BoardPatch{Title: &concurrentTitle, UpdateAt: &baseUpdateAt})
+		th.CheckOK(resp)
+		require.Equal(t, concurrentTitle, rBoard.Title)
+
+		time.Sleep(10 * time.Millisecond)
+		staleTitle := "stale title"
+		rBoard, resp = th.Client.PatchBoard(board.ID, &model.BoardPatch{Title: &staleTitle, UpdateAt: &baseUpdateAt})
+		th.CheckConflict(resp)
+		require.Nil(t, rBoard)
+		conflict := client.PatchConflictFromError(resp.Error)
+		require.NotNil(t, conflict)
+		require.NotNil(t, conflict.Board)
+		require.Equal(t, concurrentTitle, conflict.Board.Title)
+
+		newDescription := "new description"
+		rBoard, resp = th.Client.PatchBoard(board.ID, &model.BoardPatch{Description: &newDescription, UpdateAt: &baseUpdateAt})
+		th.CheckOK(resp)
+		require.Equal(t, concurrentTitle, rBoard.Title)
+		require.Equal(t, newDescription, rBoard.Description)
+	})
 }
 
 func TestDeleteBoard(t *testing.T) {
@@ -2012,3 +2052,303 @@ func TestJoinBoard(t *testing.T) {
 		require.Nil(t, member)
 	})
 }
+
+func TestGetViewAggregations(t *testing.T) {
+	th := SetupTestHelper(t).InitBasic()
+	defer th.TearDown()
+
+	board, resp := th.Client.CreateBoard(&model.Board{
+		TeamID: testTeamID,
+		Type:   model.BoardTypeOpen,
+		CardProperties: []map[string]interface{}{
+			{"id": "amount", "name": "Amount", "type": "currency", "currency": "USD", "precision": 2},
+			{"id": "status", "name": "Status", "type": "select", "options": []interface{}{
+				map[string]interface{}{"id": "open", "value": "Open"},
+				map[string]interface{}{"id": "paid", "value": "Paid"},
+			}},
+			{"id": "notes", "name": "Notes", "type": "text"},
+		},
+	})
+	th.CheckOK(resp)
+
+	newCard := func(props map[string]interface{}) model.Block {
+		return model.Block{
+			ID:       utils.NewID(utils.IDTypeCard),
+			BoardID:  board.ID,
+			Type:     model.TypeCard,
+			CreateAt: 1,
+			UpdateAt: 1,
+			Fields:   map[string]interface{}{"properties": props},
+		}
+	}
+	view := model.Block{
+		ID:       utils.NewID(utils.IDTypeView),
+		BoardID:  board.ID,
+		Type:     model.TypeView,
+		CreateAt: 1,
+		UpdateAt: 1,
+		Fields: map[string]interface{}{
+			"groupById": "status",
+			"filter": map[string]interface{}{
+				"operation": "and",
+				"filters": []interface{}{
+					map[string]interface{}{"propertyId": "notes", "condition": "notIncludes", "values": []interface{}{"excluded"}},
+				},
+			},
+		},
+	}
+	template := newCard(map[string]interface{}{"amount": "1000"})
+	template.Fields["isTemplate"] = true
+
+	inserted, resp := th.Client.InsertBlocks(board.ID, []model.Block{
+		view,
+		template,
+		newCard(map[string]interface{}{"amount": "0.10", "status": "open"}),
+		newCard(map[string]interface{}{"amount": "0.20", "status": "open"}),
+		newCard(map[string]interface{}{"amount": "5", "status": "paid", "notes": "late"}),
+		newCard(map[string]interface{}{"status": "paid"}),
+		newCard(map[string]interface{}{"amount": "7"}),
+		newCard(map[string]interface{}{"amount": "100", "notes": "excluded"}),
+	})
+	th.CheckOK(resp)
+	// block ids are regenerated on insert.
+	view = inserted[0]
+
+	t.Run("totals and groups", func(t *testing.T) {
+		aggregations, resp := th.Client.GetViewAggregations(board.ID, view.ID)
+		th.CheckOK(resp)
+		require.Equal(t, "status", aggregations.GroupByPropertyID)
+
+		require.Equal(t, 5, aggregations.Total.Count)
+		amount := aggregations.Total.Columns["amount"]
+		require.Equal(t, 1, amount.CountEmpty)
+		require.Equal(t, 4, amount.CountNotEmpty)
+		require.Equal(t, 12.3, *amount.Sum)
+		require.Equal(t, 3.075, *amount.Average)
+		require.Equal(t, 0.1, *amount.Min)
+		require.Equal(t, 7.0, *amount.Max)
+		require.Nil(t, aggregations.Total.Columns["notes"].Sum)
+
+		require.Len(t, aggregations.Groups, 3)
+		require.Equal(t, "open", aggregations.Groups[0].Value)
+		require.Equal(t, 2, aggregations.Groups[0].Count)
+		require.Equal(t, 0.3, *aggregations.Groups[0].Columns["amount"].Sum)
+		require.Equal(t, "paid", aggregations.Groups[1].Value)
+		require.Equal(t, 1, aggregations.Groups[1].Columns["amount"].CountEmpty)
+		require.Equal(t, "", aggregations.Groups[2].Value)
+		require.Equal(t, 7.0, *aggregations.Groups[2].Columns["amount"].Sum)
+	})
+
+	t.Run("selected properties", func(t *testing.T) {
+		aggregations, resp := th.Client.GetViewAggregations(board.ID, view.ID, "notes")
+		th.CheckOK(resp)
+		require.Len(t, aggregations.Total.Columns, 1)
+		require.Equal(t, 1, aggregations.Total.Columns["notes"].CountNotEmpty)
+
+		_, resp = th.Client.GetViewAggregations(board.ID, view.ID, "unknown")
+		th.CheckBadRequest(resp)
+	})
+
+	t.Run("unknown view", func(t *testing.T) {
+		_, resp := th.Client.GetViewAggregations(board.ID, "unknown-view")
+		th.CheckNotFound(resp)
+	})
+
+	t.Run("a user without permissions should be rejected", func(t *testing.T) {
+		_, resp := th.Client2.GetViewAggregations(board.ID, view.ID)
+		th.CheckForbidden(resp)
+	})
+}
+
+func TestGetCardsOverlappingDates(t *testing.T) {
+	th := SetupTestHelper(t).InitBasic()
+	defer th.TearDown()
+
+	board, resp := th.Client.CreateBoard(&model.Board{
+		TeamID: testTeamID,
+		Type:   model.BoardTypeOpen,
+		CardProperties: []map[string]interface{}{
+			{"id": "due", "name": "Due", "type": "date"},
+			{"id": "notes", "name": "Notes", "type": "text"},
+		},
+	})
+	th.CheckOK(resp)
+
+	now := utils.GetMillis()
+	day := int64(24 * time.Hour / time.Millisecond)
+	newCard := func(title, due string) model.Block {
+		return model.Block{
+			ID:       utils.NewID(utils.IDTypeCard),
+			BoardID:  board.ID,
+			Type:     model.TypeCard,
+			Title:    title,
+			CreateAt: 1,
+			UpdateAt: 1,
+			Fields:   map[string]interface{}{"properties": map[string]interface{}{"due": due}},
+		}
+	}
+	_, resp = th.Client.InsertBlocks(board.ID, []model.Block{
+		newCard("today", fmt.Sprintf(`{"from":%d,"includeTime":true}`, now)),
+		newCard("spanning", fmt.Sprintf(`{"from":%d,"to":%d}`, now-30*day, now+30*day)),
+		newCard("past", fmt.Sprintf(`{"from":%d}`, now-60*day)),
+	})
+	th.CheckOK(resp)
+
+	template := newCard("template", fmt.Sprintf(`{"from":%d}`, now-60*day))
+	template.Fields["isTemplate"] = true
+	_, resp = th.Client.InsertBlocks(board.ID, []model.Block{template})
+	th.CheckOK(resp)
+
+	titles := func(cards []model.Block) []string {
+		result := make([]string, 0, len(cards))
+		for _, card := range cards {
+			result = append(result, card.Title)
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	t.Run("explicit range", func(t *testing.T) {
+		cards, resp := th.Client.GetCardsOverlappingDates(board.ID, "due", now-61*day, now-59*day)
+		th.CheckOK(resp)
+		require.Equal(t, []string{"past"}, titles(cards))
+	})
+
+	t.Run("period in a timezone", func(t *testing.T) {
+		cards, resp := th.Client.GetCardsOverlappingPeriod(board.ID, "due", model.DatePeriodThisWeek, "Asia/Tokyo")
+		th.CheckOK(resp)
+		require.Equal(t, []string{"spanning", "today"}, titles(cards))
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, resp := th.Client.GetCardsOverlappingDates(board.ID, "notes", now, now+day)
+		th.CheckBadRequest(resp)
+
+		_, resp = th.Client.GetCardsOverlappingDates(board.ID, "due", now, now-day)
+		th.CheckBadRequest(resp)
+
+		_, resp = th.Client.GetCardsOverlappingPeriod(board.ID, "due", model.DatePeriodThisWeek, "Mars/Olympus")
+		th.CheckBadRequest(resp)
+	})
+}
+
+func TestPropertyMigrations(t *testing.T) {
+	th := SetupTestHelper(t).InitBasic()
+	defer th.TearDown()
+
+	board, resp := th.Client.CreateBoard(&model.Board{
+		TeamID: testTeamID,
+		Type:   model.BoardTypeOpen,
+		CardProperties: []map[string]interface{}{
+			{
+				"id":   "status",
+				"name": "Status",
+				"type": "select",
+				"options": []interface{}{
+					map[string]interface{}{"id": "todo", "value": "To Do", "color": "propColorRed"},
+					map[string]interface{}{"id": "doing", "value": "Doing", "color": "propColorBlue"},
+				},
+			},
+			{"id": "notes", "name": "Notes", "type": "text"},
+		},
+	})
+	th.CheckOK(resp)
+
+	blocks, resp := th.Client.InsertBlocks(board.ID, []model.Block{
+		{
+			ID:       utils.NewID(utils.IDTypeCard),
+			BoardID:  board.ID,
+			Type:     model.TypeCard,
+			Title:    "card",
+			CreateAt: 1,
+			UpdateAt: 1,
+			Fields: map[string]interface{}{"properties": map[string]interface{}{
+				"status": "doing",
+				"notes":  "some notes",
+			}},
+		},
+	})
+	th.CheckOK(resp)
+	require.Len(t, blocks, 1)
+	cardID := blocks[0].ID
+
+	cardValue := func(propertyID string) interface{} {
+		cards, resp := th.Client.GetBlocksForBoard(board.ID)
+		th.CheckOK(resp)
+		for _, block := range cards {
+			if block.ID == cardID {
+				props, _ := block.Fields["properties"].(map[string]interface{})
+				return props[propertyID]
+			}
+		}
+		return nil
+	}
+
+	t.Run("merge options and undo", func(t *testing.T) {
+		migration := &model.PropertyMigration{Operation: model.PropertyMigrationMergeOptions, PropertyID: "status"}
+		migration.OptionIDs = []string{"doing"}
+		migration.TargetOptionID = "todo"
+		migration, resp := th.Client.MigrateCardProperty(board.ID, migration)
+		th.CheckOK(resp)
+		require.NotEmpty(t, migration.ID)
+		require.Len(t, migration.Changes, 1)
+		require.Equal(t, "todo", cardValue("status"))
+
+		migrations, resp := th.Client.GetPropertyMigrations(board.ID)
+		th.CheckOK(resp)
+		require.Len(t, migrations, 1)
+
+		undone, resp := th.Client.UndoPropertyMigration(board.ID, migration.ID)
+		th.CheckOK(resp)
+		require.NotZero(t, undone.UndoneAt)
+		require.Equal(t, "doing", cardValue("status"))
+
+		b, resp := th.Client.GetBoard(board.ID, "")
+		th.CheckOK(resp)
+		require.Len(t, b.CardProperties[0]["options"], 2)
+
+		_, resp = th.Client.UndoPropertyMigration(board.ID, migration.ID)
+		th.CheckBadRequest(resp)
+	})
+
+	t.Run("only the latest migration can be undone", func(t *testing.T) {
+		first, resp := th.Client.MigrateCardProperty(board.ID, &model.PropertyMigration{
+			Operation:  model.PropertyMigrationDelete,
+			PropertyID: "notes",
+		})
+		th.CheckOK(resp)
+		require.Nil(t, cardValue("notes"))
+
+		rename := &model.PropertyMigration{Operation: model.PropertyMigrationRenameOption, PropertyID: "status"}
+		rename.OptionID = "todo"
+		rename.Value = "Backlog"
+		second, resp := th.Client.MigrateCardProperty(board.ID, rename)
+		th.CheckOK(resp)
+
+		_, resp = th.Client.UndoPropertyMigration(board.ID, first.ID)
+		th.CheckBadRequest(resp)
+
+		_, resp = th.Client.UndoPropertyMigration(board.ID, second.ID)
+		th.CheckOK(resp)
+		_, resp = th.Client.UndoPropertyMigration(board.ID, first.ID)
+		th.CheckOK(resp)
+		require.Equal(t, "some notes", cardValue("notes"))
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, resp := th.Client.MigrateCardProperty(board.ID, &model.PropertyMigration{
+			Operation:  model.PropertyMigrationDelete,
+			PropertyID: "unknown",
+		})
+		th.CheckBadRequest(resp)
+
+		_, resp = th.Client.UndoPropertyMigration(board.ID, "unknown")
+		th.CheckNotFound(resp)
+
+		_, resp = th.Client2.MigrateCardProperty(board.ID, &model.PropertyMigration{
+			Operation:  model.PropertyMigrationDelete,
+			PropertyID: "notes",
+		})
+		th.CheckForbidden(resp)
+	})
+}
@@ -63,4 +63,147 @@ func TestExportBoard(t *testing.T) {
 		require.Len(t, blocksImported, 1)
 		require.Equal(t, block.Title, blocksImported[0].Title)
 	})
+	t.Run("export board as markdown", func(t *testing.T) {
+		th := SetupTestHelper(t).InitBasic()
+		defer th.TearDown()
+
+		board := &model.Board{
+			ID:        utils.NewID(utils.IDTypeBoard),
+			TeamID:    "test-team",
+			Title:     "Markdown Test Board",
+			CreatedBy: th.GetUser1().ID,
+			Type:      model.BoardTypeOpen,
+			CreateAt:  utils.GetMillis(),
+			UpdateAt:  utils.GetMillis(),
+		}
+
+		block := model.Block{
+			ID:        utils.NewID(utils.IDTypeCard),
+			ParentID:  board.ID,
+			Type:      model.TypeCard,
+			BoardID:   board.ID,
+			Title:     "Markdown card",
+			CreatedBy: th.GetUser1().ID,
+			CreateAt:  utils.GetMillis(),
+			UpdateAt:  utils.GetMillis(),
+		}
+
+		babs := &model.BoardsAndBlocks{
+			Boards: []*model.Board{board},
+			Blocks: []model.Block{block},
+		}
+
+		babs, resp := th.Client.CreateBoardsAndBlocks(babs)
+		th.CheckOK(resp)
+
+		buf, resp := th.Client.ExportBoardDocument(babs.Boards[0].ID, model.ExportFormatMarkdown, "")
+		th.CheckOK(resp)
+		require.Contains(t, string(buf), "# Markdown Test Board")
+		require.Contains(t, string(buf), "## Markdown card")
+
+		_, resp = th.Client.ExportBoardDocument(babs.Boards[0].ID, "pdf", "")
+		th.CheckBadRequest(resp)
+	})
+	t.Run("export and import board membership", func(t *testing.T) {
+		th := SetupTestHelper(t).InitBasic()
+		defer th.TearDown()
+
+		user1 := th.GetUser1()
+		user2 := th.GetUser2()
+
+		board := &model.Board{
+			ID:        utils.NewID(utils.IDTypeBoard),
+			TeamID:    "test-team",
+			Title:     "Membership Test Board",
+			CreatedBy: user1.ID,
+			Type:      model.BoardTypeOpen,
+			CreateAt:  utils.GetMillis(),
+			UpdateAt:  utils.GetMillis(),
+		}
+
+		block := model.Block{
+			ID:        utils.NewID(utils.IDTypeCard),
+			ParentID:  board.ID,
+			Type:      model.TypeCard,
+			BoardID:   board.ID,
+			Title:     "Subscribed card",
+			CreatedBy: user1.ID,
+			CreateAt:  utils.GetMillis(),
+			UpdateAt:  utils.GetMillis(),
+		}
+
+		babs, resp := th.Client.CreateBoardsAndBlocks(&model.BoardsAndBlocks{
+			Boards: []*model.Board{board},
+			Blocks: []model.Block{block},
+		})
+		th.CheckOK(resp)
+		boardID := babs.Boards[0].ID
+		cardID := babs.Blocks[0].ID
+
+		_, resp = th.Client.AddMemberToBoard(&model.BoardMember{
+			BoardID:      boardID,
+			UserID:       user2.ID,
+			SchemeEditor: true,
+		})
+		th.CheckOK(resp)
+
+		category, err := th.Server.App().CreateCategory(&model.Category{
+			Name:   "Planning",
+			UserID: user2.ID,
+			TeamID: "test-team",
+		})
+		require.NoError(t, err)
+		require.NoError(t, th.Server.App().AddUpdateUserCategoryBoard("test-team", user2.ID, category.ID, boardID))
+
+		_, resp = th.Client2.CreateSubscription(&model.Subscription{
+			BlockType:      model.TypeCard,
+			BlockID:        cardID,
+			SubscriberType: model.SubTypeUser,
+			SubscriberID:   user2.ID,
+		})
+		th.CheckOK(resp)
+
+		// only board admins can export membership
+		_, resp = th.Client2.ExportBoardArchiveWithMembership(boardID)
+		th.CheckForbidden(resp)
+
+		buf, resp := th.Client.ExportBoardArchiveWithMembership(boardID)
+		th.CheckOK(resp)
+
+		importResp, resp := th.Client.ImportArchiveWithOptions(model.GlobalTeamID, bytes.NewReader(buf), true)
+		th.CheckOK(resp)
+		require.Len(t, importResp.BoardIDs, 1)
+		newBoardID := importResp.BoardIDs[boardID]
+		require.NotEmpty(t, newBoardID)
+
+		member, err := th.Server.App().GetMemberForBoard(newBoardID, user2.ID)
+		require.NoError(t, err)
+		require.True(t, member.SchemeEditor)
+		require.False(t, member.SchemeAdmin)
+
+		categories, err := th.Server.App().GetUserCategoryBoards(user2.ID, model.GlobalTeamID)
+		require.NoError(t, err)
+		var found bool
+		for _, c := range categories {
+			if c.Name == "Planning" {
+				require.Contains(t, c.BoardIDs, newBoardID)
+				found = true
+			}
+		}
+		require.True(t, found, "category not imported")
+
+		blocksImported, err := th.Server.App().GetBlocksForBoard(newBoardID)
+		require.NoError(t, err)
+		require.Len(t, blocksImported, 1)
+
+		subs, resp := th.Client2.GetSubscriptions(user2.ID)
+		th.CheckOK(resp)
+		var subscribed bool
+		for _, sub := range subs {
+			if sub.BlockID == blocksImported[0].ID {
+				subscribed = true
+			}
+		}
+		require.True(t, subscribed, "subscription not imported")
+	})
 }
@@ -2,9 +2,19 @@ package integrationtests
 
 import (
 	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
 	"testing"
 
+	"github.com/mattermost/focalboard/server/client"
 	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
 
 	"github.com/stretchr/testify/require"
 )
@@ -69,3 +79,328 @@ func TestUploadFile(t *testing.T) {
 		require.NotNil(t, file.FileID)
 	})
 }
+
+func TestAttachments(t *testing.T) {
+	const (
+		testTeamID = "team-id"
+	)
+
+	t.Run("upload records the file metadata", func(t *testing.T) {
+		th := SetupTestHelper(t).InitBasic()
+		defer th.TearDown()
+
+		testBoard := th.CreateBoard(testTeamID, model.BoardTypeOpen)
+		file, resp := th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "Report.pdf", bytes.NewBuffer([]byte("test")))
+		th.CheckOK(resp)
+		require.Equal(t, "Report.pdf", file.Name)
+		require.Equal(t, "application/pdf", file.MimeType)
+		require.Equal(t, int64(4), file.Size)
+		require.Equal(t, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", file.Checksum)
+
+		card := model.Block{ID: "card", BoardID: testBoard.ID, Type: model.TypeCard, CreateAt: 1, UpdateAt: 1}
+		attachment := model.Block{
+			ID:       "attachment",
+			BoardID:  testBoard.ID,
+			ParentID: "card",
+			Type:     model.TypeAttachment,
+			Fields:   map[string]interface{}{"fileId": file.FileID},
+			CreateAt: 1,
+			UpdateAt: 1,
+		}
+		inserted, resp := th.Client.InsertBlocks(testBoard.ID, []model.Block{card, attachment})
+		th.CheckOK(resp)
+		require.Len(t, inserted, 2)
+		require.Equal(t, "Report.pdf", inserted[1].Fields["name"])
+		require.Equal(t, "application/pdf", inserted[1].Fields["mimeType"])
+		require.EqualValues(t, 4, inserted[1].Fields["size"])
+
+		data, resp := th.Client.GetFile(testTeamID, testBoard.ID, file.FileID)
+		th.CheckOK(resp)
+		require.Equal(t, "test", string(data))
+		require.Equal(t, "application/pdf", resp.Header.Get("Content-Type"))
+		require.Equal(t, `attachment; filename=Report.pdf`, resp.Header.Get("Content-Disposition"))
+	})
+
+	t.Run("images are served inline", func(t *testing.T) {
+		th := SetupTestHelper(t).InitBasic()
+		defer th.TearDown()
+
+		testBoard := th.CreateBoard(testTeamID, model.BoardTypeOpen)
+		file, resp := th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "photo.png", bytes.NewBuffer([]byte("test")))
+		th.CheckOK(resp)
+
+		_, resp = th.Client.GetFile(testTeamID, testBoard.ID, file.FileID)
+		th.CheckOK(resp)
+		require.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+		require.Equal(t, "inline; filename="+file.FileID, resp.Header.Get("Content-Disposition"))
+	})
+
+	t.Run("svg images are downloaded", func(t *testing.T) {
+		th := SetupTestHelper(t).InitBasic()
+		defer th.TearDown()
+
+		testBoard := th.CreateBoard(testTeamID, model.BoardTypeOpen)
+		file, resp := th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "drawing.svg", bytes.NewBuffer([]byte("test")))
+		th.CheckOK(resp)
+
+		_, resp = th.Client.GetFile(testTeamID, testBoard.ID, file.FileID)
+		th.CheckOK(resp)
+		require.Equal(t, "image/svg+xml", resp.Header.Get("Content-Type"))
+		require.Equal(t, `attachment; filename=drawing.svg`, resp.Header.Get("Content-Disposition"))
+		require.Equal(t, "default-src 'none'; sandbox", resp.Header.Get("Content-Security-Policy"))
+	})
+
+	t.Run("an attachment without a file is rejected", func(t *testing.T) {
+		th := SetupTestHelper(t).InitBasic()
+		defer th.TearDown()
+
+		testBoard := th.CreateBoard(testTeamID, model.BoardTypeOpen)
+		attachment := model.Block{
+			ID:       "attachment",
+			BoardID:  testBoard.ID,
+			Type:     model.TypeAttachment,
+			Fields:   map[string]interface{}{},
+			CreateAt: 1,
+			UpdateAt: 1,
+		}
+		_, resp := th.Client.InsertBlocks(testBoard.ID, []model.Block{attachment})
+		th.CheckBadRequest(resp)
+	})
+
+	t.Run("uploads over the team quota are rejected", func(t *testing.T) {
+		th := SetupTestHelper(t).InitBasic()
+		defer th.TearDown()
+
+		testBoard := th.CreateBoard(testTeamID, model.BoardTypeOpen)
+		th.Server.Config().TeamFileQuota = 6
+
+		file, resp := th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "first.txt", bytes.NewBuffer([]byte("test")))
+		th.CheckOK(resp)
+		require.NotNil(t, file)
+
+		file, resp = th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "second.txt", bytes.NewBuffer([]byte("test")))
+		th.CheckRequestEntityTooLarge(resp)
+		require.Nil(t, file)
+
+		file, resp = th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "third.txt", bytes.NewBuffer([]byte("te")))
+		th.CheckOK(resp)
+		require.NotNil(t, file)
+
+		_, resp = th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "fourth.txt", bytes.NewBuffer([]byte("t")))
+		th.CheckRequestEntityTooLarge(resp)
+	})
+}
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestUploadValidation(t *testing.T) {
+	const (
+		testTeamID = "team-id"
+	)
+
+	t.Run("content not matching the extension is rejected", func(t *testing.T) {
+		th := SetupTestHelper(t).InitBasic()
+		defer th.TearDown()
+
+		testBoard := th.CreateBoard(testTeamID, model.BoardTypeOpen)
+		file, resp := th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "photo.png", bytes.NewBufferString("<html><script>alert(1)</script></html>"))
+		th.CheckUnsupportedMediaType(resp)
+		require.Nil(t, file)
+
+		file, resp = th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "report.pdf", bytes.NewBuffer(encodeTestPNG(t, 10, 10)))
+		th.CheckUnsupportedMediaType(resp)
+		require.Nil(t, file)
+
+		file, resp = th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "photo.png", bytes.NewBuffer(encodeTestPNG(t, 10, 10)))
+		th.CheckOK(resp)
+		require.Equal(t, "image/png", file.MimeType)
+	})
+
+	t.Run("only the allowed file types are accepted", func(t *testing.T) {
+		th := SetupTestHelper(t).InitBasic()
+		defer th.TearDown()
+
+		testBoard := th.CreateBoard(testTeamID, model.BoardTypeOpen)
+		th.Server.Config().AllowedFileTypes = []string{"image/*", "application/pdf"}
+
+		_, resp := th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "photo.png", bytes.NewBuffer(encodeTestPNG(t, 10, 10)))
+		th.CheckOK(resp)
+
+		_, resp = th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "report.pdf", bytes.NewBufferString("%PDF-1.4"))
+		th.CheckOK(resp)
+
+		file, resp := th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "page.html", bytes.NewBufferString("<html></html>"))
+		th.CheckUnsupportedMediaType(resp)
+		require.Nil(t, file)
+	})
+}
+
+func TestFileDeduplication(t *testing.T) {
+	const (
+		testTeamID = "team-id"
+	)
+
+	th := SetupTestHelper(t).InitBasic()
+	defer th.TearDown()
+
+	testBoard := th.CreateBoard(testTeamID, model.BoardTypeOpen)
+	data := encodeTestPNG(t, 10, 10)
+	first, resp := th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "first.png", bytes.NewBuffer(data))
+	th.CheckOK(resp)
+	second, resp := th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "second.png", bytes.NewBuffer(data))
+	th.CheckOK(resp)
+	require.NotEqual(t, first.FileID, second.FileID)
+	require.Equal(t, first.Checksum, second.Checksum)
+
+	// the files share one content, named after its checksum.
+	contentPath := filepath.Join(th.Server.Config().FilesPath, "content", first.Checksum[:2], first.Checksum)
+	require.FileExists(t, contentPath)
+
+	var images []model.Block
+	for i, fileID := range []string{first.FileID, second.FileID} {
+		images = append(images, model.Block{
+			ID:       fmt.Sprintf("image-%d", i),
+			BoardID:  testBoard.ID,
+			Type:     model.TypeImage,
+			Fields:   map[string]interface{}{"fileId": fileID},
+			CreateAt: 1,
+			UpdateAt: 1,
+		})
+	}
+	inserted, resp := th.Client.InsertBlocks(testBoard.ID, images)
+	th.CheckOK(resp)
+
+	// the duplicated board shares the content of the files.
+	duplicate, resp := th.Client.DuplicateBoard(testBoard.ID, false, testTeamID)
+	th.CheckOK(resp)
+	var duplicatedImages []model.Block
+	for _, block := range duplicate.Blocks {
+		if block.Type == model.TypeImage {
+			duplicatedImages = append(duplicatedImages, block)
+		}
+	}
+	require.Len(t, duplicatedImages, 2)
+	duplicatedBoardID := duplicatedImages[0].BoardID
+	for _, block := range duplicatedImages {
+		duplicatedFileID := block.Fields["fileId"].(string)
+		require.NotContains(t, []string{first.FileID, second.FileID}, duplicatedFileID)
+
+		served, getResp := th.Client.GetFile(testTeamID, duplicatedBoardID, duplicatedFileID)
+		th.CheckOK(getResp)
+		require.Equal(t, data, served)
+	}
+
+	// the files of a board cannot be read through another board.
+	_, resp = th.Client.GetFile(testTeamID, duplicatedBoardID, first.FileID)
+	require.Error(t, resp.Error)
+
+	// the content is left to the file cleanup, so that the deleted blocks can be
+	// restored with their files.
+	for _, block := range append(inserted, duplicatedImages...) {
+		_, resp = th.Client.DeleteBlock(block.BoardID, block.ID)
+		th.CheckOK(resp)
+	}
+	require.FileExists(t, contentPath)
+
+	_, resp = th.Client.UndeleteBlock(testBoard.ID, inserted[0].ID)
+	th.CheckOK(resp)
+	served, resp := th.Client.GetFile(testTeamID, testBoard.ID, first.FileID)
+	th.CheckOK(resp)
+	require.Equal(t, data, served)
+}
+
+func TestSignedFileURLs(t *testing.T) {
+	const (
+		testTeamID = "team-id"
+	)
+
+	th := SetupTestHelper(t).InitBasic()
+	defer th.TearDown()
+
+	testBoard := th.CreateBoard(testTeamID, model.BoardTypeOpen)
+	data := encodeTestPNG(t, 10, 10)
+	file, resp := th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "photo.png", bytes.NewBuffer(data))
+	th.CheckOK(resp)
+
+	fetch := func(fileURL string) (int, []byte) {
+		res, err := http.Get(fileURL) //nolint:gosec
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, body
+	}
+
+	t.Run("signing requires a secret", func(t *testing.T) {
+		_, resp := th.Client.GetFileURL(testTeamID, testBoard.ID, file.FileID)
+		th.CheckNotImplemented(resp)
+	})
+
+	th.Server.Config().Secret = "test-secret"
+	defer func() { th.Server.Config().Secret = "" }()
+
+	t.Run("a signed URL does not require a session", func(t *testing.T) {
+		fileURL, resp := th.Client.GetFileURL(testTeamID, testBoard.ID, file.FileID)
+		th.CheckOK(resp)
+		require.Greater(t, fileURL.ExpiresAt, utils.GetMillis())
+
+		status, body := fetch(fileURL.URL)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, data, body)
+	})
+
+	t.Run("a tampered URL is rejected", func(t *testing.T) {
+		fileURL, resp := th.Client.GetFileURL(testTeamID, testBoard.ID, file.FileID)
+		th.CheckOK(resp)
+
+		u, err := url.Parse(fileURL.URL)
+		require.NoError(t, err)
+		query := u.Query()
+		query.Set("expires", strconv.FormatInt(fileURL.ExpiresAt+60*60*1000, 10))
+		u.RawQuery = query.Encode()
+
+		status, _ := fetch(u.String())
+		require.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("a session is required to sign a URL", func(t *testing.T) {
+		anonymous := client.NewClient(th.Server.Config().ServerRoot, "")
+		_, resp := anonymous.GetFileURL(testTeamID, testBoard.ID, file.FileID)
+		th.CheckUnauthorized(resp)
+	})
+}
+
+func TestImageVariants(t *testing.T) {
+	const (
+		testTeamID = "team-id"
+	)
+
+	th := SetupTestHelper(t).InitBasic()
+	defer th.TearDown()
+
+	img := image.NewRGBA(image.Rect(0, 0, 1000, 500))
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	testBoard := th.CreateBoard(testTeamID, model.BoardTypeOpen)
+	file, resp := th.Client.TeamUploadNamedFile(testTeamID, testBoard.ID, "photo.png", &buf)
+	th.CheckOK(resp)
+
+	for size, width := range map[string]int{"thumbnail": 200, "preview": 800, "": 1000} {
+		data, resp := th.Client.GetFileVariant(testTeamID, testBoard.ID, file.FileID, size)
+		th.CheckOK(resp)
+		require.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+
+		config, err := png.DecodeConfig(bytes.NewReader(data))
+		require.NoError(t, err)
+		require.Equal(t, width, config.Width, size)
+		require.Equal(t, width/2, config.Height, size)
+	}
+
+	_, resp = th.Client.GetFileVariant(testTeamID, testBoard.ID, file.FileID, "huge")
+	th.CheckBadRequest(resp)
+}
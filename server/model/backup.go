@@ -0,0 +1,41 @@
+package model
+
+// BackupHealth is the health record of the scheduled backups
+// swagger:model
+type BackupHealth struct {
+	// The last time a backup was attempted
+	// required: true
+	LastRunAt int64 `json:"lastRunAt"`
+
+	// The last time a backup completed successfully
+	// required: true
+	LastSuccessAt int64 `json:"lastSuccessAt"`
+
+	// The error of the last backup, if it failed
+	// required: false
+	LastError string `json:"lastError,omitempty"`
+
+	// The name of the last successful backup
+	// required: true
+	LastBackup string `json:"lastBackup"`
+
+	// The duration of the last successful backup, in milliseconds
+	// required: true
+	Duration int64 `json:"duration"`
+
+	// The number of teams in the last successful backup
+	// required: true
+	Teams int `json:"teams"`
+
+	// The number of boards in the last successful backup
+	// required: true
+	Boards int `json:"boards"`
+
+	// The size of the last successful backup, in bytes
+	// required: true
+	Size int64 `json:"size"`
+
+	// The names of the backups kept after rotation, newest first
+	// required: true
+	Backups []string `json:"backups"`
+}
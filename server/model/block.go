@@ -94,6 +94,15 @@ type BlockPatch struct {
 	// The board id that the block belongs to
 	// required: false
 	BoardID *string `json:"boardId"`
+
+	// The update time of the version of the block the patch is based on. If the block
+	// was updated since, the patch is merged with the changes unless they overlap
+	// required: false
+	UpdateAt *int64 `json:"updateAt,omitempty"`
+
+	// The ids of the computed card properties, set by the server. Their values are
+	// not merged when the patch is rebased, they are computed again instead.
+	ComputedPropertyIDs []string `json:"-"`
 }
 
 // BlockPatchBatch is a batch of IDs and patches for modify blocks
@@ -161,6 +170,9 @@ func (p *BlockPatch) Patch(block *Block) *Block {
 		block.Title = *p.Title
 	}
 
+	if block.Fields == nil && len(p.UpdatedFields) > 0 {
+		block.Fields = make(map[string]interface{}, len(p.UpdatedFields))
+	}
 	for key, field := range p.UpdatedFields {
 		block.Fields[key] = field
 	}
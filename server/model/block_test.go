@@ -253,6 +253,40 @@ func TestGenerateBlockIDs(t *testing.T) {
 		require.Equal(t, blocks[1].ID, block4ContentOrder[1].([]interface{})[0])
 		require.Equal(t, blocks[2].ID, block4ContentOrder[1].([]interface{})[1])
 	})
+
+	t.Run("Should update relation property values", func(t *testing.T) {
+		blockID1 := utils.NewID(utils.IDTypeCard)
+		blockID2 := utils.NewID(utils.IDTypeCard)
+		boardID := utils.NewID(utils.IDTypeBoard)
+		externalID := utils.NewID(utils.IDTypeCard)
+
+		block1 := Block{
+			ID:      blockID1,
+			BoardID: boardID,
+			Type:    TypeCard,
+		}
+		block2 := Block{
+			ID:      blockID2,
+			BoardID: boardID,
+			Type:    TypeCard,
+			Fields: map[string]interface{}{
+				"properties": map[string]interface{}{
+					"parent":  blockID1,
+					"related": []interface{}{blockID1, externalID},
+					"notes":   "some notes",
+				},
+			},
+		}
+
+		blocks := GenerateBlockIDs([]Block{block1, block2}, &mlog.Logger{})
+
+		require.NotEqual(t, blockID1, blocks[0].ID)
+		props, ok := blocks[1].Fields["properties"].(map[string]interface{})
+		require.True(t, ok)
+		require.Equal(t, blocks[0].ID, props["parent"])
+		require.Equal(t, []interface{}{blocks[0].ID, externalID}, props["related"])
+		require.Equal(t, "some notes", props["notes"])
+	})
 }
 
 func TestStampModificationMetadata(t *testing.T) {
@@ -27,6 +27,15 @@ func GenerateBlockIDs(blocks []Block, logger *mlog.Logger) []Block {
 			referenceIDs[block.ParentID] = true
 		}
 
+		// relation properties reference other cards of the list.
+		if props, ok := block.Fields["properties"].(map[string]interface{}); ok {
+			for _, v := range props {
+				for _, id := range RelationIDs(v) {
+					referenceIDs[id] = true
+				}
+			}
+		}
+
 		if _, ok := block.Fields["contentOrder"]; ok {
 			contentOrder, typeOk := block.Fields["contentOrder"].([]interface{})
 			if !typeOk {
@@ -93,6 +102,10 @@ func GenerateBlockIDs(blocks []Block, logger *mlog.Logger) []Block {
 			fixFieldIDs(&blockMod, "cardOrder", getExistingOrOldID, logger)
 		}
 
+		if _, ok := blockMod.Fields["properties"]; ok {
+			fixPropertyIDs(&blockMod, newIDs)
+		}
+
 		newBlocks[i] = blockMod
 	}
 
@@ -124,3 +137,28 @@ func fixFieldIDs(block *Block, fieldName string, getExistingOrOldID func(string)
 		}
 	}
 }
+
+// fixPropertyIDs replaces the IDs of the blocks of the list in the values of
+// the properties of a block, as relation properties hold card IDs.
+func fixPropertyIDs(block *Block, newIDs map[string]string) {
+	props, ok := block.Fields["properties"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for k, v := range props {
+		switch t := v.(type) {
+		case string:
+			if newID, found := newIDs[t]; found {
+				props[k] = newID
+			}
+		case []interface{}:
+			for j := range t {
+				if id, isString := t[j].(string); isString {
+					if newID, found := newIDs[id]; found {
+						t[j] = newID
+					}
+				}
+			}
+		}
+	}
+}
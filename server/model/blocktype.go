@@ -21,6 +21,12 @@ const (
 	TypeText    = "text"
 	TypeComment = "comment"
 	TypeImage   = "image"
+
+	// TypeCheckbox is a checklist item of a card.
+	TypeCheckbox = "checkbox"
+
+	// TypeAttachment is a file of any type attached to a card.
+	TypeAttachment = "attachment"
 )
 
 func (bt BlockType) String() string {
@@ -42,6 +48,10 @@ func BlockTypeFromString(s string) (BlockType, error) {
 		return TypeComment, nil
 	case "image":
 		return TypeImage, nil
+	case "checkbox":
+		return TypeCheckbox, nil
+	case "attachment":
+		return TypeAttachment, nil
 	}
 	return TypeUnknown, ErrInvalidBlockType{s}
 }
@@ -55,7 +65,7 @@ func BlockType2IDType(blockType BlockType) utils.IDType {
 		return utils.IDTypeCard
 	case TypeView:
 		return utils.IDTypeView
-	case TypeText, TypeComment:
+	case TypeText, TypeComment, TypeCheckbox, TypeAttachment:
 		return utils.IDTypeBlock
 	}
 	return utils.IDTypeNone
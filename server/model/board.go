@@ -123,6 +123,11 @@ type BoardPatch struct {
 	// The board removed card properties
 	// required: false
 	DeletedCardProperties []string `json:"deletedCardProperties"`
+
+	// The update time of the version of the board the patch is based on. If the board
+	// was updated since, the patch is merged with the changes unless they overlap
+	// required: false
+	UpdateAt *int64 `json:"updateAt,omitempty"`
 }
 
 // BoardMember stores the information of the membership of a user on a board
@@ -233,6 +238,9 @@ func (p *BoardPatch) Patch(board *Board) *Board {
 		board.ShowDescription = *p.ShowDescription
 	}
 
+	if len(p.UpdatedProperties) != 0 && board.Properties == nil {
+		board.Properties = map[string]interface{}{}
+	}
 	for key, property := range p.UpdatedProperties {
 		board.Properties[key] = property
 	}
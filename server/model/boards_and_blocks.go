@@ -136,16 +136,22 @@ func GenerateBoardsAndBlocksIDs(bab *BoardsAndBlocks, logger *mlog.Logger) (*Boa
 		blocksByBoard[block.BoardID] = append(blocksByBoard[block.BoardID], block)
 	}
 
+	newBoardIDs := map[string]string{}
+	for _, board := range bab.Boards {
+		newBoardIDs[board.ID] = utils.NewID(utils.IDTypeBoard)
+	}
+
 	boards := []*Board{}
 	blocks := []Block{}
 	for _, board := range bab.Boards {
-		newID := utils.NewID(utils.IDTypeBoard)
+		newID := newBoardIDs[board.ID]
 		for _, block := range blocksByBoard[board.ID] {
 			block.BoardID = newID
 			blocks = append(blocks, block)
 		}
 
 		board.ID = newID
+		remapRelationBoardIDs(board, newBoardIDs)
 		boards = append(boards, board)
 	}
 
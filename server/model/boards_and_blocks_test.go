@@ -148,6 +148,50 @@ func TestGenerateBoardsAndBlocksIDs(t *testing.T) {
 		require.NotEqual(t, "block-id-3", block3.ID)
 		require.Equal(t, board2.ID, block3.BoardID)
 	})
+
+	t.Run("remaps the relation properties between the boards", func(t *testing.T) {
+		relation := func(boardID string) map[string]interface{} {
+			return map[string]interface{}{
+				"id":   "relation-to-" + boardID,
+				"type": PropTypeRelation,
+				"relation": map[string]interface{}{
+					"boardId":                 boardID,
+					"backReferencePropertyId": "back-reference",
+				},
+			}
+		}
+		bab := &BoardsAndBlocks{
+			Boards: []*Board{
+				{
+					ID:             "board-id-1",
+					Title:          "board1",
+					CardProperties: []map[string]interface{}{relation("board-id-1"), relation("board-id-2"), relation("external-board-id")},
+				},
+				{ID: "board-id-2", Title: "board2"},
+			},
+			Blocks: []Block{
+				{ID: "block-id-1", BoardID: "board-id-1", Type: TypeCard},
+			},
+		}
+
+		rBab, err := GenerateBoardsAndBlocksIDs(bab, logger)
+		require.NoError(t, err)
+
+		board1 := getBoardByTitle(rBab.Boards, "board1")
+		board2 := getBoardByTitle(rBab.Boards, "board2")
+		schema, err := ParsePropertySchema(board1)
+		require.NoError(t, err)
+
+		relations := map[string]*RelationDef{}
+		for _, def := range schema {
+			relations[def.Relation.BoardID] = def.Relation
+		}
+		require.Equal(t, &RelationDef{BoardID: board1.ID, BackReferencePropertyID: "back-reference"}, relations[board1.ID])
+		require.Equal(t, &RelationDef{BoardID: board2.ID, BackReferencePropertyID: "back-reference"}, relations[board2.ID])
+
+		// the back-reference stays with the original board.
+		require.Equal(t, &RelationDef{BoardID: "external-board-id"}, relations["external-board-id"])
+	})
 }
 
 func TestIsValidPatchBoardsAndBlocks(t *testing.T) {
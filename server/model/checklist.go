@@ -0,0 +1,160 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrInvalidChecklistItem = errors.New("invalid checklist item")
+
+// Fields of the checklist items, the text of an item is its title.
+const (
+	ChecklistItemChecked  = "value"
+	ChecklistItemAssignee = "assignee"
+	ChecklistItemDueDate  = "dueDate"
+)
+
+// ChecklistProgressField is the field of a card holding the progress of its checklist.
+const ChecklistProgressField = "checklistProgress"
+
+// ChecklistProgress summarizes the checklist items of a card
+// swagger:model
+type ChecklistProgress struct {
+	// The number of items
+	// required: true
+	Total int `json:"total"`
+
+	// The number of checked items
+	// required: true
+	Checked int `json:"checked"`
+}
+
+// ChecklistCard is a card with incomplete checklist items
+// swagger:model
+type ChecklistCard struct {
+	// The card
+	// required: true
+	Card Block `json:"card"`
+
+	// The progress of the checklist of the card
+	// required: true
+	Progress ChecklistProgress `json:"progress"`
+
+	// The incomplete items of the card matching the query
+	// required: true
+	Items []Block `json:"items"`
+}
+
+// ChecklistQuery selects the incomplete checklist items of cards.
+type ChecklistQuery struct {
+	// Assignee is the user the items are assigned to, any user if empty.
+	Assignee string
+
+	// DueBefore is the time in milliseconds the items are due before, any time if zero.
+	DueBefore int64
+}
+
+func ChecklistCardsFromJSON(data io.Reader) []ChecklistCard {
+	var cards []ChecklistCard
+	_ = json.NewDecoder(data).Decode(&cards)
+	return cards
+}
+
+// IsChecklistItemChecked returns true if a checklist item is checked.
+func IsChecklistItemChecked(item *Block) bool {
+	checked, _ := item.Fields[ChecklistItemChecked].(bool)
+	return checked
+}
+
+// GetChecklistItemAssignee returns the ID of the user a checklist item is assigned to.
+func GetChecklistItemAssignee(item *Block) string {
+	assignee, _ := item.Fields[ChecklistItemAssignee].(string)
+	return assignee
+}
+
+// GetChecklistItemDueDate returns the due date of a checklist item in milliseconds, or
+// zero if the item has no due date.
+func GetChecklistItemDueDate(item *Block) int64 {
+	dueDate, _ := item.Fields[ChecklistItemDueDate].(float64)
+	return int64(dueDate)
+}
+
+// ValidateChecklistItem checks the fields of a checklist item: the checked state is a
+// boolean, the assignee a user ID and the due date a time in milliseconds.
+func ValidateChecklistItem(item *Block) error {
+	if item.ParentID == "" {
+		return fmt.Errorf("%w: checklist item %s has no card", ErrInvalidChecklistItem, item.ID)
+	}
+	if v, ok := item.Fields[ChecklistItemChecked]; ok && v != nil {
+		if _, isBool := v.(bool); !isBool {
+			return fmt.Errorf("%w: the checked state of item %s is not a boolean", ErrInvalidChecklistItem, item.ID)
+		}
+	}
+	if v, ok := item.Fields[ChecklistItemAssignee]; ok && v != nil {
+		if _, isString := v.(string); !isString {
+			return fmt.Errorf("%w: the assignee of item %s is not a user ID", ErrInvalidChecklistItem, item.ID)
+		}
+	}
+	if v, ok := item.Fields[ChecklistItemDueDate]; ok && v != nil {
+		if dueDate, isNumber := v.(float64); !isNumber || dueDate < 0 {
+			return fmt.Errorf("%w: the due date of item %s is not a time", ErrInvalidChecklistItem, item.ID)
+		}
+	}
+	return nil
+}
+
+// Matches returns true if a checklist item is incomplete and meets the query.
+func (q ChecklistQuery) Matches(item *Block) bool {
+	if item.Type != TypeCheckbox || IsChecklistItemChecked(item) {
+		return false
+	}
+	if q.Assignee != "" && GetChecklistItemAssignee(item) != q.Assignee {
+		return false
+	}
+	if q.DueBefore != 0 {
+		dueDate := GetChecklistItemDueDate(item)
+		if dueDate == 0 || dueDate >= q.DueBefore {
+			return false
+		}
+	}
+	return true
+}
+
+// ComputeChecklistProgress returns the progress of the checklist items among blocks.
+func ComputeChecklistProgress(blocks []Block) ChecklistProgress {
+	var progress ChecklistProgress
+	for i := range blocks {
+		if blocks[i].Type != TypeCheckbox || blocks[i].DeleteAt != 0 {
+			continue
+		}
+		progress.Total++
+		if IsChecklistItemChecked(&blocks[i]) {
+			progress.Checked++
+		}
+	}
+	return progress
+}
+
+// GetChecklistProgress returns the checklist progress stored on a card.
+func GetChecklistProgress(card *Block) ChecklistProgress {
+	var progress ChecklistProgress
+	m, ok := card.Fields[ChecklistProgressField].(map[string]interface{})
+	if !ok {
+		return progress
+	}
+	total, _ := m["total"].(float64)
+	checked, _ := m["checked"].(float64)
+	progress.Total = int(total)
+	progress.Checked = int(checked)
+	return progress
+}
+
+// FieldValue returns the value stored in the fields of a card.
+func (p ChecklistProgress) FieldValue() map[string]interface{} {
+	return map[string]interface{}{
+		"total":   float64(p.Total),
+		"checked": float64(p.Checked),
+	}
+}
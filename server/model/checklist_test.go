@@ -0,0 +1,74 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecklistItems(t *testing.T) {
+	newItem := func(id string, fields map[string]interface{}) Block {
+		return Block{ID: id, ParentID: "card-id", Type: TypeCheckbox, Fields: fields}
+	}
+
+	t.Run("block type", func(t *testing.T) {
+		blockType, err := BlockTypeFromString("checkbox")
+		require.NoError(t, err)
+		require.Equal(t, BlockType(TypeCheckbox), blockType)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		item := newItem("item", map[string]interface{}{"value": true, "assignee": "user-id", "dueDate": float64(1000)})
+		require.NoError(t, ValidateChecklistItem(&item))
+
+		item = newItem("item", map[string]interface{}{})
+		require.NoError(t, ValidateChecklistItem(&item))
+
+		for _, fields := range []map[string]interface{}{
+			{"value": "yes"},
+			{"assignee": 12.0},
+			{"dueDate": "tomorrow"},
+			{"dueDate": -1.0},
+		} {
+			item = newItem("item", fields)
+			require.ErrorIs(t, ValidateChecklistItem(&item), ErrInvalidChecklistItem)
+		}
+
+		item = Block{ID: "item", Type: TypeCheckbox}
+		require.ErrorIs(t, ValidateChecklistItem(&item), ErrInvalidChecklistItem)
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		items := []Block{
+			newItem("item1", map[string]interface{}{"value": true}),
+			newItem("item2", map[string]interface{}{"value": false}),
+			newItem("item3", map[string]interface{}{}),
+			{ID: "text", Type: TypeText},
+		}
+		progress := ComputeChecklistProgress(items)
+		require.Equal(t, ChecklistProgress{Total: 3, Checked: 1}, progress)
+
+		card := &Block{Type: TypeCard, Fields: map[string]interface{}{ChecklistProgressField: progress.FieldValue()}}
+		require.Equal(t, progress, GetChecklistProgress(card))
+	})
+
+	t.Run("query", func(t *testing.T) {
+		checked := newItem("checked", map[string]interface{}{"value": true, "assignee": "user-id"})
+		assigned := newItem("assigned", map[string]interface{}{"assignee": "user-id", "dueDate": float64(1000)})
+		unassigned := newItem("unassigned", map[string]interface{}{})
+
+		all := ChecklistQuery{}
+		require.False(t, all.Matches(&checked))
+		require.True(t, all.Matches(&assigned))
+		require.True(t, all.Matches(&unassigned))
+
+		mine := ChecklistQuery{Assignee: "user-id"}
+		require.True(t, mine.Matches(&assigned))
+		require.False(t, mine.Matches(&unassigned))
+
+		overdue := ChecklistQuery{DueBefore: 2000}
+		require.True(t, overdue.Matches(&assigned))
+		require.False(t, overdue.Matches(&unassigned))
+		require.False(t, ChecklistQuery{DueBefore: 1000}.Matches(&assigned))
+	})
+}
@@ -0,0 +1,211 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// UserPropTimezone is the user prop holding the IANA timezone of the user.
+const UserPropTimezone = "focalboard_timezone"
+
+const (
+	dateFormat     = "January 02, 2006"
+	dateTimeFormat = "January 02, 2006 3:04 PM MST"
+	dayMillis      = int64(24 * time.Hour / time.Millisecond)
+)
+
+var ErrInvalidTimezone = errors.New("invalid timezone")
+
+// DateValue is the value of a date property, a JSON snippet of the form
+// {"from":1642161600000, "to":1642248000000, "includeTime":true, "timezone":"Europe/Paris"}.
+// Timestamps are in milliseconds UTC. "to" is set for date ranges only.
+// Without "includeTime" the value is a whole day, or a range of whole days.
+type DateValue struct {
+	From        int64  `json:"from"`
+	To          int64  `json:"to,omitempty"`
+	IncludeTime bool   `json:"includeTime,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+}
+
+// ParseDateValue parses and validates the value of a date property.
+func ParseDateValue(s string) (*DateValue, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return nil, err
+	}
+	if _, ok := m["from"]; !ok {
+		return nil, ErrInvalidDate
+	}
+
+	var dv DateValue
+	if err := json.Unmarshal([]byte(s), &dv); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDate, err)
+	}
+	if dv.To != 0 && dv.To < dv.From {
+		return nil, fmt.Errorf("%w: range ends before it starts", ErrInvalidDate)
+	}
+	if _, err := LoadLocation(dv.Timezone); err != nil {
+		return nil, err
+	}
+	return &dv, nil
+}
+
+// LoadLocation returns the location of an IANA timezone name, or UTC for an empty name.
+func LoadLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTimezone, timezone)
+	}
+	return loc, nil
+}
+
+// IsRange returns true if the value is a date range.
+func (dv *DateValue) IsRange() bool {
+	return dv.To != 0
+}
+
+// Location returns the location a value is rendered in: the timezone of the value
+// if it has one, otherwise the reader's location for values with a time of day.
+// Whole days are rendered in UTC so they never shift to another day.
+func (dv *DateValue) Location(reader *time.Location) *time.Location {
+	if dv.Timezone != "" {
+		if loc, err := LoadLocation(dv.Timezone); err == nil {
+			return loc
+		}
+	}
+	if dv.IncludeTime && reader != nil {
+		return reader
+	}
+	return time.UTC
+}
+
+// Format renders the value for a reader in the given location, which may be nil.
+func (dv *DateValue) Format(reader *time.Location) string {
+	loc := dv.Location(reader)
+	layout := dateFormat
+	if dv.IncludeTime {
+		layout = dateTimeFormat
+	}
+
+	s := millisToTime(dv.From, loc).Format(layout)
+	if dv.IsRange() {
+		s += " -> " + millisToTime(dv.To, loc).Format(layout)
+	}
+	return s
+}
+
+// Bounds returns the interval covered by the value in milliseconds, end excluded.
+// Whole days cover the entire day in the location of the value.
+func (dv *DateValue) Bounds() (int64, int64) {
+	end := dv.From
+	if dv.IsRange() {
+		end = dv.To
+	}
+	if dv.IncludeTime {
+		return dv.From, end + 1
+	}
+
+	loc := dv.Location(nil)
+	return startOfDay(dv.From, loc), startOfDay(end, loc) + dayMillis
+}
+
+// Overlaps returns true if the value overlaps the interval [start, end[ in milliseconds.
+func (dv *DateValue) Overlaps(start, end int64) bool {
+	from, to := dv.Bounds()
+	return from < end && start < to
+}
+
+func startOfDay(millis int64, loc *time.Location) int64 {
+	t := millisToTime(millis, loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return day.UnixNano() / int64(time.Millisecond)
+}
+
+func millisToTime(millis int64, loc *time.Location) time.Time {
+	return time.Unix(0, millis*int64(time.Millisecond)).In(loc)
+}
+
+// Periods of date range queries.
+const (
+	DatePeriodToday     = "today"
+	DatePeriodThisWeek  = "thisWeek"
+	DatePeriodThisMonth = "thisMonth"
+)
+
+var ErrInvalidDatePeriod = errors.New("invalid date period")
+
+// DatePeriodBounds returns the interval [start, end[ in milliseconds of a period
+// containing now in the given location. Weeks start on Monday.
+func DatePeriodBounds(period string, now time.Time, loc *time.Location) (int64, int64, error) {
+	now = now.In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	var end time.Time
+
+	switch period {
+	case DatePeriodToday:
+		end = start.AddDate(0, 0, 1)
+	case DatePeriodThisWeek:
+		offset := (int(start.Weekday()) + 6) % 7
+		start = start.AddDate(0, 0, -offset)
+		end = start.AddDate(0, 0, 7)
+	case DatePeriodThisMonth:
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+		end = start.AddDate(0, 1, 0)
+	default:
+		return 0, 0, fmt.Errorf("%w: %s", ErrInvalidDatePeriod, period)
+	}
+	return start.UnixNano() / int64(time.Millisecond), end.UnixNano() / int64(time.Millisecond), nil
+}
+
+// UserLocation returns the location of the timezone in the props of a user, or nil
+// if the user has none.
+func UserLocation(user *User) *time.Location {
+	if user == nil {
+		return nil
+	}
+	timezone, ok := user.Props[UserPropTimezone].(string)
+	if !ok || timezone == "" {
+		return nil
+	}
+	loc, err := LoadLocation(timezone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// PropValueLocator is implemented by the PropValueResolvers that render dates in the
+// location of a reader.
+type PropValueLocator interface {
+	Location() *time.Location
+}
+
+type locatedResolver struct {
+	PropValueResolver
+	loc *time.Location
+}
+
+func (r locatedResolver) Location() *time.Location {
+	return r.loc
+}
+
+func (r locatedResolver) GetUserByID(userID string) (*User, error) {
+	if r.PropValueResolver == nil {
+		return nil, nil
+	}
+	return r.PropValueResolver.GetUserByID(userID)
+}
+
+// ResolverWithLocation returns a PropValueResolver that renders dates in the given
+// location, or the resolver itself if loc is nil.
+func ResolverWithLocation(resolver PropValueResolver, loc *time.Location) PropValueResolver {
+	if loc == nil {
+		return resolver
+	}
+	return locatedResolver{PropValueResolver: resolver, loc: loc}
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDateValue(t *testing.T) {
+	// 2022-01-14 12:00 UTC, a Friday.
+	const noon = int64(1642161600000)
+	const hour = int64(3600 * 1000)
+
+	t.Run("parse", func(t *testing.T) {
+		dv, err := ParseDateValue(`{"from":1642161600000,"to":1642248000000,"includeTime":true,"timezone":"Europe/Paris"}`)
+		require.NoError(t, err)
+		assert.Equal(t, &DateValue{From: noon, To: noon + 24*hour, IncludeTime: true, Timezone: "Europe/Paris"}, dv)
+		assert.True(t, dv.IsRange())
+
+		_, err = ParseDateValue(`{"to":1642161600000}`)
+		require.ErrorIs(t, err, ErrInvalidDate)
+		_, err = ParseDateValue(`{"from":1642161600000,"to":1}`)
+		require.ErrorIs(t, err, ErrInvalidDate)
+		_, err = ParseDateValue(`{"from":1642161600000,"timezone":"Mars/Olympus"}`)
+		require.ErrorIs(t, err, ErrInvalidTimezone)
+	})
+
+	t.Run("format", func(t *testing.T) {
+		tokyo, err := LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+
+		testCases := []struct {
+			name     string
+			value    DateValue
+			reader   *time.Location
+			expected string
+		}{
+			{name: "whole day", value: DateValue{From: noon}, reader: tokyo, expected: "January 14, 2022"},
+			{name: "range of days", value: DateValue{From: noon, To: noon + 48*hour}, expected: "January 14, 2022 -> January 16, 2022"},
+			{name: "time in UTC", value: DateValue{From: noon, IncludeTime: true}, expected: "January 14, 2022 12:00 PM UTC"},
+			{name: "time for a reader", value: DateValue{From: noon, IncludeTime: true}, reader: tokyo, expected: "January 14, 2022 9:00 PM JST"},
+			{
+				name:     "time with a timezone",
+				value:    DateValue{From: noon, To: noon + hour, IncludeTime: true, Timezone: "America/New_York"},
+				reader:   tokyo,
+				expected: "January 14, 2022 7:00 AM EST -> January 14, 2022 8:00 AM EST",
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				assert.Equal(t, tc.expected, tc.value.Format(tc.reader))
+			})
+		}
+	})
+
+	t.Run("overlaps", func(t *testing.T) {
+		day := DateValue{From: noon}
+		assert.True(t, day.Overlaps(noon-11*hour, noon-10*hour))
+		assert.False(t, day.Overlaps(noon+12*hour, noon+13*hour))
+
+		meeting := DateValue{From: noon, To: noon + hour, IncludeTime: true}
+		assert.True(t, meeting.Overlaps(noon+hour/2, noon+2*hour))
+		assert.False(t, meeting.Overlaps(noon+2*hour, noon+3*hour))
+	})
+
+	t.Run("periods", func(t *testing.T) {
+		now := time.Unix(0, noon*int64(time.Millisecond))
+		start, end, err := DatePeriodBounds(DatePeriodThisWeek, now, time.UTC)
+		require.NoError(t, err)
+		// Monday 2022-01-10 to Monday 2022-01-17.
+		assert.Equal(t, int64(1641772800000), start)
+		assert.Equal(t, int64(1642377600000), end)
+
+		start, end, err = DatePeriodBounds(DatePeriodToday, now, time.UTC)
+		require.NoError(t, err)
+		assert.Equal(t, 24*hour, end-start)
+
+		_, _, err = DatePeriodBounds("someday", now, time.UTC)
+		require.ErrorIs(t, err, ErrInvalidDatePeriod)
+	})
+
+	t.Run("property value in the reader location", func(t *testing.T) {
+		tokyo, err := LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+		def := PropDef{Type: "date"}
+
+		value, err := def.GetValue(`{"from":1642161600000,"includeTime":true}`, ResolverWithLocation(nil, tokyo))
+		require.NoError(t, err)
+		assert.Equal(t, "January 14, 2022 9:00 PM JST", value)
+
+		assert.Equal(t, tokyo, UserLocation(&User{Props: map[string]interface{}{UserPropTimezone: "Asia/Tokyo"}}))
+		assert.Nil(t, UserLocation(&User{Props: map[string]interface{}{UserPropTimezone: "nowhere"}}))
+	})
+}
@@ -0,0 +1,41 @@
+package model
+
+// FileCleanupReport is the result of a sweep of the files no block references
+// swagger:model
+type FileCleanupReport struct {
+	// The time the sweep started
+	// required: true
+	RunAt int64 `json:"runAt"`
+
+	// The duration of the sweep, in milliseconds
+	// required: true
+	Duration int64 `json:"duration"`
+
+	// The error that stopped the sweep, if any
+	// required: false
+	Error string `json:"error,omitempty"`
+
+	// The number of files checked
+	// required: true
+	Scanned int `json:"scanned"`
+
+	// The number of files moved to the quarantine
+	// required: true
+	Quarantined int `json:"quarantined"`
+
+	// The size of the files moved to the quarantine, in bytes
+	// required: true
+	QuarantinedBytes int64 `json:"quarantinedBytes"`
+
+	// The number of files restored from the quarantine as they are referenced again
+	// required: true
+	Restored int `json:"restored"`
+
+	// The number of files deleted after their grace period in the quarantine
+	// required: true
+	Deleted int `json:"deleted"`
+
+	// The size of the deleted files, in bytes
+	// required: true
+	ReclaimedBytes int64 `json:"reclaimedBytes"`
+}
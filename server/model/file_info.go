@@ -0,0 +1,171 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	ErrTeamFileQuotaExceeded  = errors.New("team file quota exceeded")
+	ErrInvalidAttachmentBlock = errors.New("invalid attachment block")
+	ErrInvalidFileVariant     = errors.New("invalid file variant")
+	ErrFileTypeNotAllowed     = errors.New("file type not allowed")
+	ErrFileTypeMismatch       = errors.New("file content does not match its extension")
+	ErrFileInfected           = errors.New("file infected")
+	ErrFileURLSigningDisabled = errors.New("file URL signing disabled")
+	ErrInvalidFileURL         = errors.New("invalid file URL signature")
+	ErrFileURLExpired         = errors.New("file URL expired")
+)
+
+// TeamSettingAllowedFileTypes is the team setting listing the MIME types the files
+// uploaded to the team can have, like "image/png" or "image/*". It overrides the
+// AllowedFileTypes setting of the server.
+const TeamSettingAllowedFileTypes = "allowedFileTypes"
+
+// Variants of the uploaded images, resized to fit a width in pixels. They are generated
+// the first time they are requested and kept next to the original file.
+const (
+	FileVariantThumbnail = "thumbnail"
+	FileVariantPreview   = "preview"
+)
+
+// FileVariantWidths are the widths in pixels of the image variants.
+var FileVariantWidths = map[string]int{
+	FileVariantThumbnail: 200,
+	FileVariantPreview:   800,
+}
+
+// Fields of the attachment blocks, the file metadata is copied from the
+// file info of the uploaded file.
+const (
+	AttachmentFileID   = "fileId"
+	AttachmentName     = "name"
+	AttachmentMimeType = "mimeType"
+	AttachmentSize     = "size"
+	AttachmentChecksum = "checksum"
+)
+
+// FileInfo is the metadata of an uploaded file
+// swagger:model
+type FileInfo struct {
+	// The ID of the file, which is its name in the files storage
+	// required: true
+	ID string `json:"id"`
+
+	// The ID of the team the file belongs to
+	// required: true
+	TeamID string `json:"teamId"`
+
+	// The ID of the board the file was uploaded to
+	// required: true
+	BoardID string `json:"boardId"`
+
+	// The original name of the file
+	// required: true
+	Name string `json:"name"`
+
+	// The MIME type of the file
+	// required: true
+	MimeType string `json:"mimeType"`
+
+	// The size of the file in bytes
+	// required: true
+	Size int64 `json:"size"`
+
+	// The SHA-256 checksum of the file content, hex encoded
+	// required: true
+	Checksum string `json:"checksum"`
+
+	// The ID of the user who uploaded the file
+	// required: true
+	CreatedBy string `json:"createdBy"`
+
+	// The creation time in milliseconds since the current epoch
+	// required: true
+	CreateAt int64 `json:"createAt"`
+
+	// The deleted time in milliseconds since the current epoch, zero if not deleted
+	// required: true
+	DeleteAt int64 `json:"deleteAt"`
+}
+
+func FileInfoFromJSON(data io.Reader) (*FileInfo, error) {
+	var info FileInfo
+	if err := json.NewDecoder(data).Decode(&info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// inlineMimeTypes are the raster image types displayed inline. The other types,
+// including SVG images that can run scripts, are downloaded as attachments.
+var inlineMimeTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+	"image/bmp":  true,
+}
+
+// IsInlineMimeType returns true if files of a MIME type can be displayed inline.
+func IsInlineMimeType(mimeType string) bool {
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return inlineMimeTypes[strings.ToLower(strings.TrimSpace(mimeType))]
+}
+
+// IsImage returns true if the file is an image browsers can display.
+func (fi *FileInfo) IsImage() bool {
+	return IsInlineMimeType(fi.MimeType)
+}
+
+// AttachmentFields returns the fields of an attachment block for the file.
+func (fi *FileInfo) AttachmentFields() map[string]interface{} {
+	return map[string]interface{}{
+		AttachmentFileID:   fi.ID,
+		AttachmentName:     fi.Name,
+		AttachmentMimeType: fi.MimeType,
+		AttachmentSize:     float64(fi.Size),
+		AttachmentChecksum: fi.Checksum,
+	}
+}
+
+// BlockFileID returns the ID of the file of an image or attachment block, and false
+// if the block has no file.
+func BlockFileID(block *Block) (string, bool) {
+	if block.Type != TypeImage && block.Type != TypeAttachment {
+		return "", false
+	}
+	fileID, ok := block.Fields[AttachmentFileID].(string)
+	if !ok || fileID == "" {
+		return "", false
+	}
+	return fileID, true
+}
+
+// GetAttachmentName returns the original name of the file of an attachment block, or
+// its file ID if the name is unknown.
+func GetAttachmentName(block *Block) string {
+	if name, ok := block.Fields[AttachmentName].(string); ok && name != "" {
+		return name
+	}
+	fileID, _ := BlockFileID(block)
+	return fileID
+}
+
+// ValidateAttachmentBlock checks that an attachment block references a file.
+func ValidateAttachmentBlock(block *Block) error {
+	if _, ok := BlockFileID(block); !ok {
+		return fmt.Errorf("%w: attachment %s has no file", ErrInvalidAttachmentBlock, block.ID)
+	}
+	if v, ok := block.Fields[AttachmentSize]; ok && v != nil {
+		if size, isNumber := v.(float64); !isNumber || size < 0 {
+			return fmt.Errorf("%w: the size of attachment %s is not a number of bytes", ErrInvalidAttachmentBlock, block.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentBlocks(t *testing.T) {
+	t.Run("block type", func(t *testing.T) {
+		blockType, err := BlockTypeFromString("attachment")
+		require.NoError(t, err)
+		require.Equal(t, BlockType(TypeAttachment), blockType)
+	})
+
+	t.Run("file ID", func(t *testing.T) {
+		image := &Block{Type: TypeImage, Fields: map[string]interface{}{"fileId": "7abc.png"}}
+		fileID, ok := BlockFileID(image)
+		require.True(t, ok)
+		require.Equal(t, "7abc.png", fileID)
+
+		attachment := &Block{Type: TypeAttachment, Fields: map[string]interface{}{"fileId": "7def.pdf"}}
+		fileID, ok = BlockFileID(attachment)
+		require.True(t, ok)
+		require.Equal(t, "7def.pdf", fileID)
+
+		text := &Block{Type: TypeText, Fields: map[string]interface{}{"fileId": "7abc.png"}}
+		_, ok = BlockFileID(text)
+		require.False(t, ok)
+
+		_, ok = BlockFileID(&Block{Type: TypeAttachment})
+		require.False(t, ok)
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		fileInfo := &FileInfo{ID: "7def.pdf", Name: "report.pdf", MimeType: "application/pdf", Size: 1024, Checksum: "abc"}
+		attachment := &Block{ID: "attachment", Type: TypeAttachment, Fields: fileInfo.AttachmentFields()}
+		require.NoError(t, ValidateAttachmentBlock(attachment))
+		require.Equal(t, "report.pdf", GetAttachmentName(attachment))
+		require.False(t, fileInfo.IsImage())
+
+		delete(attachment.Fields, AttachmentName)
+		require.Equal(t, "7def.pdf", GetAttachmentName(attachment))
+	})
+
+	t.Run("inline types", func(t *testing.T) {
+		require.True(t, IsInlineMimeType("image/png"))
+		require.True(t, IsInlineMimeType("Image/JPEG; charset=binary"))
+		require.False(t, IsInlineMimeType("image/svg+xml"))
+		require.False(t, IsInlineMimeType("text/html"))
+		require.False(t, IsInlineMimeType(""))
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		for _, fields := range []map[string]interface{}{
+			{},
+			{"fileId": ""},
+			{"fileId": 12.0},
+			{"fileId": "7def.pdf", "size": "large"},
+			{"fileId": "7def.pdf", "size": -1.0},
+		} {
+			attachment := &Block{ID: "attachment", Type: TypeAttachment, Fields: fields}
+			require.ErrorIs(t, ValidateAttachmentBlock(attachment), ErrInvalidAttachmentBlock)
+		}
+	})
+}
@@ -4,10 +4,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"time"
 )
 
 var (
-	ErrInvalidImageBlock = errors.New("invalid image block")
+	ErrInvalidImageBlock       = errors.New("invalid image block")
+	ErrUnsupportedExportFormat = errors.New("unsupported export format")
+	ErrInvalidGroupByProperty  = errors.New("group by property must be a select property")
+	ErrMissingArchiveManifest  = errors.New("archive manifest missing")
+)
+
+const (
+	ExportFormatMarkdown = "markdown"
+	ExportFormatHTML     = "html"
 )
 
 // Archive is an import / export archive.
@@ -24,12 +34,80 @@ type ArchiveHeader struct {
 	Date    int64 `json:"date"`
 }
 
+// ArchiveManifest is the content of the `manifest.json` file within an archive.
+// It lists the boards in the archive and the size and SHA-256 hash of every
+// other file, allowing the archive to be verified before it is imported.
+type ArchiveManifest struct {
+	Version  int                    `json:"version"`
+	Date     int64                  `json:"date"`
+	Exporter ArchiveExporter        `json:"exporter"`
+	Boards   []ArchiveManifestBoard `json:"boards"`
+	Files    []ArchiveManifestFile  `json:"files"`
+}
+
+// ArchiveExporter describes the server and user that created an archive.
+type ArchiveExporter struct {
+	UserID      string `json:"userId,omitempty"`
+	Version     string `json:"version"`
+	BuildNumber string `json:"buildNumber,omitempty"`
+	Edition     string `json:"edition,omitempty"`
+}
+
+// ArchiveManifestBoard lists a board within an archive and the number of
+// records of each kind exported with it.
+type ArchiveManifestBoard struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	Blocks     int    `json:"blocks"`
+	Members    int    `json:"members"`
+	Categories int    `json:"categories"`
+}
+
+// ArchiveManifestFile is a file within an archive, identified by its path
+// within the zip.
+type ArchiveManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
 // ArchiveLine is any line in an archive.
 type ArchiveLine struct {
 	Type string          `json:"type"`
 	Data json.RawMessage `json:"data"`
 }
 
+// ArchiveUser identifies a user within an archive. Users are matched by
+// username or email when an archive is imported into another server.
+type ArchiveUser struct {
+	UserID   string `json:"userId"`
+	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+}
+
+// ArchiveMember is a board membership within an archive.
+type ArchiveMember struct {
+	ArchiveUser
+	Roles           string `json:"roles"`
+	SchemeAdmin     bool   `json:"schemeAdmin"`
+	SchemeEditor    bool   `json:"schemeEditor"`
+	SchemeCommenter bool   `json:"schemeCommenter"`
+	SchemeViewer    bool   `json:"schemeViewer"`
+}
+
+// ArchiveCategory is the sidebar category a user placed an archived board in.
+type ArchiveCategory struct {
+	ArchiveUser
+	Name string `json:"name"`
+}
+
+// ArchiveSubscription is a user's subscription to an archived board or card.
+type ArchiveSubscription struct {
+	ArchiveUser
+	BlockType BlockType `json:"blockType"`
+	BlockID   string    `json:"blockId"`
+}
+
 // ExportArchiveOptions provides options when exporting one or more boards
 // to an archive.
 type ExportArchiveOptions struct {
@@ -38,6 +116,33 @@ type ExportArchiveOptions struct {
 	// BoardIDs is the list of boards to include in the archive.
 	// Empty slice means export all boards from workspace/team.
 	BoardIDs []string
+
+	// ExportedBy is the id of the user creating the archive, recorded in
+	// the archive manifest.
+	ExportedBy string
+
+	// IncludeMembership adds board members, their sidebar categories and
+	// card subscriptions to the archive.
+	IncludeMembership bool
+}
+
+// ExportDocumentOptions provides options when exporting a board as a
+// human-readable Markdown or HTML document.
+type ExportDocumentOptions struct {
+	TeamID string
+
+	// Format is either ExportFormatMarkdown or ExportFormatHTML.
+	Format string
+
+	// GroupByPropertyID is the id of a select property used to group cards.
+	// Empty string means cards are not grouped.
+	GroupByPropertyID string
+
+	// EmbedImages inlines images as data URIs instead of linking to the server.
+	EmbedImages bool
+
+	// Location is the location dates with a time of day are rendered in, nil for UTC.
+	Location *time.Location
 }
 
 // ImportArchiveOptions provides options when importing an archive.
@@ -46,6 +151,25 @@ type ImportArchiveOptions struct {
 	ModifiedBy    string
 	BoardModifier BoardModifier
 	BlockModifier BlockModifier
+
+	// ImportMembership restores board members, sidebar categories and
+	// subscriptions found in the archive. Users are matched by username or
+	// email; entries for users that do not exist on this server are skipped.
+	ImportMembership bool
+}
+
+// ImportArchiveResponse is the response to an archive import.
+// swagger:model
+type ImportArchiveResponse struct {
+	// Maps the ids of the boards in the archive to the ids of the imported boards
+	// required: true
+	BoardIDs map[string]string `json:"boardIds"`
+}
+
+func ImportArchiveResponseFromJSON(data io.Reader) *ImportArchiveResponse {
+	var resp *ImportArchiveResponse
+	_ = json.NewDecoder(data).Decode(&resp)
+	return resp
 }
 
 // ErrUnsupportedArchiveVersion is an error returned when trying to import an
@@ -67,6 +191,23 @@ func (e ErrUnsupportedArchiveVersion) Error() string {
 	return fmt.Sprintf("unsupported archive version; got %d, want %d", e.got, e.want)
 }
 
+// ErrArchiveChecksumMismatch is an error returned when a file within an
+// archive does not match the size or hash listed in the archive manifest.
+type ErrArchiveChecksumMismatch struct {
+	path string
+}
+
+// NewErrArchiveChecksumMismatch creates a ErrArchiveChecksumMismatch error.
+func NewErrArchiveChecksumMismatch(path string) ErrArchiveChecksumMismatch {
+	return ErrArchiveChecksumMismatch{
+		path: path,
+	}
+}
+
+func (e ErrArchiveChecksumMismatch) Error() string {
+	return fmt.Sprintf("archive file %s does not match manifest", e.path)
+}
+
 // ErrUnsupportedArchiveLineType is an error returned when trying to import an
 // archive containing an unsupported line type.
 type ErrUnsupportedArchiveLineType struct {
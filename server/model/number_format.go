@@ -0,0 +1,91 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const maxNumberPrecision = 10
+
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"CAD": "CA$",
+	"AUD": "A$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"CNY": "CN¥",
+	"INR": "₹",
+	"KRW": "₩",
+	"BRL": "R$",
+}
+
+// FormatNumber formats the value of a numeric property according to its
+// precision, unit and currency.
+func (pd PropDef) FormatNumber(s string) (string, error) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return s, fmt.Errorf("%w: %s", ErrInvalidPropertyValue, err)
+	}
+	return pd.formatFloat(n), nil
+}
+
+func (pd PropDef) formatFloat(n float64) string {
+	precision := -1
+	if pd.Precision != nil {
+		precision = *pd.Precision
+		if precision < 0 {
+			precision = 0
+		}
+		if precision > maxNumberPrecision {
+			precision = maxNumberPrecision
+		}
+	} else if pd.Type == PropTypeCurrency && pd.Currency != "JPY" && pd.Currency != "KRW" {
+		precision = 2
+	}
+
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = math.Abs(n)
+	}
+	digits := strconv.FormatFloat(n, 'f', precision, 64)
+
+	var formatted string
+	switch pd.Type {
+	case PropTypeCurrency:
+		symbol, ok := currencySymbols[strings.ToUpper(pd.Currency)]
+		if !ok && pd.Currency != "" {
+			symbol = strings.ToUpper(pd.Currency) + " "
+		}
+		formatted = sign + symbol + groupThousands(digits)
+	case PropTypePercentage:
+		formatted = sign + digits + "%"
+	default:
+		formatted = sign + digits
+	}
+
+	if pd.Unit != "" {
+		formatted += " " + pd.Unit
+	}
+	return formatted
+}
+
+// groupThousands inserts thousands separators in the integer part of a formatted number.
+func groupThousands(digits string) string {
+	intPart, fracPart := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		intPart, fracPart = digits[:i], digits[i:]
+	}
+
+	var sb strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String() + fracPart
+}
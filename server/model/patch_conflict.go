@@ -0,0 +1,287 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+)
+
+const propertiesField = "properties"
+
+// ErrPatchConflict is returned when a patch based on an outdated version of a block or a
+// board changes some of the fields that were changed since that version. It carries the
+// current version, so that the client can merge its changes and patch it again.
+type ErrPatchConflict struct {
+	Block *Block
+	Board *Board
+}
+
+func (e *ErrPatchConflict) Error() string {
+	if e.Block != nil {
+		return fmt.Sprintf("patch conflicts with version %d of block %s", e.Block.UpdateAt, e.Block.ID)
+	}
+	if e.Board != nil {
+		return fmt.Sprintf("patch conflicts with version %d of board %s", e.Board.UpdateAt, e.Board.ID)
+	}
+	return "patch conflicts with a newer version"
+}
+
+// PatchConflictResponse is an error response including the current version of the
+// patched block or board
+// swagger:model
+type PatchConflictResponse struct {
+	ErrorResponse
+
+	// The current version of the block
+	// required: false
+	Block *Block `json:"block,omitempty"`
+
+	// The current version of the board
+	// required: false
+	Board *Board `json:"board,omitempty"`
+}
+
+// Rebase returns the patch to apply to the current version of a block, for a patch based
+// on an older version of it. Only the changes of the patch are kept, so that the fields
+// changed since the base version are preserved, and the card properties are merged one
+// by one, except the computed ones that keep their current value. It returns false if
+// the patch changes a field that was changed since the base version.
+func (p *BlockPatch) Rebase(base, current *Block) (*BlockPatch, bool) {
+	changed := blockChanges(base, current)
+	rebased := &BlockPatch{}
+
+	conflicts := false
+	rebaseField := func(name string, patchValue, baseValue interface{}) bool {
+		if reflect.DeepEqual(patchValue, baseValue) {
+			return false
+		}
+		conflicts = conflicts || changed[name]
+		return true
+	}
+
+	if p.ParentID != nil && rebaseField("parentId", *p.ParentID, base.ParentID) {
+		rebased.ParentID = p.ParentID
+	}
+	if p.BoardID != nil && rebaseField("boardId", *p.BoardID, base.BoardID) {
+		rebased.BoardID = p.BoardID
+	}
+	if p.Schema != nil && rebaseField("schema", *p.Schema, base.Schema) {
+		rebased.Schema = p.Schema
+	}
+	if p.Type != nil && rebaseField("type", *p.Type, base.Type) {
+		rebased.Type = p.Type
+	}
+	if p.Title != nil && rebaseField("title", *p.Title, base.Title) {
+		rebased.Title = p.Title
+	}
+
+	for key, value := range p.UpdatedFields {
+		if patchProperties, ok := value.(map[string]interface{}); ok && key == propertiesField {
+			properties, propertiesConflict := rebaseProperties(patchProperties, base.Fields[key], current.Fields[key], changed, p.ComputedPropertyIDs)
+			conflicts = conflicts || propertiesConflict
+			if properties != nil {
+				setUpdatedField(rebased, key, properties)
+			}
+			continue
+		}
+		if rebaseField("fields."+key, value, base.Fields[key]) {
+			setUpdatedField(rebased, key, value)
+		}
+	}
+	for _, key := range p.DeletedFields {
+		if _, ok := base.Fields[key]; ok {
+			conflicts = conflicts || changed["fields."+key]
+			rebased.DeletedFields = append(rebased.DeletedFields, key)
+		}
+	}
+
+	if conflicts {
+		return nil, false
+	}
+	return rebased, true
+}
+
+func setUpdatedField(patch *BlockPatch, key string, value interface{}) {
+	if patch.UpdatedFields == nil {
+		patch.UpdatedFields = map[string]interface{}{}
+	}
+	patch.UpdatedFields[key] = value
+}
+
+// rebaseProperties merges the card property values a patch sets with the current values.
+// It returns nil if the patch changes no value, and true if it changes a value that was
+// changed since the base version. The computed properties are skipped, as their values
+// change with their inputs.
+func rebaseProperties(patchProperties map[string]interface{}, baseValue, currentValue interface{}, changed map[string]bool, computedIDs []string) (map[string]interface{}, bool) {
+	baseProperties, _ := baseValue.(map[string]interface{})
+	currentProperties, _ := currentValue.(map[string]interface{})
+	computed := make(map[string]bool, len(computedIDs))
+	for _, id := range computedIDs {
+		computed[id] = true
+	}
+
+	merged := make(map[string]interface{}, len(currentProperties))
+	for id, value := range currentProperties {
+		merged[id] = value
+	}
+	modified := false
+	conflicts := false
+	for id, value := range patchProperties {
+		if computed[id] {
+			continue
+		}
+		if baseProperty, ok := baseProperties[id]; !ok || !reflect.DeepEqual(value, baseProperty) {
+			modified = true
+			conflicts = conflicts || changed["fields.properties."+id]
+			merged[id] = value
+		}
+	}
+	for id := range baseProperties {
+		if _, ok := patchProperties[id]; !ok && !computed[id] {
+			modified = true
+			conflicts = conflicts || changed["fields.properties."+id]
+			delete(merged, id)
+		}
+	}
+	if !modified {
+		return nil, false
+	}
+	return merged, conflicts
+}
+
+// blockChanges returns the names of the fields changed between two versions of a block.
+// The card properties are compared one by one.
+func blockChanges(base, current *Block) map[string]bool {
+	changed := map[string]bool{
+		"parentId": base.ParentID != current.ParentID,
+		"boardId":  base.BoardID != current.BoardID,
+		"schema":   base.Schema != current.Schema,
+		"type":     base.Type != current.Type,
+		"title":    base.Title != current.Title,
+	}
+	for key := range mergeKeys(base.Fields, current.Fields) {
+		if !reflect.DeepEqual(base.Fields[key], current.Fields[key]) {
+			changed["fields."+key] = true
+		}
+	}
+	baseProperties, _ := base.Fields[propertiesField].(map[string]interface{})
+	currentProperties, _ := current.Fields[propertiesField].(map[string]interface{})
+	for id := range mergeKeys(baseProperties, currentProperties) {
+		if !reflect.DeepEqual(baseProperties[id], currentProperties[id]) {
+			changed["fields.properties."+id] = true
+		}
+	}
+	return changed
+}
+
+// Rebase returns the patch to apply to the current version of a board, for a patch based
+// on an older version of it. Only the changes of the patch are kept, so that the fields,
+// properties and card properties changed since the base version are preserved. It returns
+// false if the patch changes something that was changed since the base version.
+func (p *BoardPatch) Rebase(base, current *Board) (*BoardPatch, bool) {
+	changed := boardChanges(base, current)
+	rebased := &BoardPatch{}
+
+	conflicts := false
+	rebaseField := func(name string, patchValue, baseValue interface{}) bool {
+		if reflect.DeepEqual(patchValue, baseValue) {
+			return false
+		}
+		conflicts = conflicts || changed[name]
+		return true
+	}
+
+	if p.Type != nil && rebaseField("type", *p.Type, base.Type) {
+		rebased.Type = p.Type
+	}
+	if p.Title != nil && rebaseField("title", *p.Title, base.Title) {
+		rebased.Title = p.Title
+	}
+	if p.Description != nil && rebaseField("description", *p.Description, base.Description) {
+		rebased.Description = p.Description
+	}
+	if p.Icon != nil && rebaseField("icon", *p.Icon, base.Icon) {
+		rebased.Icon = p.Icon
+	}
+	if p.ShowDescription != nil && rebaseField("showDescription", *p.ShowDescription, base.ShowDescription) {
+		rebased.ShowDescription = p.ShowDescription
+	}
+
+	for key, value := range p.UpdatedProperties {
+		if rebaseField("properties."+key, value, base.Properties[key]) {
+			if rebased.UpdatedProperties == nil {
+				rebased.UpdatedProperties = map[string]interface{}{}
+			}
+			rebased.UpdatedProperties[key] = value
+		}
+	}
+	for _, key := range p.DeletedProperties {
+		if _, ok := base.Properties[key]; ok {
+			conflicts = conflicts || changed["properties."+key]
+			rebased.DeletedProperties = append(rebased.DeletedProperties, key)
+		}
+	}
+
+	baseCardProperties := cardPropertiesByID(base.CardProperties)
+	for _, property := range p.UpdatedCardProperties {
+		id, _ := property["id"].(string)
+		if rebaseField("cardProperties."+id, property, baseCardProperties[id]) {
+			rebased.UpdatedCardProperties = append(rebased.UpdatedCardProperties, property)
+		}
+	}
+	for _, id := range p.DeletedCardProperties {
+		if _, ok := baseCardProperties[id]; ok {
+			conflicts = conflicts || changed["cardProperties."+id]
+			rebased.DeletedCardProperties = append(rebased.DeletedCardProperties, id)
+		}
+	}
+
+	if conflicts {
+		return nil, false
+	}
+	return rebased, true
+}
+
+// boardChanges returns the names of the fields changed between two versions of a board.
+// The properties and the card properties are compared one by one.
+func boardChanges(base, current *Board) map[string]bool {
+	changed := map[string]bool{
+		"type":            base.Type != current.Type,
+		"title":           base.Title != current.Title,
+		"description":     base.Description != current.Description,
+		"icon":            base.Icon != current.Icon,
+		"showDescription": base.ShowDescription != current.ShowDescription,
+	}
+	for key := range mergeKeys(base.Properties, current.Properties) {
+		if !reflect.DeepEqual(base.Properties[key], current.Properties[key]) {
+			changed["properties."+key] = true
+		}
+	}
+	baseCardProperties := cardPropertiesByID(base.CardProperties)
+	currentCardProperties := cardPropertiesByID(current.CardProperties)
+	for id := range mergeKeys(baseCardProperties, currentCardProperties) {
+		if !reflect.DeepEqual(baseCardProperties[id], currentCardProperties[id]) {
+			changed["cardProperties."+id] = true
+		}
+	}
+	return changed
+}
+
+func cardPropertiesByID(cardProperties []map[string]interface{}) map[string]interface{} {
+	byID := make(map[string]interface{}, len(cardProperties))
+	for _, property := range cardProperties {
+		if id, ok := property["id"].(string); ok {
+			byID[id] = property
+		}
+	}
+	return byID
+}
+
+func mergeKeys(maps ...map[string]interface{}) map[string]bool {
+	keys := map[string]bool{}
+	for _, m := range maps {
+		for key := range m {
+			keys[key] = true
+		}
+	}
+	return keys
+}
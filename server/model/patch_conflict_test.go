@@ -0,0 +1,165 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockPatchRebase(t *testing.T) {
+	base := &Block{
+		ID:    "card-id",
+		Title: "Card",
+		Fields: map[string]interface{}{
+			"icon":       "a",
+			"properties": map[string]interface{}{"status": "todo", "priority": "low"},
+		},
+		UpdateAt: 1,
+	}
+	current := &Block{
+		ID:    "card-id",
+		Title: "Renamed card",
+		Fields: map[string]interface{}{
+			"icon":       "a",
+			"properties": map[string]interface{}{"status": "done", "priority": "low"},
+		},
+		UpdateAt: 2,
+	}
+
+	t.Run("the changes made since the base version are preserved", func(t *testing.T) {
+		title := "Card"
+		patch := &BlockPatch{
+			Title: &title,
+			UpdatedFields: map[string]interface{}{
+				"icon":       "b",
+				"properties": map[string]interface{}{"status": "todo", "priority": "high"},
+			},
+		}
+
+		rebased, ok := patch.Rebase(base, current)
+		require.True(t, ok)
+		require.Nil(t, rebased.Title)
+		require.Equal(t, map[string]interface{}{
+			"icon":       "b",
+			"properties": map[string]interface{}{"status": "done", "priority": "high"},
+		}, rebased.UpdatedFields)
+	})
+
+	t.Run("the deleted card properties are merged", func(t *testing.T) {
+		patch := &BlockPatch{
+			UpdatedFields: map[string]interface{}{
+				"properties": map[string]interface{}{"status": "todo"},
+			},
+		}
+
+		rebased, ok := patch.Rebase(base, current)
+		require.True(t, ok)
+		require.Equal(t, map[string]interface{}{"status": "done"}, rebased.UpdatedFields["properties"])
+	})
+
+	t.Run("a patch changing the same title conflicts", func(t *testing.T) {
+		title := "Other title"
+		_, ok := (&BlockPatch{Title: &title}).Rebase(base, current)
+		require.False(t, ok)
+	})
+
+	t.Run("a patch changing the same card property conflicts", func(t *testing.T) {
+		patch := &BlockPatch{
+			UpdatedFields: map[string]interface{}{
+				"properties": map[string]interface{}{"status": "in progress", "priority": "low"},
+			},
+		}
+		_, ok := patch.Rebase(base, current)
+		require.False(t, ok)
+	})
+
+	t.Run("the computed card properties do not conflict", func(t *testing.T) {
+		computedBase := &Block{ID: "card-id", Fields: map[string]interface{}{
+			"properties": map[string]interface{}{"a": "1", "b": "1", "sum": "2"},
+		}}
+		computedCurrent := &Block{ID: "card-id", Fields: map[string]interface{}{
+			"properties": map[string]interface{}{"a": "2", "b": "1", "sum": "3"},
+		}}
+		patch := &BlockPatch{
+			UpdatedFields: map[string]interface{}{
+				"properties": map[string]interface{}{"a": "1", "b": "5", "sum": "6"},
+			},
+			ComputedPropertyIDs: []string{"sum"},
+		}
+
+		rebased, ok := patch.Rebase(computedBase, computedCurrent)
+		require.True(t, ok)
+		require.Equal(t, map[string]interface{}{"a": "2", "b": "5", "sum": "3"}, rebased.UpdatedFields["properties"])
+
+		patch.ComputedPropertyIDs = nil
+		_, ok = patch.Rebase(computedBase, computedCurrent)
+		require.False(t, ok)
+	})
+
+	t.Run("a patch deleting a changed field conflicts", func(t *testing.T) {
+		_, ok := (&BlockPatch{DeletedFields: []string{"properties"}}).Rebase(base, current)
+		require.False(t, ok)
+
+		rebased, ok := (&BlockPatch{DeletedFields: []string{"icon", "missing"}}).Rebase(base, current)
+		require.True(t, ok)
+		require.Equal(t, []string{"icon"}, rebased.DeletedFields)
+	})
+}
+
+func TestBoardPatchRebase(t *testing.T) {
+	base := &Board{
+		ID:          "board-id",
+		Title:       "Board",
+		Description: "Description",
+		Properties:  map[string]interface{}{"a": "1"},
+		CardProperties: []map[string]interface{}{
+			{"id": "status", "name": "Status", "type": "select"},
+			{"id": "priority", "name": "Priority", "type": "select"},
+		},
+		UpdateAt: 1,
+	}
+	current := &Board{
+		ID:          "board-id",
+		Title:       "Board",
+		Description: "New description",
+		Properties:  map[string]interface{}{"a": "2"},
+		CardProperties: []map[string]interface{}{
+			{"id": "status", "name": "State", "type": "select"},
+			{"id": "priority", "name": "Priority", "type": "select"},
+		},
+		UpdateAt: 2,
+	}
+
+	t.Run("the changes made since the base version are preserved", func(t *testing.T) {
+		title := "New title"
+		description := "Description"
+		patch := &BoardPatch{
+			Title:             &title,
+			Description:       &description,
+			UpdatedProperties: map[string]interface{}{"a": "1", "b": "1"},
+			UpdatedCardProperties: []map[string]interface{}{
+				{"id": "status", "name": "Status", "type": "select"},
+				{"id": "priority", "name": "Importance", "type": "select"},
+			},
+		}
+
+		rebased, ok := patch.Rebase(base, current)
+		require.True(t, ok)
+		require.Equal(t, &title, rebased.Title)
+		require.Nil(t, rebased.Description)
+		require.Equal(t, map[string]interface{}{"b": "1"}, rebased.UpdatedProperties)
+		require.Equal(t, []map[string]interface{}{{"id": "priority", "name": "Importance", "type": "select"}}, rebased.UpdatedCardProperties)
+	})
+
+	t.Run("a patch changing the same fields conflicts", func(t *testing.T) {
+		description := "Other description"
+		_, ok := (&BoardPatch{Description: &description}).Rebase(base, current)
+		require.False(t, ok)
+
+		_, ok = (&BoardPatch{UpdatedProperties: map[string]interface{}{"a": "3"}}).Rebase(base, current)
+		require.False(t, ok)
+
+		_, ok = (&BoardPatch{DeletedCardProperties: []string{"status"}}).Rebase(base, current)
+		require.False(t, ok)
+	})
+}
@@ -7,6 +7,7 @@ import (
 var (
 	PermissionViewTeam              = mmModel.PermissionViewTeam
 	PermissionViewMembers           = mmModel.PermissionViewMembers
+	PermissionManageTeam            = mmModel.PermissionManageTeam
 	PermissionCreatePublicChannel   = mmModel.PermissionCreatePublicChannel
 	PermissionCreatePrivateChannel  = mmModel.PermissionCreatePrivateChannel
 	PermissionManageBoardType       = &mmModel.Permission{Id: "manage_board_type", Name: "", Description: "", Scope: ""}
@@ -0,0 +1,27 @@
+package model
+
+// Presence is a user viewing a board, and the card they focus on, if any. The
+// presences are sent to the members of the board over the websockets, so that
+// they can see who is viewing or editing a card.
+// swagger:model
+type Presence struct {
+	// The ID of the user
+	// required: true
+	UserID string `json:"userId"`
+
+	// The ID of the board the user is viewing
+	// required: true
+	BoardID string `json:"boardId"`
+
+	// The ID of the card the user focuses on
+	// required: false
+	CardID string `json:"cardId,omitempty"`
+
+	// Editing is true if the user is editing the card
+	// required: false
+	Editing bool `json:"editing,omitempty"`
+
+	// The time of the last activity of the user, in miliseconds since the current epoch
+	// required: true
+	UpdateAt int64 `json:"updateAt"`
+}
@@ -4,12 +4,10 @@
 package model
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
-
-	"github.com/mattermost/focalboard/server/utils"
+	"time"
 )
 
 var ErrInvalidBoardBlock = errors.New("invalid board block")
@@ -49,11 +47,81 @@ type PropDefOption struct {
 
 // PropDef represents a property definition as defined in a board's Fields member.
 type PropDef struct {
-	ID      string                   `json:"id"`
-	Index   int                      `json:"index"`
-	Name    string                   `json:"name"`
-	Type    string                   `json:"type"`
-	Options map[string]PropDefOption `json:"options"`
+	ID       string                   `json:"id"`
+	Index    int                      `json:"index"`
+	Name     string                   `json:"name"`
+	Type     string                   `json:"type"`
+	Options  map[string]PropDefOption `json:"options"`
+	Formula  string                   `json:"formula,omitempty"`
+	Rollup   *RollupDef               `json:"rollup,omitempty"`
+	Relation *RelationDef             `json:"relation,omitempty"`
+
+	// Precision is the number of decimals of numeric properties, nil to keep the value as is.
+	Precision *int   `json:"precision,omitempty"`
+	Unit      string `json:"unit,omitempty"`
+	Currency  string `json:"currency,omitempty"`
+
+	// Required is true if every card of the board must have a value for the property.
+	Required bool `json:"required,omitempty"`
+
+	// Default is the value given to the property of the new cards that have none.
+	Default interface{} `json:"default,omitempty"`
+}
+
+// Property types whose values are computed by the server.
+const (
+	PropTypeFormula = "formula"
+	PropTypeRollup  = "rollup"
+)
+
+// PropTypeRelation is the type of the properties whose values are the IDs of cards
+// of another board, or of the same board.
+const PropTypeRelation = "relation"
+
+// Numeric property types.
+const (
+	PropTypeNumber     = "number"
+	PropTypeCurrency   = "currency"
+	PropTypePercentage = "percentage"
+)
+
+// Rollup functions aggregating the values of a property over related cards.
+const (
+	RollupCount       = "count"
+	RollupCountValues = "countValues"
+	RollupCountUnique = "countUnique"
+	RollupSum         = "sum"
+	RollupAverage     = "average"
+	RollupMin         = "min"
+	RollupMax         = "max"
+)
+
+// RollupDef defines how a rollup property aggregates the values of a property of
+// the related cards. An empty RelationPropertyID aggregates over the child cards.
+type RollupDef struct {
+	RelationPropertyID string `json:"relationPropertyId"`
+	PropertyID         string `json:"propertyId"`
+	Function           string `json:"function"`
+}
+
+// RelationDef defines the cards a relation property links to.
+type RelationDef struct {
+	// BoardID is the board of the linked cards.
+	BoardID string `json:"boardId"`
+
+	// BackReferencePropertyID is the relation property of the linked cards that
+	// mirrors this one, empty for one-way relations.
+	BackReferencePropertyID string `json:"backReferencePropertyId,omitempty"`
+}
+
+// IsNumeric returns true if the property values are numbers.
+func (pd PropDef) IsNumeric() bool {
+	return pd.Type == PropTypeNumber || pd.Type == PropTypeCurrency || pd.Type == PropTypePercentage
+}
+
+// IsComputed returns true if the property value is computed by the server.
+func (pd PropDef) IsComputed() bool {
+	return pd.Type == PropTypeFormula || pd.Type == PropTypeRollup
 }
 
 // GetValue resolves the value of a property if the passed value is an ID for an option,
@@ -78,9 +146,13 @@ func (pd PropDef) GetValue(v interface{}, resolver PropValueResolver) (string, e
 		if !ok {
 			return "", ErrInvalidPropertyValueType
 		}
-		return pd.ParseDate(date)
+		var loc *time.Location
+		if locator, ok := resolver.(PropValueLocator); ok {
+			loc = locator.Location()
+		}
+		return pd.FormatDate(date, loc)
 
-	case "person":
+	case "person", PropTypeCreatedBy, PropTypeUpdatedBy:
 		// v is a userid
 		userID, ok := v.(string)
 		if !ok {
@@ -120,27 +192,41 @@ func (pd PropDef) GetValue(v interface{}, resolver PropValueResolver) (string, e
 			sb.WriteString(strings.ToUpper(opt.Value))
 		}
 		return sb.String(), nil
+
+	case PropTypeNumber, PropTypeCurrency, PropTypePercentage:
+		// v is a number stored as a string
+		switch t := v.(type) {
+		case string:
+			return pd.FormatNumber(t)
+		case float64:
+			return pd.formatFloat(t), nil
+		}
+		return "", ErrInvalidPropertyValueType
+
+	case PropTypeRelation:
+		// v is a slice of card ids
+		return strings.Join(RelationIDs(v), ", "), nil
+
+	case PropTypeCreatedTime, PropTypeUpdatedTime:
+		// v is a time in milliseconds
+		return pd.formatTimestamp(v, resolver)
 	}
 	return fmt.Sprintf("%v", v), nil
 }
 
+// ParseDate renders the value of a date property in UTC, or in the timezone of the value.
 func (pd PropDef) ParseDate(s string) (string, error) {
-	// s is a JSON snippet of the form: {"from":1642161600000, "to":1642161600000} in milliseconds UTC
-	// The UI does not yet support date ranges.
-	var m map[string]int64
-	if err := json.Unmarshal([]byte(s), &m); err != nil {
+	return pd.FormatDate(s, nil)
+}
+
+// FormatDate renders the value of a date property for a reader in the given location.
+// Values with a timezone are always rendered in their timezone, and whole days in UTC.
+func (pd PropDef) FormatDate(s string, reader *time.Location) (string, error) {
+	dv, err := ParseDateValue(s)
+	if err != nil {
 		return s, err
 	}
-	tsFrom, ok := m["from"]
-	if !ok {
-		return s, ErrInvalidDate
-	}
-	date := utils.GetTimeForMillis(tsFrom).Format("January 02, 2006")
-	tsTo, ok := m["to"]
-	if ok {
-		date += " -> " + utils.GetTimeForMillis(tsTo).Format("January 02, 2006")
-	}
-	return date, nil
+	return dv.Format(reader), nil
 }
 
 // ParsePropertySchema parses a board block's `Fields` to extract the properties
@@ -178,11 +264,71 @@ func ParsePropertySchema(board *Board) (PropSchema, error) {
 				pd.Options[po.ID] = po
 			}
 		}
+		if precision, ok := prop["precision"].(float64); ok {
+			p := int(precision)
+			pd.Precision = &p
+		}
+		pd.Unit = getMapString("unit", prop)
+		pd.Currency = getMapString("currency", prop)
+		pd.Required, _ = prop["required"].(bool)
+		pd.Default = prop["default"]
+
+		switch pd.Type {
+		case PropTypeFormula:
+			pd.Formula = getMapString("formula", prop)
+		case PropTypeRollup:
+			rollup, err := parseRollupDef(prop)
+			if err != nil {
+				return nil, err
+			}
+			pd.Rollup = rollup
+		case PropTypeRelation:
+			relation, err := parseRelationDef(prop)
+			if err != nil {
+				return nil, err
+			}
+			pd.Relation = relation
+		}
 		schema[pd.ID] = pd
 	}
 	return schema, nil
 }
 
+func parseRollupDef(prop map[string]interface{}) (*RollupDef, error) {
+	rollupIface, ok := prop["rollup"]
+	if !ok {
+		return &RollupDef{Function: RollupCount}, nil
+	}
+	m, ok := rollupIface.(map[string]interface{})
+	if !ok {
+		return nil, ErrInvalidPropSchema
+	}
+	rollup := &RollupDef{
+		RelationPropertyID: getMapString("relationPropertyId", m),
+		PropertyID:         getMapString("propertyId", m),
+		Function:           getMapString("function", m),
+	}
+	if rollup.Function == "" {
+		rollup.Function = RollupCount
+	}
+	return rollup, nil
+}
+
+func parseRelationDef(prop map[string]interface{}) (*RelationDef, error) {
+	m, ok := prop["relation"].(map[string]interface{})
+	if !ok {
+		return nil, ErrInvalidPropSchema
+	}
+	relation := &RelationDef{
+		BoardID:                 getMapString("boardId", m),
+		BackReferencePropertyID: getMapString("backReferencePropertyId", m),
+	}
+	if relation.BoardID == "" {
+		return nil, ErrInvalidPropSchema
+	}
+	return relation, nil
+}
+
 func getMapString(key string, m map[string]interface{}) string {
 	iface, ok := m[key]
 	if !ok {
@@ -198,6 +344,7 @@ func getMapString(key string, m map[string]interface{}) string {
 
 // ParseProperties parses a block's `Fields` to extract the properties. Properties typically exist on
 // card blocks.  A resolver can optionally be provided to fetch usernames for `person` prop type.
+// The system properties of the schema, such as the creation time, are taken from the block itself.
 func ParseProperties(block *Block, schema PropSchema, resolver PropValueResolver) (BlockProperties, error) {
 	props := make(map[string]BlockProp)
 
@@ -205,19 +352,18 @@ func ParseProperties(block *Block, schema PropSchema, resolver PropValueResolver
 		return props, nil
 	}
 
-	// `properties` contains a map (untyped at this point).
-	propsIface, ok := block.Fields["properties"]
-	if !ok {
-		return props, nil // this is expected for blocks that don't have any properties.
-	}
-
-	blockProps, ok := propsIface.(map[string]interface{})
-	if !ok {
-		return props, fmt.Errorf("`properties` field wrong type: %w", ErrInvalidProperty)
+	// `properties` contains a map (untyped at this point), which is expected to be
+	// missing for blocks that don't have any properties.
+	blockProps := map[string]interface{}{}
+	if propsIface, ok := block.Fields["properties"]; ok {
+		blockProps, ok = propsIface.(map[string]interface{})
+		if !ok {
+			return props, fmt.Errorf("`properties` field wrong type: %w", ErrInvalidProperty)
+		}
 	}
 
-	if len(blockProps) == 0 {
-		return props, nil
+	if block.Type == TypeCard {
+		blockProps = CardPropertyValues(block, schema)
 	}
 
 	for k, v := range blockProps {
@@ -231,6 +377,9 @@ func ParseProperties(block *Block, schema PropSchema, resolver PropValueResolver
 
 		def, ok := schema[k]
 		if ok {
+			if def.IsSystem() && IsEmptyPropertyValue(v) {
+				continue
+			}
 			val, err := def.GetValue(v, resolver)
 			if err != nil {
 				return props, fmt.Errorf("could not parse property value (%s): %w", fmt.Sprintf("%v", v), err)
@@ -42,6 +42,124 @@ func Test_parsePropertySchema(t *testing.T) {
 		assert.Equal(t, "MyDate", prop.Name)
 		assert.Empty(t, prop.Options)
 	})
+
+	t.Run("parse computed properties", func(t *testing.T) {
+		computed := &Board{
+			CardProperties: []map[string]interface{}{
+				{"id": "total", "name": "Total", "type": "formula", "formula": `prop("Estimate") * 2`},
+				{"id": "sum", "name": "Sum", "type": "rollup", "rollup": map[string]interface{}{
+					"propertyId": "estimate",
+					"function":   "sum",
+				}},
+				{"id": "count", "name": "Count", "type": "rollup"},
+			},
+		}
+		schema, err := ParsePropertySchema(computed)
+		require.NoError(t, err)
+
+		assert.Equal(t, `prop("Estimate") * 2`, schema["total"].Formula)
+		assert.True(t, schema["total"].IsComputed())
+		assert.Equal(t, &RollupDef{PropertyID: "estimate", Function: RollupSum}, schema["sum"].Rollup)
+		assert.Equal(t, &RollupDef{Function: RollupCount}, schema["count"].Rollup)
+
+		computed.CardProperties = append(computed.CardProperties, map[string]interface{}{
+			"id": "price", "name": "Price", "type": "currency", "currency": "EUR", "precision": float64(2),
+		})
+		schema, err = ParsePropertySchema(computed)
+		require.NoError(t, err)
+		require.NotNil(t, schema["price"].Precision)
+		assert.Equal(t, 2, *schema["price"].Precision)
+		assert.Equal(t, "EUR", schema["price"].Currency)
+		assert.True(t, schema["price"].IsNumeric())
+
+		computed.CardProperties[1]["rollup"] = "sum"
+		_, err = ParsePropertySchema(computed)
+		require.ErrorIs(t, err, ErrInvalidPropSchema)
+	})
+}
+
+func TestGetValueNumeric(t *testing.T) {
+	two := 2
+	testCases := []struct {
+		name     string
+		def      PropDef
+		value    interface{}
+		expected string
+	}{
+		{name: "number as is", def: PropDef{Type: PropTypeNumber}, value: "1234.5", expected: "1234.5"},
+		{name: "number with precision and unit", def: PropDef{Type: PropTypeNumber, Precision: &two, Unit: "kg"}, value: "3.14159", expected: "3.14 kg"},
+		{name: "legacy float value", def: PropDef{Type: PropTypeNumber}, value: float64(3), expected: "3"},
+		{name: "currency", def: PropDef{Type: PropTypeCurrency, Currency: "USD"}, value: "1234567.5", expected: "$1,234,567.50"},
+		{name: "negative currency", def: PropDef{Type: PropTypeCurrency, Currency: "EUR"}, value: "-12", expected: "-€12.00"},
+		{name: "currency without decimals", def: PropDef{Type: PropTypeCurrency, Currency: "JPY"}, value: "1500", expected: "¥1,500"},
+		{name: "unknown currency", def: PropDef{Type: PropTypeCurrency, Currency: "chf"}, value: "10", expected: "CHF 10.00"},
+		{name: "percentage", def: PropDef{Type: PropTypePercentage, Precision: &two}, value: "12.345", expected: "12.35%"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := tc.def.GetValue(tc.value, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+
+	t.Run("invalid number", func(t *testing.T) {
+		_, err := PropDef{Type: PropTypeNumber}.GetValue("three", nil)
+		require.ErrorIs(t, err, ErrInvalidPropertyValue)
+	})
+}
+
+func TestParsePropertiesSystemProperties(t *testing.T) {
+	schema := PropSchema{
+		"created":   {ID: "created", Index: 0, Name: "Created", Type: PropTypeCreatedTime},
+		"creator":   {ID: "creator", Index: 1, Name: "Created by", Type: PropTypeCreatedBy},
+		"updated":   {ID: "updated", Index: 2, Name: "Updated", Type: PropTypeUpdatedTime},
+		"modifier":  {ID: "modifier", Index: 3, Name: "Updated by", Type: PropTypeUpdatedBy},
+		"notesProp": {ID: "notesProp", Index: 4, Name: "Notes", Type: "text"},
+	}
+	card := &Block{
+		ID:         "card-id",
+		Type:       TypeCard,
+		CreatedBy:  "user-1",
+		ModifiedBy: "user-2",
+		CreateAt:   1642161600000,
+		UpdateAt:   1642248000000,
+		Fields: map[string]interface{}{"properties": map[string]interface{}{
+			"notesProp": "some notes",
+			"created":   "stale value",
+		}},
+	}
+
+	t.Run("values", func(t *testing.T) {
+		values := CardPropertyValues(card, schema)
+		assert.Equal(t, map[string]interface{}{
+			"created":   "1642161600000",
+			"creator":   "user-1",
+			"updated":   "1642248000000",
+			"modifier":  "user-2",
+			"notesProp": "some notes",
+		}, values)
+		assert.Equal(t, "stale value", card.Fields["properties"].(map[string]interface{})["created"])
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		props, err := ParseProperties(card, schema, nil)
+		require.NoError(t, err)
+		require.Len(t, props, 5)
+		assert.Equal(t, "January 14, 2022 12:00 PM UTC", props["created"].Value)
+		assert.Equal(t, "Created", props["created"].Name)
+		assert.Equal(t, "user-1", props["creator"].Value)
+		assert.Equal(t, "January 15, 2022 12:00 PM UTC", props["updated"].Value)
+		assert.Equal(t, "user-2", props["modifier"].Value)
+	})
+
+	t.Run("card without properties", func(t *testing.T) {
+		props, err := ParseProperties(&Block{ID: "card-id", Type: TypeCard, CreatedBy: "user-1", CreateAt: 1}, schema, nil)
+		require.NoError(t, err)
+		assert.Contains(t, props, "creator")
+		assert.Contains(t, props, "created")
+		assert.NotContains(t, props, "modifier")
+	})
 }
 
 const (
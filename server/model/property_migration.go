@@ -0,0 +1,501 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// Operations of property migrations.
+const (
+	PropertyMigrationDelete       = "delete"
+	PropertyMigrationRetype       = "retype"
+	PropertyMigrationMergeOptions = "mergeOptions"
+	PropertyMigrationRenameOption = "renameOption"
+)
+
+var ErrInvalidPropertyMigration = errors.New("invalid property migration")
+var ErrPropertyMigrationNotUndoable = errors.New("property migration cannot be undone")
+
+// retypeTargets are the property types a property can be converted to.
+var retypeTargets = map[string]bool{
+	"text":             true,
+	"url":              true,
+	"email":            true,
+	"phone":            true,
+	"select":           true,
+	"multiSelect":      true,
+	"checkbox":         true,
+	"date":             true,
+	PropTypeNumber:     true,
+	PropTypeCurrency:   true,
+	PropTypePercentage: true,
+}
+
+// PropertyMigrationParams are the parameters of a property migration operation.
+type PropertyMigrationParams struct {
+	// The new type of the property, for "retype"
+	// required: false
+	Type string `json:"type,omitempty"`
+
+	// The ids of the options merged into the target option, for "mergeOptions"
+	// required: false
+	OptionIDs []string `json:"optionIds,omitempty"`
+
+	// The id of the option the options are merged into, for "mergeOptions"
+	// required: false
+	TargetOptionID string `json:"targetOptionId,omitempty"`
+
+	// The id of the renamed option, for "renameOption"
+	// required: false
+	OptionID string `json:"optionId,omitempty"`
+
+	// The new value of the renamed option, for "renameOption"
+	// required: false
+	Value string `json:"value,omitempty"`
+}
+
+// PropertyMigration is a change of a card property of a board that rewrites the values
+// of the cards of the board accordingly. Migrations are recorded so they can be undone.
+// swagger:model
+type PropertyMigration struct {
+	// The id of the migration
+	// required: true
+	ID string `json:"id"`
+
+	// The id of the board
+	// required: true
+	BoardID string `json:"boardId"`
+
+	// The operation, one of "delete", "retype", "mergeOptions" or "renameOption"
+	// required: true
+	Operation string `json:"operation"`
+
+	// The id of the migrated property
+	// required: true
+	PropertyID string `json:"propertyId"`
+
+	PropertyMigrationParams
+
+	// The definition of the property before the migration
+	// required: false
+	PreviousProperty map[string]interface{} `json:"previousProperty,omitempty"`
+
+	// The position of the property in the card properties before the migration
+	// required: false
+	PreviousIndex int `json:"previousIndex"`
+
+	// The card values rewritten by the migration
+	// required: false
+	Changes []PropertyValueChange `json:"changes"`
+
+	// The id of the user who ran the migration
+	// required: false
+	CreatedBy string `json:"createdBy"`
+
+	// The creation time in miliseconds since the current epoch
+	// required: false
+	CreateAt int64 `json:"createAt"`
+
+	// The id of the user who undid the migration
+	// required: false
+	UndoneBy string `json:"undoneBy,omitempty"`
+
+	// The time the migration was undone in miliseconds since the current epoch, or zero
+	// required: false
+	UndoneAt int64 `json:"undoneAt"`
+}
+
+// PropertyValueChange is a card property value rewritten by a property migration.
+// A nil value means the card had, or has, no value.
+// swagger:model
+type PropertyValueChange struct {
+	// The id of the card
+	// required: true
+	BlockID string `json:"blockId"`
+
+	// The value before the migration
+	// required: false
+	Before interface{} `json:"before"`
+
+	// The value after the migration
+	// required: false
+	After interface{} `json:"after"`
+}
+
+func PropertyMigrationFromJSON(data io.Reader) (*PropertyMigration, error) {
+	var migration PropertyMigration
+	if err := json.NewDecoder(data).Decode(&migration); err != nil {
+		return nil, err
+	}
+	return &migration, nil
+}
+
+func PropertyMigrationsFromJSON(data io.Reader) []*PropertyMigration {
+	var migrations []*PropertyMigration
+	_ = json.NewDecoder(data).Decode(&migrations)
+	return migrations
+}
+
+// MigrateCardProperties applies a property migration to the card properties of a board
+// and to the values of the given cards of the board. It returns the new card properties,
+// and records in the migration the previous definition of the property and the card
+// values it changes.
+func MigrateCardProperties(board *Board, cards []Block, m *PropertyMigration) ([]map[string]interface{}, error) {
+	index := -1
+	for i, prop := range board.CardProperties {
+		if getMapString("id", prop) == m.PropertyID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("%w: unknown property %s", ErrInvalidPropertyMigration, m.PropertyID)
+	}
+
+	schema, err := ParsePropertySchema(board)
+	if err != nil {
+		return nil, err
+	}
+	def := schema[m.PropertyID]
+	prop := board.CardProperties[index]
+
+	var newProp map[string]interface{}
+	var convert func(v interface{}) interface{}
+	switch m.Operation {
+	case PropertyMigrationDelete:
+		convert = func(interface{}) interface{} { return nil }
+	case PropertyMigrationRetype:
+		newProp, convert, err = retypeProperty(def, prop, cards, m.Type)
+	case PropertyMigrationMergeOptions:
+		newProp, convert, err = mergePropertyOptions(def, prop, m.OptionIDs, m.TargetOptionID)
+	case PropertyMigrationRenameOption:
+		newProp, err = renamePropertyOption(def, prop, m.OptionID, m.Value)
+		convert = func(v interface{}) interface{} { return v }
+	default:
+		err = fmt.Errorf("%w: unknown operation %s", ErrInvalidPropertyMigration, m.Operation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m.Changes = []PropertyValueChange{}
+	for _, card := range cards {
+		if card.Type != TypeCard {
+			continue
+		}
+		props, ok := card.Fields["properties"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		v, ok := props[m.PropertyID]
+		if !ok {
+			continue
+		}
+		if nv := convert(v); !PropertyValuesEqual(v, nv) {
+			m.Changes = append(m.Changes, PropertyValueChange{BlockID: card.ID, Before: v, After: nv})
+		}
+	}
+
+	m.PreviousProperty = prop
+	m.PreviousIndex = index
+
+	cardProperties := make([]map[string]interface{}, 0, len(board.CardProperties))
+	cardProperties = append(cardProperties, board.CardProperties[:index]...)
+	if newProp != nil {
+		cardProperties = append(cardProperties, newProp)
+	}
+	return append(cardProperties, board.CardProperties[index+1:]...), nil
+}
+
+// UndoCardProperties returns the card properties of a board with the migrated property
+// restored to its definition and position before the migration.
+func (m *PropertyMigration) UndoCardProperties(board *Board) []map[string]interface{} {
+	cardProperties := make([]map[string]interface{}, 0, len(board.CardProperties)+1)
+	for _, prop := range board.CardProperties {
+		if getMapString("id", prop) != m.PropertyID {
+			cardProperties = append(cardProperties, prop)
+		}
+	}
+
+	index := m.PreviousIndex
+	if index > len(cardProperties) {
+		index = len(cardProperties)
+	}
+	cardProperties = append(cardProperties, nil)
+	copy(cardProperties[index+1:], cardProperties[index:])
+	cardProperties[index] = m.PreviousProperty
+	return cardProperties
+}
+
+// UndoValues returns the values to restore on the given cards to undo the migration,
+// keyed by card id, a nil value removing the property. The cards deleted or whose value
+// changed since the migration are left out.
+func (m *PropertyMigration) UndoValues(cards []Block) map[string]interface{} {
+	current := make(map[string]interface{}, len(cards))
+	for _, card := range cards {
+		if props, ok := card.Fields["properties"].(map[string]interface{}); ok {
+			current[card.ID] = props[m.PropertyID]
+		}
+	}
+	values := make(map[string]interface{}, len(m.Changes))
+	for _, change := range m.Changes {
+		value, ok := current[change.BlockID]
+		if !ok || !PropertyValuesEqual(value, change.After) {
+			continue
+		}
+		values[change.BlockID] = change.Before
+	}
+	return values
+}
+
+// PropertyValuesEqual returns true if two card property values are the same, nil
+// meaning no value.
+func PropertyValuesEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+}
+
+func copyProperty(prop map[string]interface{}) map[string]interface{} {
+	newProp := make(map[string]interface{}, len(prop))
+	for k, v := range prop {
+		newProp[k] = v
+	}
+	return newProp
+}
+
+func isOptionType(propType string) bool {
+	return propType == "select" || propType == "multiSelect"
+}
+
+// retypeProperty changes the type of a property, converting the values through their
+// text representation. Values that cannot be converted are removed. Options are created
+// for the distinct values of properties converted to a select or multiSelect.
+func retypeProperty(def PropDef, prop map[string]interface{}, cards []Block, newType string) (map[string]interface{}, func(interface{}) interface{}, error) {
+	if !retypeTargets[newType] {
+		return nil, nil, fmt.Errorf("%w: cannot convert a property to %s", ErrInvalidPropertyMigration, newType)
+	}
+	if newType == def.Type {
+		return nil, nil, fmt.Errorf("%w: the property is already of type %s", ErrInvalidPropertyMigration, newType)
+	}
+
+	newProp := copyProperty(prop)
+	newProp["type"] = newType
+	for _, key := range []string{"formula", "rollup", "relation"} {
+		delete(newProp, key)
+	}
+
+	// options are kept between option types, the values are option ids in both.
+	if isOptionType(def.Type) && isOptionType(newType) {
+		return newProp, func(v interface{}) interface{} {
+			ids := RelationIDs(v)
+			if len(ids) == 0 {
+				return nil
+			}
+			if newType == "select" {
+				return ids[0]
+			}
+			return RelationValue(ids)
+		}, nil
+	}
+
+	optionIDs := map[string]string{} // option ids keyed by lower case value
+	if isOptionType(newType) {
+		var options []interface{}
+		for _, card := range cards {
+			props, _ := card.Fields["properties"].(map[string]interface{})
+			for _, text := range propertyValueTexts(def, props[def.ID]) {
+				key := strings.ToLower(text)
+				if _, ok := optionIDs[key]; ok {
+					continue
+				}
+				optionIDs[key] = utils.NewID(utils.IDTypeBlock)
+				options = append(options, map[string]interface{}{
+					"id":    optionIDs[key],
+					"value": text,
+					"color": "propColorDefault",
+				})
+			}
+		}
+		newProp["options"] = options
+	} else {
+		delete(newProp, "options")
+	}
+
+	newDef := PropDef{ID: def.ID, Type: newType, Options: make(map[string]PropDefOption, len(optionIDs))}
+	for _, id := range optionIDs {
+		newDef.Options[id] = PropDefOption{ID: id}
+	}
+	return newProp, func(v interface{}) interface{} {
+		texts := propertyValueTexts(def, v)
+		if len(texts) == 0 {
+			return nil
+		}
+
+		var nv interface{}
+		switch newType {
+		case "select":
+			nv = optionIDs[strings.ToLower(texts[0])]
+		case "multiSelect":
+			ids := make([]string, 0, len(texts))
+			for _, text := range texts {
+				ids = append(ids, optionIDs[strings.ToLower(text)])
+			}
+			nv = RelationValue(ids)
+		case "date":
+			if def.Type != "date" {
+				return nil
+			}
+			nv = v
+		case PropTypeNumber, PropTypeCurrency, PropTypePercentage:
+			f, err := strconv.ParseFloat(strings.TrimSpace(texts[0]), 64)
+			if err != nil {
+				return nil
+			}
+			nv = strconv.FormatFloat(f, 'f', -1, 64)
+		default:
+			nv = strings.Join(texts, ", ")
+		}
+
+		// the values that are still invalid for the new type are removed.
+		normalized, action, _ := normalizePropertyValue(newDef, nv)
+		if action == propertyInvalid || action == propertyRemove {
+			return nil
+		}
+		return normalized
+	}, nil
+}
+
+// propertyValueTexts returns the text representation of the value of a property, one
+// text for each option of a multiSelect or card of a relation.
+func propertyValueTexts(def PropDef, v interface{}) []string {
+	var texts []string
+	add := func(s string) {
+		if s = strings.TrimSpace(s); s != "" {
+			texts = append(texts, s)
+		}
+	}
+
+	switch def.Type {
+	case "select", "multiSelect":
+		for _, id := range RelationIDs(v) {
+			if opt, ok := def.Options[id]; ok {
+				add(opt.Value)
+			}
+		}
+	case PropTypeRelation:
+		for _, id := range RelationIDs(v) {
+			add(id)
+		}
+	case "date":
+		if s, ok := v.(string); ok {
+			if formatted, err := def.ParseDate(s); err == nil {
+				add(formatted)
+			}
+		}
+	default:
+		switch t := v.(type) {
+		case nil:
+		case string:
+			add(t)
+		default:
+			add(fmt.Sprintf("%v", t))
+		}
+	}
+	return texts
+}
+
+// mergePropertyOptions merges options of a select or multiSelect property into a target
+// option, which replaces them in the card values.
+func mergePropertyOptions(def PropDef, prop map[string]interface{}, optionIDs []string, targetID string) (map[string]interface{}, func(interface{}) interface{}, error) {
+	if !isOptionType(def.Type) {
+		return nil, nil, fmt.Errorf("%w: %s is not a select or multiSelect property", ErrInvalidPropertyMigration, def.ID)
+	}
+	if _, ok := def.Options[targetID]; !ok {
+		return nil, nil, fmt.Errorf("%w: unknown target option %s", ErrInvalidPropertyMigration, targetID)
+	}
+	if len(optionIDs) == 0 {
+		return nil, nil, fmt.Errorf("%w: no options to merge", ErrInvalidPropertyMigration)
+	}
+	merged := make(map[string]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		if _, ok := def.Options[id]; !ok || id == targetID {
+			return nil, nil, fmt.Errorf("%w: cannot merge option %s", ErrInvalidPropertyMigration, id)
+		}
+		merged[id] = true
+	}
+
+	newProp := copyProperty(prop)
+	options, _ := prop["options"].([]interface{})
+	kept := make([]interface{}, 0, len(options))
+	for _, opt := range options {
+		if m, ok := opt.(map[string]interface{}); ok && merged[getMapString("id", m)] {
+			continue
+		}
+		kept = append(kept, opt)
+	}
+	newProp["options"] = kept
+
+	return newProp, func(v interface{}) interface{} {
+		if id, ok := v.(string); ok {
+			if merged[id] {
+				return targetID
+			}
+			return v
+		}
+		ids := RelationIDs(v)
+		replaced := make([]string, 0, len(ids))
+		seen := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			if merged[id] {
+				id = targetID
+			}
+			if !seen[id] {
+				seen[id] = true
+				replaced = append(replaced, id)
+			}
+		}
+		return RelationValue(replaced)
+	}, nil
+}
+
+// renamePropertyOption changes the value of an option of a select or multiSelect property.
+// The cards reference options by id, so their values stay the same.
+func renamePropertyOption(def PropDef, prop map[string]interface{}, optionID, value string) (map[string]interface{}, error) {
+	if !isOptionType(def.Type) {
+		return nil, fmt.Errorf("%w: %s is not a select or multiSelect property", ErrInvalidPropertyMigration, def.ID)
+	}
+	if _, ok := def.Options[optionID]; !ok {
+		return nil, fmt.Errorf("%w: unknown option %s", ErrInvalidPropertyMigration, optionID)
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("%w: empty option value", ErrInvalidPropertyMigration)
+	}
+	for _, opt := range def.Options {
+		if opt.ID != optionID && strings.EqualFold(opt.Value, value) {
+			return nil, fmt.Errorf("%w: option %s already exists, merge the options instead", ErrInvalidPropertyMigration, value)
+		}
+	}
+
+	newProp := copyProperty(prop)
+	options, _ := prop["options"].([]interface{})
+	renamed := make([]interface{}, 0, len(options))
+	for _, opt := range options {
+		if m, ok := opt.(map[string]interface{}); ok && getMapString("id", m) == optionID {
+			renamedOpt := copyProperty(m)
+			renamedOpt["value"] = value
+			opt = renamedOpt
+		}
+		renamed = append(renamed, opt)
+	}
+	newProp["options"] = renamed
+	return newProp, nil
+}
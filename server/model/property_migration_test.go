@@ -0,0 +1,176 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateCardProperties(t *testing.T) {
+	newBoard := func() *Board {
+		return &Board{
+			ID: "board-id",
+			CardProperties: []map[string]interface{}{
+				{
+					"id":   "status",
+					"name": "Status",
+					"type": "select",
+					"options": []interface{}{
+						map[string]interface{}{"id": "todo", "value": "To Do", "color": "propColorRed"},
+						map[string]interface{}{"id": "doing", "value": "Doing", "color": "propColorBlue"},
+						map[string]interface{}{"id": "done", "value": "Done", "color": "propColorGreen"},
+					},
+				},
+				{"id": "notes", "name": "Notes", "type": "text"},
+				{"id": "estimate", "name": "Estimate", "type": "number"},
+			},
+		}
+	}
+
+	newCard := func(id string, props map[string]interface{}) Block {
+		return Block{
+			ID:      id,
+			BoardID: "board-id",
+			Type:    TypeCard,
+			Fields:  map[string]interface{}{"properties": props},
+		}
+	}
+
+	propertyIDs := func(cardProperties []map[string]interface{}) []string {
+		ids := make([]string, 0, len(cardProperties))
+		for _, prop := range cardProperties {
+			ids = append(ids, getMapString("id", prop))
+		}
+		return ids
+	}
+
+	t.Run("delete a property", func(t *testing.T) {
+		board := newBoard()
+		cards := []Block{
+			newCard("card1", map[string]interface{}{"notes": "first", "estimate": "3"}),
+			newCard("card2", map[string]interface{}{"estimate": "5"}),
+		}
+		m := &PropertyMigration{Operation: PropertyMigrationDelete, PropertyID: "notes"}
+
+		cardProperties, err := MigrateCardProperties(board, cards, m)
+		require.NoError(t, err)
+		require.Equal(t, []string{"status", "estimate"}, propertyIDs(cardProperties))
+		require.Equal(t, 1, m.PreviousIndex)
+		require.Equal(t, "Notes", m.PreviousProperty["name"])
+		require.Equal(t, []PropertyValueChange{{BlockID: "card1", Before: "first", After: nil}}, m.Changes)
+
+		board.CardProperties = cardProperties
+		require.Equal(t, []string{"status", "notes", "estimate"}, propertyIDs(m.UndoCardProperties(board)))
+	})
+
+	t.Run("unknown property", func(t *testing.T) {
+		m := &PropertyMigration{Operation: PropertyMigrationDelete, PropertyID: "unknown"}
+		_, err := MigrateCardProperties(newBoard(), nil, m)
+		require.ErrorIs(t, err, ErrInvalidPropertyMigration)
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+		m := &PropertyMigration{Operation: "unknown", PropertyID: "notes"}
+		_, err := MigrateCardProperties(newBoard(), nil, m)
+		require.ErrorIs(t, err, ErrInvalidPropertyMigration)
+	})
+
+	t.Run("retype a select to a multiSelect", func(t *testing.T) {
+		m := &PropertyMigration{Operation: PropertyMigrationRetype, PropertyID: "status"}
+		m.Type = "multiSelect"
+		cards := []Block{newCard("card1", map[string]interface{}{"status": "todo"})}
+
+		cardProperties, err := MigrateCardProperties(newBoard(), cards, m)
+		require.NoError(t, err)
+		require.Equal(t, "multiSelect", cardProperties[0]["type"])
+		require.Len(t, cardProperties[0]["options"], 3)
+		require.Equal(t, []PropertyValueChange{{BlockID: "card1", Before: "todo", After: []interface{}{"todo"}}}, m.Changes)
+	})
+
+	t.Run("retype a text to a select", func(t *testing.T) {
+		m := &PropertyMigration{Operation: PropertyMigrationRetype, PropertyID: "notes"}
+		m.Type = "select"
+		cards := []Block{
+			newCard("card1", map[string]interface{}{"notes": "Backend"}),
+			newCard("card2", map[string]interface{}{"notes": "backend "}),
+			newCard("card3", map[string]interface{}{"notes": "Frontend"}),
+		}
+
+		cardProperties, err := MigrateCardProperties(newBoard(), cards, m)
+		require.NoError(t, err)
+		options, ok := cardProperties[1]["options"].([]interface{})
+		require.True(t, ok)
+		require.Len(t, options, 2)
+		require.Len(t, m.Changes, 3)
+		require.Equal(t, m.Changes[0].After, m.Changes[1].After)
+		require.NotEqual(t, m.Changes[0].After, m.Changes[2].After)
+	})
+
+	t.Run("retype a text to a number", func(t *testing.T) {
+		m := &PropertyMigration{Operation: PropertyMigrationRetype, PropertyID: "notes"}
+		m.Type = PropTypeNumber
+		cards := []Block{
+			newCard("card1", map[string]interface{}{"notes": " 42 "}),
+			newCard("card2", map[string]interface{}{"notes": "many"}),
+		}
+
+		_, err := MigrateCardProperties(newBoard(), cards, m)
+		require.NoError(t, err)
+		require.Equal(t, []PropertyValueChange{
+			{BlockID: "card1", Before: " 42 ", After: "42"},
+			{BlockID: "card2", Before: "many", After: nil},
+		}, m.Changes)
+	})
+
+	t.Run("retype to an unsupported type", func(t *testing.T) {
+		m := &PropertyMigration{Operation: PropertyMigrationRetype, PropertyID: "notes"}
+		m.Type = PropTypeRollup
+		_, err := MigrateCardProperties(newBoard(), nil, m)
+		require.ErrorIs(t, err, ErrInvalidPropertyMigration)
+	})
+
+	t.Run("merge options", func(t *testing.T) {
+		m := &PropertyMigration{Operation: PropertyMigrationMergeOptions, PropertyID: "status"}
+		m.OptionIDs = []string{"doing"}
+		m.TargetOptionID = "todo"
+		cards := []Block{
+			newCard("card1", map[string]interface{}{"status": "doing"}),
+			newCard("card2", map[string]interface{}{"status": "done"}),
+		}
+
+		cardProperties, err := MigrateCardProperties(newBoard(), cards, m)
+		require.NoError(t, err)
+		require.Len(t, cardProperties[0]["options"], 2)
+		require.Equal(t, []PropertyValueChange{{BlockID: "card1", Before: "doing", After: "todo"}}, m.Changes)
+	})
+
+	t.Run("merge an option into itself", func(t *testing.T) {
+		m := &PropertyMigration{Operation: PropertyMigrationMergeOptions, PropertyID: "status"}
+		m.OptionIDs = []string{"todo"}
+		m.TargetOptionID = "todo"
+		_, err := MigrateCardProperties(newBoard(), nil, m)
+		require.ErrorIs(t, err, ErrInvalidPropertyMigration)
+	})
+
+	t.Run("rename an option", func(t *testing.T) {
+		m := &PropertyMigration{Operation: PropertyMigrationRenameOption, PropertyID: "status"}
+		m.OptionID = "doing"
+		m.Value = "In Progress"
+		cards := []Block{newCard("card1", map[string]interface{}{"status": "doing"})}
+
+		cardProperties, err := MigrateCardProperties(newBoard(), cards, m)
+		require.NoError(t, err)
+		options, ok := cardProperties[0]["options"].([]interface{})
+		require.True(t, ok)
+		require.Equal(t, "In Progress", options[1].(map[string]interface{})["value"])
+		require.Empty(t, m.Changes)
+	})
+
+	t.Run("rename an option to an existing value", func(t *testing.T) {
+		m := &PropertyMigration{Operation: PropertyMigrationRenameOption, PropertyID: "status"}
+		m.OptionID = "doing"
+		m.Value = "done"
+		_, err := MigrateCardProperties(newBoard(), nil, m)
+		require.ErrorIs(t, err, ErrInvalidPropertyMigration)
+	})
+}
@@ -0,0 +1,449 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	PropertyErrorWrongType     = "wrong_type"
+	PropertyErrorMalformedDate = "malformed_date"
+	PropertyErrorNotANumber    = "not_a_number"
+	PropertyErrorNotABoolean   = "not_a_boolean"
+	PropertyErrorRequired      = "required"
+)
+
+// How the server enforces the required card properties.
+const (
+	// RequiredPropertiesWarn saves the cards missing required properties and logs a warning.
+	RequiredPropertiesWarn = "warn"
+
+	// RequiredPropertiesReject rejects the cards missing required properties.
+	RequiredPropertiesReject = "reject"
+)
+
+// PropertyValueError describes a card property value that does not match
+// the property schema of its board
+// swagger:model
+type PropertyValueError struct {
+	// The id of the card
+	// required: true
+	BlockID string `json:"blockId"`
+
+	// The id of the property
+	// required: true
+	PropertyID string `json:"propertyId"`
+
+	// The type of the property
+	// required: true
+	PropertyType string `json:"propertyType"`
+
+	// The rejected value
+	// required: true
+	Value interface{} `json:"value"`
+
+	// The reason the value was rejected
+	// required: true
+	Reason string `json:"reason"`
+}
+
+// ErrInvalidPropertyValues is returned when card property values do not match
+// the property schema of their board. It wraps ErrInvalidPropertyValue.
+type ErrInvalidPropertyValues struct {
+	Errors []PropertyValueError
+}
+
+func (e *ErrInvalidPropertyValues) Error() string {
+	if len(e.Errors) == 1 {
+		pe := e.Errors[0]
+		return fmt.Sprintf("invalid value for property %s of block %s: %s", pe.PropertyID, pe.BlockID, pe.Reason)
+	}
+	return fmt.Sprintf("%d invalid property values", len(e.Errors))
+}
+
+func (e *ErrInvalidPropertyValues) Unwrap() error {
+	return ErrInvalidPropertyValue
+}
+
+// PropertyErrorResponse is an error response listing the invalid card property values
+// swagger:model
+type PropertyErrorResponse struct {
+	ErrorResponse
+
+	// The invalid property values
+	// required: true
+	PropertyErrors []PropertyValueError `json:"propertyErrors"`
+}
+
+// PropertyRepairResult is the result of repairing the card property values of boards
+// swagger:model
+type PropertyRepairResult struct {
+	// The number of boards checked
+	// required: true
+	Boards int `json:"boards"`
+
+	// The number of cards checked
+	// required: true
+	Cards int `json:"cards"`
+
+	// The number of cards whose properties were normalized or removed
+	// required: true
+	RepairedCards int `json:"repairedCards"`
+
+	// The invalid values that were removed
+	// required: true
+	Removed []PropertyValueError `json:"removed"`
+}
+
+// PropertyConformanceReport lists the cards of a board whose property values do not
+// conform to the property schema of the board
+// swagger:model
+type PropertyConformanceReport struct {
+	// The number of cards checked
+	// required: true
+	Cards int `json:"cards"`
+
+	// The number of cards with missing required properties or invalid values
+	// required: true
+	NonConformingCards int `json:"nonConformingCards"`
+
+	// The missing required properties and invalid values
+	// required: true
+	Errors []PropertyValueError `json:"errors"`
+}
+
+func PropertyConformanceReportFromJSON(data io.Reader) *PropertyConformanceReport {
+	var report *PropertyConformanceReport
+	_ = json.NewDecoder(data).Decode(&report)
+	return report
+}
+
+type propertyAction int
+
+const (
+	propertyKeep propertyAction = iota
+	propertyUpdate
+	propertyRemove
+	propertyInvalid
+)
+
+// NormalizePropertyValues checks the `properties` field of a card against the property
+// schema of its board. Values that can be fixed without guessing are normalized in place:
+// empty values are removed, references to deleted options are dropped and scalar values
+// are converted to the string representation the web app uses. The remaining invalid
+// values are returned. Properties that are not in the schema are left untouched.
+func NormalizePropertyValues(block *Block, schema PropSchema) (bool, []PropertyValueError) {
+	return normalizePropertyValues(block, schema, false)
+}
+
+// RepairPropertyValues normalizes the `properties` field of a card like
+// NormalizePropertyValues, and also removes the values that cannot be normalized.
+// The removed values are returned.
+func RepairPropertyValues(block *Block, schema PropSchema) (bool, []PropertyValueError) {
+	return normalizePropertyValues(block, schema, true)
+}
+
+func normalizePropertyValues(block *Block, schema PropSchema, removeInvalid bool) (bool, []PropertyValueError) {
+	propsIface, ok := block.Fields["properties"]
+	if !ok || propsIface == nil {
+		return false, nil
+	}
+
+	props, ok := propsIface.(map[string]interface{})
+	if !ok {
+		err := PropertyValueError{
+			BlockID: block.ID,
+			Value:   propsIface,
+			Reason:  PropertyErrorWrongType,
+		}
+		if removeInvalid {
+			delete(block.Fields, "properties")
+			return true, []PropertyValueError{err}
+		}
+		return false, []PropertyValueError{err}
+	}
+
+	var errs []PropertyValueError
+	var normalized map[string]interface{}
+	for id, v := range props {
+		def, found := schema[id]
+		if !found {
+			continue
+		}
+
+		nv, action, reason := normalizePropertyValue(def, v)
+		if action == propertyInvalid {
+			errs = append(errs, PropertyValueError{
+				BlockID:      block.ID,
+				PropertyID:   id,
+				PropertyType: def.Type,
+				Value:        v,
+				Reason:       reason,
+			})
+			if !removeInvalid {
+				continue
+			}
+			action = propertyRemove
+		}
+		if action == propertyKeep {
+			continue
+		}
+
+		// copy on write, the properties map may be shared with a patch.
+		if normalized == nil {
+			normalized = make(map[string]interface{}, len(props))
+			for k, pv := range props {
+				normalized[k] = pv
+			}
+		}
+		if action == propertyRemove {
+			delete(normalized, id)
+		} else {
+			normalized[id] = nv
+		}
+	}
+
+	if normalized == nil {
+		return false, errs
+	}
+	block.Fields["properties"] = normalized
+	return true, errs
+}
+
+// normalizePropertyValue returns the normalized value of a property and what to do with it.
+func normalizePropertyValue(def PropDef, v interface{}) (interface{}, propertyAction, string) {
+	if v == nil {
+		return nil, propertyRemove, ""
+	}
+
+	switch def.Type {
+	case "select", "person":
+		s, ok := v.(string)
+		if !ok {
+			return nil, propertyInvalid, PropertyErrorWrongType
+		}
+		if s == "" {
+			return nil, propertyRemove, ""
+		}
+		if def.Type == "select" {
+			if _, found := def.Options[s]; !found {
+				// the option was deleted from the board.
+				return nil, propertyRemove, ""
+			}
+		}
+		return s, propertyKeep, ""
+
+	case "multiSelect":
+		var ids []interface{}
+		changed := false
+		switch t := v.(type) {
+		case string:
+			ids = []interface{}{t}
+			changed = true
+		case []interface{}:
+			ids = t
+		default:
+			return nil, propertyInvalid, PropertyErrorWrongType
+		}
+		values := make([]interface{}, 0, len(ids))
+		for _, idIface := range ids {
+			id, ok := idIface.(string)
+			if !ok {
+				return nil, propertyInvalid, PropertyErrorWrongType
+			}
+			if _, found := def.Options[id]; !found {
+				changed = true
+				continue
+			}
+			values = append(values, id)
+		}
+		if len(values) == 0 {
+			return nil, propertyRemove, ""
+		}
+		if changed {
+			return values, propertyUpdate, ""
+		}
+		return v, propertyKeep, ""
+
+	case PropTypeRelation:
+		// unknown cards are dropped by the app, which can look them up.
+		var ids []interface{}
+		changed := false
+		switch t := v.(type) {
+		case string:
+			ids = []interface{}{t}
+			changed = true
+		case []interface{}:
+			ids = t
+		default:
+			return nil, propertyInvalid, PropertyErrorWrongType
+		}
+		values := make([]interface{}, 0, len(ids))
+		seen := make(map[string]bool, len(ids))
+		for _, idIface := range ids {
+			id, ok := idIface.(string)
+			if !ok {
+				return nil, propertyInvalid, PropertyErrorWrongType
+			}
+			if id == "" || seen[id] {
+				changed = true
+				continue
+			}
+			seen[id] = true
+			values = append(values, id)
+		}
+		if len(values) == 0 {
+			return nil, propertyRemove, ""
+		}
+		if changed {
+			return values, propertyUpdate, ""
+		}
+		return v, propertyKeep, ""
+
+	case "date":
+		switch t := v.(type) {
+		case string:
+			if t == "" {
+				return nil, propertyRemove, ""
+			}
+			if _, err := def.ParseDate(t); err != nil {
+				return nil, propertyInvalid, PropertyErrorMalformedDate
+			}
+			return t, propertyKeep, ""
+		case float64:
+			return fmt.Sprintf(`{"from":%d}`, int64(t)), propertyUpdate, ""
+		}
+		return nil, propertyInvalid, PropertyErrorWrongType
+
+	case PropTypeNumber, PropTypeCurrency, PropTypePercentage:
+		switch t := v.(type) {
+		case string:
+			if strings.TrimSpace(t) == "" {
+				return nil, propertyRemove, ""
+			}
+			// ParseFloat accepts "NaN" and "Inf", and returns an error with ±Inf for 1e999.
+			n, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+			if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+				return nil, propertyInvalid, PropertyErrorNotANumber
+			}
+			return t, propertyKeep, ""
+		case float64:
+			if math.IsNaN(t) || math.IsInf(t, 0) {
+				return nil, propertyInvalid, PropertyErrorNotANumber
+			}
+			return strconv.FormatFloat(t, 'f', -1, 64), propertyUpdate, ""
+		}
+		return nil, propertyInvalid, PropertyErrorWrongType
+
+	case "checkbox":
+		switch t := v.(type) {
+		case string:
+			if t == "" {
+				return nil, propertyRemove, ""
+			}
+			if t != "true" && t != "false" {
+				return nil, propertyInvalid, PropertyErrorNotABoolean
+			}
+			return t, propertyKeep, ""
+		case bool:
+			return strconv.FormatBool(t), propertyUpdate, ""
+		}
+		return nil, propertyInvalid, PropertyErrorWrongType
+
+	case "text", "url", "email", "phone":
+		switch t := v.(type) {
+		case string:
+			return t, propertyKeep, ""
+		case float64, bool:
+			return fmt.Sprintf("%v", t), propertyUpdate, ""
+		}
+		return nil, propertyInvalid, PropertyErrorWrongType
+	}
+
+	// computed properties and unknown types are not validated.
+	return v, propertyKeep, ""
+}
+
+// ApplyPropertyDefaults sets the properties of a card that have no value to the default
+// value of the property, if any. Default values that are not valid for the property are
+// ignored.
+func ApplyPropertyDefaults(block *Block, schema PropSchema) bool {
+	props, _ := block.Fields["properties"].(map[string]interface{})
+
+	var updated map[string]interface{}
+	for id, def := range schema {
+		if def.Default == nil || def.IsComputed() || def.IsSystem() {
+			continue
+		}
+		if hasPropertyValue(def, props[id]) {
+			continue
+		}
+		value, action, _ := normalizePropertyValue(def, def.Default)
+		if action == propertyInvalid || action == propertyRemove {
+			continue
+		}
+
+		// copy on write, the properties map may be shared.
+		if updated == nil {
+			updated = make(map[string]interface{}, len(props)+1)
+			for k, v := range props {
+				updated[k] = v
+			}
+		}
+		updated[id] = value
+	}
+
+	if updated == nil {
+		return false
+	}
+	if block.Fields == nil {
+		block.Fields = make(map[string]interface{})
+	}
+	block.Fields["properties"] = updated
+	return true
+}
+
+// MissingRequiredProperties returns an error for each required property of the schema
+// that has no value on a card. Computed and system properties are never missing.
+func MissingRequiredProperties(block *Block, schema PropSchema) []PropertyValueError {
+	props, _ := block.Fields["properties"].(map[string]interface{})
+
+	var errs []PropertyValueError
+	for id, def := range schema {
+		if !def.Required || def.IsComputed() || def.IsSystem() {
+			continue
+		}
+		if hasPropertyValue(def, props[id]) {
+			continue
+		}
+		errs = append(errs, PropertyValueError{
+			BlockID:      block.ID,
+			PropertyID:   id,
+			PropertyType: def.Type,
+			Value:        props[id],
+			Reason:       PropertyErrorRequired,
+		})
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		return schema[errs[i].PropertyID].Index < schema[errs[j].PropertyID].Index
+	})
+	return errs
+}
+
+// hasPropertyValue returns true if a value is set for a property, empty texts and
+// references to deleted options meaning no value.
+func hasPropertyValue(def PropDef, v interface{}) bool {
+	nv, action, _ := normalizePropertyValue(def, v)
+	if action == propertyRemove {
+		return false
+	}
+	if s, ok := nv.(string); ok && strings.TrimSpace(s) == "" {
+		return false
+	}
+	return true
+}
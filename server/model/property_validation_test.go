@@ -0,0 +1,212 @@
+package model
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePropertyValues(t *testing.T) {
+	schema := PropSchema{
+		"status": {
+			ID:   "status",
+			Type: "select",
+			Options: map[string]PropDefOption{
+				"todo": {ID: "todo", Value: "To Do"},
+				"done": {ID: "done", Value: "Done"},
+			},
+		},
+		"tags": {
+			ID:   "tags",
+			Type: "multiSelect",
+			Options: map[string]PropDefOption{
+				"bug":  {ID: "bug", Value: "Bug"},
+				"docs": {ID: "docs", Value: "Docs"},
+			},
+		},
+		"due":      {ID: "due", Type: "date"},
+		"estimate": {ID: "estimate", Type: "number"},
+		"done":     {ID: "done", Type: "checkbox"},
+		"notes":    {ID: "notes", Type: "text"},
+		"owner":    {ID: "owner", Type: "person"},
+		"links":    {ID: "links", Type: PropTypeRelation, Relation: &RelationDef{BoardID: "board-id"}},
+	}
+
+	newCard := func(props map[string]interface{}) *Block {
+		return &Block{
+			ID:     "card-id",
+			Type:   TypeCard,
+			Fields: map[string]interface{}{"properties": props},
+		}
+	}
+
+	t.Run("valid values are untouched", func(t *testing.T) {
+		props := map[string]interface{}{
+			"status":   "todo",
+			"tags":     []interface{}{"bug", "docs"},
+			"due":      `{"from":1642161600000}`,
+			"estimate": "3.5",
+			"done":     "true",
+			"notes":    "some notes",
+			"owner":    "user-id",
+			"links":    []interface{}{"card-1", "card-2"},
+			"unknown":  map[string]interface{}{"any": "thing"},
+		}
+		card := newCard(props)
+
+		changed, errs := NormalizePropertyValues(card, schema)
+		require.False(t, changed)
+		require.Empty(t, errs)
+		require.Equal(t, props, card.Fields["properties"])
+	})
+
+	t.Run("fixable values are normalized", func(t *testing.T) {
+		props := map[string]interface{}{
+			"status":   "deleted-option",
+			"tags":     []interface{}{"bug", "deleted-option"},
+			"due":      float64(1642161600000),
+			"estimate": float64(3),
+			"done":     true,
+			"notes":    "",
+			"owner":    "",
+			"links":    []interface{}{"card-1", "", "card-1"},
+		}
+		card := newCard(props)
+
+		changed, errs := NormalizePropertyValues(card, schema)
+		require.True(t, changed)
+		require.Empty(t, errs)
+		require.Equal(t, map[string]interface{}{
+			"tags":     []interface{}{"bug"},
+			"due":      `{"from":1642161600000}`,
+			"estimate": "3",
+			"done":     "true",
+			"notes":    "",
+			"links":    []interface{}{"card-1"},
+		}, card.Fields["properties"])
+
+		// the original map is not modified.
+		require.Equal(t, "deleted-option", props["status"])
+	})
+
+	t.Run("invalid values are reported", func(t *testing.T) {
+		card := newCard(map[string]interface{}{
+			"status":   []interface{}{"todo"},
+			"due":      "not json",
+			"estimate": "three",
+			"done":     "maybe",
+			"notes":    "valid",
+			"links":    []interface{}{"card-1", float64(2)},
+		})
+
+		changed, errs := NormalizePropertyValues(card, schema)
+		require.False(t, changed)
+		require.ElementsMatch(t, []PropertyValueError{
+			{BlockID: "card-id", PropertyID: "status", PropertyType: "select", Value: []interface{}{"todo"}, Reason: PropertyErrorWrongType},
+			{BlockID: "card-id", PropertyID: "due", PropertyType: "date", Value: "not json", Reason: PropertyErrorMalformedDate},
+			{BlockID: "card-id", PropertyID: "estimate", PropertyType: "number", Value: "three", Reason: PropertyErrorNotANumber},
+			{BlockID: "card-id", PropertyID: "done", PropertyType: "checkbox", Value: "maybe", Reason: PropertyErrorNotABoolean},
+			{BlockID: "card-id", PropertyID: "links", PropertyType: PropTypeRelation, Value: []interface{}{"card-1", float64(2)}, Reason: PropertyErrorWrongType},
+		}, errs)
+
+		err := &ErrInvalidPropertyValues{Errors: errs}
+		require.ErrorIs(t, err, ErrInvalidPropertyValue)
+	})
+
+	t.Run("non-finite numbers are invalid", func(t *testing.T) {
+		for _, value := range []interface{}{"NaN", "Inf", "-infinity", "1e999", math.NaN(), math.Inf(1)} {
+			card := newCard(map[string]interface{}{"estimate": value})
+
+			_, errs := NormalizePropertyValues(card, schema)
+			require.Len(t, errs, 1, "value %v", value)
+			require.Equal(t, PropertyErrorNotANumber, errs[0].Reason)
+		}
+	})
+
+	t.Run("repair removes invalid values", func(t *testing.T) {
+		card := newCard(map[string]interface{}{
+			"due":    "not json",
+			"status": "deleted-option",
+			"notes":  "valid",
+		})
+
+		changed, removed := RepairPropertyValues(card, schema)
+		require.True(t, changed)
+		require.Len(t, removed, 1)
+		require.Equal(t, "due", removed[0].PropertyID)
+		require.Equal(t, map[string]interface{}{"notes": "valid"}, card.Fields["properties"])
+	})
+
+	t.Run("properties field of the wrong type", func(t *testing.T) {
+		card := &Block{ID: "card-id", Type: TypeCard, Fields: map[string]interface{}{"properties": "oops"}}
+
+		changed, errs := NormalizePropertyValues(card, schema)
+		require.False(t, changed)
+		require.Len(t, errs, 1)
+
+		changed, _ = RepairPropertyValues(card, schema)
+		require.True(t, changed)
+		require.NotContains(t, card.Fields, "properties")
+	})
+}
+
+func TestRequiredPropertiesAndDefaults(t *testing.T) {
+	schema := PropSchema{
+		"status": {
+			ID:       "status",
+			Index:    0,
+			Type:     "select",
+			Required: true,
+			Default:  "todo",
+			Options: map[string]PropDefOption{
+				"todo": {ID: "todo", Value: "To Do"},
+			},
+		},
+		"owner":    {ID: "owner", Index: 1, Type: "person", Required: true},
+		"notes":    {ID: "notes", Index: 2, Type: "text", Required: true},
+		"estimate": {ID: "estimate", Index: 3, Type: "number", Default: 3.0},
+		"due":      {ID: "due", Index: 4, Type: "date", Default: "not a date"},
+		"total":    {ID: "total", Index: 5, Type: PropTypeFormula, Required: true, Default: "1"},
+	}
+
+	newCard := func(props map[string]interface{}) *Block {
+		return &Block{
+			ID:     "card-id",
+			Type:   TypeCard,
+			Fields: map[string]interface{}{"properties": props},
+		}
+	}
+
+	t.Run("defaults fill the missing values", func(t *testing.T) {
+		card := &Block{ID: "card-id", Type: TypeCard}
+
+		require.True(t, ApplyPropertyDefaults(card, schema))
+		require.Equal(t, map[string]interface{}{"status": "todo", "estimate": "3"}, card.Fields["properties"])
+	})
+
+	t.Run("defaults do not override values", func(t *testing.T) {
+		props := map[string]interface{}{"status": "todo", "estimate": "5"}
+		card := newCard(props)
+
+		require.False(t, ApplyPropertyDefaults(card, schema))
+		require.Equal(t, "5", props["estimate"])
+	})
+
+	t.Run("missing required properties", func(t *testing.T) {
+		card := newCard(map[string]interface{}{"status": "deleted-option", "notes": " "})
+
+		errs := MissingRequiredProperties(card, schema)
+		require.Len(t, errs, 3)
+		require.Equal(t, "status", errs[0].PropertyID)
+		require.Equal(t, "owner", errs[1].PropertyID)
+		require.Equal(t, "notes", errs[2].PropertyID)
+		require.Equal(t, PropertyErrorRequired, errs[0].Reason)
+	})
+
+	t.Run("no missing required properties", func(t *testing.T) {
+		card := newCard(map[string]interface{}{"status": "todo", "owner": "user-id", "notes": "some notes"})
+
+		require.Empty(t, MissingRequiredProperties(card, schema))
+	})
+}
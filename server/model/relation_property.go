@@ -0,0 +1,93 @@
+package model
+
+// RelationIDs returns the card IDs of the value of a relation property, which is
+// either a slice of IDs or a single ID.
+func RelationIDs(v interface{}) []string {
+	var ids []string
+	switch t := v.(type) {
+	case string:
+		if t != "" {
+			ids = append(ids, t)
+		}
+	case []interface{}:
+		for _, idIface := range t {
+			if id, ok := idIface.(string); ok && id != "" {
+				ids = append(ids, id)
+			}
+		}
+	case []string:
+		for _, id := range t {
+			if id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// RelationValue returns the value a relation property stores for a list of card IDs.
+func RelationValue(ids []string) []interface{} {
+	value := make([]interface{}, len(ids))
+	for i, id := range ids {
+		value[i] = id
+	}
+	return value
+}
+
+// CardRelationIDs returns the IDs of the cards linked to a card by a relation property.
+func CardRelationIDs(card *Block, propertyID string) []string {
+	if card == nil {
+		return nil
+	}
+	props, ok := card.Fields["properties"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return RelationIDs(props[propertyID])
+}
+
+// CardPropertyRelations returns the relations defined by card properties.
+func CardPropertyRelations(cardProperties []map[string]interface{}) []RelationDef {
+	relations := make([]RelationDef, 0)
+	for _, prop := range cardProperties {
+		if getMapString("type", prop) != PropTypeRelation {
+			continue
+		}
+		if relation, err := parseRelationDef(prop); err == nil {
+			relations = append(relations, *relation)
+		}
+	}
+	return relations
+}
+
+// remapRelationBoardIDs updates the relation properties of a board whose ID, or
+// the IDs of the boards it links to, changed. Relations to boards that were not
+// given new IDs lose their back-reference, which stays with the original board.
+func remapRelationBoardIDs(board *Board, newBoardIDs map[string]string) {
+	for i, prop := range board.CardProperties {
+		if getMapString("type", prop) != PropTypeRelation {
+			continue
+		}
+		relation, ok := prop["relation"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		remapped := make(map[string]interface{}, len(relation))
+		for k, v := range relation {
+			remapped[k] = v
+		}
+		if newID, found := newBoardIDs[getMapString("boardId", relation)]; found {
+			remapped["boardId"] = newID
+		} else {
+			delete(remapped, "backReferencePropertyId")
+		}
+
+		newProp := make(map[string]interface{}, len(prop))
+		for k, v := range prop {
+			newProp[k] = v
+		}
+		newProp["relation"] = remapped
+		board.CardProperties[i] = newProp
+	}
+}
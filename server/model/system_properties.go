@@ -0,0 +1,90 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+// System property types, whose values are not stored in the properties of a card but
+// taken from the block itself.
+const (
+	PropTypeCreatedTime = "createdTime"
+	PropTypeCreatedBy   = "createdBy"
+	PropTypeUpdatedTime = "updatedTime"
+	PropTypeUpdatedBy   = "updatedBy"
+)
+
+// IsSystem returns true if the property value is taken from the block metadata.
+func (pd PropDef) IsSystem() bool {
+	switch pd.Type {
+	case PropTypeCreatedTime, PropTypeCreatedBy, PropTypeUpdatedTime, PropTypeUpdatedBy:
+		return true
+	}
+	return false
+}
+
+// IsTimestamp returns true if the property value is a time in milliseconds.
+func (pd PropDef) IsTimestamp() bool {
+	return pd.Type == PropTypeCreatedTime || pd.Type == PropTypeUpdatedTime
+}
+
+// SystemPropertyValue returns the value of a system property of a block: the user ID for
+// the created by and updated by properties, and the time in milliseconds as a string for
+// the timestamp properties, the way numbers are stored. It returns false if the property
+// is not a system property.
+func SystemPropertyValue(def PropDef, block *Block) (interface{}, bool) {
+	switch def.Type {
+	case PropTypeCreatedTime:
+		return strconv.FormatInt(block.CreateAt, 10), true
+	case PropTypeUpdatedTime:
+		return strconv.FormatInt(block.UpdateAt, 10), true
+	case PropTypeCreatedBy:
+		return block.CreatedBy, true
+	case PropTypeUpdatedBy:
+		return block.ModifiedBy, true
+	}
+	return nil, false
+}
+
+// CardPropertyValues returns the property values of a card, including the values of the
+// system properties of the schema. The properties of the card are not modified.
+func CardPropertyValues(card *Block, schema PropSchema) map[string]interface{} {
+	props, _ := card.Fields["properties"].(map[string]interface{})
+	values := make(map[string]interface{}, len(props)+4)
+	for k, v := range props {
+		values[k] = v
+	}
+	for id, def := range schema {
+		if v, ok := SystemPropertyValue(def, card); ok {
+			values[id] = v
+		}
+	}
+	return values
+}
+
+// formatTimestamp renders the value of a timestamp property, in the location of the
+// resolver if any.
+func (pd PropDef) formatTimestamp(v interface{}, resolver PropValueResolver) (string, error) {
+	var millis int64
+	switch t := v.(type) {
+	case string:
+		ms, err := strconv.ParseInt(t, 10, 64)
+		if err != nil {
+			return "", ErrInvalidPropertyValue
+		}
+		millis = ms
+	case int64:
+		millis = t
+	case float64:
+		millis = int64(t)
+	default:
+		return "", ErrInvalidPropertyValueType
+	}
+
+	var loc *time.Location
+	if locator, ok := resolver.(PropValueLocator); ok {
+		loc = locator.Location()
+	}
+	dv := &DateValue{From: millis, IncludeTime: true}
+	return dv.Format(loc), nil
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ViewAggregations are the aggregated card property values of a view
+// swagger:model
+type ViewAggregations struct {
+	// The id of the view
+	// required: true
+	ViewID string `json:"viewId"`
+
+	// The id of the property the cards are grouped by, empty if the view is not grouped
+	// required: true
+	GroupByPropertyID string `json:"groupByPropertyId"`
+
+	// The aggregations over all the cards shown by the view
+	// required: true
+	Total *AggregationGroup `json:"total"`
+
+	// The aggregations per group, in the order of the options of the group property.
+	// Cards without a value are in the last group, whose value is empty
+	// required: true
+	Groups []*AggregationGroup `json:"groups"`
+}
+
+// AggregationGroup is a group of cards with their aggregated property values
+// swagger:model
+type AggregationGroup struct {
+	// The option id of the group, empty for the total and for cards without a value
+	// required: true
+	Value string `json:"value"`
+
+	// The number of cards in the group
+	// required: true
+	Count int `json:"count"`
+
+	// The aggregated values, keyed by property id
+	// required: true
+	Columns map[string]*PropertyAggregation `json:"columns"`
+}
+
+// PropertyAggregation is the aggregation of the values of a card property
+// swagger:model
+type PropertyAggregation struct {
+	// The number of cards
+	// required: true
+	Count int `json:"count"`
+
+	// The number of cards without a value
+	// required: true
+	CountEmpty int `json:"countEmpty"`
+
+	// The number of cards with a value
+	// required: true
+	CountNotEmpty int `json:"countNotEmpty"`
+
+	// The sum of the numeric values, absent for non-numeric properties
+	// required: false
+	Sum *float64 `json:"sum,omitempty"`
+
+	// The average of the numeric values, absent for non-numeric properties
+	// required: false
+	Average *float64 `json:"average,omitempty"`
+
+	// The smallest numeric value, absent for non-numeric properties
+	// required: false
+	Min *float64 `json:"min,omitempty"`
+
+	// The largest numeric value, absent for non-numeric properties
+	// required: false
+	Max *float64 `json:"max,omitempty"`
+}
+
+func ViewAggregationsFromJSON(data io.Reader) *ViewAggregations {
+	var aggregations *ViewAggregations
+	_ = json.NewDecoder(data).Decode(&aggregations)
+	return aggregations
+}
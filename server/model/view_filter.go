@@ -0,0 +1,169 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Filter conditions of view filter clauses.
+const (
+	FilterConditionIncludes    = "includes"
+	FilterConditionNotIncludes = "notIncludes"
+	FilterConditionIsEmpty     = "isEmpty"
+	FilterConditionIsNotEmpty  = "isNotEmpty"
+)
+
+// FilterClause is a condition on a card property of a view filter.
+type FilterClause struct {
+	PropertyID string   `json:"propertyId"`
+	Condition  string   `json:"condition"`
+	Values     []string `json:"values"`
+}
+
+// FilterGroup is the filter of a view, a group of clauses and nested groups
+// combined with the "and" or "or" operation.
+type FilterGroup struct {
+	Operation string
+	Clauses   []FilterClause
+	Groups    []*FilterGroup
+}
+
+type filterItem struct {
+	FilterClause
+	Operation *string           `json:"operation"`
+	Filters   []json.RawMessage `json:"filters"`
+}
+
+// ParseViewFilter returns the filter of a view block, or nil if the view has no filter.
+func ParseViewFilter(view *Block) (*FilterGroup, error) {
+	filterIface, ok := view.Fields["filter"]
+	if !ok || filterIface == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(filterIface)
+	if err != nil {
+		return nil, err
+	}
+	var item filterItem
+	if err = json.Unmarshal(data, &item); err != nil {
+		return nil, fmt.Errorf("invalid view filter: %w", err)
+	}
+	return parseFilterGroup(item)
+}
+
+func parseFilterGroup(item filterItem) (*FilterGroup, error) {
+	fg := &FilterGroup{Operation: "and"}
+	if item.Operation != nil && *item.Operation == "or" {
+		fg.Operation = "or"
+	}
+
+	for _, raw := range item.Filters {
+		var child filterItem
+		if err := json.Unmarshal(raw, &child); err != nil {
+			return nil, fmt.Errorf("invalid view filter: %w", err)
+		}
+		if child.Operation != nil {
+			group, err := parseFilterGroup(child)
+			if err != nil {
+				return nil, err
+			}
+			fg.Groups = append(fg.Groups, group)
+			continue
+		}
+		fg.Clauses = append(fg.Clauses, child.FilterClause)
+	}
+	return fg, nil
+}
+
+// Matches returns true if a card meets the filter, following the same rules as the web app.
+func (fg *FilterGroup) Matches(card *Block) bool {
+	props, _ := card.Fields["properties"].(map[string]interface{})
+	return fg.MatchesValues(props)
+}
+
+// MatchesValues returns true if the property values of a card meet the filter. Unlike
+// Matches, it can filter on the system properties returned by CardPropertyValues.
+func (fg *FilterGroup) MatchesValues(props map[string]interface{}) bool {
+	if fg == nil || len(fg.Clauses)+len(fg.Groups) == 0 {
+		return true
+	}
+
+	if fg.Operation == "or" {
+		for _, clause := range fg.Clauses {
+			if clause.matches(props) {
+				return true
+			}
+		}
+		for _, group := range fg.Groups {
+			if group.MatchesValues(props) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, clause := range fg.Clauses {
+		if !clause.matches(props) {
+			return false
+		}
+	}
+	for _, group := range fg.Groups {
+		if !group.MatchesValues(props) {
+			return false
+		}
+	}
+	return true
+}
+
+func (fc FilterClause) matches(props map[string]interface{}) bool {
+	value := props[fc.PropertyID]
+	switch fc.Condition {
+	case FilterConditionIncludes:
+		if len(fc.Values) == 0 {
+			return true
+		}
+		return valueIncludesAny(value, fc.Values)
+	case FilterConditionNotIncludes:
+		if len(fc.Values) == 0 {
+			return true
+		}
+		return !valueIncludesAny(value, fc.Values)
+	case FilterConditionIsEmpty:
+		return IsEmptyPropertyValue(value)
+	case FilterConditionIsNotEmpty:
+		return !IsEmptyPropertyValue(value)
+	}
+	return true
+}
+
+func valueIncludesAny(value interface{}, values []string) bool {
+	for _, v := range values {
+		switch t := value.(type) {
+		case string:
+			if t == v {
+				return true
+			}
+		case []interface{}:
+			for _, item := range t {
+				if item == v {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// IsEmptyPropertyValue returns true if a card property value is empty.
+func IsEmptyPropertyValue(value interface{}) bool {
+	switch t := value.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case []interface{}:
+		return len(t) == 0
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewFilter(t *testing.T) {
+	view := &Block{
+		Type: TypeView,
+		Fields: map[string]interface{}{
+			"filter": map[string]interface{}{
+				"operation": "or",
+				"filters": []interface{}{
+					map[string]interface{}{"propertyId": "status", "condition": "includes", "values": []interface{}{"done"}},
+					map[string]interface{}{
+						"operation": "and",
+						"filters": []interface{}{
+							map[string]interface{}{"propertyId": "tags", "condition": "includes", "values": []interface{}{"bug"}},
+							map[string]interface{}{"propertyId": "owner", "condition": "isEmpty", "values": []interface{}{}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	filter, err := ParseViewFilter(view)
+	require.NoError(t, err)
+	require.Equal(t, "or", filter.Operation)
+	require.Len(t, filter.Clauses, 1)
+	require.Len(t, filter.Groups, 1)
+
+	card := func(props map[string]interface{}) *Block {
+		return &Block{Type: TypeCard, Fields: map[string]interface{}{"properties": props}}
+	}
+	require.True(t, filter.Matches(card(map[string]interface{}{"status": "done"})))
+	require.True(t, filter.Matches(card(map[string]interface{}{"tags": []interface{}{"docs", "bug"}})))
+	require.False(t, filter.Matches(card(map[string]interface{}{"tags": []interface{}{"bug"}, "owner": "user-id"})))
+	require.False(t, filter.Matches(card(map[string]interface{}{})))
+
+	t.Run("system properties", func(t *testing.T) {
+		creatorFilter := &FilterGroup{
+			Operation: "and",
+			Clauses:   []FilterClause{{PropertyID: "creator", Condition: FilterConditionIncludes, Values: []string{"user-1"}}},
+		}
+		schema := PropSchema{"creator": {ID: "creator", Type: PropTypeCreatedBy}}
+		created := &Block{Type: TypeCard, CreatedBy: "user-1"}
+
+		require.False(t, creatorFilter.Matches(created))
+		require.True(t, creatorFilter.MatchesValues(CardPropertyValues(created, schema)))
+	})
+
+	t.Run("view without filter", func(t *testing.T) {
+		empty, errEmpty := ParseViewFilter(&Block{Type: TypeView, Fields: map[string]interface{}{}})
+		require.NoError(t, errEmpty)
+		require.Nil(t, empty)
+		require.True(t, empty.Matches(card(nil)))
+	})
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"fmt"
 
+	"github.com/mattermost/focalboard/server/services/cluster"
 	"github.com/mattermost/focalboard/server/services/config"
 	"github.com/mattermost/focalboard/server/services/notify"
 	"github.com/mattermost/focalboard/server/services/permissions"
@@ -21,6 +22,11 @@ type Params struct {
 	WSAdapter          ws.Adapter
 	NotifyBackends     []notify.Backend
 	PermissionsService permissions.PermissionsService
+
+	// Cluster is the node of the server in a cluster. If nil, the server joins the
+	// cluster of the servers sharing its database when the cluster mode is enabled. The
+	// server leaves the cluster when it shuts down.
+	Cluster cluster.Node
 }
 
 func (p Params) CheckValid() error {
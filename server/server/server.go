@@ -19,7 +19,10 @@ import (
 	"github.com/mattermost/focalboard/server/auth"
 	appModel "github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/services/audit"
+	"github.com/mattermost/focalboard/server/services/cluster"
 	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/filepresign"
+	"github.com/mattermost/focalboard/server/services/filescan"
 	"github.com/mattermost/focalboard/server/services/metrics"
 	"github.com/mattermost/focalboard/server/services/notify"
 	"github.com/mattermost/focalboard/server/services/notify/notifylogger"
@@ -41,10 +44,15 @@ import (
 const (
 	cleanupSessionTaskFrequency = 10 * time.Minute
 	updateMetricsTaskFrequency  = 15 * time.Minute
+	defaultBackupTaskFrequency  = 24 * time.Hour
+	defaultFileCleanupFrequency = 24 * time.Hour
+	presenceExpiryFrequency     = 30 * time.Second
 
 	minSessionExpiryTime = int64(60 * 60 * 24 * 31) // 31 days
 
 	MattermostAuthMod = "mattermost"
+
+	filesDriverS3 = "amazons3"
 )
 
 type Server struct {
@@ -59,8 +67,12 @@ type Server struct {
 	metricsServer          *metrics.Service
 	metricsService         *metrics.Metrics
 	metricsUpdaterTask     *scheduler.ScheduledTask
+	backupTask             *scheduler.ScheduledTask
+	fileCleanupTask        *scheduler.ScheduledTask
+	presenceExpiryTask     *scheduler.ScheduledTask
 	auditService           *audit.Audit
 	notificationService    *notify.Service
+	cluster                cluster.Node
 	servicesStartStopMutex sync.Mutex
 
 	localRouter     *mux.Router
@@ -76,10 +88,26 @@ func New(params Params) (*Server, error) {
 
 	authenticator := auth.New(params.Cfg, params.DBStore, params.PermissionsService)
 
+	clusterNode := params.Cluster
+	if clusterNode == nil && params.Cfg.ClusterEnabled {
+		var err error
+		if clusterNode, err = newClusterNode(params.Cfg, params.Logger); err != nil {
+			params.Logger.Error("Unable to join the cluster", mlog.Err(err))
+			return nil, err
+		}
+	}
+
 	// if no ws adapter is provided, we spin up a websocket server
 	wsAdapter := params.WSAdapter
 	if wsAdapter == nil {
-		wsAdapter = ws.NewServer(authenticator, params.SingleUserToken, params.Cfg.AuthMode == MattermostAuthMod, params.Logger, params.DBStore)
+		wsServer := ws.NewServer(authenticator, params.PermissionsService, params.SingleUserToken, params.Cfg.AuthMode == MattermostAuthMod, params.Logger, params.DBStore)
+		if clusterNode != nil {
+			if err := wsServer.SetClusterBus(clusterNode); err != nil {
+				params.Logger.Error("Unable to subscribe to the cluster bus", mlog.Err(err))
+				return nil, err
+			}
+		}
+		wsAdapter = wsServer
 	}
 
 	filesBackendSettings := filestore.FileBackendSettings{}
@@ -103,6 +131,32 @@ func New(params Params) (*Server, error) {
 		return nil, errors.New("unable to initialize the files storage")
 	}
 
+	var backupBackend filestore.FileBackend
+	if params.Cfg.BackupEnabled {
+		backupBackend, appErr = newBackupBackend(params.Cfg)
+		if appErr != nil {
+			params.Logger.Error("Unable to initialize the backup storage", mlog.Err(appErr))
+
+			return nil, errors.New("unable to initialize the backup storage")
+		}
+	}
+
+	var fileScanner filescan.Scanner
+	if params.Cfg.FileScannerAddress != "" {
+		fileScanner, appErr = filescan.NewClamAV(params.Cfg.FileScannerAddress)
+		if appErr != nil {
+			return nil, fmt.Errorf("unable to initialize the file scanner: %w", appErr)
+		}
+	}
+
+	var filePresigner filepresign.Presigner
+	if params.Cfg.FilesDriver == filesDriverS3 && params.Cfg.FilesS3PresignedURLs {
+		filePresigner, appErr = filepresign.NewS3(params.Cfg.FilesS3Config)
+		if appErr != nil {
+			return nil, fmt.Errorf("unable to initialize the file URL presigner: %w", appErr)
+		}
+	}
+
 	webhookClient := webhook.NewClient(params.Cfg, params.Logger)
 
 	// Init metrics
@@ -133,6 +187,9 @@ func New(params Params) (*Server, error) {
 		Auth:             authenticator,
 		Store:            params.DBStore,
 		FilesBackend:     filesBackend,
+		BackupBackend:    backupBackend,
+		FileScanner:      fileScanner,
+		FilePresigner:    filePresigner,
 		Webhook:          webhookClient,
 		Metrics:          metricsService,
 		Notifications:    notificationService,
@@ -196,6 +253,7 @@ func New(params Params) (*Server, error) {
 		metricsService:      metricsService,
 		auditService:        auditService,
 		notificationService: notificationService,
+		cluster:             clusterNode,
 		logger:              params.Logger,
 		localRouter:         localRouter,
 		api:                 focalboardAPI,
@@ -237,6 +295,35 @@ func NewStore(config *config.Configuration, logger *mlog.Logger) (store.Store, e
 	return db, nil
 }
 
+// newClusterNode joins the cluster of the servers sharing the database. The messages of
+// the cluster are sent through the database, which must be a postgres one.
+func newClusterNode(cfg *config.Configuration, logger *mlog.Logger) (cluster.Node, error) {
+	if cfg.DBType != appModel.PostgresDBType {
+		return nil, cluster.ErrUnsupportedDatabase
+	}
+	return cluster.NewPostgres(cfg.DBConfigString, "focalboard_"+cfg.DBTablePrefix, logger)
+}
+
+// newBackupBackend creates the storage for the scheduled backups, either a local
+// directory or an S3 bucket and prefix.
+func newBackupBackend(cfg *config.Configuration) (filestore.FileBackend, error) {
+	settings := filestore.FileBackendSettings{}
+	settings.DriverName = cfg.BackupDriver
+	settings.Directory = cfg.BackupPath
+	settings.AmazonS3AccessKeyId = cfg.BackupS3Config.AccessKeyID
+	settings.AmazonS3SecretAccessKey = cfg.BackupS3Config.SecretAccessKey
+	settings.AmazonS3Bucket = cfg.BackupS3Config.Bucket
+	settings.AmazonS3PathPrefix = cfg.BackupS3Config.PathPrefix
+	settings.AmazonS3Region = cfg.BackupS3Config.Region
+	settings.AmazonS3Endpoint = cfg.BackupS3Config.Endpoint
+	settings.AmazonS3SSL = cfg.BackupS3Config.SSL
+	settings.AmazonS3SignV2 = cfg.BackupS3Config.SignV2
+	settings.AmazonS3SSE = cfg.BackupS3Config.SSE
+	settings.AmazonS3Trace = cfg.BackupS3Config.Trace
+
+	return filestore.NewFileBackend(settings)
+}
+
 func (s *Server) Start() error {
 	s.logger.Info("Server.Start")
 
@@ -253,6 +340,10 @@ func (s *Server) Start() error {
 
 	if s.config.AuthMode != MattermostAuthMod {
 		s.cleanUpSessionsTask = scheduler.CreateRecurringTask("cleanUpSessions", func() {
+			if !s.isLeader() {
+				return
+			}
+
 			secondsAgo := minSessionExpiryTime
 			if secondsAgo < s.config.SessionExpireTime {
 				secondsAgo = s.config.SessionExpireTime
@@ -285,6 +376,40 @@ func (s *Server) Start() error {
 	// metricsUpdater()   Calling this immediately causes integration unit tests to fail.
 	s.metricsUpdaterTask = scheduler.CreateRecurringTask("updateMetrics", metricsUpdater, updateMetricsTaskFrequency)
 
+	if s.config.BackupEnabled {
+		backupFrequency := time.Duration(s.config.BackupFrequencySeconds) * time.Second
+		if backupFrequency <= 0 {
+			backupFrequency = defaultBackupTaskFrequency
+		}
+		s.backupTask = scheduler.CreateRecurringTask("backup", func() {
+			if !s.isLeader() {
+				return
+			}
+			if _, err := s.app.RunBackup(); err != nil {
+				s.logger.Error("Unable to back up the boards", mlog.Err(err))
+			}
+		}, backupFrequency)
+	}
+
+	if s.config.FileCleanupEnabled {
+		fileCleanupFrequency := time.Duration(s.config.FileCleanupFrequencySeconds) * time.Second
+		if fileCleanupFrequency <= 0 {
+			fileCleanupFrequency = defaultFileCleanupFrequency
+		}
+		s.fileCleanupTask = scheduler.CreateRecurringTask("fileCleanup", func() {
+			if !s.isLeader() {
+				return
+			}
+			if _, err := s.app.RunFileCleanup(); err != nil {
+				s.logger.Error("Unable to clean up the orphaned files", mlog.Err(err))
+			}
+		}, fileCleanupFrequency)
+	}
+
+	if presenceExpirer, ok := s.wsAdapter.(ws.PresenceExpirer); ok {
+		s.presenceExpiryTask = scheduler.CreateRecurringTask("expirePresences", presenceExpirer.ExpireIdlePresences, presenceExpiryFrequency)
+	}
+
 	if s.config.Telemetry {
 		firstRun := utils.GetMillis()
 		s.telemetry.RunTelemetryJob(firstRun)
@@ -326,6 +451,18 @@ func (s *Server) Shutdown() error {
 		s.metricsUpdaterTask.Cancel()
 	}
 
+	if s.backupTask != nil {
+		s.backupTask.Cancel()
+	}
+
+	if s.fileCleanupTask != nil {
+		s.fileCleanupTask.Cancel()
+	}
+
+	if s.presenceExpiryTask != nil {
+		s.presenceExpiryTask.Cancel()
+	}
+
 	if err := s.telemetry.Shutdown(); err != nil {
 		s.logger.Warn("Error occurred when shutting down telemetry", mlog.Err(err))
 	}
@@ -340,11 +477,23 @@ func (s *Server) Shutdown() error {
 
 	s.app.Shutdown()
 
+	if s.cluster != nil {
+		if err := s.cluster.Close(); err != nil {
+			s.logger.Warn("Error occurred when leaving the cluster", mlog.Err(err))
+		}
+	}
+
 	defer s.logger.Info("Server.Shutdown")
 
 	return s.store.Shutdown()
 }
 
+// isLeader tells if the server runs the scheduled tasks that must only run once in the
+// cluster. A server that is not part of a cluster always runs them.
+func (s *Server) isLeader() bool {
+	return s.cluster == nil || s.cluster.IsLeader()
+}
+
 func (s *Server) Config() *config.Configuration {
 	return s.config
 }
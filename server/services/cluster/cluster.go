@@ -0,0 +1,42 @@
+// Package cluster lets several standalone servers share a database and run as the nodes
+// of a cluster. The nodes fan out their websocket broadcasts through a bus, and elect a
+// leader to run the tasks that must run once in the cluster.
+package cluster
+
+import (
+	"errors"
+)
+
+var (
+	ErrUnsupportedDatabase = errors.New("cluster mode requires a postgres database")
+	ErrMessageTooLarge     = errors.New("cluster message too large")
+)
+
+// Handler receives the messages published on a channel.
+type Handler func(message []byte)
+
+// Bus fans out messages to all the nodes of a cluster, including the node publishing
+// them. The messages are delivered asynchronously, and may be lost if a node is
+// disconnected from the bus.
+type Bus interface {
+	// Publish sends a message to the handlers of a channel on all the nodes.
+	Publish(channel string, message []byte) error
+
+	// Subscribe registers a handler for the messages of a channel.
+	Subscribe(channel string, handler Handler) error
+}
+
+// Elector elects one of the nodes of a cluster as the leader. The leadership may move
+// to another node at any time, so the tasks check it each time they run.
+type Elector interface {
+	IsLeader() bool
+}
+
+// Node is the membership of a server in a cluster.
+type Node interface {
+	Bus
+	Elector
+
+	// Close leaves the cluster, giving up the leadership if the node has it.
+	Close() error
+}
@@ -0,0 +1,88 @@
+package cluster
+
+import (
+	"sync"
+)
+
+// Memory is an in-process cluster, used to run several servers in the same process in
+// the tests. The first node that joins it is the leader until it leaves.
+type Memory struct {
+	mu    sync.Mutex
+	nodes []*MemoryNode
+}
+
+// NewMemory creates an in-process cluster.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Join adds a node to the cluster.
+func (m *Memory) Join() *MemoryNode {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node := &MemoryNode{
+		cluster:  m,
+		handlers: map[string][]Handler{},
+	}
+	m.nodes = append(m.nodes, node)
+	return node
+}
+
+func (m *Memory) leave(node *MemoryNode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nodes := []*MemoryNode{}
+	for _, n := range m.nodes {
+		if n != node {
+			nodes = append(nodes, n)
+		}
+	}
+	m.nodes = nodes
+}
+
+func (m *Memory) getNodes() []*MemoryNode {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*MemoryNode{}, m.nodes...)
+}
+
+// MemoryNode is a node of an in-process cluster. The messages are delivered before
+// Publish returns.
+type MemoryNode struct {
+	cluster  *Memory
+	mu       sync.Mutex
+	handlers map[string][]Handler
+}
+
+func (n *MemoryNode) Publish(channel string, message []byte) error {
+	for _, node := range n.cluster.getNodes() {
+		node.mu.Lock()
+		handlers := append([]Handler{}, node.handlers[channel]...)
+		node.mu.Unlock()
+
+		for _, handler := range handlers {
+			handler(append([]byte{}, message...))
+		}
+	}
+	return nil
+}
+
+func (n *MemoryNode) Subscribe(channel string, handler Handler) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.handlers[channel] = append(n.handlers[channel], handler)
+	return nil
+}
+
+func (n *MemoryNode) IsLeader() bool {
+	nodes := n.cluster.getNodes()
+	return len(nodes) != 0 && nodes[0] == n
+}
+
+func (n *MemoryNode) Close() error {
+	n.cluster.leave(n)
+	return nil
+}
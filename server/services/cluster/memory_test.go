@@ -0,0 +1,48 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	t.Run("the messages are delivered to all the nodes", func(t *testing.T) {
+		cluster := NewMemory()
+		node1 := cluster.Join()
+		node2 := cluster.Join()
+
+		received := map[string][]string{}
+		require.NoError(t, node1.Subscribe("channel", func(message []byte) {
+			received["node1"] = append(received["node1"], string(message))
+		}))
+		require.NoError(t, node2.Subscribe("channel", func(message []byte) {
+			received["node2"] = append(received["node2"], string(message))
+		}))
+		require.NoError(t, node2.Subscribe("other", func(message []byte) {
+			received["other"] = append(received["other"], string(message))
+		}))
+
+		require.NoError(t, node1.Publish("channel", []byte("hello")))
+		require.Equal(t, []string{"hello"}, received["node1"])
+		require.Equal(t, []string{"hello"}, received["node2"])
+		require.Empty(t, received["other"])
+
+		require.NoError(t, node2.Close())
+		require.NoError(t, node1.Publish("channel", []byte("bye")))
+		require.Equal(t, []string{"hello", "bye"}, received["node1"])
+		require.Equal(t, []string{"hello"}, received["node2"])
+	})
+
+	t.Run("the leadership moves when the leader leaves", func(t *testing.T) {
+		cluster := NewMemory()
+		node1 := cluster.Join()
+		node2 := cluster.Join()
+		require.True(t, node1.IsLeader())
+		require.False(t, node2.IsLeader())
+
+		require.NoError(t, node1.Close())
+		require.False(t, node1.IsLeader())
+		require.True(t, node2.IsLeader())
+	})
+}
@@ -0,0 +1,352 @@
+package cluster
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+const (
+	// the payload of a notification is limited to 8000 bytes, the larger messages are
+	// split in several notifications.
+	notificationPayloadSize = 7900
+	maxMessageSize          = 4 * 1024 * 1024
+
+	listenerMinReconnect  = 10 * time.Second
+	listenerMaxReconnect  = time.Minute
+	listenerPingInterval  = 90 * time.Second
+	leaderCheckInterval   = 10 * time.Second
+	partialMessageTimeout = time.Minute
+)
+
+// Postgres is a node of a cluster of servers sharing a postgres database. The messages
+// are sent with NOTIFY and received with LISTEN, and the leader is the node holding an
+// advisory lock. The channels and the lock are scoped to a prefix, usually the table
+// prefix, so that several installations can share a database.
+type Postgres struct {
+	prefix   string
+	lockKey  int64
+	db       *sql.DB
+	listener *pq.Listener
+	logger   *mlog.Logger
+
+	mu       sync.Mutex
+	handlers map[string][]Handler
+	partials map[string]*partialMessage
+	isLeader bool
+
+	// leaderConn is the connection holding the advisory lock, only used by the election.
+	leaderConn *sql.Conn
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// partialMessage is a message split in several notifications, some of which are not
+// received yet.
+type partialMessage struct {
+	parts    []string
+	received int
+	createAt time.Time
+}
+
+// NewPostgres joins the cluster of the servers using a postgres database.
+func NewPostgres(connectionString, prefix string, logger *mlog.Logger) (*Postgres, error) {
+	db, err := sql.Open(model.PostgresDBType, connectionString)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(prefix + "leader"))
+
+	p := &Postgres{
+		prefix:   prefix,
+		lockKey:  int64(hash.Sum64()),
+		db:       db,
+		logger:   logger,
+		handlers: map[string][]Handler{},
+		partials: map[string]*partialMessage{},
+		done:     make(chan struct{}),
+	}
+	p.listener = pq.NewListener(connectionString, listenerMinReconnect, listenerMaxReconnect, p.onListenerEvent)
+
+	p.wg.Add(2)
+	go p.receive()
+	go p.elect()
+
+	return p, nil
+}
+
+// Publish sends a message with one or several notifications, in a transaction so that
+// they are delivered together. The messages must be text.
+func (p *Postgres) Publish(channel string, message []byte) error {
+	if len(message) > maxMessageSize {
+		return fmt.Errorf("%w: %d bytes", ErrMessageTooLarge, len(message))
+	}
+
+	messageID := utils.NewID(utils.IDTypeNone)
+	parts := splitMessage(message, notificationPayloadSize)
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	for i, part := range parts {
+		payload := fmt.Sprintf("%s %d %d %s", messageID, i, len(parts), part)
+		if _, err = tx.Exec("SELECT pg_notify($1, $2)", p.prefix+channel, payload); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				p.logger.Error("transaction rollback error", mlog.Err(rollbackErr), mlog.String("methodName", "Publish"))
+			}
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *Postgres) Subscribe(channel string, handler Handler) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.handlers[channel]; !ok {
+		if err := p.listener.Listen(p.prefix + channel); err != nil {
+			return err
+		}
+	}
+	p.handlers[channel] = append(p.handlers[channel], handler)
+	return nil
+}
+
+func (p *Postgres) IsLeader() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.isLeader
+}
+
+// Close stops listening and closes the connections, which releases the advisory lock
+// if the node is the leader.
+func (p *Postgres) Close() error {
+	close(p.done)
+	p.wg.Wait()
+
+	if p.leaderConn != nil {
+		discardConn(p.leaderConn)
+	}
+	if err := p.listener.Close(); err != nil {
+		p.logger.Warn("Error closing the cluster listener", mlog.Err(err))
+	}
+	return p.db.Close()
+}
+
+func (p *Postgres) receive() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case notification := <-p.listener.Notify:
+			if notification == nil {
+				// the listener reconnected, the messages sent meanwhile are lost
+				p.logger.Warn("Cluster listener reconnected, some messages may have been lost")
+				continue
+			}
+			p.handleNotification(notification.Channel, notification.Extra)
+		case <-ticker.C:
+			go func() {
+				if err := p.listener.Ping(); err != nil {
+					p.logger.Warn("Cluster listener ping failed", mlog.Err(err))
+				}
+			}()
+			p.dropPartialMessages()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// handleNotification passes a message to the handlers of its channel once all its
+// notifications are received.
+func (p *Postgres) handleNotification(channel, payload string) {
+	fields := strings.SplitN(payload, " ", 4)
+	if len(fields) != 4 {
+		p.logger.Error("Invalid cluster notification", mlog.String("channel", channel))
+		return
+	}
+	index, errIndex := strconv.Atoi(fields[1])
+	count, errCount := strconv.Atoi(fields[2])
+	if errIndex != nil || errCount != nil || index < 0 || index >= count {
+		p.logger.Error("Invalid cluster notification", mlog.String("channel", channel))
+		return
+	}
+
+	message := fields[3]
+	if count > 1 {
+		var ok bool
+		if message, ok = p.addPart(fields[0], index, count, fields[3]); !ok {
+			return
+		}
+	}
+
+	p.mu.Lock()
+	handlers := append([]Handler{}, p.handlers[strings.TrimPrefix(channel, p.prefix)]...)
+	p.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler([]byte(message))
+	}
+}
+
+// addPart records a notification of a message split in several ones, and returns the
+// message if all its notifications are received.
+func (p *Postgres) addPart(messageID string, index, count int, part string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	partial, ok := p.partials[messageID]
+	if !ok {
+		partial = &partialMessage{parts: make([]string, count), createAt: time.Now()}
+		p.partials[messageID] = partial
+	}
+	if len(partial.parts) != count || partial.parts[index] != "" {
+		return "", false
+	}
+	partial.parts[index] = part
+	partial.received++
+	if partial.received < count {
+		return "", false
+	}
+
+	delete(p.partials, messageID)
+	return strings.Join(partial.parts, ""), true
+}
+
+// dropPartialMessages drops the messages some notifications of which were lost.
+func (p *Postgres) dropPartialMessages() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for messageID, partial := range p.partials {
+		if time.Since(partial.createAt) > partialMessageTimeout {
+			delete(p.partials, messageID)
+		}
+	}
+}
+
+func (p *Postgres) elect() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(leaderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		p.checkLeadership()
+
+		select {
+		case <-ticker.C:
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// checkLeadership tries to take the advisory lock if the node is not the leader, and
+// checks that the connection holding the lock is alive if it is.
+func (p *Postgres) checkLeadership() {
+	ctx, cancel := context.WithTimeout(context.Background(), leaderCheckInterval)
+	defer cancel()
+
+	isLeader, err := p.holdLock(ctx)
+	if err != nil {
+		p.logger.Error("Cluster leader election failed", mlog.Err(err))
+		if p.leaderConn != nil {
+			discardConn(p.leaderConn)
+			p.leaderConn = nil
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if isLeader != p.isLeader {
+		p.logger.Info("Cluster leadership changed", mlog.Bool("is_leader", isLeader))
+	}
+	p.isLeader = isLeader
+}
+
+func (p *Postgres) holdLock(ctx context.Context) (bool, error) {
+	if p.leaderConn == nil {
+		conn, err := p.db.Conn(ctx)
+		if err != nil {
+			return false, err
+		}
+		p.leaderConn = conn
+	}
+
+	if p.IsLeader() {
+		if err := p.leaderConn.PingContext(ctx); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	var locked bool
+	if err := p.leaderConn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", p.lockKey).Scan(&locked); err != nil {
+		return false, err
+	}
+	return locked, nil
+}
+
+func (p *Postgres) onListenerEvent(event pq.ListenerEventType, err error) {
+	switch event {
+	case pq.ListenerEventDisconnected:
+		p.logger.Warn("Cluster listener disconnected", mlog.Err(err))
+	case pq.ListenerEventConnectionAttemptFailed:
+		p.logger.Error("Cluster listener connection failed", mlog.Err(err))
+	case pq.ListenerEventReconnected:
+		p.logger.Info("Cluster listener reconnected")
+	case pq.ListenerEventConnected:
+	}
+}
+
+// discardConn closes a connection instead of returning it to the pool, so that the
+// session locks it holds are released.
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(driverConn interface{}) error {
+		return driver.ErrBadConn
+	})
+	_ = conn.Close()
+}
+
+// splitMessage splits a message in parts of at most size bytes, without splitting its
+// characters, as the payloads of the notifications are text.
+func splitMessage(message []byte, size int) []string {
+	parts := []string{}
+	for len(message) > size {
+		end := size
+		for end > 0 && !utf8.RuneStart(message[end]) {
+			end--
+		}
+		parts = append(parts, string(message[:end]))
+		message = message[end:]
+	}
+	return append(parts, string(message))
+}
@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store/sqlstore"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+func TestSplitMessage(t *testing.T) {
+	require.Equal(t, []string{""}, splitMessage([]byte{}, 4))
+	require.Equal(t, []string{"abcd"}, splitMessage([]byte("abcd"), 4))
+	require.Equal(t, []string{"abcd", "ef"}, splitMessage([]byte("abcdef"), 4))
+
+	// the characters are not split
+	parts := splitMessage([]byte("abcé€f"), 4)
+	require.Equal(t, []string{"abc", "é", "€f"}, parts)
+	require.Equal(t, "abcé€f", strings.Join(parts, ""))
+}
+
+func TestPostgres(t *testing.T) {
+	if strings.TrimSpace(os.Getenv("FB_STORE_TEST_DB_TYPE")) != model.PostgresDBType {
+		t.Skip("the cluster bus requires a postgres database")
+	}
+	_, connectionString, err := sqlstore.PrepareNewTestDatabase()
+	require.NoError(t, err)
+
+	logger := mlog.CreateConsoleTestLogger(true, mlog.LvlError)
+	prefix := "test_" + utils.NewID(utils.IDTypeNone)[:8] + "_"
+
+	node1, err := NewPostgres(connectionString, prefix, logger)
+	require.NoError(t, err)
+	node2, err := NewPostgres(connectionString, prefix, logger)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	received := []string{}
+	require.NoError(t, node2.Subscribe("channel", func(message []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, string(message))
+	}))
+
+	t.Run("the large messages are delivered whole", func(t *testing.T) {
+		large := strings.Repeat("é", notificationPayloadSize)
+		require.NoError(t, node1.Publish("channel", []byte("hello")))
+		require.NoError(t, node1.Publish("channel", []byte(large)))
+
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(received) == 2
+		}, 10*time.Second, 50*time.Millisecond)
+		require.Equal(t, []string{"hello", large}, received)
+	})
+
+	t.Run("one node is the leader until it leaves", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			return node1.IsLeader() != node2.IsLeader()
+		}, 10*time.Second, 50*time.Millisecond)
+
+		leader, follower := node1, node2
+		if node2.IsLeader() {
+			leader, follower = node2, node1
+		}
+		require.NoError(t, leader.Close())
+		require.Eventually(t, follower.IsLeader, 3*leaderCheckInterval, 100*time.Millisecond)
+		require.NoError(t, follower.Close())
+	})
+}
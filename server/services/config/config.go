@@ -36,8 +36,13 @@ type Configuration struct {
 	WebPath                  string            `json:"webpath" mapstructure:"webpath"`
 	FilesDriver              string            `json:"filesdriver" mapstructure:"filesdriver"`
 	FilesS3Config            AmazonS3Config    `json:"filess3config" mapstructure:"filess3config"`
+	FilesS3PresignedURLs     bool              `json:"filess3presignedurls" mapstructure:"filess3presignedurls"`
 	FilesPath                string            `json:"filespath" mapstructure:"filespath"`
 	MaxFileSize              int64             `json:"maxfilesize" mapstructure:"mafilesize"`
+	TeamFileQuota            int64             `json:"teamfilequota" mapstructure:"teamfilequota"`
+	AllowedFileTypes         []string          `json:"allowedfiletypes" mapstructure:"allowedfiletypes"`
+	FileScannerAddress       string            `json:"filescanneraddress" mapstructure:"filescanneraddress"`
+	FileURLExpireSeconds     int64             `json:"fileurlexpireseconds" mapstructure:"fileurlexpireseconds"`
 	Telemetry                bool              `json:"telemetry" mapstructure:"telemetry"`
 	TelemetryID              string            `json:"telemetryid" mapstructure:"telemetryid"`
 	PrometheusAddress        string            `json:"prometheusaddress" mapstructure:"prometheusaddress"`
@@ -61,6 +66,31 @@ type Configuration struct {
 
 	NotifyFreqCardSeconds  int `json:"notify_freq_card_seconds" mapstructure:"notify_freq_card_seconds"`
 	NotifyFreqBoardSeconds int `json:"notify_freq_board_seconds" mapstructure:"notify_freq_board_seconds"`
+
+	// RequiredPropertiesMode is "warn" to save the cards missing required properties
+	// with a warning, or "reject" to reject them.
+	RequiredPropertiesMode string `json:"requiredPropertiesMode" mapstructure:"requiredPropertiesMode"`
+
+	BackupEnabled          bool           `json:"backupEnabled" mapstructure:"backupEnabled"`
+	BackupDriver           string         `json:"backupDriver" mapstructure:"backupDriver"`
+	BackupPath             string         `json:"backupPath" mapstructure:"backupPath"`
+	BackupS3Config         AmazonS3Config `json:"backupS3Config" mapstructure:"backupS3Config"`
+	BackupFrequencySeconds int            `json:"backupFrequencySeconds" mapstructure:"backupFrequencySeconds"`
+	BackupKeepDaily        int            `json:"backupKeepDaily" mapstructure:"backupKeepDaily"`
+	BackupKeepWeekly       int            `json:"backupKeepWeekly" mapstructure:"backupKeepWeekly"`
+
+	// FileCleanupEnabled schedules the sweep of the files no block references. The
+	// files referenced by the block history of the last FileCleanupRetentionSeconds are
+	// kept, the others are quarantined and deleted after FileCleanupGraceSeconds.
+	FileCleanupEnabled          bool  `json:"fileCleanupEnabled" mapstructure:"fileCleanupEnabled"`
+	FileCleanupFrequencySeconds int   `json:"fileCleanupFrequencySeconds" mapstructure:"fileCleanupFrequencySeconds"`
+	FileCleanupRetentionSeconds int64 `json:"fileCleanupRetentionSeconds" mapstructure:"fileCleanupRetentionSeconds"`
+	FileCleanupGraceSeconds     int64 `json:"fileCleanupGraceSeconds" mapstructure:"fileCleanupGraceSeconds"`
+
+	// ClusterEnabled runs the server as a node of a cluster of servers sharing the same
+	// postgres database. The websocket updates are sent to the clients of all the nodes,
+	// and the scheduled tasks only run on the leader node.
+	ClusterEnabled bool `json:"clusterEnabled" mapstructure:"clusterEnabled"`
 }
 
 // ReadConfigFile read the configuration from the filesystem.
@@ -82,6 +112,11 @@ func ReadConfigFile(configFilePath string) (*Configuration, error) {
 	viper.SetDefault("WebPath", "./pack")
 	viper.SetDefault("FilesPath", "./files")
 	viper.SetDefault("FilesDriver", "local")
+	viper.SetDefault("TeamFileQuota", 0)             // no limit on the total size of the files of a team
+	viper.SetDefault("AllowedFileTypes", []string{}) // all the file types are allowed
+	viper.SetDefault("FileScannerAddress", "")       // the uploaded files are not scanned
+	viper.SetDefault("FileURLExpireSeconds", 60*60)  // 1 hour signed file URL lifetime
+	viper.SetDefault("FilesS3PresignedURLs", false)
 	viper.SetDefault("Telemetry", true)
 	viper.SetDefault("TelemetryID", "")
 	viper.SetDefault("WebhookUpdate", nil)
@@ -95,7 +130,19 @@ func ReadConfigFile(configFilePath string) (*Configuration, error) {
 	viper.SetDefault("AuthMode", "native")
 	viper.SetDefault("NotifyFreqCardSeconds", 120)    // 2 minutes after last card edit
 	viper.SetDefault("NotifyFreqBoardSeconds", 86400) // 1 day after last card edit
+	viper.SetDefault("RequiredPropertiesMode", "warn")
 	viper.SetDefault("PrometheusAddress", "")
+	viper.SetDefault("BackupEnabled", false)
+	viper.SetDefault("BackupDriver", "local")
+	viper.SetDefault("BackupPath", "./backups")
+	viper.SetDefault("BackupFrequencySeconds", 86400) // 1 day between backups
+	viper.SetDefault("BackupKeepDaily", 7)
+	viper.SetDefault("BackupKeepWeekly", 4)
+	viper.SetDefault("FileCleanupEnabled", false)
+	viper.SetDefault("FileCleanupFrequencySeconds", 86400)    // 1 day between sweeps
+	viper.SetDefault("FileCleanupRetentionSeconds", 86400*30) // 30 days of block history
+	viper.SetDefault("FileCleanupGraceSeconds", 86400*7)      // 7 days in quarantine
+	viper.SetDefault("ClusterEnabled", false)
 
 	err := viper.ReadInConfig() // Find and read the config file
 	if err != nil {             // Handle errors reading the config file
@@ -0,0 +1,18 @@
+// Package filepresign issues the URLs clients download the files from directly, without
+// going through the server.
+package filepresign
+
+import (
+	"time"
+)
+
+// MaxExpiry is the longest lifetime of a presigned URL.
+const MaxExpiry = 7 * 24 * time.Hour
+
+// Presigner issues temporary URLs to download the files of the files storage.
+type Presigner interface {
+	// PresignedURL returns a URL to download the file at a path of the files storage
+	// until it expires. The content type and disposition of the response are set to
+	// the values given, when they are not empty.
+	PresignedURL(path string, expiry time.Duration, contentType, contentDisposition string) (string, error)
+}
@@ -0,0 +1,69 @@
+package filepresign
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/mattermost/focalboard/server/services/config"
+)
+
+// S3 presigns the URLs of the files stored in an Amazon S3 bucket.
+type S3 struct {
+	client     *minio.Client
+	bucket     string
+	pathPrefix string
+}
+
+// NewS3 returns a presigner for the files stored in the S3 bucket of a configuration.
+// The credentials are the same as the files storage's.
+func NewS3(cfg config.AmazonS3Config) (*S3, error) {
+	var creds *credentials.Credentials
+	switch {
+	case cfg.AccessKeyID == "" && cfg.SecretAccessKey == "":
+		creds = credentials.NewIAM("")
+	case cfg.SignV2:
+		creds = credentials.NewStaticV2(cfg.AccessKeyID, cfg.SecretAccessKey, "")
+	default:
+		creds = credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, "")
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  creds,
+		Secure: cfg.SSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3{client: client, bucket: cfg.Bucket, pathPrefix: cfg.PathPrefix}, nil
+}
+
+// PresignedURL returns a presigned URL of an object of the bucket. The expiry is capped
+// to MaxExpiry.
+func (s *S3) PresignedURL(filePath string, expiry time.Duration, contentType, contentDisposition string) (string, error) {
+	if expiry > MaxExpiry {
+		expiry = MaxExpiry
+	}
+	if expiry < time.Second {
+		expiry = time.Second
+	}
+
+	params := url.Values{}
+	if contentType != "" {
+		params.Set("response-content-type", contentType)
+	}
+	if contentDisposition != "" {
+		params.Set("response-content-disposition", contentDisposition)
+	}
+
+	u, err := s.client.PresignedGetObject(context.Background(), s.bucket, path.Join(s.pathPrefix, filePath), expiry, params)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
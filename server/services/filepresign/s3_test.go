@@ -0,0 +1,50 @@
+package filepresign
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/services/config"
+)
+
+func TestS3PresignedURL(t *testing.T) {
+	presigner, err := NewS3(config.AmazonS3Config{
+		AccessKeyID:     "access-key",
+		SecretAccessKey: "secret-key",
+		Bucket:          "bucket",
+		PathPrefix:      "prefix",
+		Region:          "us-east-1",
+		Endpoint:        "s3.example.com",
+		SSL:             true,
+	})
+	require.NoError(t, err)
+
+	t.Run("the URL points to the object", func(t *testing.T) {
+		presigned, err := presigner.PresignedURL("content/ab/abcd", time.Hour, "image/png", `inline; filename="photo.png"`)
+		require.NoError(t, err)
+
+		u, err := url.Parse(presigned)
+		require.NoError(t, err)
+		require.Equal(t, "https", u.Scheme)
+		require.Contains(t, u.Path, "/bucket/prefix/content/ab/abcd")
+
+		query := u.Query()
+		require.Equal(t, "3600", query.Get("X-Amz-Expires"))
+		require.Equal(t, "image/png", query.Get("response-content-type"))
+		require.Equal(t, `inline; filename="photo.png"`, query.Get("response-content-disposition"))
+		require.NotEmpty(t, query.Get("X-Amz-Signature"))
+	})
+
+	t.Run("the expiry is capped", func(t *testing.T) {
+		presigned, err := presigner.PresignedURL("content/ab/abcd", 30*24*time.Hour, "", "")
+		require.NoError(t, err)
+
+		u, err := url.Parse(presigned)
+		require.NoError(t, err)
+		require.Equal(t, "604800", u.Query().Get("X-Amz-Expires"))
+		require.Empty(t, u.Query().Get("response-content-type"))
+	})
+}
@@ -0,0 +1,100 @@
+package filescan
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	clamAVChunkSize = 64 * 1024
+	clamAVTimeout   = 2 * time.Minute
+)
+
+// ClamAV scans the files with a clamd daemon, using the INSTREAM command.
+type ClamAV struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamAV creates a scanner for the clamd daemon listening at an address like
+// unix:///var/run/clamav/clamd.ctl or tcp://localhost:3310.
+func NewClamAV(address string) (*ClamAV, error) {
+	var network, addr string
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		network, addr = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		network, addr = "tcp", strings.TrimPrefix(address, "tcp://")
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAddress, address)
+	}
+	return &ClamAV{network: network, address: addr, timeout: clamAVTimeout}, nil
+}
+
+// Scan streams the content to clamd and parses its verdict.
+func (c *ClamAV) Scan(content io.Reader) (*Result, error) {
+	conn, err := net.DialTimeout(c.network, c.address, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if err = conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+
+	if _, err = conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, err
+	}
+
+	chunk := make([]byte, clamAVChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := content.Read(chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err = conn.Write(size); err != nil {
+				return nil, err
+			}
+			if _, err = conn.Write(chunk[:n]); err != nil {
+				return nil, err
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+	}
+	// a zero length chunk ends the stream.
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err = conn.Write(size); err != nil {
+		return nil, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return parseClamAVReply(reply)
+}
+
+// parseClamAVReply parses replies like "stream: OK" and "stream: Eicar-Signature FOUND".
+func parseClamAVReply(reply string) (*Result, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	verdict := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case verdict == "OK":
+		return &Result{Clean: true}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return &Result{Threat: strings.TrimSuffix(verdict, " FOUND")}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrScanFailed, reply)
+}
@@ -0,0 +1,82 @@
+package filescan
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// serveClamAV answers the INSTREAM commands like clamd, finding a threat in the
+// streams containing the word "virus".
+func serveClamAV(t *testing.T, listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		reader := bufio.NewReader(conn)
+		command, err := reader.ReadString(0)
+		require.NoError(t, err)
+		require.Equal(t, "zINSTREAM\x00", command)
+
+		var content bytes.Buffer
+		for {
+			var size uint32
+			require.NoError(t, binary.Read(reader, binary.BigEndian, &size))
+			if size == 0 {
+				break
+			}
+			_, err = io.CopyN(&content, reader, int64(size))
+			require.NoError(t, err)
+		}
+
+		reply := "stream: OK\x00"
+		if bytes.Contains(content.Bytes(), []byte("virus")) {
+			reply = "stream: Test-Signature FOUND\x00"
+		}
+		_, err = conn.Write([]byte(reply))
+		require.NoError(t, err)
+		conn.Close()
+	}
+}
+
+func TestClamAV(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "clamd.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	defer listener.Close()
+	go serveClamAV(t, listener)
+
+	scanner, err := NewClamAV("unix://" + socket)
+	require.NoError(t, err)
+
+	t.Run("clean file", func(t *testing.T) {
+		result, err := scanner.Scan(bytes.NewReader(bytes.Repeat([]byte("clean "), 20000)))
+		require.NoError(t, err)
+		require.True(t, result.Clean)
+	})
+
+	t.Run("infected file", func(t *testing.T) {
+		result, err := scanner.Scan(bytes.NewReader([]byte("a virus")))
+		require.NoError(t, err)
+		require.False(t, result.Clean)
+		require.Equal(t, "Test-Signature", result.Threat)
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		_, err := NewClamAV("localhost:3310")
+		require.ErrorIs(t, err, ErrUnsupportedAddress)
+	})
+
+	t.Run("scan error", func(t *testing.T) {
+		_, err := parseClamAVReply("INSTREAM size limit exceeded. ERROR\x00")
+		require.ErrorIs(t, err, ErrScanFailed)
+	})
+}
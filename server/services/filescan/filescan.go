@@ -0,0 +1,27 @@
+// Package filescan checks the content of the uploaded files before they are stored.
+package filescan
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	ErrUnsupportedAddress = errors.New("unsupported scanner address")
+	ErrScanFailed         = errors.New("file scan failed")
+)
+
+// Result is the verdict of a scanner on a file.
+type Result struct {
+	// Clean is true if no threat was found.
+	Clean bool
+
+	// Threat is the name of the threat found, if any.
+	Threat string
+}
+
+// Scanner checks the content of a file. An error is returned when the file could not be
+// scanned, in which case the file is not stored.
+type Scanner interface {
+	Scan(content io.Reader) (*Result, error)
+}
@@ -0,0 +1,398 @@
+package formula
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Resolver provides the values of the properties referenced by a formula.
+type Resolver interface {
+	// PropertyValue returns the value of a property, by name or id. Numbers must be
+	// float64, dates are numbers of milliseconds and empty values are nil.
+	PropertyValue(name string) (interface{}, error)
+}
+
+// Now returns the current time in milliseconds; it can be replaced in tests.
+var Now = func() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+// Eval evaluates the formula.
+func (f *Formula) Eval(r Resolver) (interface{}, error) {
+	return f.root.eval(r)
+}
+
+type node interface {
+	eval(r Resolver) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(Resolver) (interface{}, error) {
+	return n.value, nil
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n *unaryNode) eval(r Resolver) (interface{}, error) {
+	v, err := n.operand.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !Truthy(v), nil
+	}
+	num, err := toNumber(v)
+	if err != nil {
+		return nil, err
+	}
+	return -num, nil
+}
+
+type binaryNode struct {
+	op    string
+	left  node
+	right node
+}
+
+func (n *binaryNode) eval(r Resolver) (interface{}, error) {
+	left, err := n.left.eval(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// logical operators short-circuit.
+	switch n.op {
+	case "&&":
+		if !Truthy(left) {
+			return false, nil
+		}
+		right, errRight := n.right.eval(r)
+		return Truthy(right), errRight
+	case "||":
+		if Truthy(left) {
+			return true, nil
+		}
+		right, errRight := n.right.eval(r)
+		return Truthy(right), errRight
+	}
+
+	right, err := n.right.eval(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, left, right), nil
+	case "+":
+		_, ls := left.(string)
+		_, rs := right.(string)
+		if ls || rs {
+			return ToString(left) + ToString(right), nil
+		}
+	}
+
+	a, err := toNumber(left)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toNumber(right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, ErrDivisionByZero
+		}
+		return a / b, nil
+	case "%":
+		if b == 0 {
+			return nil, ErrDivisionByZero
+		}
+		return math.Mod(a, b), nil
+	}
+	return nil, fmt.Errorf("%w: unknown operator %s", ErrSyntax, n.op)
+}
+
+type callNode struct {
+	name string
+	fn   function
+	args []node
+}
+
+func (n *callNode) eval(r Resolver) (interface{}, error) {
+	if n.fn.lazy != nil {
+		return n.fn.lazy(r, n.args)
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(r)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	v, err := n.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+type function struct {
+	minArgs int
+	maxArgs int // -1 for variadic functions
+	call    func(args []interface{}) (interface{}, error)
+	lazy    func(r Resolver, args []node) (interface{}, error)
+}
+
+var dateUnits = map[string]float64{
+	"minutes": float64(time.Minute / time.Millisecond),
+	"hours":   float64(time.Hour / time.Millisecond),
+	"days":    float64(24 * time.Hour / time.Millisecond),
+	"weeks":   float64(7 * 24 * time.Hour / time.Millisecond),
+}
+
+var functions map[string]function
+
+func init() {
+	functions = map[string]function{
+		"prop": {minArgs: 1, maxArgs: 1, lazy: func(r Resolver, args []node) (interface{}, error) {
+			name := args[0].(*literalNode).value.(string)
+			return r.PropertyValue(name)
+		}},
+		"if": {minArgs: 3, maxArgs: 3, lazy: func(r Resolver, args []node) (interface{}, error) {
+			cond, err := args[0].eval(r)
+			if err != nil {
+				return nil, err
+			}
+			if Truthy(cond) {
+				return args[1].eval(r)
+			}
+			return args[2].eval(r)
+		}},
+		"concat": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+			var sb strings.Builder
+			for _, arg := range args {
+				sb.WriteString(ToString(arg))
+			}
+			return sb.String(), nil
+		}},
+		"dateDiff": {minArgs: 2, maxArgs: 3, call: func(args []interface{}) (interface{}, error) {
+			if args[0] == nil || args[1] == nil {
+				return nil, nil
+			}
+			a, err := toNumber(args[0])
+			if err != nil {
+				return nil, err
+			}
+			b, err := toNumber(args[1])
+			if err != nil {
+				return nil, err
+			}
+			unit := "days"
+			if len(args) == 3 {
+				unit = ToString(args[2])
+			}
+			ms, ok := dateUnits[unit]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown unit %q", ErrInvalidOperand, unit)
+			}
+			return math.Trunc((a - b) / ms), nil
+		}},
+		"formatDate": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+			if args[0] == nil {
+				return nil, nil
+			}
+			ms, err := toNumber(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return time.Unix(0, int64(ms)*int64(time.Millisecond)).UTC().Format("January 02, 2006"), nil
+		}},
+		"now": {minArgs: 0, maxArgs: 0, call: func([]interface{}) (interface{}, error) {
+			return float64(Now()), nil
+		}},
+		"round": {minArgs: 1, maxArgs: 2, call: func(args []interface{}) (interface{}, error) {
+			x, err := toNumber(args[0])
+			if err != nil {
+				return nil, err
+			}
+			digits := 0.0
+			if len(args) == 2 {
+				if digits, err = toNumber(args[1]); err != nil {
+					return nil, err
+				}
+			}
+			pow := math.Pow(10, math.Trunc(digits))
+			return math.Round(x*pow) / pow, nil
+		}},
+		"abs": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+			x, err := toNumber(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return math.Abs(x), nil
+		}},
+		"min": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+			return extreme(args, func(a, b float64) bool { return a < b })
+		}},
+		"max": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+			return extreme(args, func(a, b float64) bool { return a > b })
+		}},
+		"length": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+			return float64(len([]rune(ToString(args[0])))), nil
+		}},
+		"lower": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+			return strings.ToLower(ToString(args[0])), nil
+		}},
+		"upper": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+			return strings.ToUpper(ToString(args[0])), nil
+		}},
+		"empty": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+			return args[0] == nil || args[0] == "", nil
+		}},
+		"toNumber": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+			return toNumber(args[0])
+		}},
+		"format": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+			return ToString(args[0]), nil
+		}},
+	}
+}
+
+func extreme(args []interface{}, better func(a, b float64) bool) (interface{}, error) {
+	var result interface{}
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+		x, err := toNumber(arg)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil || better(x, result.(float64)) {
+			result = x
+		}
+	}
+	return result, nil
+}
+
+// Truthy returns the boolean value of a formula value.
+func Truthy(v interface{}) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case string:
+		return t != ""
+	}
+	return false
+}
+
+// ToString returns the string representation of a formula value.
+func ToString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case bool:
+		return strconv.FormatBool(t)
+	case float64:
+		// hide floating point noise, e.g. 0.1+0.2.
+		return strconv.FormatFloat(math.Round(t*1e9)/1e9, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func toNumber(v interface{}) (float64, error) {
+	switch t := v.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return t, nil
+	case bool:
+		if t {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		if strings.TrimSpace(t) == "" {
+			return 0, nil
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q is not a number", ErrInvalidOperand, t)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("%w: %v", ErrInvalidOperand, v)
+}
+
+func equal(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return (a == nil || a == "") && (b == nil || b == "")
+	}
+	if an, ok := a.(float64); ok {
+		if bn, err := toNumber(b); err == nil {
+			return an == bn
+		}
+	}
+	if bn, ok := b.(float64); ok {
+		if an, err := toNumber(a); err == nil {
+			return an == bn
+		}
+	}
+	return ToString(a) == ToString(b)
+}
+
+func compare(op string, a, b interface{}) bool {
+	var c int
+	an, errA := toNumber(a)
+	bn, errB := toNumber(b)
+	if errA == nil && errB == nil {
+		switch {
+		case an < bn:
+			c = -1
+		case an > bn:
+			c = 1
+		}
+	} else {
+		c = strings.Compare(ToString(a), ToString(b))
+	}
+
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
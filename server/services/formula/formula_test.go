@@ -0,0 +1,130 @@
+package formula
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mapResolver map[string]interface{}
+
+func (m mapResolver) PropertyValue(name string) (interface{}, error) {
+	v, ok := m[name]
+	if !ok {
+		return nil, ErrUnknownPropertyValue
+	}
+	return v, nil
+}
+
+func TestParse(t *testing.T) {
+	t.Run("references", func(t *testing.T) {
+		f, err := Parse(`if(prop("Done"), 0, prop('Estimate') * 2)`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"Done", "Estimate"}, f.References())
+		require.Equal(t, `if(prop("Done"), 0, prop('Estimate') * 2)`, f.String())
+	})
+
+	testCases := []struct {
+		name string
+		expr string
+		err  error
+	}{
+		{name: "empty", expr: "", err: ErrSyntax},
+		{name: "unbalanced parenthesis", expr: "(1 + 2", err: ErrSyntax},
+		{name: "trailing operator", expr: "1 +", err: ErrSyntax},
+		{name: "unterminated string", expr: `"abc`, err: ErrSyntax},
+		{name: "unknown character", expr: "1 # 2", err: ErrSyntax},
+		{name: "unknown function", expr: "sqrt(4)", err: ErrUnknownFunction},
+		{name: "missing arguments", expr: "if(true, 1)", err: ErrWrongArgumentCount},
+		{name: "prop without a name", expr: "prop(1)", err: ErrSyntax},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.expr)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestEval(t *testing.T) {
+	now := Now
+	Now = func() int64 { return 1642161600000 }
+	defer func() { Now = now }()
+
+	resolver := mapResolver{
+		"Estimate": float64(3),
+		"Done":     false,
+		"Owner":    "alice",
+		"Status":   "In Progress",
+		"Due":      float64(1642161600000 + 3*24*3600*1000),
+		"Empty":    nil,
+	}
+
+	testCases := []struct {
+		expr     string
+		expected interface{}
+	}{
+		{expr: "1 + 2 * 3", expected: float64(7)},
+		{expr: "(1 + 2) * 3", expected: float64(9)},
+		{expr: "-prop('Estimate') + 10 % 4", expected: float64(-1)},
+		{expr: `prop("Estimate") / 2`, expected: 1.5},
+		{expr: `prop("Empty") + 1`, expected: float64(1)},
+		{expr: `if(prop("Done"), 0, prop("Estimate") * 2)`, expected: float64(6)},
+		{expr: `!prop("Done") && prop("Estimate") >= 3`, expected: true},
+		{expr: `prop("Done") || prop("Empty")`, expected: false},
+		{expr: `concat(prop("Owner"), " - ", prop("Status"))`, expected: "alice - In Progress"},
+		{expr: `prop("Owner") + 1`, expected: "alice1"},
+		{expr: `"10" == 10`, expected: true},
+		{expr: `"b" > "a"`, expected: true},
+		{expr: `prop("Empty") == ""`, expected: true},
+		{expr: `dateDiff(prop("Due"), now(), "days")`, expected: float64(3)},
+		{expr: `dateDiff(prop("Due"), now(), "hours")`, expected: float64(72)},
+		{expr: `dateDiff(prop("Empty"), now())`, expected: nil},
+		{expr: `formatDate(now())`, expected: "January 14, 2022"},
+		{expr: `round(2.345, 2)`, expected: 2.35},
+		{expr: `abs(-2)`, expected: float64(2)},
+		{expr: `min(3, prop("Empty"), 1, 2)`, expected: float64(1)},
+		{expr: `max(3, 1, 2)`, expected: float64(3)},
+		{expr: `length("héllo")`, expected: float64(5)},
+		{expr: `upper(lower("MiXed"))`, expected: "MIXED"},
+		{expr: `empty(prop("Empty"))`, expected: true},
+		{expr: `toNumber("4.5") * 2`, expected: float64(9)},
+		{expr: `format(0.1 + 0.2)`, expected: "0.3"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			f, err := Parse(tc.expr)
+			require.NoError(t, err)
+			result, err := f.Eval(resolver)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, result)
+		})
+	}
+
+	errorCases := []struct {
+		expr string
+		err  error
+	}{
+		{expr: "1 / 0", err: ErrDivisionByZero},
+		{expr: "1 % 0", err: ErrDivisionByZero},
+		{expr: `prop("Owner") * 2`, err: ErrInvalidOperand},
+		{expr: `dateDiff(now(), now(), "years")`, err: ErrInvalidOperand},
+		{expr: `prop("Missing")`, err: ErrUnknownPropertyValue},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			f, err := Parse(tc.expr)
+			require.NoError(t, err)
+			_, err = f.Eval(resolver)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	t.Run("lazy evaluation", func(t *testing.T) {
+		f, err := Parse(`if(true, 1, 1 / 0) + (false && 1 / 0)`)
+		require.NoError(t, err)
+		result, err := f.Eval(resolver)
+		require.NoError(t, err)
+		require.Equal(t, float64(1), result)
+	})
+}
@@ -0,0 +1,358 @@
+// Package formula implements the expression language of formula properties.
+//
+// A formula combines literals, the values of other properties of the same card
+// (prop("Name")), operators and functions:
+//
+//	if(prop("Done"), 0, prop("Estimate") * 2)
+//	concat(prop("Owner"), " - ", prop("Status"))
+//	dateDiff(prop("Due"), now(), "days")
+//
+// Values are numbers (float64), strings, booleans or nil for empty values.
+package formula
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const maxExpressionLength = 4096
+
+var (
+	ErrSyntax               = errors.New("formula syntax error")
+	ErrExpressionTooLong    = errors.New("formula expression too long")
+	ErrUnknownFunction      = errors.New("unknown formula function")
+	ErrWrongArgumentCount   = errors.New("wrong number of arguments")
+	ErrDivisionByZero       = errors.New("division by zero")
+	ErrInvalidOperand       = errors.New("invalid operand")
+	ErrCircularReference    = errors.New("circular property reference")
+	ErrUnknownPropertyValue = errors.New("unknown property")
+)
+
+// Formula is a parsed formula expression.
+type Formula struct {
+	expr string
+	root node
+	refs []string
+}
+
+// Parse parses a formula expression.
+func Parse(expr string) (*Formula, error) {
+	if len(expr) > maxExpressionLength {
+		return nil, ErrExpressionTooLong
+	}
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, p.errorf("unexpected %q", p.peek().text)
+	}
+
+	f := &Formula{expr: expr, root: root}
+	collectReferences(root, &f.refs)
+	return f, nil
+}
+
+// String returns the source expression of the formula.
+func (f *Formula) String() string {
+	return f.expr
+}
+
+// References returns the names of the properties referenced by the formula.
+func (f *Formula) References() []string {
+	return f.refs
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "+", "-", "*", "/", "%", "<", ">", "!", "(", ")", ","}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+
+		case r == '"' || r == '\'':
+			start := i
+			quote := r
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != quote; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated string at %d", ErrSyntax, start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if hasRunePrefix(runes[i:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("%w: unexpected character %q at %d", ErrSyntax, r, i)
+			}
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+// hasRunePrefix reports whether runes begin with prefix, without converting the
+// rest of the expression to a string.
+func hasRunePrefix(runes []rune, prefix string) bool {
+	i := 0
+	for _, r := range prefix {
+		if i >= len(runes) || runes[i] != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) acceptOperator(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expectOperator(op string) error {
+	if _, ok := p.acceptOperator(op); !ok {
+		return p.errorf("expected %q", op)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at %d", ErrSyntax, fmt.Sprintf(format, args...), p.peek().pos)
+}
+
+func (p *parser) parseBinary(next func() (node, error), ops ...string) (node, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOperator(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, errRight := next()
+		if errRight != nil {
+			return nil, errRight
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseBinary(p.parseComparison, "&&")
+}
+
+func (p *parser) parseComparison() (node, error) {
+	return p.parseBinary(p.parseAdditive, "==", "!=", "<=", ">=", "<", ">")
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	return p.parseBinary(p.parseMultiplicative, "+", "-")
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	return p.parseBinary(p.parseUnary, "*", "/", "%")
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if op, ok := p.acceptOperator("-", "!"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid number %q at %d", ErrSyntax, t.text, t.pos)
+		}
+		return &literalNode{value: n}, nil
+
+	case tokenString:
+		return &literalNode{value: t.text}, nil
+
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		}
+		return p.parseCall(t)
+
+	case tokenOperator:
+		if t.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expectOperator(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+
+	case tokenEOF:
+		return nil, fmt.Errorf("%w: unexpected end of expression", ErrSyntax)
+	}
+	return nil, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, t.text, t.pos)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFunction, name.text)
+	}
+	if err := p.expectOperator("("); err != nil {
+		return nil, err
+	}
+
+	var args []node
+	if _, closed := p.acceptOperator(")"); !closed {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, more := p.acceptOperator(","); !more {
+				break
+			}
+		}
+		if err := p.expectOperator(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("%w: %s at %d", ErrWrongArgumentCount, name.text, name.pos)
+	}
+
+	call := &callNode{name: name.text, fn: fn, args: args}
+	if name.text == "prop" {
+		lit, ok := args[0].(*literalNode)
+		if !ok {
+			return nil, fmt.Errorf("%w: prop() expects a property name at %d", ErrSyntax, name.pos)
+		}
+		if _, isString := lit.value.(string); !isString {
+			return nil, fmt.Errorf("%w: prop() expects a property name at %d", ErrSyntax, name.pos)
+		}
+	}
+	return call, nil
+}
+
+func collectReferences(n node, refs *[]string) {
+	switch t := n.(type) {
+	case *callNode:
+		if t.name == "prop" {
+			*refs = append(*refs, t.args[0].(*literalNode).value.(string))
+			return
+		}
+		for _, arg := range t.args {
+			collectReferences(arg, refs)
+		}
+	case *binaryNode:
+		collectReferences(t.left, refs)
+		collectReferences(t.right, refs)
+	case *unaryNode:
+		collectReferences(t.operand, refs)
+	}
+}
@@ -6,6 +6,7 @@ package notifysubscriptions
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/mattermost/focalboard/server/model"
 
@@ -53,6 +54,9 @@ type diffGenerator struct {
 	hint         *model.NotificationHint
 	lastNotifyAt int64
 	logger       *mlog.Logger
+
+	// location is the location dates are rendered in, nil for UTC.
+	location *time.Location
 }
 
 func (dg *diffGenerator) generateDiffs() ([]*Diff, error) {
@@ -293,7 +297,9 @@ func (dg *diffGenerator) generateDiffForBlock(newBlock *model.Block, schema mode
 func (dg *diffGenerator) generatePropDiffs(oldBlock, newBlock *model.Block, schema model.PropSchema) []PropDiff {
 	var propDiffs []PropDiff
 
-	oldProps, err := model.ParseProperties(oldBlock, schema, dg.store)
+	resolver := model.ResolverWithLocation(dg.store, dg.location)
+
+	oldProps, err := model.ParseProperties(oldBlock, schema, resolver)
 	if err != nil {
 		dg.logger.Error("Cannot parse properties for old block",
 			mlog.String("block_id", oldBlock.ID),
@@ -301,7 +307,7 @@ func (dg *diffGenerator) generatePropDiffs(oldBlock, newBlock *model.Block, sche
 		)
 	}
 
-	newProps, err := model.ParseProperties(newBlock, schema, dg.store)
+	newProps, err := model.ParseProperties(newBlock, schema, resolver)
 	if err != nil {
 		dg.logger.Error("Cannot parse properties for new block",
 			mlog.String("block_id", oldBlock.ID),
@@ -309,6 +315,15 @@ func (dg *diffGenerator) generatePropDiffs(oldBlock, newBlock *model.Block, sche
 		)
 	}
 
+	// the updated time and updated by properties change with every edit, the authors
+	// and time of the diff already tell who changed the card and when.
+	for id, def := range schema {
+		if def.Type == model.PropTypeUpdatedTime || def.Type == model.PropTypeUpdatedBy {
+			delete(oldProps, id)
+			delete(newProps, id)
+		}
+	}
+
 	// look for new or changed properties.
 	for k, prop := range newProps {
 		oldP, ok := oldProps[k]
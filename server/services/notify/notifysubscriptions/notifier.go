@@ -14,6 +14,7 @@ import (
 	"github.com/mattermost/focalboard/server/utils"
 	"github.com/wiggin77/merror"
 
+	mm_model "github.com/mattermost/mattermost-server/v6/model"
 	"github.com/mattermost/mattermost-server/v6/shared/mlog"
 )
 
@@ -212,6 +213,36 @@ func (n *notifier) notifySubscribers(hint *model.NotificationHint) error {
 		return err
 	}
 
+	// dates are rendered in the timezone of each subscriber, the attachments are
+	// generated once per timezone.
+	attachmentsByTimezone := make(map[string][]*mm_model.SlackAttachment)
+	attachmentsForSubscriber := func(sub *model.Subscriber) []*mm_model.SlackAttachment {
+		loc := n.subscriberLocation(sub)
+		if loc == nil {
+			return attachments
+		}
+		if cached, ok := attachmentsByTimezone[loc.String()]; ok {
+			return cached
+		}
+
+		localDG := *dg
+		localDG.location = loc
+		localized := attachments
+		localDiffs, errDiffs := localDG.generateDiffs()
+		if errDiffs == nil {
+			localized, errDiffs = Diffs2SlackAttachments(localDiffs, opts)
+		}
+		if errDiffs != nil {
+			n.logger.Error("notifySubscribers - cannot render notification in subscriber timezone",
+				mlog.String("subscriber_id", sub.SubscriberID),
+				mlog.Err(errDiffs),
+			)
+			localized = attachments
+		}
+		attachmentsByTimezone[loc.String()] = localized
+		return localized
+	}
+
 	merr := merror.New()
 	if len(attachments) > 0 {
 		for _, sub := range subs {
@@ -243,7 +274,7 @@ func (n *notifier) notifySubscribers(hint *model.NotificationHint) error {
 				mlog.String("subscriber_type", string(sub.SubscriberType)),
 			)
 
-			if err = n.delivery.SubscriptionDeliverSlackAttachments(sub.SubscriberID, sub.SubscriberType, attachments); err != nil {
+			if err = n.delivery.SubscriptionDeliverSlackAttachments(sub.SubscriberID, sub.SubscriberType, attachmentsForSubscriber(sub)); err != nil {
 				merr.Append(fmt.Errorf("cannot deliver notification to subscriber %s [%s]: %w",
 					sub.SubscriberID, sub.SubscriberType, err))
 			}
@@ -276,3 +307,16 @@ func (n *notifier) notifySubscribers(hint *model.NotificationHint) error {
 
 	return merr.ErrorOrNil()
 }
+
+// subscriberLocation returns the location of the timezone of a user subscriber,
+// or nil if the subscriber has none.
+func (n *notifier) subscriberLocation(sub *model.Subscriber) *time.Location {
+	if sub.SubscriberType != model.SubTypeUser {
+		return nil
+	}
+	user, err := n.store.GetUserByID(sub.SubscriberID)
+	if err != nil {
+		return nil
+	}
+	return model.UserLocation(user)
+}
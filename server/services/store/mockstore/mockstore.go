@@ -222,6 +222,20 @@ func (mr *MockStoreMockRecorder) DeleteCategory(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategory", reflect.TypeOf((*MockStore)(nil).DeleteCategory), arg0, arg1, arg2)
 }
 
+// DeleteFileInfo mocks base method.
+func (m *MockStore) DeleteFileInfo(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFileInfo", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFileInfo indicates an expected call of DeleteFileInfo.
+func (mr *MockStoreMockRecorder) DeleteFileInfo(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFileInfo", reflect.TypeOf((*MockStore)(nil).DeleteFileInfo), arg0)
+}
+
 // DeleteMember mocks base method.
 func (m *MockStore) DeleteMember(arg0, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -414,6 +428,21 @@ func (mr *MockStoreMockRecorder) GetBlocksForBoard(arg0 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksForBoard", reflect.TypeOf((*MockStore)(nil).GetBlocksForBoard), arg0)
 }
 
+// GetBlocksReferencingFiles mocks base method.
+func (m *MockStore) GetBlocksReferencingFiles(arg0 int64) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocksReferencingFiles", arg0)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocksReferencingFiles indicates an expected call of GetBlocksReferencingFiles.
+func (mr *MockStoreMockRecorder) GetBlocksReferencingFiles(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksReferencingFiles", reflect.TypeOf((*MockStore)(nil).GetBlocksReferencingFiles), arg0)
+}
+
 // GetBlocksWithBoardID mocks base method.
 func (m *MockStore) GetBlocksWithBoardID(arg0 string) ([]model.Block, error) {
 	m.ctrl.T.Helper()
@@ -551,6 +580,36 @@ func (mr *MockStoreMockRecorder) GetBoardMemberHistory(arg0, arg1, arg2 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBoardMemberHistory", reflect.TypeOf((*MockStore)(nil).GetBoardMemberHistory), arg0, arg1, arg2)
 }
 
+// GetBoardsForTeam mocks base method.
+func (m *MockStore) GetBoardsForTeam(arg0 string) ([]*model.Board, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBoardsForTeam", arg0)
+	ret0, _ := ret[0].([]*model.Board)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBoardsForTeam indicates an expected call of GetBoardsForTeam.
+func (mr *MockStoreMockRecorder) GetBoardsForTeam(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBoardsForTeam", reflect.TypeOf((*MockStore)(nil).GetBoardsForTeam), arg0)
+}
+
+// GetBoardsReferencingBoard mocks base method.
+func (m *MockStore) GetBoardsReferencingBoard(arg0 string, arg1 string) ([]*model.Board, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBoardsReferencingBoard", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Board)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBoardsReferencingBoard indicates an expected call of GetBoardsReferencingBoard.
+func (mr *MockStoreMockRecorder) GetBoardsReferencingBoard(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBoardsReferencingBoard", reflect.TypeOf((*MockStore)(nil).GetBoardsReferencingBoard), arg0, arg1)
+}
+
 // GetBoardsForUserAndTeam mocks base method.
 func (m *MockStore) GetBoardsForUserAndTeam(arg0, arg1 string) ([]*model.Board, error) {
 	m.ctrl.T.Helper()
@@ -581,6 +640,36 @@ func (mr *MockStoreMockRecorder) GetCategory(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategory", reflect.TypeOf((*MockStore)(nil).GetCategory), arg0)
 }
 
+// GetFileInfo mocks base method.
+func (m *MockStore) GetFileInfo(arg0 string) (*model.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileInfo", arg0)
+	ret0, _ := ret[0].(*model.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileInfo indicates an expected call of GetFileInfo.
+func (mr *MockStoreMockRecorder) GetFileInfo(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileInfo", reflect.TypeOf((*MockStore)(nil).GetFileInfo), arg0)
+}
+
+// GetFileInfosByChecksum mocks base method.
+func (m *MockStore) GetFileInfosByChecksum(arg0 string) ([]*model.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileInfosByChecksum", arg0)
+	ret0, _ := ret[0].([]*model.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileInfosByChecksum indicates an expected call of GetFileInfosByChecksum.
+func (mr *MockStoreMockRecorder) GetFileInfosByChecksum(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileInfosByChecksum", reflect.TypeOf((*MockStore)(nil).GetFileInfosByChecksum), arg0)
+}
+
 // GetLicense mocks base method.
 func (m *MockStore) GetLicense() *model0.License {
 	m.ctrl.T.Helper()
@@ -670,6 +759,36 @@ func (mr *MockStoreMockRecorder) GetNotificationHint(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationHint", reflect.TypeOf((*MockStore)(nil).GetNotificationHint), arg0)
 }
 
+// GetPropertyMigration mocks base method.
+func (m *MockStore) GetPropertyMigration(arg0 string) (*model.PropertyMigration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPropertyMigration", arg0)
+	ret0, _ := ret[0].(*model.PropertyMigration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPropertyMigration indicates an expected call of GetPropertyMigration.
+func (mr *MockStoreMockRecorder) GetPropertyMigration(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPropertyMigration", reflect.TypeOf((*MockStore)(nil).GetPropertyMigration), arg0)
+}
+
+// GetPropertyMigrations mocks base method.
+func (m *MockStore) GetPropertyMigrations(arg0 string) ([]*model.PropertyMigration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPropertyMigrations", arg0)
+	ret0, _ := ret[0].([]*model.PropertyMigration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPropertyMigrations indicates an expected call of GetPropertyMigrations.
+func (mr *MockStoreMockRecorder) GetPropertyMigrations(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPropertyMigrations", reflect.TypeOf((*MockStore)(nil).GetPropertyMigrations), arg0)
+}
+
 // GetRegisteredUserCount mocks base method.
 func (m *MockStore) GetRegisteredUserCount() (int, error) {
 	m.ctrl.T.Helper()
@@ -850,6 +969,21 @@ func (mr *MockStoreMockRecorder) GetTeamCount() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTeamCount", reflect.TypeOf((*MockStore)(nil).GetTeamCount))
 }
 
+// GetTeamFileUsage mocks base method.
+func (m *MockStore) GetTeamFileUsage(arg0 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTeamFileUsage", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTeamFileUsage indicates an expected call of GetTeamFileUsage.
+func (mr *MockStoreMockRecorder) GetTeamFileUsage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTeamFileUsage", reflect.TypeOf((*MockStore)(nil).GetTeamFileUsage), arg0)
+}
+
 // GetTeamsForUser mocks base method.
 func (m *MockStore) GetTeamsForUser(arg0 string) ([]*model.Team, error) {
 	m.ctrl.T.Helper()
@@ -1114,6 +1248,20 @@ func (mr *MockStoreMockRecorder) RemoveDefaultTemplates(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDefaultTemplates", reflect.TypeOf((*MockStore)(nil).RemoveDefaultTemplates), arg0)
 }
 
+// SaveFileInfo mocks base method.
+func (m *MockStore) SaveFileInfo(arg0 *model.FileInfo) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFileInfo", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveFileInfo indicates an expected call of SaveFileInfo.
+func (mr *MockStoreMockRecorder) SaveFileInfo(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFileInfo", reflect.TypeOf((*MockStore)(nil).SaveFileInfo), arg0)
+}
+
 // SaveMember mocks base method.
 func (m *MockStore) SaveMember(arg0 *model.BoardMember) (*model.BoardMember, error) {
 	m.ctrl.T.Helper()
@@ -1129,6 +1277,21 @@ func (mr *MockStoreMockRecorder) SaveMember(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMember", reflect.TypeOf((*MockStore)(nil).SaveMember), arg0)
 }
 
+// SavePropertyMigration mocks base method.
+func (m *MockStore) SavePropertyMigration(arg0 *model.PropertyMigration, arg1 string) (*model.Board, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePropertyMigration", arg0, arg1)
+	ret0, _ := ret[0].(*model.Board)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SavePropertyMigration indicates an expected call of SavePropertyMigration.
+func (mr *MockStoreMockRecorder) SavePropertyMigration(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePropertyMigration", reflect.TypeOf((*MockStore)(nil).SavePropertyMigration), arg0, arg1)
+}
+
 // SearchBoardsForUser mocks base method.
 func (m *MockStore) SearchBoardsForUser(arg0, arg1 string) ([]*model.Board, error) {
 	m.ctrl.T.Helper()
@@ -1159,6 +1322,34 @@ func (mr *MockStoreMockRecorder) SearchUsersByTeam(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUsersByTeam", reflect.TypeOf((*MockStore)(nil).SearchUsersByTeam), arg0, arg1)
 }
 
+// SetBlockField mocks base method.
+func (m *MockStore) SetBlockField(arg0 string, arg1 string, arg2 interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBlockField", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBlockField indicates an expected call of SetBlockField.
+func (mr *MockStoreMockRecorder) SetBlockField(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBlockField", reflect.TypeOf((*MockStore)(nil).SetBlockField), arg0, arg1, arg2)
+}
+
+// SetCardPropertyValues mocks base method.
+func (m *MockStore) SetCardPropertyValues(arg0 string, arg1 map[string]interface{}, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCardPropertyValues", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCardPropertyValues indicates an expected call of SetCardPropertyValues.
+func (mr *MockStoreMockRecorder) SetCardPropertyValues(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCardPropertyValues", reflect.TypeOf((*MockStore)(nil).SetCardPropertyValues), arg0, arg1, arg2)
+}
+
 // SetSystemSetting mocks base method.
 func (m *MockStore) SetSystemSetting(arg0, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -1215,6 +1406,22 @@ func (mr *MockStoreMockRecorder) UndeleteBoard(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UndeleteBoard", reflect.TypeOf((*MockStore)(nil).UndeleteBoard), arg0, arg1)
 }
 
+// UndoPropertyMigration mocks base method.
+func (m *MockStore) UndoPropertyMigration(arg0, arg1, arg2 string) (*model.PropertyMigration, *model.Board, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UndoPropertyMigration", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.PropertyMigration)
+	ret1, _ := ret[1].(*model.Board)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UndoPropertyMigration indicates an expected call of UndoPropertyMigration.
+func (mr *MockStoreMockRecorder) UndoPropertyMigration(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UndoPropertyMigration", reflect.TypeOf((*MockStore)(nil).UndoPropertyMigration), arg0, arg1, arg2)
+}
+
 // UpdateCategory mocks base method.
 func (m *MockStore) UpdateCategory(arg0 model.Category) error {
 	m.ctrl.T.Helper()
@@ -319,6 +319,12 @@ func (s *SQLStore) insertBlock(db sq.BaseRunner, block *model.Block, userID stri
 }
 
 func (s *SQLStore) patchBlock(db sq.BaseRunner, blockID string, blockPatch *model.BlockPatch, userID string) error {
+	unlock, err := s.lockForPatch(db, "blocks", blockID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	existingBlock, err := s.getBlock(db, blockID)
 	if err != nil {
 		return err
@@ -327,10 +333,36 @@ func (s *SQLStore) patchBlock(db sq.BaseRunner, blockID string, blockPatch *mode
 		return BlockNotFoundErr{blockID}
 	}
 
+	if blockPatch.UpdateAt != nil && *blockPatch.UpdateAt != existingBlock.UpdateAt {
+		blockPatch, err = s.rebaseBlockPatch(db, existingBlock, blockPatch)
+		if err != nil {
+			return err
+		}
+	}
+
 	block := blockPatch.Patch(existingBlock)
 	return s.insertBlock(db, block, userID)
 }
 
+// rebaseBlockPatch returns the patch to apply to a block updated since the version the
+// patch is based on, or an ErrPatchConflict if the patch changes the same fields.
+func (s *SQLStore) rebaseBlockPatch(db sq.BaseRunner, block *model.Block, blockPatch *model.BlockPatch) (*model.BlockPatch, error) {
+	opts := model.QueryBlockHistoryOptions{BeforeUpdateAt: *blockPatch.UpdateAt + 1, Limit: 1, Descending: true}
+	versions, err := s.getBlockHistory(db, block.ID, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, &model.ErrPatchConflict{Block: block}
+	}
+
+	rebased, ok := blockPatch.Rebase(&versions[0], block)
+	if !ok {
+		return nil, &model.ErrPatchConflict{Block: block}
+	}
+	return rebased, nil
+}
+
 func (s *SQLStore) patchBlocks(db sq.BaseRunner, blockPatches *model.BlockPatchBatch, userID string) error {
 	for i, blockID := range blockPatches.BlockIDs {
 		err := s.patchBlock(db, blockID, &blockPatches.BlockPatches[i], userID)
@@ -341,6 +373,101 @@ func (s *SQLStore) patchBlocks(db sq.BaseRunner, blockPatches *model.BlockPatchB
 	return nil
 }
 
+// setBlockField sets a field of a block, or deletes it if the value is nil, without
+// changing the update time and the author of the block, for the fields the server
+// derives from other blocks.
+func (s *SQLStore) setBlockField(db sq.BaseRunner, blockID, field string, value interface{}) error {
+	unlock, err := s.lockForPatch(db, "blocks", blockID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	block, err := s.getBlock(db, blockID)
+	if err != nil {
+		return err
+	}
+	if block == nil {
+		return BlockNotFoundErr{blockID}
+	}
+
+	fields := make(map[string]interface{}, len(block.Fields)+1)
+	for k, v := range block.Fields {
+		fields[k] = v
+	}
+	if value == nil {
+		delete(fields, field)
+	} else {
+		fields[field] = value
+	}
+	return s.updateBlockFields(db, blockID, fields)
+}
+
+// setCardPropertyValues sets some property values of a card, deleting the properties
+// whose value is nil, and leaves its other properties untouched. The change is recorded
+// as an edit of the user, or keeps the update time and the author of the card if the
+// user ID is empty, for the values the server computes.
+func (s *SQLStore) setCardPropertyValues(db sq.BaseRunner, cardID string, values map[string]interface{}, userID string) error {
+	unlock, err := s.lockForPatch(db, "blocks", cardID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	card, err := s.getBlock(db, cardID)
+	if err != nil {
+		return err
+	}
+	if card == nil {
+		return model.NewErrNotFound(cardID)
+	}
+	return s.updateCardPropertyValues(db, card, values, userID)
+}
+
+// updateCardPropertyValues sets some property values of a card locked for a patch.
+func (s *SQLStore) updateCardPropertyValues(db sq.BaseRunner, card *model.Block, values map[string]interface{}, userID string) error {
+	props, _ := card.Fields["properties"].(map[string]interface{})
+	merged := make(map[string]interface{}, len(props)+len(values))
+	for k, v := range props {
+		merged[k] = v
+	}
+	for k, v := range values {
+		if v == nil {
+			delete(merged, k)
+		} else {
+			merged[k] = v
+		}
+	}
+	fields := make(map[string]interface{}, len(card.Fields)+1)
+	for k, v := range card.Fields {
+		fields[k] = v
+	}
+	fields["properties"] = merged
+
+	if userID != "" {
+		card.Fields = fields
+		return s.insertBlock(db, card, userID)
+	}
+	return s.updateBlockFields(db, card.ID, fields)
+}
+
+// updateBlockFields writes the fields of a block, leaving its other columns untouched.
+func (s *SQLStore) updateBlockFields(db sq.BaseRunner, blockID string, fields map[string]interface{}) error {
+	fieldsJSON, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	query := s.getQueryBuilder(db).Update(s.tablePrefix+"blocks").
+		Where(sq.Eq{"id": blockID}).
+		Set("fields", fieldsJSON)
+	if _, err = query.Exec(); err != nil {
+		s.logger.Error(`updateBlockFields ERROR`, mlog.String("blockID", blockID), mlog.Err(err))
+		return err
+	}
+	return nil
+}
+
 func (s *SQLStore) insertBlocks(db sq.BaseRunner, blocks []model.Block, userID string) error {
 	for _, block := range blocks {
 		if block.BoardID == "" {
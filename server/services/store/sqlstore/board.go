@@ -267,6 +267,50 @@ func (s *SQLStore) getBoardsForUserAndTeam(db sq.BaseRunner, userID, teamID stri
 	return s.boardsFromRows(rows)
 }
 
+// getBoardsForTeam returns all the boards and templates of a team,
+// regardless of their type or membership.
+func (s *SQLStore) getBoardsForTeam(db sq.BaseRunner, teamID string) ([]*model.Board, error) {
+	query := s.getQueryBuilder(db).
+		Select(boardFields("")...).
+		From(s.tablePrefix + "boards").
+		Where(sq.Eq{"team_id": teamID}).
+		OrderBy("create_at")
+
+	rows, err := query.Query()
+	if err != nil {
+		s.logger.Error(`getBoardsForTeam ERROR`, mlog.Err(err))
+		return nil, err
+	}
+	defer s.CloseRows(rows)
+
+	return s.boardsFromRows(rows)
+}
+
+// getBoardsReferencingBoard returns the boards of a team whose card properties
+// mention the ID of a board, which include the boards with relations to it.
+func (s *SQLStore) getBoardsReferencingBoard(db sq.BaseRunner, teamID, boardID string) ([]*model.Board, error) {
+	cardProperties := "card_properties"
+	if s.dbType == model.PostgresDBType {
+		cardProperties = "card_properties::text"
+	}
+
+	query := s.getQueryBuilder(db).
+		Select(boardFields("")...).
+		From(s.tablePrefix + "boards").
+		Where(sq.Eq{"team_id": teamID}).
+		Where(sq.Like{cardProperties: "%" + boardID + "%"}).
+		OrderBy("create_at")
+
+	rows, err := query.Query()
+	if err != nil {
+		s.logger.Error(`getBoardsReferencingBoard ERROR`, mlog.Err(err))
+		return nil, err
+	}
+	defer s.CloseRows(rows)
+
+	return s.boardsFromRows(rows)
+}
+
 func (s *SQLStore) insertBoard(db sq.BaseRunner, board *model.Board, userID string) (*model.Board, error) {
 	propertiesBytes, err := json.Marshal(board.Properties)
 	if err != nil {
@@ -362,6 +406,12 @@ func (s *SQLStore) insertBoard(db sq.BaseRunner, board *model.Board, userID stri
 }
 
 func (s *SQLStore) patchBoard(db sq.BaseRunner, boardID string, boardPatch *model.BoardPatch, userID string) (*model.Board, error) {
+	unlock, err := s.lockForPatch(db, "boards", boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	existingBoard, err := s.getBoard(db, boardID)
 	if err != nil {
 		return nil, err
@@ -370,10 +420,36 @@ func (s *SQLStore) patchBoard(db sq.BaseRunner, boardID string, boardPatch *mode
 		return nil, BoardNotFoundErr{boardID}
 	}
 
+	if boardPatch.UpdateAt != nil && *boardPatch.UpdateAt != existingBoard.UpdateAt {
+		boardPatch, err = s.rebaseBoardPatch(db, existingBoard, boardPatch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	board := boardPatch.Patch(existingBoard)
 	return s.insertBoard(db, board, userID)
 }
 
+// rebaseBoardPatch returns the patch to apply to a board updated since the version the
+// patch is based on, or an ErrPatchConflict if the patch changes the same fields.
+func (s *SQLStore) rebaseBoardPatch(db sq.BaseRunner, board *model.Board, boardPatch *model.BoardPatch) (*model.BoardPatch, error) {
+	opts := model.QueryBoardHistoryOptions{BeforeUpdateAt: *boardPatch.UpdateAt + 1, Limit: 1, Descending: true}
+	versions, err := s.getBoardHistory(db, board.ID, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, &model.ErrPatchConflict{Board: board}
+	}
+
+	rebased, ok := boardPatch.Rebase(versions[0], board)
+	if !ok {
+		return nil, &model.ErrPatchConflict{Board: board}
+	}
+	return rebased, nil
+}
+
 func (s *SQLStore) deleteBoard(db sq.BaseRunner, boardID, userID string) error {
 	now := utils.GetMillis()
 
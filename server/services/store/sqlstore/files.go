@@ -0,0 +1,199 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+var fileInfoFields = []string{
+	"id",
+	"team_id",
+	"board_id",
+	"name",
+	"mime_type",
+	"size",
+	"checksum",
+	"created_by",
+	"create_at",
+	"delete_at",
+}
+
+func (s *SQLStore) fileInfosFromRows(rows *sql.Rows) ([]*model.FileInfo, error) {
+	fileInfos := []*model.FileInfo{}
+
+	for rows.Next() {
+		var fileInfo model.FileInfo
+
+		err := rows.Scan(
+			&fileInfo.ID,
+			&fileInfo.TeamID,
+			&fileInfo.BoardID,
+			&fileInfo.Name,
+			&fileInfo.MimeType,
+			&fileInfo.Size,
+			&fileInfo.Checksum,
+			&fileInfo.CreatedBy,
+			&fileInfo.CreateAt,
+			&fileInfo.DeleteAt,
+		)
+		if err != nil {
+			s.logger.Error("fileInfosFromRows scan error", mlog.Err(err))
+			return nil, err
+		}
+
+		fileInfos = append(fileInfos, &fileInfo)
+	}
+	return fileInfos, nil
+}
+
+func (s *SQLStore) saveFileInfo(db sq.BaseRunner, fileInfo *model.FileInfo) error {
+	if fileInfo.CreateAt == 0 {
+		fileInfo.CreateAt = utils.GetMillis()
+	}
+
+	query := s.getQueryBuilder(db).
+		Insert(s.tablePrefix+"file_info").
+		Columns(fileInfoFields...).
+		Values(
+			fileInfo.ID,
+			fileInfo.TeamID,
+			fileInfo.BoardID,
+			fileInfo.Name,
+			fileInfo.MimeType,
+			fileInfo.Size,
+			fileInfo.Checksum,
+			fileInfo.CreatedBy,
+			fileInfo.CreateAt,
+			fileInfo.DeleteAt,
+		)
+
+	if _, err := query.Exec(); err != nil {
+		s.logger.Error("Cannot save file info",
+			mlog.String("file_id", fileInfo.ID),
+			mlog.String("team_id", fileInfo.TeamID),
+			mlog.Err(err),
+		)
+		return err
+	}
+	return nil
+}
+
+func (s *SQLStore) getFileInfo(db sq.BaseRunner, id string) (*model.FileInfo, error) {
+	query := s.getQueryBuilder(db).
+		Select(fileInfoFields...).
+		From(s.tablePrefix + "file_info").
+		Where(sq.Eq{"id": id})
+
+	rows, err := query.Query()
+	if err != nil {
+		s.logger.Error("Cannot fetch file info", mlog.String("file_id", id), mlog.Err(err))
+		return nil, err
+	}
+	defer s.CloseRows(rows)
+
+	fileInfos, err := s.fileInfosFromRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(fileInfos) == 0 {
+		return nil, model.NewErrNotFound(id)
+	}
+	return fileInfos[0], nil
+}
+
+// getFileInfosByChecksum returns the files with a content, including the deleted ones.
+func (s *SQLStore) getFileInfosByChecksum(db sq.BaseRunner, checksum string) ([]*model.FileInfo, error) {
+	query := s.getQueryBuilder(db).
+		Select(fileInfoFields...).
+		From(s.tablePrefix + "file_info").
+		Where(sq.Eq{"checksum": checksum}).
+		OrderBy("create_at")
+
+	rows, err := query.Query()
+	if err != nil {
+		s.logger.Error("Cannot fetch file infos by checksum", mlog.String("checksum", checksum), mlog.Err(err))
+		return nil, err
+	}
+	defer s.CloseRows(rows)
+
+	return s.fileInfosFromRows(rows)
+}
+
+func (s *SQLStore) deleteFileInfo(db sq.BaseRunner, id string) error {
+	query := s.getQueryBuilder(db).
+		Update(s.tablePrefix+"file_info").
+		Set("delete_at", utils.GetMillis()).
+		Where(sq.Eq{"id": id}).
+		Where(sq.Eq{"delete_at": 0})
+
+	if _, err := query.Exec(); err != nil {
+		s.logger.Error("Cannot delete file info", mlog.String("file_id", id), mlog.Err(err))
+		return err
+	}
+	return nil
+}
+
+// getTeamFileUsage returns the total size in bytes of the files of a team that are
+// not deleted.
+func (s *SQLStore) getTeamFileUsage(db sq.BaseRunner, teamID string) (int64, error) {
+	query := s.getQueryBuilder(db).
+		Select("COALESCE(SUM(size), 0)").
+		From(s.tablePrefix + "file_info").
+		Where(sq.Eq{"team_id": teamID}).
+		Where(sq.Eq{"delete_at": 0})
+
+	var usage int64
+	if err := query.QueryRow().Scan(&usage); err != nil {
+		s.logger.Error("Cannot compute the file usage of a team", mlog.String("team_id", teamID), mlog.Err(err))
+		return 0, err
+	}
+	return usage, nil
+}
+
+// getBlocksReferencingFiles returns the image and attachment blocks, and the versions
+// of these blocks in the history updated since a time in milliseconds, which includes
+// the deleted blocks that can still be restored.
+func (s *SQLStore) getBlocksReferencingFiles(db sq.BaseRunner, historySince int64) ([]model.Block, error) {
+	fileTypes := []string{model.TypeImage, model.TypeAttachment}
+
+	query := s.getQueryBuilder(db).
+		Select(s.blockFields()...).
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"type": fileTypes})
+
+	rows, err := query.Query()
+	if err != nil {
+		s.logger.Error("Cannot fetch the blocks referencing files", mlog.Err(err))
+		return nil, err
+	}
+	defer s.CloseRows(rows)
+
+	blocks, err := s.blocksFromRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	historyQuery := s.getQueryBuilder(db).
+		Select(s.blockFields()...).
+		From(s.tablePrefix + "blocks_history").
+		Where(sq.Eq{"type": fileTypes}).
+		Where(sq.GtOrEq{"update_at": historySince})
+
+	historyRows, err := historyQuery.Query()
+	if err != nil {
+		s.logger.Error("Cannot fetch the history of the blocks referencing files", mlog.Err(err))
+		return nil, err
+	}
+	defer s.CloseRows(historyRows)
+
+	history, err := s.blocksFromRows(historyRows)
+	if err != nil {
+		return nil, err
+	}
+	return append(blocks, history...), nil
+}
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}property_migrations (
+    id VARCHAR(36) NOT NULL,
+    board_id VARCHAR(36) NOT NULL,
+    operation VARCHAR(20) NOT NULL,
+    property_id VARCHAR(36) NOT NULL,
+    {{if .mysql}}
+    params JSON,
+    previous_property JSON,
+    changes JSON,
+    {{end}}
+    {{if .postgres}}
+    params JSONB,
+    previous_property JSONB,
+    changes JSONB,
+    {{end}}
+    {{if .sqlite}}
+    params TEXT,
+    previous_property TEXT,
+    changes TEXT,
+    {{end}}
+    previous_index INT,
+    created_by VARCHAR(36),
+    create_at BIGINT,
+    undone_by VARCHAR(36),
+    undone_at BIGINT,
+    PRIMARY KEY (id)
+) {{if .mysql}}DEFAULT CHARACTER SET utf8mb4{{end}};
+
+CREATE INDEX idx_propertymigrations_board_id ON {{.prefix}}property_migrations(board_id, create_at);
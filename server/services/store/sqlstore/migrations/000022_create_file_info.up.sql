@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}file_info (
+    id VARCHAR(64) NOT NULL,
+    team_id VARCHAR(36) NOT NULL,
+    board_id VARCHAR(36) NOT NULL,
+    name TEXT,
+    mime_type VARCHAR(255),
+    size BIGINT,
+    checksum VARCHAR(64),
+    created_by VARCHAR(36),
+    create_at BIGINT,
+    delete_at BIGINT,
+    PRIMARY KEY (id)
+) {{if .mysql}}DEFAULT CHARACTER SET utf8mb4{{end}};
+
+CREATE INDEX idx_fileinfo_team_id ON {{.prefix}}file_info(team_id, delete_at);
@@ -0,0 +1 @@
+DROP INDEX idx_fileinfo_checksum{{if .mysql}} ON {{.prefix}}file_info{{end}};
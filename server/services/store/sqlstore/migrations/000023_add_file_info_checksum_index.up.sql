@@ -0,0 +1 @@
+CREATE INDEX idx_fileinfo_checksum ON {{.prefix}}file_info(checksum, delete_at);
@@ -0,0 +1,270 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+var propertyMigrationFields = []string{
+	"id",
+	"board_id",
+	"operation",
+	"property_id",
+	"params",
+	"previous_property",
+	"previous_index",
+	"changes",
+	"created_by",
+	"create_at",
+	"undone_by",
+	"undone_at",
+}
+
+func (s *SQLStore) propertyMigrationsFromRows(rows *sql.Rows) ([]*model.PropertyMigration, error) {
+	migrations := []*model.PropertyMigration{}
+
+	for rows.Next() {
+		var migration model.PropertyMigration
+		var paramsBytes []byte
+		var previousPropertyBytes []byte
+		var changesBytes []byte
+
+		err := rows.Scan(
+			&migration.ID,
+			&migration.BoardID,
+			&migration.Operation,
+			&migration.PropertyID,
+			&paramsBytes,
+			&previousPropertyBytes,
+			&migration.PreviousIndex,
+			&changesBytes,
+			&migration.CreatedBy,
+			&migration.CreateAt,
+			&migration.UndoneBy,
+			&migration.UndoneAt,
+		)
+		if err != nil {
+			s.logger.Error("propertyMigrationsFromRows scan error", mlog.Err(err))
+			return nil, err
+		}
+
+		if err = json.Unmarshal(paramsBytes, &migration.PropertyMigrationParams); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(previousPropertyBytes, &migration.PreviousProperty); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(changesBytes, &migration.Changes); err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, &migration)
+	}
+	return migrations, nil
+}
+
+// savePropertyMigration applies a property migration to the card properties of a board
+// and to the values of its cards, and records the migration.
+func (s *SQLStore) savePropertyMigration(db sq.BaseRunner, migration *model.PropertyMigration, userID string) (*model.Board, error) {
+	board, cards, unlock, err := s.getBoardCardsForPatch(db, migration.BoardID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	cardProperties, err := model.MigrateCardProperties(board, cards, migration)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{}, len(migration.Changes))
+	for _, change := range migration.Changes {
+		values[change.BlockID] = change.After
+	}
+	if board, err = s.migrateCardProperty(db, board, cardProperties, cards, migration.PropertyID, values, userID); err != nil {
+		return nil, err
+	}
+
+	paramsBytes, err := json.Marshal(migration.PropertyMigrationParams)
+	if err != nil {
+		return nil, err
+	}
+	previousPropertyBytes, err := json.Marshal(migration.PreviousProperty)
+	if err != nil {
+		return nil, err
+	}
+	changesBytes, err := json.Marshal(migration.Changes)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.getQueryBuilder(db).
+		Insert(s.tablePrefix+"property_migrations").
+		Columns(propertyMigrationFields...).
+		Values(
+			migration.ID,
+			migration.BoardID,
+			migration.Operation,
+			migration.PropertyID,
+			paramsBytes,
+			previousPropertyBytes,
+			migration.PreviousIndex,
+			changesBytes,
+			migration.CreatedBy,
+			migration.CreateAt,
+			migration.UndoneBy,
+			migration.UndoneAt,
+		)
+	if _, err = query.Exec(); err != nil {
+		s.logger.Error("Cannot record property migration",
+			mlog.String("board_id", migration.BoardID),
+			mlog.String("property_id", migration.PropertyID),
+			mlog.Err(err),
+		)
+		return nil, err
+	}
+	return board, nil
+}
+
+// undoPropertyMigration restores the property changed by the latest migration of a board
+// and the card values it rewrote, except for the cards whose value changed since.
+func (s *SQLStore) undoPropertyMigration(db sq.BaseRunner, boardID, migrationID, userID string) (*model.PropertyMigration, *model.Board, error) {
+	board, cards, unlock, err := s.getBoardCardsForPatch(db, boardID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	migration, err := s.getPropertyMigration(db, migrationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if migration.BoardID != boardID {
+		return nil, nil, model.NewErrNotFound(migrationID)
+	}
+
+	migrations, err := s.getPropertyMigrations(db, boardID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, m := range migrations {
+		if m.UndoneAt != 0 {
+			continue
+		}
+		if m.ID != migration.ID {
+			return nil, nil, fmt.Errorf("%w: only the latest migration of a board can be undone", model.ErrPropertyMigrationNotUndoable)
+		}
+		break
+	}
+	if migration.UndoneAt != 0 {
+		return nil, nil, fmt.Errorf("%w: the migration is already undone", model.ErrPropertyMigrationNotUndoable)
+	}
+
+	values := migration.UndoValues(cards)
+	board, err = s.migrateCardProperty(db, board, migration.UndoCardProperties(board), cards, migration.PropertyID, values, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migration.UndoneBy = userID
+	migration.UndoneAt = utils.GetMillis()
+	query := s.getQueryBuilder(db).
+		Update(s.tablePrefix+"property_migrations").
+		Set("undone_by", migration.UndoneBy).
+		Set("undone_at", migration.UndoneAt).
+		Where(sq.Eq{"id": migration.ID})
+	if _, err = query.Exec(); err != nil {
+		return nil, nil, err
+	}
+	return migration, board, nil
+}
+
+// getBoardCardsForPatch locks a board and its cards for a patch, and returns them with
+// the function releasing the lock.
+func (s *SQLStore) getBoardCardsForPatch(db sq.BaseRunner, boardID string) (*model.Board, []model.Block, func(), error) {
+	unlock, err := s.lockForPatch(db, "boards", boardID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	board, err := s.getBoard(db, boardID)
+	if err != nil {
+		unlock()
+		return nil, nil, nil, err
+	}
+	if err = s.lockBlocksForPatch(db, boardID, model.TypeCard); err != nil {
+		unlock()
+		return nil, nil, nil, err
+	}
+	cards, err := s.getBlocksWithType(db, boardID, model.TypeCard)
+	if err != nil {
+		unlock()
+		return nil, nil, nil, err
+	}
+	return board, cards, unlock, nil
+}
+
+// migrateCardProperty saves the card properties of a locked board, where only the
+// migrated property changed, and sets the values of the migrated property on its cards,
+// keyed by card id.
+func (s *SQLStore) migrateCardProperty(db sq.BaseRunner, board *model.Board, cardProperties []map[string]interface{}, cards []model.Block, propertyID string, values map[string]interface{}, userID string) (*model.Board, error) {
+	for i := range cards {
+		value, ok := values[cards[i].ID]
+		if !ok {
+			continue
+		}
+		err := s.updateCardPropertyValues(db, &cards[i], map[string]interface{}{propertyID: value}, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	board.CardProperties = cardProperties
+	return s.insertBoard(db, board, userID)
+}
+
+func (s *SQLStore) getPropertyMigration(db sq.BaseRunner, id string) (*model.PropertyMigration, error) {
+	query := s.getQueryBuilder(db).
+		Select(propertyMigrationFields...).
+		From(s.tablePrefix + "property_migrations").
+		Where(sq.Eq{"id": id})
+
+	rows, err := query.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer s.CloseRows(rows)
+
+	migrations, err := s.propertyMigrationsFromRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, model.NewErrNotFound(id)
+	}
+	return migrations[0], nil
+}
+
+// getPropertyMigrations returns the property migrations of a board, latest first.
+func (s *SQLStore) getPropertyMigrations(db sq.BaseRunner, boardID string) ([]*model.PropertyMigration, error) {
+	query := s.getQueryBuilder(db).
+		Select(propertyMigrationFields...).
+		From(s.tablePrefix+"property_migrations").
+		Where(sq.Eq{"board_id": boardID}).
+		OrderBy("create_at DESC", "id DESC")
+
+	rows, err := query.Query()
+	if err != nil {
+		s.logger.Error("Cannot fetch property migrations", mlog.String("board_id", boardID), mlog.Err(err))
+		return nil, err
+	}
+	defer s.CloseRows(rows)
+
+	return s.propertyMigrationsFromRows(rows)
+}
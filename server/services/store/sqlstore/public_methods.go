@@ -196,6 +196,11 @@ func (s *SQLStore) DeleteCategory(categoryID string, userID string, teamID strin
 
 }
 
+func (s *SQLStore) DeleteFileInfo(id string) error {
+	return s.deleteFileInfo(s.db, id)
+
+}
+
 func (s *SQLStore) DeleteMember(boardID string, userID string) error {
 	return s.deleteMember(s.db, boardID, userID)
 
@@ -299,6 +304,11 @@ func (s *SQLStore) GetBlocksForBoard(boardID string) ([]model.Block, error) {
 
 }
 
+func (s *SQLStore) GetBlocksReferencingFiles(historySince int64) ([]model.Block, error) {
+	return s.getBlocksReferencingFiles(s.db, historySince)
+
+}
+
 func (s *SQLStore) GetBlocksWithBoardID(boardID string) ([]model.Block, error) {
 	return s.getBlocksWithBoardID(s.db, boardID)
 
@@ -344,16 +354,36 @@ func (s *SQLStore) GetBoardMemberHistory(boardID string, userID string, limit ui
 
 }
 
+func (s *SQLStore) GetBoardsForTeam(teamID string) ([]*model.Board, error) {
+	return s.getBoardsForTeam(s.db, teamID)
+
+}
+
 func (s *SQLStore) GetBoardsForUserAndTeam(userID string, teamID string) ([]*model.Board, error) {
 	return s.getBoardsForUserAndTeam(s.db, userID, teamID)
 
 }
 
+func (s *SQLStore) GetBoardsReferencingBoard(teamID string, boardID string) ([]*model.Board, error) {
+	return s.getBoardsReferencingBoard(s.db, teamID, boardID)
+
+}
+
 func (s *SQLStore) GetCategory(id string) (*model.Category, error) {
 	return s.getCategory(s.db, id)
 
 }
 
+func (s *SQLStore) GetFileInfo(id string) (*model.FileInfo, error) {
+	return s.getFileInfo(s.db, id)
+
+}
+
+func (s *SQLStore) GetFileInfosByChecksum(checksum string) ([]*model.FileInfo, error) {
+	return s.getFileInfosByChecksum(s.db, checksum)
+
+}
+
 func (s *SQLStore) GetLicense() *mmModel.License {
 	return s.getLicense(s.db)
 
@@ -384,6 +414,16 @@ func (s *SQLStore) GetNotificationHint(blockID string) (*model.NotificationHint,
 
 }
 
+func (s *SQLStore) GetPropertyMigration(id string) (*model.PropertyMigration, error) {
+	return s.getPropertyMigration(s.db, id)
+
+}
+
+func (s *SQLStore) GetPropertyMigrations(boardID string) ([]*model.PropertyMigration, error) {
+	return s.getPropertyMigrations(s.db, boardID)
+
+}
+
 func (s *SQLStore) GetRegisteredUserCount() (int, error) {
 	return s.getRegisteredUserCount(s.db)
 
@@ -444,6 +484,11 @@ func (s *SQLStore) GetTeamCount() (int64, error) {
 
 }
 
+func (s *SQLStore) GetTeamFileUsage(teamID string) (int64, error) {
+	return s.getTeamFileUsage(s.db, teamID)
+
+}
+
 func (s *SQLStore) GetTeamsForUser(userID string) ([]*model.Team, error) {
 	return s.getTeamsForUser(s.db, userID)
 
@@ -667,11 +712,40 @@ func (s *SQLStore) RemoveDefaultTemplates(boards []*model.Board) error {
 
 }
 
+func (s *SQLStore) SaveFileInfo(fileInfo *model.FileInfo) error {
+	return s.saveFileInfo(s.db, fileInfo)
+
+}
+
 func (s *SQLStore) SaveMember(bm *model.BoardMember) (*model.BoardMember, error) {
 	return s.saveMember(s.db, bm)
 
 }
 
+func (s *SQLStore) SavePropertyMigration(migration *model.PropertyMigration, userID string) (*model.Board, error) {
+	if s.dbType == model.SqliteDBType {
+		return s.savePropertyMigration(s.db, migration, userID)
+	}
+	tx, txErr := s.db.BeginTx(context.Background(), nil)
+	if txErr != nil {
+		return nil, txErr
+	}
+	result, err := s.savePropertyMigration(tx, migration, userID)
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			s.logger.Error("transaction rollback error", mlog.Err(rollbackErr), mlog.String("methodName", "SavePropertyMigration"))
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+
+}
+
 func (s *SQLStore) SearchBoardsForUser(term string, userID string) ([]*model.Board, error) {
 	return s.searchBoardsForUser(s.db, term, userID)
 
@@ -682,6 +756,54 @@ func (s *SQLStore) SearchUsersByTeam(teamID string, searchQuery string) ([]*mode
 
 }
 
+func (s *SQLStore) SetBlockField(blockID string, field string, value interface{}) error {
+	if s.dbType == model.SqliteDBType {
+		return s.setBlockField(s.db, blockID, field, value)
+	}
+	tx, txErr := s.db.BeginTx(context.Background(), nil)
+	if txErr != nil {
+		return txErr
+	}
+	err := s.setBlockField(tx, blockID, field, value)
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			s.logger.Error("transaction rollback error", mlog.Err(rollbackErr), mlog.String("methodName", "SetBlockField"))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *SQLStore) SetCardPropertyValues(cardID string, values map[string]interface{}, userID string) error {
+	if s.dbType == model.SqliteDBType {
+		return s.setCardPropertyValues(s.db, cardID, values, userID)
+	}
+	tx, txErr := s.db.BeginTx(context.Background(), nil)
+	if txErr != nil {
+		return txErr
+	}
+	err := s.setCardPropertyValues(tx, cardID, values, userID)
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			s.logger.Error("transaction rollback error", mlog.Err(rollbackErr), mlog.String("methodName", "SetCardPropertyValues"))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
 func (s *SQLStore) SetSystemSetting(key string, value string) error {
 	return s.setSystemSetting(s.db, key, value)
 
@@ -735,6 +857,30 @@ func (s *SQLStore) UndeleteBoard(boardID string, modifiedBy string) error {
 
 }
 
+func (s *SQLStore) UndoPropertyMigration(boardID string, migrationID string, userID string) (*model.PropertyMigration, *model.Board, error) {
+	if s.dbType == model.SqliteDBType {
+		return s.undoPropertyMigration(s.db, boardID, migrationID, userID)
+	}
+	tx, txErr := s.db.BeginTx(context.Background(), nil)
+	if txErr != nil {
+		return nil, nil, txErr
+	}
+	result, resultVar1, err := s.undoPropertyMigration(tx, boardID, migrationID, userID)
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			s.logger.Error("transaction rollback error", mlog.Err(rollbackErr), mlog.String("methodName", "UndoPropertyMigration"))
+		}
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return result, resultVar1, nil
+
+}
+
 func (s *SQLStore) UpdateCategory(category model.Category) error {
 	return s.updateCategory(s.db, category)
 
@@ -2,6 +2,7 @@ package sqlstore
 
 import (
 	"database/sql"
+	"sync"
 
 	"github.com/mattermost/mattermost-server/v6/plugin"
 
@@ -24,6 +25,10 @@ type SQLStore struct {
 	logger           *mlog.Logger
 	NewMutexFn       MutexFactory
 	pluginAPI        *plugin.API
+
+	// sqlitePatchMutex serializes the patches on SQLite, which are not run in a
+	// transaction.
+	sqlitePatchMutex sync.Mutex
 }
 
 // MutexFactory is used by the store in plugin mode to generate
@@ -7,6 +7,8 @@ import (
 	"os"
 	"strings"
 
+	sq "github.com/Masterminds/squirrel"
+
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/utils"
 
@@ -23,6 +25,49 @@ func (s *SQLStore) IsErrNotFound(err error) bool {
 	return model.IsErrNotFound(err)
 }
 
+// lockForPatch locks a row of a table until the end of the transaction, so that the
+// concurrent patches of a block or a board are checked against its latest version one
+// after the other. It returns the function releasing the lock on SQLite.
+func (s *SQLStore) lockForPatch(db sq.BaseRunner, table, id string) (func(), error) {
+	if s.dbType == model.SqliteDBType {
+		s.sqlitePatchMutex.Lock()
+		return s.sqlitePatchMutex.Unlock, nil
+	}
+
+	rows, err := s.getQueryBuilder(db).
+		Select("id").
+		From(s.tablePrefix + table).
+		Where(sq.Eq{"id": id}).
+		Suffix("FOR UPDATE").
+		Query()
+	if err != nil {
+		return nil, err
+	}
+	s.CloseRows(rows)
+	return func() {}, nil
+}
+
+// lockBlocksForPatch locks the blocks of a type of a board like lockForPatch. It must be
+// called with the board locked, which locks its blocks on SQLite.
+func (s *SQLStore) lockBlocksForPatch(db sq.BaseRunner, boardID, blockType string) error {
+	if s.dbType == model.SqliteDBType {
+		return nil
+	}
+
+	rows, err := s.getQueryBuilder(db).
+		Select("id").
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"board_id": boardID}).
+		Where(sq.Eq{"type": blockType}).
+		Suffix("FOR UPDATE").
+		Query()
+	if err != nil {
+		return err
+	}
+	s.CloseRows(rows)
+	return nil
+}
+
 func PrepareNewTestDatabase() (dbType string, connectionString string, err error) {
 	dbType = strings.TrimSpace(os.Getenv("FB_STORE_TEST_DB_TYPE"))
 	if dbType == "" {
@@ -43,6 +43,10 @@ type Store interface {
 	DuplicateBlock(boardID string, blockID string, userID string, asTemplate bool) ([]model.Block, error)
 	// @withTransaction
 	PatchBlocks(blockPatches *model.BlockPatchBatch, userID string) error
+	// @withTransaction
+	SetBlockField(blockID, field string, value interface{}) error
+	// @withTransaction
+	SetCardPropertyValues(cardID string, values map[string]interface{}, userID string) error
 
 	Shutdown() error
 
@@ -87,6 +91,8 @@ type Store interface {
 	PatchBoard(boardID string, boardPatch *model.BoardPatch, userID string) (*model.Board, error)
 	GetBoard(id string) (*model.Board, error)
 	GetBoardsForUserAndTeam(userID, teamID string) ([]*model.Board, error)
+	GetBoardsForTeam(teamID string) ([]*model.Board, error)
+	GetBoardsReferencingBoard(teamID, boardID string) ([]*model.Board, error)
 	// @withTransaction
 	DeleteBoard(boardID, userID string) error
 
@@ -107,6 +113,20 @@ type Store interface {
 	// @withTransaction
 	DeleteBoardsAndBlocks(dbab *model.DeleteBoardsAndBlocks, userID string) error
 
+	// @withTransaction
+	SavePropertyMigration(migration *model.PropertyMigration, userID string) (*model.Board, error)
+	// @withTransaction
+	UndoPropertyMigration(boardID, migrationID, userID string) (*model.PropertyMigration, *model.Board, error)
+	GetPropertyMigration(id string) (*model.PropertyMigration, error)
+	GetPropertyMigrations(boardID string) ([]*model.PropertyMigration, error)
+
+	SaveFileInfo(fileInfo *model.FileInfo) error
+	GetFileInfo(id string) (*model.FileInfo, error)
+	GetFileInfosByChecksum(checksum string) ([]*model.FileInfo, error)
+	DeleteFileInfo(id string) error
+	GetTeamFileUsage(teamID string) (int64, error)
+	GetBlocksReferencingFiles(historySince int64) ([]model.Block, error)
+
 	GetCategory(id string) (*model.Category, error)
 	CreateCategory(category model.Category) error
 	UpdateCategory(category model.Category) error
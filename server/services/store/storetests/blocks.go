@@ -34,6 +34,16 @@ func StoreTestBlocksStore(t *testing.T, setup func(t *testing.T) (store.Store, f
 		defer tearDown()
 		testPatchBlock(t, store)
 	})
+	t.Run("SetBlockField", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testSetBlockField(t, store)
+	})
+	t.Run("SetCardPropertyValues", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testSetCardPropertyValues(t, store)
+	})
 	t.Run("PatchBlocks", func(t *testing.T) {
 		store, tearDown := setup(t)
 		defer tearDown()
@@ -357,6 +367,122 @@ func testPatchBlock(t *testing.T, store store.Store) {
 		require.Equal(t, "test value 2", retrievedBlock.Fields["test2"])
 		require.Equal(t, nil, retrievedBlock.Fields["test3"])
 	})
+
+	t.Run("patch based on an outdated version", func(t *testing.T) {
+		baseBlock, err := store.GetBlock("id-test")
+		require.NoError(t, err)
+
+		time.Sleep(1 * time.Millisecond)
+		concurrentTitle := "Concurrent title"
+		err = store.PatchBlock("id-test", &model.BlockPatch{Title: &concurrentTitle}, "user-id-2")
+		require.NoError(t, err)
+
+		time.Sleep(1 * time.Millisecond)
+		staleTitle := "Stale title"
+		err = store.PatchBlock("id-test", &model.BlockPatch{Title: &staleTitle, UpdateAt: &baseBlock.UpdateAt}, "user-id-1")
+		var errConflict *model.ErrPatchConflict
+		require.ErrorAs(t, err, &errConflict)
+		require.Equal(t, concurrentTitle, errConflict.Block.Title)
+
+		blockPatch := model.BlockPatch{
+			Title:         &baseBlock.Title,
+			UpdatedFields: map[string]interface{}{"test2": "merged value"},
+			UpdateAt:      &baseBlock.UpdateAt,
+		}
+		err = store.PatchBlock("id-test", &blockPatch, "user-id-1")
+		require.NoError(t, err)
+
+		retrievedBlock, err := store.GetBlock("id-test")
+		require.NoError(t, err)
+		require.Equal(t, concurrentTitle, retrievedBlock.Title)
+		require.Equal(t, "merged value", retrievedBlock.Fields["test2"])
+	})
+}
+
+func testSetBlockField(t *testing.T, store store.Store) {
+	block := model.Block{
+		ID:      "id-test",
+		BoardID: "board-id-1",
+		Fields:  map[string]interface{}{"test": "test value"},
+	}
+	err := store.InsertBlock(&block, "user-id-1")
+	require.NoError(t, err)
+	inserted, err := store.GetBlock("id-test")
+	require.NoError(t, err)
+
+	t.Run("set a field", func(t *testing.T) {
+		time.Sleep(1 * time.Millisecond)
+		err := store.SetBlockField("id-test", "progress", "1/2")
+		require.NoError(t, err)
+
+		retrievedBlock, err := store.GetBlock("id-test")
+		require.NoError(t, err)
+		require.Equal(t, "1/2", retrievedBlock.Fields["progress"])
+		require.Equal(t, "test value", retrievedBlock.Fields["test"])
+		require.Equal(t, inserted.UpdateAt, retrievedBlock.UpdateAt)
+		require.Equal(t, "user-id-1", retrievedBlock.ModifiedBy)
+	})
+
+	t.Run("delete a field", func(t *testing.T) {
+		err := store.SetBlockField("id-test", "progress", nil)
+		require.NoError(t, err)
+
+		retrievedBlock, err := store.GetBlock("id-test")
+		require.NoError(t, err)
+		require.NotContains(t, retrievedBlock.Fields, "progress")
+		require.Equal(t, inserted.UpdateAt, retrievedBlock.UpdateAt)
+	})
+
+	t.Run("not existing block id", func(t *testing.T) {
+		err := store.SetBlockField("invalid-block-id", "progress", "1/2")
+		require.Error(t, err)
+	})
+}
+
+func testSetCardPropertyValues(t *testing.T, store store.Store) {
+	block := model.Block{
+		ID:      "card-id",
+		BoardID: "board-id-1",
+		Type:    model.TypeCard,
+		Fields: map[string]interface{}{
+			"icon":       "i",
+			"properties": map[string]interface{}{"a": "1", "b": "2"},
+		},
+	}
+	err := store.InsertBlock(&block, "user-id-1")
+	require.NoError(t, err)
+	inserted, err := store.GetBlock("card-id")
+	require.NoError(t, err)
+
+	t.Run("set values computed by the server", func(t *testing.T) {
+		time.Sleep(1 * time.Millisecond)
+		err := store.SetCardPropertyValues("card-id", map[string]interface{}{"b": "3", "c": "4"}, "")
+		require.NoError(t, err)
+
+		card, err := store.GetBlock("card-id")
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"a": "1", "b": "3", "c": "4"}, card.Fields["properties"])
+		require.Equal(t, "i", card.Fields["icon"])
+		require.Equal(t, inserted.UpdateAt, card.UpdateAt)
+		require.Equal(t, "user-id-1", card.ModifiedBy)
+	})
+
+	t.Run("set and delete values as a user", func(t *testing.T) {
+		time.Sleep(1 * time.Millisecond)
+		err := store.SetCardPropertyValues("card-id", map[string]interface{}{"a": nil, "b": "5"}, "user-id-2")
+		require.NoError(t, err)
+
+		card, err := store.GetBlock("card-id")
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"b": "5", "c": "4"}, card.Fields["properties"])
+		require.Greater(t, card.UpdateAt, inserted.UpdateAt)
+		require.Equal(t, "user-id-2", card.ModifiedBy)
+	})
+
+	t.Run("not existing card id", func(t *testing.T) {
+		err := store.SetCardPropertyValues("invalid-card-id", map[string]interface{}{"a": "1"}, "")
+		require.True(t, model.IsErrNotFound(err))
+	})
 }
 
 func testPatchBlocks(t *testing.T, store store.Store) {
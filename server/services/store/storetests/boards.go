@@ -22,6 +22,16 @@ func StoreTestBoardStore(t *testing.T, setup func(t *testing.T) (store.Store, fu
 		defer tearDown()
 		testGetBoardsForUserAndTeam(t, store)
 	})
+	t.Run("GetBoardsForTeam", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetBoardsForTeam(t, store)
+	})
+	t.Run("GetBoardsReferencingBoard", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetBoardsReferencingBoard(t, store)
+	})
 	t.Run("InsertBoard", func(t *testing.T) {
 		store, tearDown := setup(t)
 		defer tearDown()
@@ -186,6 +196,87 @@ func testGetBoardsForUserAndTeam(t *testing.T, store store.Store) {
 	})
 }
 
+func testGetBoardsForTeam(t *testing.T, store store.Store) {
+	teamID := "team-id-1"
+
+	t.Run("should return an empty list for a team without boards", func(t *testing.T) {
+		boards, err := store.GetBoardsForTeam(teamID)
+		require.NoError(t, err)
+		require.Empty(t, boards)
+	})
+
+	t.Run("should return every board and template of the team", func(t *testing.T) {
+		board1 := &model.Board{
+			ID:     "board-id-1",
+			TeamID: teamID,
+			Type:   model.BoardTypeOpen,
+		}
+		_, err := store.InsertBoard(board1, "user-id-1")
+		require.NoError(t, err)
+
+		board2 := &model.Board{
+			ID:     "board-id-2",
+			TeamID: teamID,
+			Type:   model.BoardTypePrivate,
+		}
+		_, err = store.InsertBoard(board2, "user-id-2")
+		require.NoError(t, err)
+
+		template := &model.Board{
+			ID:         "template-id-1",
+			TeamID:     teamID,
+			Type:       model.BoardTypeOpen,
+			IsTemplate: true,
+		}
+		_, err = store.InsertBoard(template, "user-id-1")
+		require.NoError(t, err)
+
+		otherTeamBoard := &model.Board{
+			ID:     "board-id-3",
+			TeamID: "team-id-2",
+			Type:   model.BoardTypeOpen,
+		}
+		_, err = store.InsertBoard(otherTeamBoard, "user-id-1")
+		require.NoError(t, err)
+
+		boards, err := store.GetBoardsForTeam(teamID)
+		require.NoError(t, err)
+		require.Len(t, boards, 3)
+
+		ids := []string{boards[0].ID, boards[1].ID, boards[2].ID}
+		require.ElementsMatch(t, []string{board1.ID, board2.ID, template.ID}, ids)
+	})
+}
+
+func testGetBoardsReferencingBoard(t *testing.T, store store.Store) {
+	teamID := "team-id-1"
+	relationTo := func(boardID string) []map[string]interface{} {
+		return []map[string]interface{}{
+			{"id": "relation", "name": "Relation", "type": model.PropTypeRelation, "relation": map[string]interface{}{"boardId": boardID}},
+		}
+	}
+
+	boards := []*model.Board{
+		{ID: "board-id-1", TeamID: teamID, Type: model.BoardTypeOpen},
+		{ID: "board-id-2", TeamID: teamID, Type: model.BoardTypeOpen, CardProperties: relationTo("board-id-1")},
+		{ID: "board-id-3", TeamID: teamID, Type: model.BoardTypeOpen, CardProperties: relationTo("board-id-2")},
+		{ID: "board-id-4", TeamID: "team-id-2", Type: model.BoardTypeOpen, CardProperties: relationTo("board-id-1")},
+	}
+	for _, board := range boards {
+		_, err := store.InsertBoard(board, testUserID)
+		require.NoError(t, err)
+	}
+
+	referencing, err := store.GetBoardsReferencingBoard(teamID, "board-id-1")
+	require.NoError(t, err)
+	require.Len(t, referencing, 1)
+	require.Equal(t, "board-id-2", referencing[0].ID)
+
+	referencing, err = store.GetBoardsReferencingBoard(teamID, "board-id-3")
+	require.NoError(t, err)
+	require.Empty(t, referencing)
+}
+
 func testInsertBoard(t *testing.T, store store.Store) {
 	userID := testUserID
 
@@ -0,0 +1,161 @@
+package storetests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+func StoreTestFileStore(t *testing.T, setup func(t *testing.T) (store.Store, func())) {
+	t.Run("SaveAndGetFileInfo", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testSaveAndGetFileInfo(t, store)
+	})
+
+	t.Run("GetFileInfosByChecksum", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetFileInfosByChecksum(t, store)
+	})
+
+	t.Run("GetTeamFileUsage", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetTeamFileUsage(t, store)
+	})
+
+	t.Run("GetBlocksReferencingFiles", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetBlocksReferencingFiles(t, store)
+	})
+}
+
+func newTestFileInfo(teamID string, size int64) *model.FileInfo {
+	return &model.FileInfo{
+		ID:        utils.NewID(utils.IDTypeNone) + ".txt",
+		TeamID:    teamID,
+		BoardID:   utils.NewID(utils.IDTypeBoard),
+		Name:      "notes.txt",
+		MimeType:  "text/plain; charset=utf-8",
+		Size:      size,
+		Checksum:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		CreatedBy: utils.NewID(utils.IDTypeUser),
+	}
+}
+
+func testSaveAndGetFileInfo(t *testing.T, store store.Store) {
+	fileInfo := newTestFileInfo("team-id", 4)
+	require.NoError(t, store.SaveFileInfo(fileInfo))
+	require.NotZero(t, fileInfo.CreateAt)
+
+	saved, err := store.GetFileInfo(fileInfo.ID)
+	require.NoError(t, err)
+	require.Equal(t, fileInfo, saved)
+
+	_, err = store.GetFileInfo("missing.txt")
+	require.True(t, model.IsErrNotFound(err))
+
+	require.NoError(t, store.DeleteFileInfo(fileInfo.ID))
+	deleted, err := store.GetFileInfo(fileInfo.ID)
+	require.NoError(t, err)
+	require.NotZero(t, deleted.DeleteAt)
+}
+
+func testGetFileInfosByChecksum(t *testing.T, store store.Store) {
+	first := newTestFileInfo("team-id", 4)
+	first.CreateAt = 1
+	copied := newTestFileInfo("other-team-id", 4)
+	copied.CreateAt = 2
+	other := newTestFileInfo("team-id", 10)
+	other.Checksum = "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+	for _, fileInfo := range []*model.FileInfo{first, copied, other} {
+		require.NoError(t, store.SaveFileInfo(fileInfo))
+	}
+	require.NoError(t, store.DeleteFileInfo(copied.ID))
+
+	fileInfos, err := store.GetFileInfosByChecksum(first.Checksum)
+	require.NoError(t, err)
+	require.Len(t, fileInfos, 2)
+	require.Equal(t, first.ID, fileInfos[0].ID)
+	require.Equal(t, copied.ID, fileInfos[1].ID)
+	require.NotZero(t, fileInfos[1].DeleteAt)
+
+	fileInfos, err = store.GetFileInfosByChecksum("missing")
+	require.NoError(t, err)
+	require.Empty(t, fileInfos)
+}
+
+func testGetTeamFileUsage(t *testing.T, store store.Store) {
+	usage, err := store.GetTeamFileUsage("team-id")
+	require.NoError(t, err)
+	require.Zero(t, usage)
+
+	first := newTestFileInfo("team-id", 100)
+	second := newTestFileInfo("team-id", 50)
+	other := newTestFileInfo("other-team-id", 1000)
+	for _, fileInfo := range []*model.FileInfo{first, second, other} {
+		require.NoError(t, store.SaveFileInfo(fileInfo))
+	}
+
+	usage, err = store.GetTeamFileUsage("team-id")
+	require.NoError(t, err)
+	require.Equal(t, int64(150), usage)
+
+	require.NoError(t, store.DeleteFileInfo(second.ID))
+	usage, err = store.GetTeamFileUsage("team-id")
+	require.NoError(t, err)
+	require.Equal(t, int64(100), usage)
+}
+
+func testGetBlocksReferencingFiles(t *testing.T, store store.Store) {
+	boardID := utils.NewID(utils.IDTypeBoard)
+	userID := utils.NewID(utils.IDTypeUser)
+	newBlock := func(blockType model.BlockType, fileID string) *model.Block {
+		return &model.Block{
+			ID:       utils.NewID(utils.IDTypeBlock),
+			BoardID:  boardID,
+			Type:     blockType,
+			Fields:   map[string]interface{}{"fileId": fileID},
+			CreateAt: 1,
+			UpdateAt: 1,
+		}
+	}
+
+	image := newBlock(model.TypeImage, "7image.png")
+	attachment := newBlock(model.TypeAttachment, "7report.pdf")
+	deleted := newBlock(model.TypeImage, "7deleted.png")
+	text := newBlock(model.TypeText, "7text.png")
+	for _, block := range []*model.Block{image, attachment, deleted, text} {
+		require.NoError(t, store.InsertBlock(block, userID))
+	}
+	require.NoError(t, store.DeleteBlock(deleted.ID, userID))
+
+	fileIDs := func(historySince int64) map[string]bool {
+		blocks, err := store.GetBlocksReferencingFiles(historySince)
+		require.NoError(t, err)
+		ids := map[string]bool{}
+		for i := range blocks {
+			if fileID, ok := model.BlockFileID(&blocks[i]); ok {
+				ids[fileID] = true
+			}
+		}
+		return ids
+	}
+
+	ids := fileIDs(0)
+	require.True(t, ids["7image.png"])
+	require.True(t, ids["7report.pdf"])
+	require.True(t, ids["7deleted.png"])
+	require.False(t, ids["7text.png"])
+
+	ids = fileIDs(utils.GetMillis() + 1000)
+	require.True(t, ids["7image.png"])
+	require.True(t, ids["7report.pdf"])
+	require.False(t, ids["7deleted.png"])
+}
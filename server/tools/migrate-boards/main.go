@@ -0,0 +1,114 @@
+// migrate-boards copies boards from one Focalboard server to another using
+// the archive export and import APIs.
+//
+// Either server may be a standalone Focalboard server or the Mattermost plugin,
+// in which case the URL is the plugin root, e.g. https://mm.example.com/plugins/focalboard.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattermost/focalboard/server/api"
+	"github.com/mattermost/focalboard/server/client"
+)
+
+type appConfig struct {
+	srcURL      string
+	srcToken    string
+	srcUser     string
+	srcPassword string
+	srcTeam     string
+
+	destURL      string
+	destToken    string
+	destUser     string
+	destPassword string
+	destTeam     string
+
+	boardIDs   []string
+	membership bool
+	force      bool
+	dryRun     bool
+	verbose    bool
+}
+
+func main() {
+	cfg := appConfig{}
+	var boards string
+
+	flag.StringVar(&cfg.srcURL, "src", "", "source server URL")
+	flag.StringVar(&cfg.srcToken, "src-token", "", "source session or personal access token")
+	flag.StringVar(&cfg.srcUser, "src-user", "", "source username, if no token is provided")
+	flag.StringVar(&cfg.srcPassword, "src-password", "", "source password, if no token is provided")
+	flag.StringVar(&cfg.srcTeam, "src-team", "0", "source team id")
+	flag.StringVar(&cfg.destURL, "dest", "", "destination server URL")
+	flag.StringVar(&cfg.destToken, "dest-token", "", "destination session or personal access token")
+	flag.StringVar(&cfg.destUser, "dest-user", "", "destination username, if no token is provided")
+	flag.StringVar(&cfg.destPassword, "dest-password", "", "destination password, if no token is provided")
+	flag.StringVar(&cfg.destTeam, "dest-team", "", "destination team id (defaults to the source team id)")
+	flag.StringVar(&boards, "boards", "", "comma separated list of board ids to copy (defaults to every board of the source team)")
+	flag.BoolVar(&cfg.membership, "membership", true, "copy board members, categories and subscriptions, matching users by username or email")
+	flag.BoolVar(&cfg.force, "force", false, "copy boards again even if the destination copy is up to date")
+	flag.BoolVar(&cfg.dryRun, "dry-run", false, "only print what would be copied")
+	flag.BoolVar(&cfg.verbose, "verbose", false, "enable verbose output")
+	flag.Parse()
+
+	if cfg.srcURL == "" || cfg.destURL == "" {
+		flag.Usage()
+		os.Exit(-1)
+	}
+	if cfg.destTeam == "" {
+		cfg.destTeam = cfg.srcTeam
+	}
+	if boards != "" {
+		cfg.boardIDs = strings.Split(boards, ",")
+	}
+
+	var code int
+	if err := run(cfg); err != nil {
+		code = -1
+		fmt.Fprintf(os.Stderr, "error migrating boards: %v\n", err)
+	}
+
+	os.Exit(code)
+}
+
+func run(cfg appConfig) error {
+	src, err := newClient(cfg.srcURL, cfg.srcToken, cfg.srcUser, cfg.srcPassword)
+	if err != nil {
+		return fmt.Errorf("cannot connect to source server: %w", err)
+	}
+	dest, err := newClient(cfg.destURL, cfg.destToken, cfg.destUser, cfg.destPassword)
+	if err != nil {
+		return fmt.Errorf("cannot connect to destination server: %w", err)
+	}
+
+	m := &migrator{
+		src:  src,
+		dest: dest,
+		cfg:  cfg,
+		out:  os.Stdout,
+	}
+	return m.migrate()
+}
+
+// newClient creates a client for a server, logging in if no token is provided.
+func newClient(url, token, username, password string) (*client.Client, error) {
+	c := client.NewClient(url, token)
+	if token != "" {
+		return c, nil
+	}
+
+	req := &api.LoginRequest{
+		Type:     "normal",
+		Username: username,
+		Password: password,
+	}
+	if _, resp := c.Login(req); resp.Error != nil {
+		return nil, resp.Error
+	}
+	return c, nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/focalboard/server/client"
+	"github.com/mattermost/focalboard/server/model"
+)
+
+const (
+	// migratedFromProperty is the board property used to mark boards copied by this tool,
+	// which makes re-running a migration idempotent.
+	migratedFromProperty = "migratedFrom"
+
+	manifestFilename = "manifest.json"
+)
+
+var errMissingBoard = errors.New("board missing from import response")
+
+type migrator struct {
+	src  *client.Client
+	dest *client.Client
+	cfg  appConfig
+	out  io.Writer
+}
+
+// migratedFrom identifies the source of a migrated board.
+type migratedFrom struct {
+	Server      string `json:"server"`
+	BoardID     string `json:"boardId"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+func (m *migrator) migrate() error {
+	boards, err := m.sourceBoards()
+	if err != nil {
+		return err
+	}
+
+	existing, err := m.migratedBoards()
+	if err != nil {
+		return err
+	}
+
+	var copied, skipped int
+	for _, board := range boards {
+		ok, err2 := m.migrateBoard(board, existing[board.ID])
+		if err2 != nil {
+			return fmt.Errorf("cannot migrate board %s (%s): %w", board.ID, board.Title, err2)
+		}
+		if ok {
+			copied++
+		} else {
+			skipped++
+		}
+	}
+
+	fmt.Fprintf(m.out, "%d boards copied, %d boards up to date\n", copied, skipped)
+	return nil
+}
+
+// sourceBoards returns the boards to copy from the source server.
+func (m *migrator) sourceBoards() ([]*model.Board, error) {
+	if len(m.cfg.boardIDs) == 0 {
+		boards, resp := m.src.GetBoardsForTeam(m.cfg.srcTeam)
+		if resp.Error != nil {
+			return nil, fmt.Errorf("cannot list boards for team %s: %w", m.cfg.srcTeam, resp.Error)
+		}
+		return boards, nil
+	}
+
+	boards := make([]*model.Board, 0, len(m.cfg.boardIDs))
+	for _, id := range m.cfg.boardIDs {
+		board, resp := m.src.GetBoard(strings.TrimSpace(id), "")
+		if resp.Error != nil {
+			return nil, fmt.Errorf("cannot fetch board %s: %w", id, resp.Error)
+		}
+		boards = append(boards, board)
+	}
+	return boards, nil
+}
+
+// migratedBoards returns the destination boards previously copied from the source
+// server, keyed by source board id.
+func (m *migrator) migratedBoards() (map[string]*model.Board, error) {
+	boards, resp := m.dest.GetBoardsForTeam(m.cfg.destTeam)
+	if resp.Error != nil {
+		return nil, fmt.Errorf("cannot list boards for team %s: %w", m.cfg.destTeam, resp.Error)
+	}
+
+	migrated := make(map[string]*model.Board)
+	for _, board := range boards {
+		from, ok := getMigratedFrom(board)
+		if !ok || from.Server != m.cfg.srcURL {
+			continue
+		}
+		migrated[from.BoardID] = board
+	}
+	return migrated, nil
+}
+
+// migrateBoard copies a board to the destination server unless an up to date
+// copy already exists. A stale copy is replaced once the new one is imported.
+func (m *migrator) migrateBoard(board *model.Board, existing *model.Board) (bool, error) {
+	var archive []byte
+	var resp *client.Response
+	if m.cfg.membership {
+		archive, resp = m.src.ExportBoardArchiveWithMembership(board.ID)
+	} else {
+		archive, resp = m.src.ExportBoardArchive(board.ID)
+	}
+	if resp.Error != nil {
+		return false, fmt.Errorf("cannot export archive: %w", resp.Error)
+	}
+
+	fingerprint, err := archiveFingerprint(archive)
+	if err != nil {
+		return false, err
+	}
+
+	if existing != nil && !m.cfg.force {
+		if from, _ := getMigratedFrom(existing); from.Fingerprint == fingerprint {
+			m.logf("skipping board %s (%s), destination board %s is up to date\n", board.ID, board.Title, existing.ID)
+			return false, nil
+		}
+	}
+
+	if m.cfg.dryRun {
+		fmt.Fprintf(m.out, "would copy board %s (%s)\n", board.ID, board.Title)
+		return true, nil
+	}
+
+	importResp, resp := m.dest.ImportArchiveWithOptions(m.cfg.destTeam, bytes.NewReader(archive), m.cfg.membership)
+	if resp.Error != nil {
+		return false, fmt.Errorf("cannot import archive: %w", resp.Error)
+	}
+	newBoardID, ok := importResp.BoardIDs[board.ID]
+	if !ok {
+		return false, errMissingBoard
+	}
+
+	patch := &model.BoardPatch{
+		UpdatedProperties: map[string]interface{}{
+			migratedFromProperty: migratedFrom{
+				Server:      m.cfg.srcURL,
+				BoardID:     board.ID,
+				Fingerprint: fingerprint,
+			},
+		},
+	}
+	if _, resp = m.dest.PatchBoard(newBoardID, patch); resp.Error != nil {
+		return false, fmt.Errorf("cannot mark board %s as migrated: %w", newBoardID, resp.Error)
+	}
+
+	if existing != nil {
+		if _, resp = m.dest.DeleteBoard(existing.ID); resp.Error != nil {
+			return false, fmt.Errorf("cannot delete previous copy %s: %w", existing.ID, resp.Error)
+		}
+		m.logf("deleted previous copy %s of board %s\n", existing.ID, board.ID)
+	}
+
+	fmt.Fprintf(m.out, "copied board %s (%s) to %s\n", board.ID, board.Title, newBoardID)
+	return true, nil
+}
+
+func (m *migrator) logf(format string, args ...interface{}) {
+	if m.cfg.verbose {
+		fmt.Fprintf(m.out, format, args...)
+	}
+}
+
+// getMigratedFrom returns the migration marker of a board, if any.
+func getMigratedFrom(board *model.Board) (migratedFrom, bool) {
+	var from migratedFrom
+	prop, ok := board.Properties[migratedFromProperty]
+	if !ok {
+		return from, false
+	}
+
+	b, err := json.Marshal(prop)
+	if err != nil {
+		return from, false
+	}
+	if err = json.Unmarshal(b, &from); err != nil || from.BoardID == "" {
+		return from, false
+	}
+	return from, true
+}
+
+// archiveFingerprint returns a hash of the board contents of an archive, based on
+// the file checksums recorded in its manifest. Unlike a hash of the whole archive,
+// it does not change when an unmodified board is exported again.
+func archiveFingerprint(archive []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return "", fmt.Errorf("cannot read archive: %w", err)
+	}
+
+	f, err := zr.Open(manifestFilename)
+	if err != nil {
+		return "", fmt.Errorf("cannot read archive manifest: %w", err)
+	}
+	defer f.Close()
+
+	var manifest model.ArchiveManifest
+	if err = json.NewDecoder(f).Decode(&manifest); err != nil {
+		return "", fmt.Errorf("cannot decode archive manifest: %w", err)
+	}
+
+	files := manifest.Files
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	h := sha256.New()
+	for _, mf := range files {
+		// the version file holds the export date.
+		if !strings.Contains(mf.Path, "/") {
+			continue
+		}
+		fmt.Fprintf(h, "%s:%s\n", mf.Path, mf.SHA256)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
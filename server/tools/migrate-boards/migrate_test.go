@@ -0,0 +1,188 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/focalboard/server/integrationtests"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestArchive(t *testing.T, files []model.ArchiveManifestFile) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(manifestFilename)
+	require.NoError(t, err)
+	require.NoError(t, json.NewEncoder(w).Encode(model.ArchiveManifest{Version: 3, Files: files}))
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestArchiveFingerprint(t *testing.T) {
+	files := []model.ArchiveManifestFile{
+		{Path: "version.json", SHA256: "version-1"},
+		{Path: "board-1/board.jsonl", SHA256: "board-1"},
+		{Path: "board-1/image.png", SHA256: "image-1"},
+	}
+	fingerprint, err := archiveFingerprint(makeTestArchive(t, files))
+	require.NoError(t, err)
+
+	t.Run("the version file and the file order are ignored", func(t *testing.T) {
+		reexported := []model.ArchiveManifestFile{
+			{Path: "board-1/image.png", SHA256: "image-1"},
+			{Path: "version.json", SHA256: "version-2"},
+			{Path: "board-1/board.jsonl", SHA256: "board-1"},
+		}
+		other, err := archiveFingerprint(makeTestArchive(t, reexported))
+		require.NoError(t, err)
+		require.Equal(t, fingerprint, other)
+	})
+
+	t.Run("a change of the board contents changes the fingerprint", func(t *testing.T) {
+		changed := []model.ArchiveManifestFile{
+			{Path: "version.json", SHA256: "version-1"},
+			{Path: "board-1/board.jsonl", SHA256: "board-2"},
+			{Path: "board-1/image.png", SHA256: "image-1"},
+		}
+		other, err := archiveFingerprint(makeTestArchive(t, changed))
+		require.NoError(t, err)
+		require.NotEqual(t, fingerprint, other)
+	})
+
+	t.Run("archives without a manifest are rejected", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		_, err := zw.Create("version.json")
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+
+		_, err = archiveFingerprint(buf.Bytes())
+		require.Error(t, err)
+
+		_, err = archiveFingerprint([]byte("not a zip"))
+		require.Error(t, err)
+	})
+}
+
+func TestGetMigratedFrom(t *testing.T) {
+	from := migratedFrom{Server: "http://src", BoardID: "board-1", Fingerprint: "abc"}
+
+	testCases := []struct {
+		name       string
+		properties map[string]interface{}
+		expected   migratedFrom
+		ok         bool
+	}{
+		{"no marker", map[string]interface{}{}, migratedFrom{}, false},
+		{"marker", map[string]interface{}{migratedFromProperty: from}, from, true},
+		{
+			"marker decoded from JSON",
+			map[string]interface{}{migratedFromProperty: map[string]interface{}{
+				"server": "http://src", "boardId": "board-1", "fingerprint": "abc",
+			}},
+			from,
+			true,
+		},
+		{"marker without board", map[string]interface{}{migratedFromProperty: map[string]interface{}{"server": "http://src"}}, migratedFrom{Server: "http://src"}, false},
+		{"invalid marker", map[string]interface{}{migratedFromProperty: "board-1"}, migratedFrom{}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := getMigratedFrom(&model.Board{Properties: tc.properties})
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	th := integrationtests.SetupTestHelper(t).InitBasic()
+	defer th.TearDown()
+
+	board := &model.Board{
+		ID:     utils.NewID(utils.IDTypeBoard),
+		TeamID: model.GlobalTeamID,
+		Title:  "Migrated board",
+		Type:   model.BoardTypeOpen,
+	}
+	card := model.Block{
+		ID:       utils.NewID(utils.IDTypeCard),
+		BoardID:  board.ID,
+		ParentID: board.ID,
+		Type:     model.TypeCard,
+		Title:    "Card",
+		CreateAt: utils.GetMillis(),
+		UpdateAt: utils.GetMillis(),
+	}
+	babs, resp := th.Client.CreateBoardsAndBlocks(&model.BoardsAndBlocks{Boards: []*model.Board{board}, Blocks: []model.Block{card}})
+	th.CheckOK(resp)
+	sourceID := babs.Boards[0].ID
+
+	// the server is both the source and the destination of the migration.
+	var out bytes.Buffer
+	m := &migrator{
+		src:  th.Client,
+		dest: th.Client,
+		cfg: appConfig{
+			srcURL:   th.Server.Config().ServerRoot,
+			srcTeam:  model.GlobalTeamID,
+			destTeam: model.GlobalTeamID,
+			boardIDs: []string{sourceID},
+		},
+		out: &out,
+	}
+
+	getCopies := func() []*model.Board {
+		boards, resp := th.Client.GetBoardsForTeam(model.GlobalTeamID)
+		th.CheckOK(resp)
+		var copies []*model.Board
+		for _, b := range boards {
+			if from, ok := getMigratedFrom(b); ok {
+				require.Equal(t, sourceID, from.BoardID)
+				copies = append(copies, b)
+			}
+		}
+		return copies
+	}
+
+	require.NoError(t, m.migrate())
+	require.Contains(t, out.String(), "1 boards copied, 0 boards up to date")
+	copies := getCopies()
+	require.Len(t, copies, 1)
+	firstCopyID := copies[0].ID
+
+	t.Run("up to date boards are not copied again", func(t *testing.T) {
+		out.Reset()
+		require.NoError(t, m.migrate())
+		require.Contains(t, out.String(), "0 boards copied, 1 boards up to date")
+		copies := getCopies()
+		require.Len(t, copies, 1)
+		require.Equal(t, firstCopyID, copies[0].ID)
+	})
+
+	t.Run("changed boards replace their previous copy", func(t *testing.T) {
+		card2 := card
+		card2.ID = utils.NewID(utils.IDTypeCard)
+		card2.BoardID = sourceID
+		card2.ParentID = sourceID
+		_, resp := th.Client.InsertBlocks(sourceID, []model.Block{card2})
+		th.CheckOK(resp)
+
+		out.Reset()
+		require.NoError(t, m.migrate())
+		require.Contains(t, out.String(), "1 boards copied, 0 boards up to date")
+		copies := getCopies()
+		require.Len(t, copies, 1)
+		require.NotEqual(t, firstCopyID, copies[0].ID)
+
+		out.Reset()
+		require.NoError(t, m.migrate())
+		require.Contains(t, out.String(), "0 boards copied, 1 boards up to date")
+	})
+}
@@ -9,3 +9,8 @@ import "fmt"
 func MakeCardLink(serverRoot string, teamID string, boardID string, cardID string) string {
 	return fmt.Sprintf("%s/team/%s/%s/0/%s", serverRoot, teamID, boardID, cardID)
 }
+
+// MakeFileLink creates fully qualified links to files attached to a board.
+func MakeFileLink(serverRoot string, teamID string, boardID string, filename string) string {
+	return fmt.Sprintf("%s/api/v2/files/teams/%s/%s/%s", serverRoot, teamID, boardID, filename)
+}
@@ -95,3 +95,13 @@ func Intersection(x ...[]interface{}) []interface{} {
 
 	return result
 }
+
+// ContainsString returns true if the slice contains the string.
+func ContainsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -19,6 +19,11 @@ const (
 	websocketActionUpdateCategory      = "UPDATE_CATEGORY"
 	websocketActionUpdateCategoryBoard = "UPDATE_BOARD_CATEGORY"
 	websocketActionUpdateSubscription  = "UPDATE_SUBSCRIPTION"
+	websocketActionJoinBoard           = "JOIN_BOARD"
+	websocketActionLeaveBoard          = "LEAVE_BOARD"
+	websocketActionFocusCard           = "FOCUS_CARD"
+	websocketActionUpdatePresence      = "UPDATE_PRESENCE"
+	websocketActionResyncRequired      = "RESYNC_REQUIRED"
 )
 
 type Store interface {
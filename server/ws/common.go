@@ -10,27 +10,31 @@ type UpdateCategoryMessage struct {
 	TeamID          string                            `json:"teamId"`
 	Category        *model.Category                   `json:"category,omitempty"`
 	BoardCategories *model.BoardCategoryWebsocketData `json:"blockCategories,omitempty"`
+	Sequence        int64                             `json:"sequence,omitempty"`
 }
 
 // UpdateBlockMsg is sent on block updates.
 type UpdateBlockMsg struct {
-	Action string      `json:"action"`
-	TeamID string      `json:"teamId"`
-	Block  model.Block `json:"block"`
+	Action   string      `json:"action"`
+	TeamID   string      `json:"teamId"`
+	Block    model.Block `json:"block"`
+	Sequence int64       `json:"sequence,omitempty"`
 }
 
 // UpdateBoardMsg is sent on block updates.
 type UpdateBoardMsg struct {
-	Action string       `json:"action"`
-	TeamID string       `json:"teamId"`
-	Board  *model.Board `json:"board"`
+	Action   string       `json:"action"`
+	TeamID   string       `json:"teamId"`
+	Board    *model.Board `json:"board"`
+	Sequence int64        `json:"sequence,omitempty"`
 }
 
 // UpdateMemberMsg is sent on membership updates.
 type UpdateMemberMsg struct {
-	Action string             `json:"action"`
-	TeamID string             `json:"teamId"`
-	Member *model.BoardMember `json:"member"`
+	Action   string             `json:"action"`
+	TeamID   string             `json:"teamId"`
+	Member   *model.BoardMember `json:"member"`
+	Sequence int64              `json:"sequence,omitempty"`
 }
 
 // UpdateSubscription is sent on subscription updates.
@@ -39,11 +43,33 @@ type UpdateSubscription struct {
 	Subscription *model.Subscription `json:"subscription"`
 }
 
+// UpdatePresenceMsg is sent when users join, leave or focus on a card of a
+// board. It lists all the users present on the board.
+type UpdatePresenceMsg struct {
+	Action    string           `json:"action"`
+	TeamID    string           `json:"teamId"`
+	BoardID   string           `json:"boardId"`
+	Presences []model.Presence `json:"presences"`
+}
+
+// ResyncRequiredMsg is sent to a client subscribing to a team again when the events it
+// missed since the last sequence it received cannot be replayed. The client must fetch
+// the data of the team again, and receives the events after the sequence of the message.
+type ResyncRequiredMsg struct {
+	Action   string `json:"action"`
+	TeamID   string `json:"teamId"`
+	Sequence int64  `json:"sequence"`
+}
+
 // WebsocketCommand is an incoming command from the client.
 type WebsocketCommand struct {
-	Action    string   `json:"action"`
-	TeamID    string   `json:"teamId"`
-	Token     string   `json:"token"`
-	ReadToken string   `json:"readToken"`
-	BlockIDs  []string `json:"blockIds"`
+	Action       string   `json:"action"`
+	TeamID       string   `json:"teamId"`
+	Token        string   `json:"token"`
+	ReadToken    string   `json:"readToken"`
+	BlockIDs     []string `json:"blockIds"`
+	BoardID      string   `json:"boardId"`
+	CardID       string   `json:"cardId"`
+	Editing      bool     `json:"editing"`
+	LastSequence int64    `json:"lastSequence"`
 }
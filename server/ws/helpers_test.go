@@ -1,8 +1,13 @@
 package ws
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/mattermost/focalboard/server/auth"
 	authMocks "github.com/mattermost/focalboard/server/auth/mocks"
 	wsMocks "github.com/mattermost/focalboard/server/ws/mocks"
 
@@ -10,6 +15,9 @@ import (
 	"github.com/mattermost/mattermost-server/v6/shared/mlog"
 
 	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
 )
 
 type TestHelper struct {
@@ -59,3 +67,76 @@ func (th *TestHelper) UnsubscribeWebConnFromTeam(webConnID, userID, teamID strin
 	msgData := map[string]interface{}{"teamId": teamID}
 	th.ReceiveWebSocketMessage(webConnID, userID, websocketActionUnsubscribeTeam, msgData)
 }
+
+type testPermissions struct{}
+
+func (p testPermissions) HasPermissionToTeam(userID, teamID string, permission *mmModel.Permission) bool {
+	return true
+}
+
+func (p testPermissions) HasPermissionToBoard(userID, boardID string, permission *mmModel.Permission) bool {
+	return true
+}
+
+// memberPermissions allows the members of the boards to view them.
+type memberPermissions struct {
+	testPermissions
+	store Store
+}
+
+func (p memberPermissions) HasPermissionToBoard(userID, boardID string, permission *mmModel.Permission) bool {
+	isMember, err := isBoardMember(p.store, boardID, userID)
+	return err == nil && isMember
+}
+
+// startTestServer starts a standalone websocket server authenticating the users with
+// their Mattermost-User-Id header. The members of the boards are allowed to view them.
+func startTestServer(t *testing.T, store Store) (*Server, *httptest.Server) {
+	server := NewServer(auth.New(nil, nil, testPermissions{}), memberPermissions{store: store}, "", true,
+		mlog.CreateConsoleTestLogger(true, mlog.LvlError), store)
+	router := mux.NewRouter()
+	server.RegisterRoutes(router)
+	return server, httptest.NewServer(router)
+}
+
+// getTestListeners returns the listeners of a user subscribed to a team.
+func getTestListeners(server *Server, teamID, userID string) map[*websocketSession]bool {
+	server.mu.RLock()
+	defer server.mu.RUnlock()
+
+	listeners := map[*websocketSession]bool{}
+	for _, listener := range server.listenersByTeam[teamID] {
+		if listener.userID == userID {
+			listeners[listener] = true
+		}
+	}
+	return listeners
+}
+
+// connectTestListener connects a user to a test server and subscribes it to a team.
+func connectTestListener(t *testing.T, server *Server, httpServer *httptest.Server, userID string, subscribe WebsocketCommand) *websocket.Conn {
+	previous := getTestListeners(server, subscribe.TeamID, userID)
+
+	header := http.Header{}
+	header.Set("Mattermost-User-Id", userID)
+	conn, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http")+"/ws", header)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	subscribe.Action = websocketActionSubscribeTeam
+	require.NoError(t, conn.WriteJSON(subscribe))
+	require.Eventually(t, func() bool {
+		for listener := range getTestListeners(server, subscribe.TeamID, userID) {
+			if !previous[listener] {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+	return conn
+}
+
+func readTestMessage(t *testing.T, conn *websocket.Conn, message interface{}) {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, conn.ReadJSON(message))
+}
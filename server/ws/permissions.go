@@ -0,0 +1,124 @@
+package ws
+
+import (
+	"sync"
+	"time"
+
+	mmModel "github.com/mattermost/mattermost-server/v6/model"
+)
+
+// PermissionCacheTTL is the time the permissions of the recipients of the broadcasts are
+// cached. The membership changes of a board invalidate the permissions of the member at
+// once, the other changes are seen once the permissions expire.
+const PermissionCacheTTL = time.Minute
+
+// maxPermissionCacheEntries bounds the number of cached permissions. The expired
+// permissions are evicted when the cache is full, then the oldest ones.
+const maxPermissionCacheEntries = 10000
+
+// denyAllPermissions denies all the permissions. It is used when the server is created
+// without a permissions service.
+type denyAllPermissions struct{}
+
+func (denyAllPermissions) HasPermissionToTeam(userID, teamID string, permission *mmModel.Permission) bool {
+	return false
+}
+
+func (denyAllPermissions) HasPermissionToBoard(userID, boardID string, permission *mmModel.Permission) bool {
+	return false
+}
+
+// permissionKey identifies the permission of a user, or of a read token, on a board.
+type permissionKey struct {
+	boardID      string
+	userID       string
+	readToken    string
+	permissionID string
+}
+
+type permissionEntry struct {
+	granted  bool
+	expireAt time.Time
+}
+
+// permissionCache caches the permissions of the listeners on the boards, which are
+// evaluated for each recipient of the broadcasts.
+type permissionCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[permissionKey]permissionEntry
+	// generation changes on each invalidation, so that a permission evaluated while it
+	// is invalidated is not cached.
+	generation int64
+}
+
+func newPermissionCache(ttl time.Duration) *permissionCache {
+	return &permissionCache{
+		ttl:        ttl,
+		maxEntries: maxPermissionCacheEntries,
+		entries:    make(map[permissionKey]permissionEntry),
+	}
+}
+
+// get returns a cached permission, or evaluates it with check if it is not cached or
+// has expired.
+func (pc *permissionCache) get(key permissionKey, check func() bool) bool {
+	now := time.Now()
+
+	pc.mu.Lock()
+	entry, ok := pc.entries[key]
+	generation := pc.generation
+	pc.mu.Unlock()
+
+	if ok && now.Before(entry.expireAt) {
+		return entry.granted
+	}
+
+	granted := check()
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.generation != generation {
+		return granted
+	}
+	if _, ok = pc.entries[key]; !ok && len(pc.entries) >= pc.maxEntries {
+		pc.evict(now)
+	}
+	pc.entries[key] = permissionEntry{granted: granted, expireAt: now.Add(pc.ttl)}
+	return granted
+}
+
+// evict removes the expired permissions, or the oldest one if none has expired, to make
+// room for a new permission. The lock must be held.
+func (pc *permissionCache) evict(now time.Time) {
+	var oldestKey permissionKey
+	var oldest time.Time
+	for k, e := range pc.entries {
+		if !now.Before(e.expireAt) {
+			delete(pc.entries, k)
+			continue
+		}
+		if oldest.IsZero() || e.expireAt.Before(oldest) {
+			oldestKey, oldest = k, e.expireAt
+		}
+	}
+	if len(pc.entries) >= pc.maxEntries {
+		delete(pc.entries, oldestKey)
+	}
+}
+
+// invalidate removes the cached permissions of a user on a board, or the permissions of
+// all the users and read tokens on the board if userID is empty.
+func (pc *permissionCache) invalidate(boardID, userID string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.generation++
+	for key := range pc.entries {
+		if key.boardID == boardID && (userID == "" || key.userID == userID) {
+			delete(pc.entries, key)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package ws
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+	wsMocks "github.com/mattermost/focalboard/server/ws/mocks"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+func TestPermissionCache(t *testing.T) {
+	key := permissionKey{boardID: "board-1", userID: "user-1", permissionID: model.PermissionViewBoard.Id}
+	checks := 0
+	check := func(granted bool) func() bool {
+		return func() bool {
+			checks++
+			return granted
+		}
+	}
+
+	t.Run("the permissions are cached until they expire", func(t *testing.T) {
+		pc := newPermissionCache(50 * time.Millisecond)
+		checks = 0
+		require.True(t, pc.get(key, check(true)))
+		require.True(t, pc.get(key, check(false)))
+		require.Equal(t, 1, checks)
+
+		time.Sleep(60 * time.Millisecond)
+		require.False(t, pc.get(key, check(false)))
+		require.Equal(t, 2, checks)
+	})
+
+	t.Run("the permissions of a user or a board are invalidated", func(t *testing.T) {
+		pc := newPermissionCache(time.Hour)
+		otherUser := permissionKey{boardID: "board-1", userID: "user-2", permissionID: model.PermissionViewBoard.Id}
+		otherBoard := permissionKey{boardID: "board-2", userID: "user-1", permissionID: model.PermissionViewBoard.Id}
+		checks = 0
+		for _, k := range []permissionKey{key, otherUser, otherBoard} {
+			pc.get(k, check(true))
+		}
+
+		pc.invalidate("board-1", "user-1")
+		require.False(t, pc.get(key, check(false)))
+		require.True(t, pc.get(otherUser, check(false)))
+		require.True(t, pc.get(otherBoard, check(false)))
+		require.Equal(t, 4, checks)
+
+		pc.invalidate("board-1", "")
+		require.True(t, pc.get(key, check(true)))
+		require.False(t, pc.get(otherUser, check(false)))
+		require.True(t, pc.get(otherBoard, check(false)))
+		require.Equal(t, 6, checks)
+	})
+
+	t.Run("the oldest permission is evicted when the cache is full", func(t *testing.T) {
+		pc := newPermissionCache(time.Hour)
+		pc.maxEntries = 2
+		keys := []permissionKey{
+			key,
+			{boardID: "board-2", userID: "user-1", permissionID: model.PermissionViewBoard.Id},
+			{boardID: "board-3", userID: "user-1", permissionID: model.PermissionViewBoard.Id},
+		}
+		checks = 0
+		for _, k := range keys {
+			pc.get(k, check(true))
+			time.Sleep(time.Millisecond)
+		}
+		require.Len(t, pc.entries, 2)
+		require.NotContains(t, pc.entries, keys[0])
+
+		require.True(t, pc.get(keys[2], check(false)))
+		require.Equal(t, 3, checks)
+	})
+
+	t.Run("a permission evaluated while it is invalidated is not cached", func(t *testing.T) {
+		pc := newPermissionCache(time.Hour)
+		require.True(t, pc.get(key, func() bool {
+			pc.invalidate("board-1", "user-1")
+			return true
+		}))
+		require.False(t, pc.get(key, func() bool { return false }))
+	})
+}
+
+func TestServerPermissions(t *testing.T) {
+	var mu sync.Mutex
+	members := []*model.BoardMember{{BoardID: "board-id", UserID: "user-1"}}
+	setMembers := func(m ...*model.BoardMember) {
+		mu.Lock()
+		defer mu.Unlock()
+		members = m
+	}
+
+	ctrl := gomock.NewController(t)
+	store := wsMocks.NewMockStore(ctrl)
+	store.EXPECT().GetMembersForBoard("board-id").DoAndReturn(func(boardID string) ([]*model.BoardMember, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return members, nil
+	}).AnyTimes()
+
+	server, httpServer := startTestServer(t, store)
+	defer httpServer.Close()
+
+	conn1 := connectTestListener(t, server, httpServer, "user-1", WebsocketCommand{TeamID: "team-id"})
+	defer conn1.Close()
+	conn2 := connectTestListener(t, server, httpServer, "user-2", WebsocketCommand{TeamID: "team-id"})
+	defer conn2.Close()
+
+	// readNextAction returns the action of the next message of a listener. The category
+	// changes are sent to all the listeners of the team, and are broadcast after the
+	// messages a listener should not receive to check that they were not sent.
+	readNextAction := func(conn *websocket.Conn) string {
+		var message testSequencedMsg
+		readTestMessage(t, conn, &message)
+		return message.Action
+	}
+
+	t.Run("the users that cannot view a board do not receive its changes", func(t *testing.T) {
+		server.BroadcastBlockChange("team-id", model.Block{ID: "block-id", BoardID: "board-id"})
+		server.BroadcastCategoryChange(model.Category{ID: "category-id", TeamID: "team-id"})
+
+		require.Equal(t, websocketActionUpdateBlock, readNextAction(conn1))
+		require.Equal(t, websocketActionUpdateCategory, readNextAction(conn1))
+		require.Equal(t, websocketActionUpdateCategory, readNextAction(conn2))
+	})
+
+	t.Run("the new members receive the changes at once", func(t *testing.T) {
+		member := &model.BoardMember{BoardID: "board-id", UserID: "user-2", SchemeViewer: true}
+		setMembers(members[0], member)
+		server.BroadcastMemberChange("team-id", "board-id", member)
+		require.Equal(t, websocketActionUpdateMember, readNextAction(conn1))
+		require.Equal(t, websocketActionUpdateMember, readNextAction(conn2))
+
+		server.BroadcastBlockChange("team-id", model.Block{ID: "block-id", BoardID: "board-id"})
+		require.Equal(t, websocketActionUpdateBlock, readNextAction(conn1))
+		require.Equal(t, websocketActionUpdateBlock, readNextAction(conn2))
+	})
+
+	t.Run("the removed members stop receiving the changes at once", func(t *testing.T) {
+		require.NoError(t, conn2.WriteJSON(WebsocketCommand{Action: websocketActionJoinBoard, TeamID: "team-id", BoardID: "board-id"}))
+		require.Equal(t, websocketActionUpdatePresence, readNextAction(conn1))
+		require.Equal(t, websocketActionUpdatePresence, readNextAction(conn2))
+
+		setMembers(members[0])
+		server.BroadcastMemberDelete("team-id", "board-id", "user-2")
+		require.Equal(t, websocketActionDeleteMember, readNextAction(conn2))
+
+		// the other members see the removed member leave the board
+		require.Equal(t, websocketActionDeleteMember, readNextAction(conn1))
+		var presenceMsg UpdatePresenceMsg
+		readTestMessage(t, conn1, &presenceMsg)
+		require.Equal(t, websocketActionUpdatePresence, presenceMsg.Action)
+		require.Empty(t, presenceMsg.Presences)
+
+		server.BroadcastBlockChange("team-id", model.Block{ID: "block-id", BoardID: "board-id"})
+		server.BroadcastCategoryChange(model.Category{ID: "category-id", TeamID: "team-id"})
+		require.Equal(t, websocketActionUpdateBlock, readNextAction(conn1))
+		require.Equal(t, websocketActionUpdateCategory, readNextAction(conn2))
+	})
+}
+
+func TestServerWithoutPermissions(t *testing.T) {
+	server := NewServer(nil, nil, "", false, mlog.CreateConsoleTestLogger(true, mlog.LvlError), nil)
+	require.False(t, server.hasPermissionToBoard("user-1", "board-id", model.PermissionViewBoard))
+}
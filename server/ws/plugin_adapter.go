@@ -47,6 +47,10 @@ type PluginAdapter struct {
 	subscriptionsMU  sync.RWMutex
 	listenersByTeam  map[string][]*PluginAdapterClient
 	listenersByBlock map[string][]*PluginAdapterClient
+
+	// presence records the presence of the connections of all the
+	// nodes of the cluster
+	presence *presenceTracker
 }
 
 func NewPluginAdapter(api plugin.API, auth auth.AuthInterface, store Store, logger *mlog.Logger) *PluginAdapter {
@@ -62,6 +66,7 @@ func NewPluginAdapter(api plugin.API, auth auth.AuthInterface, store Store, logg
 		listenersByBlock:  make(map[string][]*PluginAdapterClient),
 		listenersMU:       sync.RWMutex{},
 		subscriptionsMU:   sync.RWMutex{},
+		presence:          newPresenceTracker(PresenceIdleTimeout),
 	}
 }
 
@@ -293,6 +298,7 @@ func (pa *PluginAdapter) OnWebSocketDisconnect(webConnID, userID string) {
 	}
 
 	atomic.StoreInt64(&pac.inactiveAt, mmModel.GetMillis())
+	pa.removePresence(webConnID)
 }
 
 func commandFromRequest(req *mmModel.WebSocketRequest) (*WebsocketCommand, error) {
@@ -312,6 +318,18 @@ func commandFromRequest(req *mmModel.WebSocketRequest) (*WebsocketCommand, error
 		c.BlockIDs = blockIDs.([]string)
 	}
 
+	if boardID, ok := req.Data["boardId"].(string); ok {
+		c.BoardID = boardID
+	}
+
+	if cardID, ok := req.Data["cardId"].(string); ok {
+		c.CardID = cardID
+	}
+
+	if editing, ok := req.Data["editing"].(bool); ok {
+		c.Editing = editing
+	}
+
 	return c, nil
 }
 
@@ -375,6 +393,25 @@ func (pa *PluginAdapter) WebSocketMessageHasBeenPosted(webConnID, userID string,
 		)
 
 		pa.unsubscribeListenerFromTeam(pac, command.TeamID)
+	case websocketActionJoinBoard, websocketActionFocusCard:
+		pa.logger.Debug(`Command: `+command.Action,
+			mlog.String("webConnID", webConnID),
+			mlog.String("userID", userID),
+			mlog.String("teamID", command.TeamID),
+			mlog.String("boardID", command.BoardID),
+			mlog.String("cardID", command.CardID),
+		)
+
+		pa.updatePresence(pac, *command)
+	case websocketActionLeaveBoard:
+		pa.logger.Debug(`Command: LEAVE_BOARD`,
+			mlog.String("webConnID", webConnID),
+			mlog.String("userID", userID),
+			mlog.String("teamID", command.TeamID),
+			mlog.String("boardID", command.BoardID),
+		)
+
+		pa.removePresence(webConnID)
 	}
 }
 
@@ -598,3 +635,84 @@ func (pa *PluginAdapter) BroadcastSubscriptionChange(teamID string, subscription
 
 	pa.sendTeamMessage(websocketActionUpdateSubscription, teamID, utils.StructToMap(message))
 }
+
+// updatePresence records the presence of a connection on a board, or
+// on one of its cards, and propagates it to the cluster. Only the
+// members of a board can be present on it.
+func (pa *PluginAdapter) updatePresence(pac *PluginAdapterClient, command WebsocketCommand) {
+	isMember, err := isBoardMember(pa.store, command.BoardID, pac.userID)
+	if err != nil {
+		pa.logger.Error("error getting members for board",
+			mlog.String("method", "updatePresence"),
+			mlog.String("boardID", command.BoardID),
+			mlog.Err(err),
+		)
+		return
+	}
+	if !isMember {
+		pa.logger.Debug("user is not a member of the board",
+			mlog.String("boardID", command.BoardID),
+			mlog.String("userID", pac.userID),
+		)
+		return
+	}
+
+	update := PresenceUpdate{
+		ConnID:   pac.webConnID,
+		TeamID:   command.TeamID,
+		Presence: presenceFromCommand(pac.userID, command),
+	}
+	go pa.sendMessageToCluster("websocket_message", &ClusterMessage{Presence: &update})
+
+	pa.applyPresenceUpdate(update)
+}
+
+// removePresence removes the presence of a connection and propagates
+// the removal to the cluster.
+func (pa *PluginAdapter) removePresence(webConnID string) {
+	boards := pa.presence.remove(webConnID)
+	if len(boards) == 0 {
+		return
+	}
+
+	update := PresenceUpdate{ConnID: webConnID, Left: true}
+	go pa.sendMessageToCluster("websocket_message", &ClusterMessage{Presence: &update})
+
+	pa.sendPresencesSkipCluster(boards)
+}
+
+// applyPresenceUpdate records a presence change, from this node or
+// from another node of the cluster, and sends the presences of the
+// boards it changed to the users connected to this node.
+func (pa *PluginAdapter) applyPresenceUpdate(update PresenceUpdate) {
+	var boards []presenceBoard
+	if update.Left {
+		boards = pa.presence.remove(update.ConnID)
+	} else {
+		boards = pa.presence.update(update.ConnID, update.TeamID, update.Presence)
+	}
+	pa.sendPresencesSkipCluster(boards)
+}
+
+// ExpireIdlePresences removes the presences of the idle connections.
+// Every node of the cluster expires the presences it knows of, the
+// expiry is not propagated.
+func (pa *PluginAdapter) ExpireIdlePresences() {
+	pa.sendPresencesSkipCluster(pa.presence.expire())
+}
+
+// sendPresencesSkipCluster sends the presences of boards to their
+// members connected to this node.
+func (pa *PluginAdapter) sendPresencesSkipCluster(boards []presenceBoard) {
+	for _, board := range boards {
+		message := UpdatePresenceMsg{
+			Action:    websocketActionUpdatePresence,
+			TeamID:    board.teamID,
+			BoardID:   board.boardID,
+			Presences: pa.presence.list(board.boardID),
+		}
+
+		userIDs := pa.getUserIDsForTeamAndBoard(board.teamID, board.boardID)
+		pa.sendUserMessageSkipCluster(websocketActionUpdatePresence, utils.StructToMap(message), userIDs...)
+	}
+}
@@ -3,6 +3,8 @@ package ws
 import (
 	"encoding/json"
 
+	"github.com/mattermost/focalboard/server/model"
+
 	mmModel "github.com/mattermost/mattermost-server/v6/model"
 )
 
@@ -11,6 +13,16 @@ type ClusterMessage struct {
 	BoardID     string
 	Payload     map[string]interface{}
 	EnsureUsers []string
+	Presence    *PresenceUpdate `json:",omitempty"`
+}
+
+// PresenceUpdate is a change of the presence of a connection to a
+// node, propagated to the other nodes of the cluster.
+type PresenceUpdate struct {
+	ConnID   string
+	TeamID   string
+	Presence model.Presence
+	Left     bool
 }
 
 //nolint:unparam // the `id` param is to key this function generic and handle more than just websocket messages
@@ -49,6 +61,11 @@ func (pa *PluginAdapter) HandleClusterEvent(ev mmModel.PluginClusterEvent) {
 		return
 	}
 
+	if clusterMessage.Presence != nil {
+		pa.applyPresenceUpdate(*clusterMessage.Presence)
+		return
+	}
+
 	if clusterMessage.BoardID != "" {
 		pa.sendBoardMessageSkipCluster(clusterMessage.TeamID, clusterMessage.BoardID, clusterMessage.Payload, clusterMessage.EnsureUsers...)
 		return
@@ -1,6 +1,7 @@
 package ws
 
 import (
+	"encoding/json"
 	"sync"
 	"testing"
 
@@ -8,6 +9,7 @@ import (
 
 	mmModel "github.com/mattermost/mattermost-server/v6/model"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -447,3 +449,70 @@ func TestParallelSubscriptionsOnMultipleConnections(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestPluginAdapterPresence(t *testing.T) {
+	th := SetupTestHelper(t)
+
+	teamID := mmModel.NewId()
+	boardID := mmModel.NewId()
+	userID := mmModel.NewId()
+	remoteUserID := mmModel.NewId()
+	webConnID := mmModel.NewId()
+
+	members := []*model.BoardMember{{BoardID: boardID, UserID: userID}, {BoardID: boardID, UserID: remoteUserID}}
+	th.store.EXPECT().GetMembersForBoard(boardID).Return(members, nil).AnyTimes()
+	th.api.EXPECT().PublishPluginClusterEvent(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	th.pa.OnWebSocketConnect(webConnID, userID)
+	th.SubscribeWebConnToTeam(webConnID, userID, teamID)
+
+	var published []map[string]interface{}
+	th.api.EXPECT().
+		PublishWebSocketEvent(websocketActionUpdatePresence, gomock.Any(), &mmModel.WebsocketBroadcast{UserId: userID}).
+		Do(func(event string, payload map[string]interface{}, broadcast *mmModel.WebsocketBroadcast) {
+			published = append(published, payload)
+		}).
+		AnyTimes()
+
+	t.Run("the members see the users joining the board", func(t *testing.T) {
+		th.ReceiveWebSocketMessage(webConnID, userID, websocketActionJoinBoard, map[string]interface{}{"teamId": teamID, "boardId": boardID})
+
+		require.Len(t, published, 1)
+		require.Equal(t, boardID, published[0]["boardId"])
+		require.Len(t, th.pa.presence.list(boardID), 1)
+	})
+
+	t.Run("the presences of the other nodes are applied", func(t *testing.T) {
+		update := PresenceUpdate{
+			ConnID:   mmModel.NewId(),
+			TeamID:   teamID,
+			Presence: model.Presence{UserID: remoteUserID, BoardID: boardID, CardID: "card-id", Editing: true, UpdateAt: mmModel.GetMillis()},
+		}
+		data, err := json.Marshal(ClusterMessage{Presence: &update})
+		require.NoError(t, err)
+		th.pa.HandleClusterEvent(mmModel.PluginClusterEvent{Id: "websocket_message", Data: data})
+
+		require.Len(t, published, 2)
+		presences := th.pa.presence.list(boardID)
+		require.Len(t, presences, 2)
+		require.Contains(t, presences, update.Presence)
+	})
+
+	t.Run("only the members can be present on a board", func(t *testing.T) {
+		th.store.EXPECT().GetMembersForBoard("other-board").Return([]*model.BoardMember{}, nil)
+		th.ReceiveWebSocketMessage(webConnID, userID, websocketActionFocusCard, map[string]interface{}{"teamId": teamID, "boardId": "other-board", "cardId": "card-id"})
+
+		require.Len(t, published, 2)
+		require.Len(t, th.pa.presence.list(boardID), 2)
+	})
+
+	t.Run("the users leave the board when they disconnect", func(t *testing.T) {
+		th.pa.OnWebSocketDisconnect(webConnID, userID)
+
+		// the remaining member is connected to another node.
+		require.Len(t, published, 2)
+		presences := th.pa.presence.list(boardID)
+		require.Len(t, presences, 1)
+		require.Equal(t, remoteUserID, presences[0].UserID)
+	})
+}
@@ -0,0 +1,190 @@
+package ws
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// PresenceIdleTimeout is the time after which the presence of a connection expires if
+// it sent no presence command. The clients send their presence again before it expires
+// while the user is active.
+const PresenceIdleTimeout = 2 * time.Minute
+
+// PresenceExpirer is implemented by the adapters that track the presence of the users
+// on the boards. ExpireIdlePresences is called periodically to remove the presences
+// of the idle connections.
+type PresenceExpirer interface {
+	ExpireIdlePresences()
+}
+
+// presenceBoard identifies a board whose presences changed.
+type presenceBoard struct {
+	teamID  string
+	boardID string
+}
+
+type presenceEntry struct {
+	teamID   string
+	presence model.Presence
+}
+
+// presenceTracker records the presence of the websocket connections on the boards. A
+// connection is present on one board at most.
+type presenceTracker struct {
+	mu          sync.Mutex
+	idleTimeout time.Duration
+	byConnID    map[string]*presenceEntry
+}
+
+func newPresenceTracker(idleTimeout time.Duration) *presenceTracker {
+	return &presenceTracker{
+		idleTimeout: idleTimeout,
+		byConnID:    make(map[string]*presenceEntry),
+	}
+}
+
+// update records the presence of a connection and returns the boards whose presences
+// changed: the board of the presence, and the board the connection left, if any. An
+// unchanged presence is only refreshed.
+func (pt *presenceTracker) update(connID, teamID string, presence model.Presence) []presenceBoard {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	changed := []presenceBoard{}
+	previous, ok := pt.byConnID[connID]
+	if ok && previous.presence.BoardID != presence.BoardID {
+		changed = append(changed, presenceBoard{teamID: previous.teamID, boardID: previous.presence.BoardID})
+	}
+	if !ok || previous.presence.BoardID != presence.BoardID || previous.presence.CardID != presence.CardID ||
+		previous.presence.Editing != presence.Editing {
+		changed = append(changed, presenceBoard{teamID: teamID, boardID: presence.BoardID})
+	}
+
+	pt.byConnID[connID] = &presenceEntry{teamID: teamID, presence: presence}
+	return changed
+}
+
+// remove removes the presence of a connection and returns the board it left, if any.
+func (pt *presenceTracker) remove(connID string) []presenceBoard {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	entry, ok := pt.byConnID[connID]
+	if !ok {
+		return nil
+	}
+	delete(pt.byConnID, connID)
+	return []presenceBoard{{teamID: entry.teamID, boardID: entry.presence.BoardID}}
+}
+
+// removeUser removes the presences of the connections of a user on a board and returns
+// the board if the user was present on it.
+func (pt *presenceTracker) removeUser(boardID, userID string) []presenceBoard {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	changed := []presenceBoard{}
+	for connID, entry := range pt.byConnID {
+		if entry.presence.BoardID != boardID || entry.presence.UserID != userID {
+			continue
+		}
+		delete(pt.byConnID, connID)
+		if len(changed) == 0 {
+			changed = append(changed, presenceBoard{teamID: entry.teamID, boardID: boardID})
+		}
+	}
+	return changed
+}
+
+// isPresent reports whether a user is present on a board.
+func (pt *presenceTracker) isPresent(boardID, userID string) bool {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	for _, entry := range pt.byConnID {
+		if entry.presence.BoardID == boardID && entry.presence.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// expire removes the presences not updated for the idle timeout and returns the boards
+// the connections left.
+func (pt *presenceTracker) expire() []presenceBoard {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	expireBefore := utils.GetMillis() - pt.idleTimeout.Milliseconds()
+	boardMap := map[presenceBoard]bool{}
+	for connID, entry := range pt.byConnID {
+		if entry.presence.UpdateAt < expireBefore {
+			delete(pt.byConnID, connID)
+			boardMap[presenceBoard{teamID: entry.teamID, boardID: entry.presence.BoardID}] = true
+		}
+	}
+
+	changed := []presenceBoard{}
+	for board := range boardMap {
+		changed = append(changed, board)
+	}
+	return changed
+}
+
+// list returns the presences on a board, one per user. The most recent presence of the
+// users with several connections is kept.
+func (pt *presenceTracker) list(boardID string) []model.Presence {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	byUserID := map[string]model.Presence{}
+	for _, entry := range pt.byConnID {
+		if entry.presence.BoardID != boardID {
+			continue
+		}
+		if current, ok := byUserID[entry.presence.UserID]; !ok || entry.presence.UpdateAt > current.UpdateAt {
+			byUserID[entry.presence.UserID] = entry.presence
+		}
+	}
+
+	presences := []model.Presence{}
+	for _, presence := range byUserID {
+		presences = append(presences, presence)
+	}
+	sort.Slice(presences, func(i, j int) bool {
+		return presences[i].UserID < presences[j].UserID
+	})
+	return presences
+}
+
+// presenceFromCommand returns the presence a command of a user sets.
+func presenceFromCommand(userID string, command WebsocketCommand) model.Presence {
+	presence := model.Presence{
+		UserID:   userID,
+		BoardID:  command.BoardID,
+		UpdateAt: utils.GetMillis(),
+	}
+	if command.Action == websocketActionFocusCard {
+		presence.CardID = command.CardID
+		presence.Editing = command.CardID != "" && command.Editing
+	}
+	return presence
+}
+
+// isBoardMember tells if a user is a member of a board, to be shown as present on it.
+func isBoardMember(store Store, boardID, userID string) (bool, error) {
+	members, err := store.GetMembersForBoard(boardID)
+	if err != nil {
+		return false, err
+	}
+	for _, member := range members {
+		if member.UserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,117 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+	wsMocks "github.com/mattermost/focalboard/server/ws/mocks"
+)
+
+func TestPresenceTracker(t *testing.T) {
+	tracker := newPresenceTracker(time.Minute)
+	now := utils.GetMillis()
+
+	t.Run("joining a board changes its presences", func(t *testing.T) {
+		changed := tracker.update("conn-1", "team-id", model.Presence{UserID: "user-1", BoardID: "board-1", UpdateAt: now})
+		require.Equal(t, []presenceBoard{{teamID: "team-id", boardID: "board-1"}}, changed)
+
+		changed = tracker.update("conn-1", "team-id", model.Presence{UserID: "user-1", BoardID: "board-1", UpdateAt: now + 1})
+		require.Empty(t, changed)
+		require.Equal(t, []model.Presence{{UserID: "user-1", BoardID: "board-1", UpdateAt: now + 1}}, tracker.list("board-1"))
+	})
+
+	t.Run("focusing on a card changes the presences", func(t *testing.T) {
+		changed := tracker.update("conn-1", "team-id", model.Presence{UserID: "user-1", BoardID: "board-1", CardID: "card-1", UpdateAt: now})
+		require.Len(t, changed, 1)
+
+		changed = tracker.update("conn-1", "team-id", model.Presence{UserID: "user-1", BoardID: "board-1", CardID: "card-1", Editing: true, UpdateAt: now})
+		require.Len(t, changed, 1)
+	})
+
+	t.Run("a connection is present on one board", func(t *testing.T) {
+		changed := tracker.update("conn-1", "team-id", model.Presence{UserID: "user-1", BoardID: "board-2", UpdateAt: now})
+		require.ElementsMatch(t, []presenceBoard{{teamID: "team-id", boardID: "board-1"}, {teamID: "team-id", boardID: "board-2"}}, changed)
+		require.Empty(t, tracker.list("board-1"))
+		require.Len(t, tracker.list("board-2"), 1)
+	})
+
+	t.Run("the users are listed once", func(t *testing.T) {
+		tracker.update("conn-2", "team-id", model.Presence{UserID: "user-1", BoardID: "board-2", CardID: "card-2", UpdateAt: now + 10})
+		tracker.update("conn-3", "team-id", model.Presence{UserID: "user-2", BoardID: "board-2", UpdateAt: now})
+
+		presences := tracker.list("board-2")
+		require.Len(t, presences, 2)
+		require.Equal(t, "user-1", presences[0].UserID)
+		require.Equal(t, "card-2", presences[0].CardID)
+		require.Equal(t, "user-2", presences[1].UserID)
+	})
+
+	t.Run("leaving a board changes its presences", func(t *testing.T) {
+		require.Equal(t, []presenceBoard{{teamID: "team-id", boardID: "board-2"}}, tracker.remove("conn-3"))
+		require.Empty(t, tracker.remove("conn-3"))
+		require.Len(t, tracker.list("board-2"), 1)
+	})
+
+	t.Run("the idle presences expire", func(t *testing.T) {
+		tracker.update("conn-1", "team-id", model.Presence{UserID: "user-1", BoardID: "board-2", UpdateAt: now - time.Hour.Milliseconds()})
+
+		require.Equal(t, []presenceBoard{{teamID: "team-id", boardID: "board-2"}}, tracker.expire())
+		require.Empty(t, tracker.expire())
+
+		presences := tracker.list("board-2")
+		require.Len(t, presences, 1)
+		require.Equal(t, "card-2", presences[0].CardID)
+	})
+}
+
+func TestServerPresence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := wsMocks.NewMockStore(ctrl)
+	members := []*model.BoardMember{{BoardID: "board-id", UserID: "user-1"}, {BoardID: "board-id", UserID: "user-2"}}
+	store.EXPECT().GetMembersForBoard("board-id").Return(members, nil).AnyTimes()
+
+	server, httpServer := startTestServer(t, store)
+	defer httpServer.Close()
+
+	connect := func(userID string) *websocket.Conn {
+		return connectTestListener(t, server, httpServer, userID, WebsocketCommand{TeamID: "team-id"})
+	}
+	readPresences := func(conn *websocket.Conn) []model.Presence {
+		var message UpdatePresenceMsg
+		readTestMessage(t, conn, &message)
+		require.Equal(t, websocketActionUpdatePresence, message.Action)
+		require.Equal(t, "board-id", message.BoardID)
+		return message.Presences
+	}
+
+	conn1 := connect("user-1")
+	defer conn1.Close()
+	conn2 := connect("user-2")
+	defer conn2.Close()
+
+	require.NoError(t, conn1.WriteJSON(WebsocketCommand{Action: websocketActionJoinBoard, TeamID: "team-id", BoardID: "board-id"}))
+	presences := readPresences(conn1)
+	require.Len(t, presences, 1)
+	require.Equal(t, "user-1", presences[0].UserID)
+	require.Len(t, readPresences(conn2), 1)
+
+	require.NoError(t, conn2.WriteJSON(WebsocketCommand{Action: websocketActionFocusCard, TeamID: "team-id", BoardID: "board-id", CardID: "card-id", Editing: true}))
+	readPresences(conn1)
+	presences = readPresences(conn2)
+	require.Len(t, presences, 2)
+	require.Equal(t, "user-2", presences[1].UserID)
+	require.Equal(t, "card-id", presences[1].CardID)
+	require.True(t, presences[1].Editing)
+
+	// the users see the others leave when they disconnect.
+	conn2.Close()
+	presences = readPresences(conn1)
+	require.Len(t, presences, 1)
+	require.Equal(t, "user-1", presences[0].UserID)
+}
@@ -0,0 +1,107 @@
+package ws
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// maxSequenceStart bounds the random start of the sequences, leaving room for the
+// sequences to grow and to be represented exactly in the javascript clients.
+const maxSequenceStart = 1 << 52
+
+// ReplayBufferSize is the number of events kept per team to be replayed to the clients
+// that reconnect. The clients that missed more events must fetch the team data again.
+const ReplayBufferSize = 1000
+
+// replayEvent is an event sent to the listeners of a team. The events of a board are
+// only replayed to the users allowed to view it and to the ensured users.
+type replayEvent struct {
+	sequence    int64
+	boardID     string
+	ensureUsers []string
+	message     interface{}
+}
+
+type teamEvents struct {
+	sequence int64
+	events   []replayEvent
+}
+
+// eventLog numbers the events of each team and keeps the most recent ones to replay
+// them. The sequences of a team start at a random value, so that the sequences a client
+// received before a restart, or from another node of the cluster, cannot be mistaken
+// for the recorded ones.
+type eventLog struct {
+	mu     sync.Mutex
+	size   int
+	byTeam map[string]*teamEvents
+}
+
+func newEventLog(size int) *eventLog {
+	return &eventLog{
+		size:   size,
+		byTeam: make(map[string]*teamEvents),
+	}
+}
+
+// record assigns the next sequence of a team to an event and keeps it. The message of
+// the event is built with its sequence.
+func (el *eventLog) record(teamID, boardID string, ensureUsers []string, build func(sequence int64) interface{}) interface{} {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+
+	team := el.team(teamID)
+	team.sequence++
+	event := replayEvent{
+		sequence:    team.sequence,
+		boardID:     boardID,
+		ensureUsers: ensureUsers,
+		message:     build(team.sequence),
+	}
+	team.events = append(team.events, event)
+	if len(team.events) > el.size {
+		team.events = append([]replayEvent{}, team.events[len(team.events)-el.size:]...)
+	}
+	return event.message
+}
+
+// since returns the events of a team after a sequence, and the current sequence of the
+// team. It returns false if some of the events after the sequence are not kept.
+func (el *eventLog) since(teamID string, sequence int64) ([]replayEvent, int64, bool) {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+
+	team := el.team(teamID)
+	if sequence > team.sequence || sequence < team.sequence-int64(len(team.events)) {
+		return nil, team.sequence, false
+	}
+
+	events := []replayEvent{}
+	for _, event := range team.events {
+		if event.sequence > sequence {
+			events = append(events, event)
+		}
+	}
+	return events, team.sequence, true
+}
+
+// team returns the events of a team, starting its sequence if it has none.
+func (el *eventLog) team(teamID string) *teamEvents {
+	team, ok := el.byTeam[teamID]
+	if !ok {
+		team = &teamEvents{sequence: randomSequenceStart()}
+		el.byTeam[teamID] = team
+	}
+	return team
+}
+
+func randomSequenceStart() int64 {
+	n, err := rand.Int(rand.Reader, big.NewInt(maxSequenceStart))
+	if err != nil {
+		return utils.GetMillis()
+	}
+	return n.Int64() + 1
+}
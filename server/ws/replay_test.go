@@ -0,0 +1,131 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+	wsMocks "github.com/mattermost/focalboard/server/ws/mocks"
+)
+
+func TestEventLog(t *testing.T) {
+	recordEvent := func(el *eventLog, teamID, boardID string) int64 {
+		message := el.record(teamID, boardID, nil, func(sequence int64) interface{} {
+			return UpdateBoardMsg{Action: websocketActionUpdateBoard, TeamID: teamID, Sequence: sequence}
+		})
+		return message.(UpdateBoardMsg).Sequence
+	}
+
+	t.Run("the events are numbered per team", func(t *testing.T) {
+		el := newEventLog(10)
+		first := recordEvent(el, "team-1", "board-1")
+		require.Equal(t, first+1, recordEvent(el, "team-1", "board-2"))
+		require.NotZero(t, recordEvent(el, "team-2", "board-3"))
+
+		events, sequence, ok := el.since("team-1", first-1)
+		require.True(t, ok)
+		require.Equal(t, first+1, sequence)
+		require.Len(t, events, 2)
+		require.Equal(t, "board-1", events[0].boardID)
+		require.Equal(t, first, events[0].message.(UpdateBoardMsg).Sequence)
+
+		events, _, ok = el.since("team-1", first)
+		require.True(t, ok)
+		require.Len(t, events, 1)
+
+		events, _, ok = el.since("team-1", first+1)
+		require.True(t, ok)
+		require.Empty(t, events)
+	})
+
+	t.Run("the events that are not kept require a resync", func(t *testing.T) {
+		el := newEventLog(2)
+		first := recordEvent(el, "team-1", "board-1")
+		recordEvent(el, "team-1", "board-1")
+		last := recordEvent(el, "team-1", "board-1")
+
+		_, sequence, ok := el.since("team-1", first-1)
+		require.False(t, ok)
+		require.Equal(t, last, sequence)
+
+		events, _, ok := el.since("team-1", first)
+		require.True(t, ok)
+		require.Len(t, events, 2)
+
+		_, _, ok = el.since("team-1", last+1)
+		require.False(t, ok)
+	})
+
+	t.Run("the sequences of another server require a resync", func(t *testing.T) {
+		el := newEventLog(10)
+		_, sequence, ok := el.since("team-1", 1)
+		require.False(t, ok)
+		require.NotZero(t, sequence)
+
+		events, _, ok := el.since("team-1", sequence)
+		require.True(t, ok)
+		require.Empty(t, events)
+		require.Equal(t, sequence+1, recordEvent(el, "team-1", "board-1"))
+	})
+}
+
+type testSequencedMsg struct {
+	Action   string `json:"action"`
+	Sequence int64  `json:"sequence"`
+}
+
+func TestServerReplay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := wsMocks.NewMockStore(ctrl)
+	store.EXPECT().GetMembersForBoard("board-1").Return([]*model.BoardMember{{BoardID: "board-1", UserID: "user-1"}}, nil).AnyTimes()
+	store.EXPECT().GetMembersForBoard("board-2").Return([]*model.BoardMember{{BoardID: "board-2", UserID: "user-2"}}, nil).AnyTimes()
+
+	server, httpServer := startTestServer(t, store)
+	defer httpServer.Close()
+
+	conn := connectTestListener(t, server, httpServer, "user-1", WebsocketCommand{TeamID: "team-id"})
+	server.BroadcastBoardChange("team-id", &model.Board{ID: "board-1", TeamID: "team-id"})
+	var message testSequencedMsg
+	readTestMessage(t, conn, &message)
+	require.Equal(t, websocketActionUpdateBoard, message.Action)
+	lastSequence := message.Sequence
+	require.NotZero(t, lastSequence)
+
+	conn.Close()
+	require.Eventually(t, func() bool {
+		return len(getTestListeners(server, "team-id", "user-1")) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the events sent while the user is disconnected
+	server.BroadcastBlockChange("team-id", model.Block{ID: "block-1", BoardID: "board-1"})
+	server.BroadcastBoardChange("team-id", &model.Board{ID: "board-2", TeamID: "team-id"})
+	server.BroadcastMemberDelete("team-id", "board-2", "user-1")
+
+	t.Run("the missed events of the boards of the user are replayed", func(t *testing.T) {
+		conn := connectTestListener(t, server, httpServer, "user-1", WebsocketCommand{TeamID: "team-id", LastSequence: lastSequence})
+		defer conn.Close()
+
+		var message testSequencedMsg
+		readTestMessage(t, conn, &message)
+		require.Equal(t, websocketActionUpdateBlock, message.Action)
+		require.Equal(t, lastSequence+1, message.Sequence)
+
+		readTestMessage(t, conn, &message)
+		require.Equal(t, websocketActionDeleteMember, message.Action)
+		require.Equal(t, lastSequence+3, message.Sequence)
+	})
+
+	t.Run("a resync is required when the events are not kept", func(t *testing.T) {
+		conn := connectTestListener(t, server, httpServer, "user-1", WebsocketCommand{TeamID: "team-id", LastSequence: 1})
+		defer conn.Close()
+
+		var message ResyncRequiredMsg
+		readTestMessage(t, conn, &message)
+		require.Equal(t, websocketActionResyncRequired, message.Action)
+		require.Equal(t, "team-id", message.TeamID)
+		require.Equal(t, lastSequence+3, message.Sequence)
+	})
+}
@@ -9,8 +9,11 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/mattermost/focalboard/server/auth"
 	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/cluster"
+	"github.com/mattermost/focalboard/server/services/permissions"
 	"github.com/mattermost/focalboard/server/utils"
 
+	mmModel "github.com/mattermost/mattermost-server/v6/model"
 	"github.com/mattermost/mattermost-server/v6/shared/mlog"
 )
 
@@ -49,10 +52,16 @@ type Server struct {
 	listenersByBlock map[string][]*websocketSession
 	mu               sync.RWMutex
 	auth             *auth.Auth
+	permissions      permissions.PermissionsService
+	permissionCache  *permissionCache
 	singleUserToken  string
 	isMattermostAuth bool
 	logger           *mlog.Logger
 	store            Store
+	presence         *presenceTracker
+	events           *eventLog
+	nodeID           string
+	bus              cluster.Bus
 }
 
 // UpdateClientConfig is sent on block updates.
@@ -63,18 +72,26 @@ type UpdateClientConfig struct {
 
 type websocketSession struct {
 	conn   *websocket.Conn
+	connID string
 	userID string
 	mu     sync.Mutex
 	teams  []string
 	blocks []string
+	// readTokens are the read tokens the blocks were subscribed with, by block ID.
+	readTokens map[string]string
 }
 
 func (wss *websocketSession) isAuthenticated() bool {
 	return wss.userID != ""
 }
 
-// NewServer creates a new Server.
-func NewServer(auth *auth.Auth, singleUserToken string, isMattermostAuth bool, logger *mlog.Logger, store Store) *Server {
+// NewServer creates a new Server. The broadcasts are only sent to the listeners allowed
+// to view the boards by the permissions service.
+func NewServer(auth *auth.Auth, permissions permissions.PermissionsService, singleUserToken string, isMattermostAuth bool, logger *mlog.Logger, store Store) *Server {
+	if permissions == nil {
+		logger.Warn("no permissions service for the websocket server, all the board permissions are denied")
+		permissions = denyAllPermissions{}
+	}
 	return &Server{
 		listeners:        make(map[*websocketSession]bool),
 		listenersByTeam:  make(map[string][]*websocketSession),
@@ -85,10 +102,15 @@ func NewServer(auth *auth.Auth, singleUserToken string, isMattermostAuth bool, l
 			},
 		},
 		auth:             auth,
+		permissions:      permissions,
+		permissionCache:  newPermissionCache(PermissionCacheTTL),
 		singleUserToken:  singleUserToken,
 		isMattermostAuth: isMattermostAuth,
 		logger:           logger,
 		store:            store,
+		presence:         newPresenceTracker(PresenceIdleTimeout),
+		events:           newEventLog(ReplayBufferSize),
+		nodeID:           utils.NewID(utils.IDTypeNone),
 	}
 }
 
@@ -108,6 +130,7 @@ func (ws *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	// create an empty session with websocket client
 	wsSession := &websocketSession{
 		conn:   client,
+		connID: utils.NewID(utils.IDTypeNone),
 		userID: "",
 		mu:     sync.Mutex{},
 		teams:  []string{},
@@ -177,7 +200,7 @@ func (ws *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 
-			ws.subscribeListenerToBlocks(wsSession, command.BlockIDs)
+			ws.subscribeListenerToBlocks(wsSession, command.BlockIDs, command.ReadToken)
 			continue
 		}
 
@@ -237,6 +260,9 @@ func (ws *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			}
 
 			ws.subscribeListenerToTeam(wsSession, command.TeamID)
+			if command.LastSequence != 0 {
+				ws.replayEvents(wsSession, command.TeamID, command.LastSequence)
+			}
 		case websocketActionUnsubscribeTeam:
 			ws.logger.Debug(`Command: UNSUBSCRIBE_TEAM`,
 				mlog.String("teamID", command.TeamID),
@@ -244,6 +270,23 @@ func (ws *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			)
 
 			ws.unsubscribeListenerFromTeam(wsSession, command.TeamID)
+		case websocketActionJoinBoard, websocketActionFocusCard:
+			ws.logger.Debug(`Command: `+command.Action,
+				mlog.String("teamID", command.TeamID),
+				mlog.String("boardID", command.BoardID),
+				mlog.String("cardID", command.CardID),
+				mlog.Stringer("client", wsSession.conn.RemoteAddr()),
+			)
+
+			ws.updatePresence(wsSession, command)
+		case websocketActionLeaveBoard:
+			ws.logger.Debug(`Command: LEAVE_BOARD`,
+				mlog.String("teamID", command.TeamID),
+				mlog.String("boardID", command.BoardID),
+				mlog.Stringer("client", wsSession.conn.RemoteAddr()),
+			)
+
+			ws.removePresence(wsSession.connID)
 		default:
 			ws.logger.Error(`ERROR webSocket command, invalid action`, mlog.String("action", command.Action))
 		}
@@ -299,8 +342,14 @@ func (ws *Server) addListener(listener *websocketSession) {
 }
 
 // removeListener removes a listener and all its subscriptions, if
-// any, from the websockets server.
+// any, from the websockets server. The other users of the board the
+// listener was present on see that it left.
 func (ws *Server) removeListener(listener *websocketSession) {
+	ws.removeListenerSubscriptions(listener)
+	ws.removePresence(listener.connID)
+}
+
+func (ws *Server) removeListenerSubscriptions(listener *websocketSession) {
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
 
@@ -348,12 +397,17 @@ func (ws *Server) unsubscribeListenerFromTeam(listener *websocketSession, teamID
 }
 
 // subscribeListenerToBlocks safely modifies the listener and the
-// server to subscribe the listener to a given set of block updates.
-func (ws *Server) subscribeListenerToBlocks(listener *websocketSession, blockIDs []string) {
+// server to subscribe the listener to a given set of block updates,
+// with the read token that granted the subscription.
+func (ws *Server) subscribeListenerToBlocks(listener *websocketSession, blockIDs []string, readToken string) {
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
 
+	if listener.readTokens == nil {
+		listener.readTokens = map[string]string{}
+	}
 	for _, blockID := range blockIDs {
+		listener.readTokens[blockID] = readToken
 		if listener.isSubscribedToBlock(blockID) {
 			continue
 		}
@@ -419,6 +473,7 @@ func (ws *Server) removeListenerFromBlock(listener *websocketSession, blockID st
 		}
 	}
 	listener.blocks = newListenerBlocks
+	delete(listener.readTokens, blockID)
 }
 
 func (ws *Server) getUserIDForToken(token string) string {
@@ -478,42 +533,80 @@ func (ws *Server) getListenersForTeam(teamID string) []*websocketSession {
 }
 
 // getListenersForTeamAndBoard returns the listeners subscribed to a
-// team changes and members of a given board.
+// team changes whose users are allowed to view a given board, and the
+// listeners of the ensured users.
 func (ws *Server) getListenersForTeamAndBoard(teamID, boardID string, ensureUsers ...string) []*websocketSession {
-	members, err := ws.store.GetMembersForBoard(boardID)
-	if err != nil {
-		ws.logger.Error("error getting members for board",
-			mlog.String("method", "getListenersForTeamAndBoard"),
-			mlog.String("teamID", teamID),
-			mlog.String("boardID", boardID),
-		)
-		return nil
-	}
-
-	memberMap := map[string]bool{}
-	for _, member := range members {
-		memberMap[member.UserID] = true
-	}
+	ensured := map[string]bool{}
 	for _, id := range ensureUsers {
-		memberMap[id] = true
+		ensured[id] = true
 	}
 
-	memberIDs := []string{}
-	for id := range memberMap {
-		memberIDs = append(memberIDs, id)
+	listeners := []*websocketSession{}
+	for _, listener := range ws.getListenersForTeam(teamID) {
+		if ensured[listener.userID] || ws.hasPermissionToBoard(listener.userID, boardID, model.PermissionViewBoard) {
+			listeners = append(listeners, listener)
+		}
 	}
+	return listeners
+}
 
+// getListenersForBlockAndBoard returns the listeners subscribed to a
+// block changes that are still allowed to view its board.
+func (ws *Server) getListenersForBlockAndBoard(blockID, boardID string) []*websocketSession {
 	listeners := []*websocketSession{}
-	for _, memberID := range memberIDs {
-		for _, listener := range ws.listenersByTeam[teamID] {
-			if listener.userID == memberID {
-				listeners = append(listeners, listener)
-			}
+	for _, listener := range ws.getListenersForBlock(blockID) {
+		if ws.canListenToBlock(listener, blockID, boardID) {
+			listeners = append(listeners, listener)
 		}
 	}
 	return listeners
 }
 
+// canListenToBlock tells if a listener subscribed to a block can
+// receive its changes: the read token it subscribed with must still be
+// valid for the board, or its user must be allowed to view the board.
+func (ws *Server) canListenToBlock(listener *websocketSession, blockID, boardID string) bool {
+	ws.mu.RLock()
+	readToken := listener.readTokens[blockID]
+	ws.mu.RUnlock()
+
+	if readToken != "" && ws.isValidReadToken(boardID, readToken) {
+		return true
+	}
+	return listener.isAuthenticated() && ws.hasPermissionToBoard(listener.userID, boardID, model.PermissionViewBoard)
+}
+
+// hasPermissionToBoard evaluates the permission of a user on a board
+// with the permissions service, and caches it.
+func (ws *Server) hasPermissionToBoard(userID, boardID string, permission *mmModel.Permission) bool {
+	if userID == "" {
+		return false
+	}
+
+	key := permissionKey{boardID: boardID, userID: userID, permissionID: permission.Id}
+	return ws.permissionCache.get(key, func() bool {
+		return ws.permissions.HasPermissionToBoard(userID, boardID, permission)
+	})
+}
+
+// isValidReadToken checks that a read token is valid for a board, and
+// caches the result.
+func (ws *Server) isValidReadToken(boardID, readToken string) bool {
+	key := permissionKey{boardID: boardID, readToken: readToken, permissionID: model.PermissionViewBoard.Id}
+	return ws.permissionCache.get(key, func() bool {
+		isValid, err := ws.auth.IsValidReadToken(boardID, readToken)
+		if err != nil {
+			ws.logger.Error("error checking the read token",
+				mlog.String("method", "isValidReadToken"),
+				mlog.String("boardID", boardID),
+				mlog.Err(err),
+			)
+			return false
+		}
+		return isValid
+	})
+}
+
 // BroadcastBlockDelete broadcasts delete messages to clients.
 func (ws *Server) BroadcastBlockDelete(teamID, blockID, boardID string) {
 	now := utils.GetMillis()
@@ -528,13 +621,22 @@ func (ws *Server) BroadcastBlockDelete(teamID, blockID, boardID string) {
 
 // BroadcastBlockChange broadcasts update messages to clients.
 func (ws *Server) BroadcastBlockChange(teamID string, block model.Block) {
+	ws.publishClusterEvent(clusterEvent{Action: websocketActionUpdateBlock, TeamID: teamID, Block: &block})
+	ws.broadcastBlockChange(teamID, block)
+}
+
+// broadcastBlockChange broadcasts update messages to the clients of this node.
+func (ws *Server) broadcastBlockChange(teamID string, block model.Block) {
 	blockIDsToNotify := []string{block.ID, block.ParentID}
 
-	message := UpdateBlockMsg{
-		Action: websocketActionUpdateBlock,
-		TeamID: teamID,
-		Block:  block,
-	}
+	message := ws.events.record(teamID, block.BoardID, nil, func(sequence int64) interface{} {
+		return UpdateBlockMsg{
+			Action:   websocketActionUpdateBlock,
+			TeamID:   teamID,
+			Block:    block,
+			Sequence: sequence,
+		}
+	})
 
 	listeners := ws.getListenersForTeamAndBoard(teamID, block.BoardID)
 	ws.logger.Trace("listener(s) for teamID",
@@ -544,7 +646,7 @@ func (ws *Server) BroadcastBlockChange(teamID string, block model.Block) {
 	)
 
 	for _, blockID := range blockIDsToNotify {
-		listeners = append(listeners, ws.getListenersForBlock(blockID)...)
+		listeners = append(listeners, ws.getListenersForBlockAndBoard(blockID, block.BoardID)...)
 		ws.logger.Trace("listener(s) for blockID",
 			mlog.Int("listener_count", len(listeners)),
 			mlog.String("blockID", blockID),
@@ -567,11 +669,19 @@ func (ws *Server) BroadcastBlockChange(teamID string, block model.Block) {
 }
 
 func (ws *Server) BroadcastCategoryChange(category model.Category) {
-	message := UpdateCategoryMessage{
-		Action:   websocketActionUpdateCategory,
-		TeamID:   category.TeamID,
-		Category: &category,
-	}
+	ws.publishClusterEvent(clusterEvent{Action: websocketActionUpdateCategory, TeamID: category.TeamID, Category: &category})
+	ws.broadcastCategoryChange(category)
+}
+
+func (ws *Server) broadcastCategoryChange(category model.Category) {
+	message := ws.events.record(category.TeamID, "", nil, func(sequence int64) interface{} {
+		return UpdateCategoryMessage{
+			Action:   websocketActionUpdateCategory,
+			TeamID:   category.TeamID,
+			Category: &category,
+			Sequence: sequence,
+		}
+	})
 
 	listeners := ws.getListenersForTeam(category.TeamID)
 	ws.logger.Debug("listener(s) for teamID",
@@ -596,11 +706,19 @@ func (ws *Server) BroadcastCategoryChange(category model.Category) {
 }
 
 func (ws *Server) BroadcastCategoryBoardChange(teamID, userID string, boardCategory model.BoardCategoryWebsocketData) {
-	message := UpdateCategoryMessage{
-		Action:          websocketActionUpdateCategoryBoard,
-		TeamID:          teamID,
-		BoardCategories: &boardCategory,
-	}
+	ws.publishClusterEvent(clusterEvent{Action: websocketActionUpdateCategoryBoard, TeamID: teamID, UserID: userID, BoardCategory: &boardCategory})
+	ws.broadcastCategoryBoardChange(teamID, userID, boardCategory)
+}
+
+func (ws *Server) broadcastCategoryBoardChange(teamID, userID string, boardCategory model.BoardCategoryWebsocketData) {
+	message := ws.events.record(teamID, "", nil, func(sequence int64) interface{} {
+		return UpdateCategoryMessage{
+			Action:          websocketActionUpdateCategoryBoard,
+			TeamID:          teamID,
+			BoardCategories: &boardCategory,
+			Sequence:        sequence,
+		}
+	})
 
 	listeners := ws.getListenersForTeam(teamID)
 	ws.logger.Debug("listener(s) for teamID",
@@ -628,6 +746,12 @@ func (ws *Server) BroadcastCategoryBoardChange(teamID, userID string, boardCateg
 
 // BroadcastConfigChange broadcasts update messages to clients.
 func (ws *Server) BroadcastConfigChange(clientConfig model.ClientConfig) {
+	ws.publishClusterEvent(clusterEvent{Action: websocketActionUpdateConfig, ClientConfig: &clientConfig})
+	ws.broadcastConfigChange(clientConfig)
+}
+
+// broadcastConfigChange broadcasts update messages to the clients of this node.
+func (ws *Server) broadcastConfigChange(clientConfig model.ClientConfig) {
 	message := UpdateClientConfig{
 		Action:       websocketActionUpdateConfig,
 		ClientConfig: clientConfig,
@@ -651,11 +775,23 @@ func (ws *Server) BroadcastConfigChange(clientConfig model.ClientConfig) {
 }
 
 func (ws *Server) BroadcastBoardChange(teamID string, board *model.Board) {
-	message := UpdateBoardMsg{
-		Action: websocketActionUpdateBoard,
-		TeamID: teamID,
-		Board:  board,
-	}
+	ws.publishClusterEvent(clusterEvent{Action: websocketActionUpdateBoard, TeamID: teamID, Board: board})
+	ws.broadcastBoardChange(teamID, board)
+}
+
+func (ws *Server) broadcastBoardChange(teamID string, board *model.Board) {
+	// the change may grant or revoke the access to the board, e.g. when
+	// its type changes
+	ws.permissionCache.invalidate(board.ID, "")
+
+	message := ws.events.record(teamID, board.ID, nil, func(sequence int64) interface{} {
+		return UpdateBoardMsg{
+			Action:   websocketActionUpdateBoard,
+			TeamID:   teamID,
+			Board:    board,
+			Sequence: sequence,
+		}
+	})
 
 	listeners := ws.getListenersForTeamAndBoard(teamID, board.ID)
 	ws.logger.Trace("listener(s) for teamID and boardID",
@@ -691,11 +827,23 @@ func (ws *Server) BroadcastBoardDelete(teamID, boardID string) {
 }
 
 func (ws *Server) BroadcastMemberChange(teamID, boardID string, member *model.BoardMember) {
-	message := UpdateMemberMsg{
-		Action: websocketActionUpdateMember,
-		TeamID: teamID,
-		Member: member,
-	}
+	ws.publishClusterEvent(clusterEvent{Action: websocketActionUpdateMember, TeamID: teamID, BoardID: boardID, Member: member})
+	ws.broadcastMemberChange(teamID, boardID, member)
+}
+
+func (ws *Server) broadcastMemberChange(teamID, boardID string, member *model.BoardMember) {
+	// the listeners of the member receive the changes of the board, or
+	// stop receiving them, as soon as their permissions change
+	ws.permissionCache.invalidate(boardID, member.UserID)
+
+	message := ws.events.record(teamID, boardID, nil, func(sequence int64) interface{} {
+		return UpdateMemberMsg{
+			Action:   websocketActionUpdateMember,
+			TeamID:   teamID,
+			Member:   member,
+			Sequence: sequence,
+		}
+	})
 
 	listeners := ws.getListenersForTeamAndBoard(teamID, boardID)
 	ws.logger.Trace("listener(s) for teamID and boardID",
@@ -717,14 +865,26 @@ func (ws *Server) BroadcastMemberChange(teamID, boardID string, member *model.Bo
 			listener.conn.Close()
 		}
 	}
+
+	ws.removePresencesWithoutAccess(boardID, member.UserID)
 }
 
 func (ws *Server) BroadcastMemberDelete(teamID, boardID, userID string) {
-	message := UpdateMemberMsg{
-		Action: websocketActionDeleteMember,
-		TeamID: teamID,
-		Member: &model.BoardMember{UserID: userID, BoardID: boardID},
-	}
+	ws.publishClusterEvent(clusterEvent{Action: websocketActionDeleteMember, TeamID: teamID, BoardID: boardID, UserID: userID})
+	ws.broadcastMemberDelete(teamID, boardID, userID)
+}
+
+func (ws *Server) broadcastMemberDelete(teamID, boardID, userID string) {
+	ws.permissionCache.invalidate(boardID, userID)
+
+	message := ws.events.record(teamID, boardID, []string{userID}, func(sequence int64) interface{} {
+		return UpdateMemberMsg{
+			Action:   websocketActionDeleteMember,
+			TeamID:   teamID,
+			Member:   &model.BoardMember{UserID: userID, BoardID: boardID},
+			Sequence: sequence,
+		}
+	})
 
 	// when fetching the members of the board that should receive the
 	// member deletion message, the deleted member will not be one of
@@ -749,8 +909,160 @@ func (ws *Server) BroadcastMemberDelete(teamID, boardID, userID string) {
 			listener.conn.Close()
 		}
 	}
+
+	ws.removePresencesWithoutAccess(boardID, userID)
+}
+
+// replayEvents sends to a listener subscribing to a team again the events it missed
+// since the last sequence it received, or asks it to resync if they are not kept. The
+// events of a board are only replayed to the users allowed to view it. The events sent to the listener
+// while they are replayed may be received twice, and the clients ignore the sequences
+// they already received.
+func (ws *Server) replayEvents(listener *websocketSession, teamID string, lastSequence int64) {
+	events, sequence, ok := ws.events.since(teamID, lastSequence)
+	if !ok {
+		ws.logger.Debug("Resync required",
+			mlog.String("teamID", teamID),
+			mlog.Int64("lastSequence", lastSequence),
+			mlog.Stringer("remoteAddr", listener.conn.RemoteAddr()),
+		)
+
+		message := ResyncRequiredMsg{
+			Action:   websocketActionResyncRequired,
+			TeamID:   teamID,
+			Sequence: sequence,
+		}
+		if err := listener.WriteJSON(message); err != nil {
+			ws.logger.Error("resync required error", mlog.Err(err))
+			listener.conn.Close()
+		}
+		return
+	}
+
+	ws.logger.Debug("Replay events",
+		mlog.String("teamID", teamID),
+		mlog.Int64("lastSequence", lastSequence),
+		mlog.Int("event_count", len(events)),
+		mlog.Stringer("remoteAddr", listener.conn.RemoteAddr()),
+	)
+
+	for _, event := range events {
+		if !ws.canReplayEvent(listener.userID, event) {
+			continue
+		}
+		if err := listener.WriteJSON(event.message); err != nil {
+			ws.logger.Error("replay error", mlog.Err(err))
+			listener.conn.Close()
+			return
+		}
+	}
+}
+
+// canReplayEvent tells if an event can be replayed to a user.
+func (ws *Server) canReplayEvent(userID string, event replayEvent) bool {
+	if event.boardID == "" {
+		return true
+	}
+	for _, id := range event.ensureUsers {
+		if id == userID {
+			return true
+		}
+	}
+	return ws.hasPermissionToBoard(userID, event.boardID, model.PermissionViewBoard)
+}
+
+// removePresencesWithoutAccess removes the presences of the listeners
+// of a user on a board once the user can no longer view it.
+func (ws *Server) removePresencesWithoutAccess(boardID, userID string) {
+	if !ws.presence.isPresent(boardID, userID) || ws.hasPermissionToBoard(userID, boardID, model.PermissionViewBoard) {
+		return
+	}
+	ws.broadcastPresences(ws.presence.removeUser(boardID, userID))
 }
 
 func (ws *Server) BroadcastSubscriptionChange(workspaceID string, subscription *model.Subscription) {
 	// not implemented for standalone server.
 }
+
+// updatePresence records the presence of a listener on a board, or on one of its cards,
+// and sends the presences of the board to its members. Only the members of a board can
+// be present on it.
+func (ws *Server) updatePresence(listener *websocketSession, command WebsocketCommand) {
+	isMember, err := isBoardMember(ws.store, command.BoardID, listener.userID)
+	if err != nil {
+		ws.logger.Error("error getting members for board",
+			mlog.String("method", "updatePresence"),
+			mlog.String("boardID", command.BoardID),
+			mlog.Err(err),
+		)
+		return
+	}
+	if !isMember {
+		ws.logger.Error("WS user is not a member of the board", mlog.String("boardID", command.BoardID), mlog.String("userID", listener.userID))
+		return
+	}
+
+	update := PresenceUpdate{
+		ConnID:   listener.connID,
+		TeamID:   command.TeamID,
+		Presence: presenceFromCommand(listener.userID, command),
+	}
+	ws.publishClusterEvent(clusterEvent{Action: websocketActionUpdatePresence, Presence: &update})
+	ws.applyPresenceUpdate(update)
+}
+
+// removePresence removes the presence of a listener and propagates the removal to the
+// cluster.
+func (ws *Server) removePresence(connID string) {
+	boards := ws.presence.remove(connID)
+	if len(boards) == 0 {
+		return
+	}
+
+	update := PresenceUpdate{ConnID: connID, Left: true}
+	ws.publishClusterEvent(clusterEvent{Action: websocketActionUpdatePresence, Presence: &update})
+	ws.broadcastPresences(boards)
+}
+
+// applyPresenceUpdate records a presence change, from this node or from another node of
+// the cluster, and sends the presences of the boards it changed to their members
+// connected to this node.
+func (ws *Server) applyPresenceUpdate(update PresenceUpdate) {
+	if update.Left {
+		ws.broadcastPresences(ws.presence.remove(update.ConnID))
+		return
+	}
+	ws.broadcastPresences(ws.presence.update(update.ConnID, update.TeamID, update.Presence))
+}
+
+// ExpireIdlePresences removes the presences of the idle listeners. Every node of the
+// cluster expires the presences it knows of, the expiry is not propagated.
+func (ws *Server) ExpireIdlePresences() {
+	ws.broadcastPresences(ws.presence.expire())
+}
+
+// broadcastPresences sends the presences of boards to their members.
+func (ws *Server) broadcastPresences(boards []presenceBoard) {
+	for _, board := range boards {
+		message := UpdatePresenceMsg{
+			Action:    websocketActionUpdatePresence,
+			TeamID:    board.teamID,
+			BoardID:   board.boardID,
+			Presences: ws.presence.list(board.boardID),
+		}
+
+		listeners := ws.getListenersForTeamAndBoard(board.teamID, board.boardID)
+		ws.logger.Trace("listener(s) for teamID and boardID",
+			mlog.Int("listener_count", len(listeners)),
+			mlog.String("teamID", board.teamID),
+			mlog.String("boardID", board.boardID),
+		)
+
+		for _, listener := range listeners {
+			if err := listener.WriteJSON(message); err != nil {
+				ws.logger.Error("broadcast presence error", mlog.Err(err))
+				listener.conn.Close()
+			}
+		}
+	}
+}
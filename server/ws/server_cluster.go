@@ -0,0 +1,102 @@
+package ws
+
+import (
+	"encoding/json"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/cluster"
+
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+const clusterChannel = "websocket"
+
+// clusterEvent is a broadcast of a node, or a change of the presences of its listeners,
+// sent to the other nodes of the cluster to be broadcast to their listeners.
+type clusterEvent struct {
+	NodeID        string
+	Action        string
+	TeamID        string                            `json:",omitempty"`
+	BoardID       string                            `json:",omitempty"`
+	UserID        string                            `json:",omitempty"`
+	Block         *model.Block                      `json:",omitempty"`
+	Board         *model.Board                      `json:",omitempty"`
+	Member        *model.BoardMember                `json:",omitempty"`
+	Category      *model.Category                   `json:",omitempty"`
+	BoardCategory *model.BoardCategoryWebsocketData `json:",omitempty"`
+	ClientConfig  *model.ClientConfig               `json:",omitempty"`
+	Presence      *PresenceUpdate                   `json:",omitempty"`
+}
+
+// SetClusterBus makes the server a node of a cluster: the broadcasts are sent to the
+// listeners of all the nodes through the bus. It must be called before the server
+// handles connections.
+func (ws *Server) SetClusterBus(bus cluster.Bus) error {
+	if err := bus.Subscribe(clusterChannel, ws.handleClusterMessage); err != nil {
+		return err
+	}
+	ws.bus = bus
+	return nil
+}
+
+func (ws *Server) publishClusterEvent(event clusterEvent) {
+	if ws.bus == nil {
+		return
+	}
+
+	event.NodeID = ws.nodeID
+	b, err := json.Marshal(event)
+	if err != nil {
+		ws.logger.Error("couldn't get JSON bytes from cluster event",
+			mlog.String("action", event.Action),
+			mlog.Err(err),
+		)
+		return
+	}
+
+	if err = ws.bus.Publish(clusterChannel, b); err != nil {
+		ws.logger.Error("error publishing cluster event",
+			mlog.String("action", event.Action),
+			mlog.Err(err),
+		)
+	}
+}
+
+// handleClusterMessage broadcasts the events of the other nodes to the listeners of this
+// node.
+func (ws *Server) handleClusterMessage(message []byte) {
+	var event clusterEvent
+	if err := json.Unmarshal(message, &event); err != nil {
+		ws.logger.Error("cannot unmarshal cluster event", mlog.Err(err))
+		return
+	}
+	if event.NodeID == ws.nodeID {
+		return
+	}
+
+	ws.logger.Debug("received cluster event",
+		mlog.String("action", event.Action),
+		mlog.String("nodeID", event.NodeID),
+	)
+
+	switch {
+	case event.Action == websocketActionUpdateBlock && event.Block != nil:
+		ws.broadcastBlockChange(event.TeamID, *event.Block)
+	case event.Action == websocketActionUpdateBoard && event.Board != nil:
+		ws.broadcastBoardChange(event.TeamID, event.Board)
+	case event.Action == websocketActionUpdateMember && event.Member != nil:
+		ws.broadcastMemberChange(event.TeamID, event.BoardID, event.Member)
+	case event.Action == websocketActionDeleteMember:
+		ws.broadcastMemberDelete(event.TeamID, event.BoardID, event.UserID)
+	case event.Action == websocketActionUpdateCategory && event.Category != nil:
+		ws.broadcastCategoryChange(*event.Category)
+	case event.Action == websocketActionUpdateCategoryBoard && event.BoardCategory != nil:
+		ws.broadcastCategoryBoardChange(event.TeamID, event.UserID, *event.BoardCategory)
+	case event.Action == websocketActionUpdateConfig && event.ClientConfig != nil:
+		ws.broadcastConfigChange(*event.ClientConfig)
+	case event.Action == websocketActionUpdatePresence && event.Presence != nil:
+		ws.applyPresenceUpdate(*event.Presence)
+	default:
+		ws.logger.Warn("invalid cluster event", mlog.String("action", event.Action))
+	}
+}
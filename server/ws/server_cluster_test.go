@@ -0,0 +1,62 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/cluster"
+	wsMocks "github.com/mattermost/focalboard/server/ws/mocks"
+)
+
+func TestServerCluster(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := wsMocks.NewMockStore(ctrl)
+	members := []*model.BoardMember{{BoardID: "board-id", UserID: "user-1"}, {BoardID: "board-id", UserID: "user-2"}}
+	store.EXPECT().GetMembersForBoard("board-id").Return(members, nil).AnyTimes()
+
+	memoryCluster := cluster.NewMemory()
+	server1, httpServer1 := startTestServer(t, store)
+	defer httpServer1.Close()
+	require.NoError(t, server1.SetClusterBus(memoryCluster.Join()))
+	server2, httpServer2 := startTestServer(t, store)
+	defer httpServer2.Close()
+	require.NoError(t, server2.SetClusterBus(memoryCluster.Join()))
+
+	conn1 := connectTestListener(t, server1, httpServer1, "user-1", WebsocketCommand{TeamID: "team-id"})
+	defer conn1.Close()
+	conn2 := connectTestListener(t, server2, httpServer2, "user-2", WebsocketCommand{TeamID: "team-id"})
+	defer conn2.Close()
+
+	t.Run("the broadcasts are sent to the listeners of all the nodes", func(t *testing.T) {
+		server1.BroadcastBlockChange("team-id", model.Block{ID: "block-id", BoardID: "board-id"})
+		server1.BroadcastBoardChange("team-id", &model.Board{ID: "board-id", TeamID: "team-id"})
+
+		for _, conn := range []*websocket.Conn{conn1, conn2} {
+			// the listeners of the node that broadcasts receive the messages once
+			var message testSequencedMsg
+			readTestMessage(t, conn, &message)
+			require.Equal(t, websocketActionUpdateBlock, message.Action)
+			readTestMessage(t, conn, &message)
+			require.Equal(t, websocketActionUpdateBoard, message.Action)
+		}
+	})
+
+	t.Run("the presences are shared by the nodes", func(t *testing.T) {
+		require.NoError(t, conn1.WriteJSON(WebsocketCommand{Action: websocketActionJoinBoard, TeamID: "team-id", BoardID: "board-id"}))
+
+		var message UpdatePresenceMsg
+		readTestMessage(t, conn2, &message)
+		require.Equal(t, websocketActionUpdatePresence, message.Action)
+		require.Len(t, message.Presences, 1)
+		require.Equal(t, "user-1", message.Presences[0].UserID)
+
+		// the other nodes see the users leave when they disconnect
+		conn1.Close()
+		readTestMessage(t, conn2, &message)
+		require.Empty(t, message.Presences)
+	})
+}
@@ -14,7 +14,7 @@ import (
 )
 
 func TestTeamSubscription(t *testing.T) {
-	server := NewServer(&auth.Auth{}, "token", false, &mlog.Logger{}, nil)
+	server := NewServer(&auth.Auth{}, testPermissions{}, "token", false, &mlog.Logger{}, nil)
 	session := &websocketSession{
 		conn:   &websocket.Conn{},
 		mu:     sync.Mutex{},
@@ -104,7 +104,7 @@ func TestTeamSubscription(t *testing.T) {
 }
 
 func TestBlocksSubscription(t *testing.T) {
-	server := NewServer(&auth.Auth{}, "token", false, &mlog.Logger{}, nil)
+	server := NewServer(&auth.Auth{}, testPermissions{}, "token", false, &mlog.Logger{}, nil)
 	session := &websocketSession{
 		conn:   &websocket.Conn{},
 		mu:     sync.Mutex{},
@@ -128,7 +128,7 @@ func TestBlocksSubscription(t *testing.T) {
 		require.False(t, session.isSubscribedToBlock(blockID2))
 		require.False(t, session.isSubscribedToBlock(blockID3))
 
-		server.subscribeListenerToBlocks(session, blockIDs)
+		server.subscribeListenerToBlocks(session, blockIDs, "")
 
 		require.Len(t, server.listenersByBlock[blockID1], 1)
 		require.Contains(t, server.listenersByBlock[blockID1], session)
@@ -148,7 +148,7 @@ func TestBlocksSubscription(t *testing.T) {
 			require.True(t, session.isSubscribedToBlock(blockID2))
 			require.True(t, session.isSubscribedToBlock(blockID3))
 
-			server.subscribeListenerToBlocks(session, blockIDs)
+			server.subscribeListenerToBlocks(session, blockIDs, "")
 
 			require.Len(t, server.listenersByBlock[blockID1], 1)
 			require.Contains(t, server.listenersByBlock[blockID1], session)
@@ -201,7 +201,7 @@ func TestBlocksSubscription(t *testing.T) {
 
 	t.Run("If subscribed to blocks and removed, should be removed from the blocks subscription list", func(t *testing.T) {
 		server.addListener(session)
-		server.subscribeListenerToBlocks(session, blockIDs)
+		server.subscribeListenerToBlocks(session, blockIDs, "")
 
 		require.Len(t, server.listeners, 1)
 		require.Len(t, server.listenersByBlock[blockID1], 1)
@@ -224,7 +224,7 @@ func TestBlocksSubscription(t *testing.T) {
 
 func TestGetUserIDForTokenInSingleUserMode(t *testing.T) {
 	singleUserToken := "single-user-token"
-	server := NewServer(&auth.Auth{}, "token", false, &mlog.Logger{}, nil)
+	server := NewServer(&auth.Auth{}, testPermissions{}, "token", false, &mlog.Logger{}, nil)
 	server.singleUserToken = singleUserToken
 
 	t.Run("Should return nothing if the token is empty", func(t *testing.T) {